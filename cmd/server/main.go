@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -8,8 +9,11 @@ import (
 	"github.com/MassBabyGeek/PumpPro-backend/internal/api"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/config"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/handler"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/jobs"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
 )
 
 func main() {
@@ -20,6 +24,21 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Configure XP curve
+	utils.ConfigureXPCurve(cfg.XPCurveBase, cfg.XPCurveExponent)
+
+	// Configure private challenges
+	utils.ConfigureChallengeAccess(cfg.ChallengeInviteURL, cfg.PrivateChallengeMaxPoints)
+
+	// Configure program sharing
+	if err := utils.ConfigureProgramShare(cfg.ProgramShareSecret); err != nil {
+		logger.Error("Could not configure program sharing: %v", err)
+		os.Exit(1)
+	}
+
+	// Configure certificate upload
+	handler.ConfigureCertificateUpload(cfg)
+
 	// Connect to PostgreSQL
 	db, err := database.ConnectPostgres(cfg)
 	if err != nil {
@@ -28,15 +47,20 @@ func main() {
 	}
 	defer db.Close()
 
+	// Start scheduled jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobs.Start(jobsCtx, jobs.DefaultJobs())
+
 	// Initialize routes
 	router := api.SetupRouter()
 
 	// Wrap router with CORS middleware
-	handler := middleware.CORSMiddleware(router)
+	httpHandler := middleware.CORSMiddleware(router)
 
 	// Start server
 	logger.Success("Server starting on port %s", cfg.Port)
-	if err := http.ListenAndServe(":"+cfg.Port, handler); err != nil {
+	if err := http.ListenAndServe(":"+cfg.Port, httpHandler); err != nil {
 		logger.Error("Server failed: %v", err)
 		os.Exit(1)
 	}
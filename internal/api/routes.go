@@ -42,16 +42,37 @@ func SetupRouter() http.Handler {
 	authenticatedRoutes.HandleFunc("/users/{userId}/stats/{period}", handler.GetUserStats).Methods(http.MethodGet)
 	authenticatedRoutes.HandleFunc("/users/{userId}/charts/{period}", handler.GetChartData).Methods(http.MethodGet)
 	r.HandleFunc("/users/{userId}/streak", handler.GetUserStreak).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/users/{userId}/streak/freezes", handler.GetStreakFreezes).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/users/{userId}/streak/freezes/use", handler.UseStreakFreeze).Methods(http.MethodPost)
+	r.HandleFunc("/users/{userId}/xp", handler.GetUserXP).Methods(http.MethodGet)
+	r.HandleFunc("/users/{userId}/badges", handler.GetUserBadges).Methods(http.MethodGet)
+	r.HandleFunc("/users/{userId}/badges/catalog", handler.GetUserBadgeProgress).Methods(http.MethodGet)
 	authenticatedRoutes.HandleFunc("/users/{userId}/challenges", handler.GetUserChallenges).Methods(http.MethodGet)
 	authenticatedRoutes.HandleFunc("/users/{id}", handler.UpdateUser).Methods(http.MethodPut, http.MethodPatch)
 
+	// Badges
+	r.HandleFunc("/badges", handler.GetBadgeCatalog).Methods(http.MethodGet)
+
+	// Recherche
+	r.HandleFunc("/search", handler.Search).Methods(http.MethodGet)
+
+	// Leagues
+	authenticatedRoutes.HandleFunc("/me/league", handler.GetMyLeague).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/me/league/history", handler.GetMyLeagueHistory).Methods(http.MethodGet)
+
 	// Challenges
 	r.HandleFunc("/challenges", handler.GetChallenges).Methods(http.MethodGet)
 	r.HandleFunc("/challenges/{id}", handler.GetChallengeById).Methods(http.MethodGet)
 	authenticatedRoutes.HandleFunc("/challenges", handler.CreateChallenge).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/challenges/join", handler.JoinChallengeByInvite).Methods(http.MethodPost)
 	authenticatedRoutes.HandleFunc("/challenges/{id}", handler.UpdateChallenge).Methods(http.MethodPut)
 	authenticatedRoutes.HandleFunc("/challenges/{id}", handler.DeleteChallenge).Methods(http.MethodDelete)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/tasks", handler.CreateChallengeTask).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/tasks/bulk", handler.BulkCreateChallengeTasks).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/tasks/order", handler.ReorderChallengeTasks).Methods(http.MethodPut)
 	authenticatedRoutes.HandleFunc("/challenges/{id}/tasks/{taskId}", handler.CompleteTask).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/tasks/{taskId}", handler.UpdateChallengeTask).Methods(http.MethodPut)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/tasks/{taskId}", handler.DeleteChallengeTask).Methods(http.MethodDelete)
 
 	// Challenge interactions
 	authenticatedRoutes.HandleFunc("/challenges/{id}/like", handler.LikeChallenge).Methods(http.MethodPost)
@@ -59,6 +80,10 @@ func SetupRouter() http.Handler {
 	authenticatedRoutes.HandleFunc("/challenges/{id}/start", handler.StartChallenge).Methods(http.MethodPost)
 	authenticatedRoutes.HandleFunc("/challenges/{id}/complete", handler.CompleteChallenge).Methods(http.MethodPost)
 	authenticatedRoutes.HandleFunc("/challenges/{id}/progress", handler.GetUserChallengeProgress).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/certificate", handler.GetChallengeCertificate).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/allowed-users", handler.GetChallengeAllowedUsers).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/allowed-users", handler.AddChallengeAllowedUsers).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/challenges/{id}/allowed-users/{userId}", handler.RemoveChallengeAllowedUser).Methods(http.MethodDelete)
 
 	// User challenges
 	r.HandleFunc("/users/{userId}/challenges/active", handler.GetUserActiveChallenges).Methods(http.MethodGet)
@@ -67,26 +92,50 @@ func SetupRouter() http.Handler {
 	// Programs
 	r.HandleFunc("/programs", handler.GetPrograms).Methods(http.MethodGet)
 	r.HandleFunc("/programs/{id}", handler.GetProgramById).Methods(http.MethodGet)
-	r.HandleFunc("/programs", handler.CreateProgram).Methods(http.MethodPost)
-	r.HandleFunc("/programs/{id}", handler.UpdateProgram).Methods(http.MethodPatch, http.MethodPut)
-	r.HandleFunc("/programs/{id}", handler.DeleteProgram).Methods(http.MethodDelete)
+	authenticatedRoutes.HandleFunc("/programs", handler.CreateProgram).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/programs/{id}", handler.UpdateProgram).Methods(http.MethodPatch, http.MethodPut)
+	authenticatedRoutes.HandleFunc("/programs/{id}", handler.DeleteProgram).Methods(http.MethodDelete)
 
 	// Progeram interactions
 	authenticatedRoutes.HandleFunc("/programs/{id}/like", handler.LikeProgram).Methods(http.MethodPost)
 	authenticatedRoutes.HandleFunc("/programs/{id}/like", handler.UnlikeProgram).Methods(http.MethodDelete)
+	authenticatedRoutes.HandleFunc("/programs/{id}/progression", handler.GetProgramProgression).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/programs/{id}/progression", handler.UpdateProgramProgression).Methods(http.MethodPut)
+	authenticatedRoutes.HandleFunc("/programs/{id}/progression", handler.ResetProgramProgression).Methods(http.MethodDelete)
 
 	// Program specific routes
 	r.HandleFunc("/programs/featured", handler.GetFeaturedPrograms).Methods(http.MethodGet)
 	r.HandleFunc("/programs/popular", handler.GetPopularPrograms).Methods(http.MethodGet)
-	r.HandleFunc("/programs/{id}/duplicate", handler.DuplicateProgram).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/programs/{id}/duplicate", handler.DuplicateProgram).Methods(http.MethodPost)
+
+	// Program sharing
+	r.HandleFunc("/programs/{id}/share", handler.ShareProgram).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/programs/import", handler.ImportProgram).Methods(http.MethodPost)
+
+	// Program versions
+	r.HandleFunc("/programs/{id}/versions", handler.GetProgramVersions).Methods(http.MethodGet)
+	r.HandleFunc("/programs/{id}/versions/diff", handler.DiffProgramVersions).Methods(http.MethodGet)
+	r.HandleFunc("/programs/{id}/versions/{version:[0-9]+}", handler.GetProgramVersion).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/programs/{id}/versions/{version:[0-9]+}/revert", handler.RevertProgramVersion).Methods(http.MethodPost)
+
+	// Program reviews
+	r.HandleFunc("/programs/{id}/reviews", handler.GetProgramReviews).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/programs/{id}/reviews", handler.UpsertProgramReview).Methods(http.MethodPut)
+	authenticatedRoutes.HandleFunc("/programs/{id}/reviews", handler.DeleteProgramReview).Methods(http.MethodDelete)
+	authenticatedRoutes.HandleFunc("/programs/{id}/reviews/me", handler.GetMyProgramReview).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/programs/{id}/reviews/{reviewId}/report", handler.ReportProgramReview).Methods(http.MethodPost)
 
 	// User programs
 	r.HandleFunc("/users/{userId}/programs", handler.GetUserCustomPrograms).Methods(http.MethodGet)
-	r.HandleFunc("/users/{userId}/programs/recommended", handler.GetRecommendedPrograms).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/users/{userId}/programs/recommended", handler.GetRecommendedPrograms).Methods(http.MethodGet)
 
 	// Programs by difficulty
 	r.HandleFunc("/programs/difficulty/{difficulty}", handler.GetProgramsByDifficulty).Methods(http.MethodGet)
 
+	// Exercises
+	r.HandleFunc("/exercises", handler.GetExercises).Methods(http.MethodGet)
+	r.HandleFunc("/exercises/{id}", handler.GetExercise).Methods(http.MethodGet)
+
 	// Workout Sessions
 	r.HandleFunc("/workouts", handler.GetWorkoutSessions).Methods(http.MethodGet)
 	authenticatedRoutes.HandleFunc("/workouts", handler.SaveWorkoutSession).Methods(http.MethodPost)
@@ -110,6 +159,39 @@ func SetupRouter() http.Handler {
 	r.HandleFunc("/leaderboard/users/{userId}", handler.GetUserRank).Methods(http.MethodGet)
 	r.HandleFunc("/leaderboard/users/{userId}/nearby", handler.GetNearbyUsers).Methods(http.MethodGet)
 
+	r.HandleFunc("/leaderboard/clubs", handler.GetClubsLeaderboard).Methods(http.MethodGet)
+
+	// Plans d'entraînement
+	r.HandleFunc("/training-plans", handler.GetTrainingPlans).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/training-plans", handler.CreateTrainingPlan).Methods(http.MethodPost)
+	r.HandleFunc("/training-plans/{id}", handler.GetTrainingPlan).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/training-plans/{id}", handler.UpdateTrainingPlan).Methods(http.MethodPut)
+	authenticatedRoutes.HandleFunc("/training-plans/{id}", handler.DeleteTrainingPlan).Methods(http.MethodDelete)
+	authenticatedRoutes.HandleFunc("/training-plans/{id}/enroll", handler.EnrollTrainingPlan).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/me/training-plans", handler.GetMyTrainingPlans).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/me/training-plans/today", handler.GetMyTrainingPlansToday).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/me/training-plans/{enrollmentId}", handler.UpdateMyTrainingPlan).Methods(http.MethodPatch)
+	authenticatedRoutes.HandleFunc("/me/training-plans/{enrollmentId}", handler.LeaveTrainingPlan).Methods(http.MethodDelete)
+
+	// Clubs
+	authenticatedRoutes.HandleFunc("/clubs", handler.CreateClub).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/clubs/join", handler.JoinClub).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/me/clubs", handler.GetMyClubs).Methods(http.MethodGet)
+	r.HandleFunc("/clubs/{id}", handler.GetClub).Methods(http.MethodGet)
+	r.HandleFunc("/clubs/{id}/members", handler.GetClubMembers).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/clubs/{id}/leave", handler.LeaveClub).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/clubs/{id}/members/{userId}", handler.RemoveClubMember).Methods(http.MethodDelete)
+	r.HandleFunc("/clubs/{id}/leaderboard", handler.GetClubLeaderboard).Methods(http.MethodGet)
+	r.HandleFunc("/clubs/{id}/challenges", handler.GetClubChallenges).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/clubs/{id}/challenges", handler.CreateClubChallenge).Methods(http.MethodPost)
+
+	// Duels
+	authenticatedRoutes.HandleFunc("/duels", handler.CreateDuel).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/me/duels", handler.GetMyDuels).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/duels/{id}", handler.GetDuel).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/duels/{id}/accept", handler.AcceptDuel).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/duels/{id}/decline", handler.DeclineDuel).Methods(http.MethodPost)
+
 	// Challenge leaderboard
 	r.HandleFunc("/challenges/{challengeId}/leaderboard", handler.GetChallengeLeaderboard).Methods(http.MethodGet)
 
@@ -141,6 +223,8 @@ func SetupRouter() http.Handler {
 	authenticatedRoutes.HandleFunc("/admin/users/{userId}", handler.AdminDeleteUser).Methods(http.MethodDelete)
 	authenticatedRoutes.HandleFunc("/admin/users/{userId}/promote", handler.PromoteUserToAdmin).Methods(http.MethodPost)
 	authenticatedRoutes.HandleFunc("/admin/users/{userId}/demote", handler.DemoteUserFromAdmin).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/admin/users/{userId}/xp/recalculate", handler.RecalculateUserXP).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/admin/xp/recalculate", handler.RecalculateAllUsersXP).Methods(http.MethodPost)
 
 	// Content Management
 	authenticatedRoutes.HandleFunc("/admin/photos", handler.GetAllPhotos).Methods(http.MethodGet)
@@ -150,6 +234,16 @@ func SetupRouter() http.Handler {
 	authenticatedRoutes.HandleFunc("/admin/bug-reports", handler.GetAdminBugReports).Methods(http.MethodGet)
 	authenticatedRoutes.HandleFunc("/admin/bug-reports/{reportId}/resolve", handler.ResolveBugReport).Methods(http.MethodPost)
 	authenticatedRoutes.HandleFunc("/admin/bug-reports/{reportId}/assign", handler.AssignBugReport).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/admin/reviews/{reviewId}", handler.ModerateProgramReview).Methods(http.MethodPatch)
+
+	// Recurring challenge templates
+	authenticatedRoutes.HandleFunc("/admin/challenge-templates", handler.GetChallengeTemplates).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/admin/challenge-templates", handler.CreateChallengeTemplate).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/admin/challenge-templates/spawn", handler.SpawnChallengeTemplates).Methods(http.MethodPost)
+	authenticatedRoutes.HandleFunc("/admin/challenge-templates/{id}", handler.GetChallengeTemplate).Methods(http.MethodGet)
+	authenticatedRoutes.HandleFunc("/admin/challenge-templates/{id}", handler.UpdateChallengeTemplate).Methods(http.MethodPut)
+	authenticatedRoutes.HandleFunc("/admin/challenge-templates/{id}", handler.DeleteChallengeTemplate).Methods(http.MethodDelete)
+	authenticatedRoutes.HandleFunc("/admin/challenge-templates/{id}/preview", handler.PreviewChallengeTemplate).Methods(http.MethodGet)
 
 	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Route not found", http.StatusNotFound)
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -20,6 +21,20 @@ type Config struct {
 	CloudinaryCloudName string
 	CloudinaryAPIKey    string
 	CloudinaryAPISecret string
+
+	// Courbe d'XP : XP pour passer du niveau N à N+1 = base * N^exponent
+	XPCurveBase     float64
+	XPCurveExponent float64
+
+	// Challenges privés : lien d'invitation et plafond de points pour les non-admins
+	ChallengeInviteURL        string
+	PrivateChallengeMaxPoints int
+
+	// Certificats de réussite : upload du PNG vers Cloudinary (si configuré)
+	CertificateUpload bool
+
+	// Clé de signature des programmes partagés
+	ProgramShareSecret string
 }
 
 func LoadConfig() (*Config, error) {
@@ -41,9 +56,41 @@ func LoadConfig() (*Config, error) {
 		CloudinaryCloudName: getEnv("CLOUDINARY_CLOUD_NAME", ""),
 		CloudinaryAPIKey:    getEnv("CLOUDINARY_API_KEY", ""),
 		CloudinaryAPISecret: getEnv("CLOUDINARY_API_SECRET", ""),
+
+		// XP
+		XPCurveBase:     getEnvFloat("XP_CURVE_BASE", 100),
+		XPCurveExponent: getEnvFloat("XP_CURVE_EXPONENT", 1.5),
+
+		// Challenges privés
+		ChallengeInviteURL:        getEnv("CHALLENGE_INVITE_URL", "pumppro://challenges/join"),
+		PrivateChallengeMaxPoints: getEnvInt("PRIVATE_CHALLENGE_MAX_POINTS", 100),
+
+		// Certificats
+		CertificateUpload: getEnv("CERTIFICATE_UPLOAD", "false") == "true",
+
+		// Partage de programmes
+		ProgramShareSecret: getEnv("PROGRAM_SHARE_SECRET", ""),
 	}, nil
 }
 
+func getEnvFloat(key string, fallback float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return fallback
+}
+
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -15,6 +15,7 @@ import (
 	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
 	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/scanner"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/services"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
@@ -30,6 +31,36 @@ type Photo struct {
 	CreatedAt  string  `json:"createdAt"`
 }
 
+// photoSources requêtes des photos par type (URL, type, entité, nom de l'entité, date)
+var photoSources = map[string]string{
+	"avatar": `
+		SELECT avatar AS url, 'avatar' AS type, id AS entity_id, name AS entity_name, created_at
+		FROM users
+		WHERE avatar IS NOT NULL AND LENGTH(avatar) > 0 AND deleted_at IS NULL`,
+	"challenge": `
+		SELECT image_url, 'challenge', id, title, created_at
+		FROM challenges
+		WHERE image_url IS NOT NULL AND LENGTH(image_url) > 0 AND deleted_at IS NULL`,
+	"bug_report": `
+		SELECT screenshot_url, 'bug_report', id, title, created_at
+		FROM bug_reports
+		WHERE screenshot_url IS NOT NULL AND LENGTH(screenshot_url) > 0`,
+}
+
+// photoSort tri de GetAllPhotos : photos les plus récentes d'abord
+var photoSort = pagination.Sort{
+	Name:    "created_at",
+	Columns: []pagination.Column{{Expr: "p.created_at", Cast: "timestamp"}},
+	Desc:    true,
+}
+
+// adminPhotoPage page de GetAllPhotos ; photos et pagination gardent l'ancienne forme de la réponse
+type adminPhotoPage struct {
+	pagination.Page[Photo]
+	Photos     []Photo               `json:"photos"`
+	Pagination pagination.LegacyMeta `json:"pagination"`
+}
+
 // GetAllPhotos récupère toutes les photos de l'application (admin only)
 func GetAllPhotos(w http.ResponseWriter, r *http.Request) {
 	// Vérifier que l'utilisateur est admin
@@ -40,159 +71,68 @@ func GetAllPhotos(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
-	query := r.URL.Query()
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
-	photoType := query.Get("type") // "avatar", "challenge", "bug_report", or "all"
+	photoType := r.URL.Query().Get("type") // "avatar", "challenge", "bug_report", or "all"
 
-	// Pagination par défaut
-	limit := 50
-	offset := 0
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
+	// Construction de la requête selon le type demandé
+	var sources []string
+	for _, t := range []string{"avatar", "challenge", "bug_report"} {
+		if photoType == "" || photoType == "all" || photoType == t {
+			sources = append(sources, photoSources[t])
 		}
 	}
+	if len(sources) == 0 {
+		utils.ErrorSimple(w, http.StatusBadRequest, "type invalide (avatar, challenge, bug_report ou all)")
+		return
+	}
+	photosQuery := `SELECT url, type, entity_id, entity_name, created_at FROM (` +
+		strings.Join(sources, "\n\t\tUNION ALL") + `
+	) p
+	WHERE TRUE`
 
-	var photos []Photo
 	var totalCount int
-
-	// Construction de la requête selon le type demandé
-	if photoType == "" || photoType == "all" || photoType == "avatar" {
-		// Récupérer les avatars des utilisateurs
-		avatarQuery := `
-			SELECT
-				avatar,
-				'avatar' as type,
-				id as entity_id,
-				name as entity_name,
-				created_at
-			FROM users
-			WHERE avatar IS NOT NULL
-			  AND LENGTH(avatar) > 0
-			  AND deleted_at IS NULL
-		`
-
-		rows, err := database.DB.Query(ctx, avatarQuery)
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "could not query user avatars", err)
-			return
-		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var photo Photo
-			var createdAt time.Time
-			err := rows.Scan(&photo.URL, &photo.Type, &photo.EntityID, &photo.EntityName, &createdAt)
-			if err != nil {
-				continue
-			}
-			photo.CreatedAt = createdAt.Format(time.RFC3339)
-			photos = append(photos, photo)
-		}
+	if err := database.DB.QueryRow(ctx, `SELECT COUNT(*) FROM (`+photosQuery+`) t`).Scan(&totalCount); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not count photos", err)
+		return
 	}
 
-	if photoType == "" || photoType == "all" || photoType == "challenge" {
-		// Récupérer les images des challenges
-		challengeQuery := `
-			SELECT
-				image_url,
-				'challenge' as type,
-				id as entity_id,
-				title as entity_name,
-				created_at
-			FROM challenges
-			WHERE image_url IS NOT NULL
-			  AND LENGTH(image_url) > 0
-			  AND deleted_at IS NULL
-		`
-
-		rows, err := database.DB.Query(ctx, challengeQuery)
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "could not query challenge images", err)
-			return
-		}
-		defer rows.Close()
+	sqlQuery, args, err := page.Apply(photosQuery, []interface{}{}, 1, photoSort, "p.entity_id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
-		for rows.Next() {
-			var photo Photo
-			var createdAt time.Time
-			err := rows.Scan(&photo.URL, &photo.Type, &photo.EntityID, &photo.EntityName, &createdAt)
-			if err != nil {
-				continue
-			}
-			photo.CreatedAt = createdAt.Format(time.RFC3339)
-			photos = append(photos, photo)
-		}
+	rows, err := database.DB.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not query photos", err)
+		return
 	}
+	defer rows.Close()
 
-	if photoType == "" || photoType == "all" || photoType == "bug_report" {
-		// Récupérer les screenshots des bug reports
-		bugReportQuery := `
-			SELECT
-				screenshot_url,
-				'bug_report' as type,
-				id as entity_id,
-				title as entity_name,
-				created_at
-			FROM bug_reports
-			WHERE screenshot_url IS NOT NULL
-			  AND LENGTH(screenshot_url) > 0
-		`
-
-		rows, err := database.DB.Query(ctx, bugReportQuery)
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "could not query bug report screenshots", err)
+	var photos []Photo
+	for rows.Next() {
+		var photo Photo
+		var createdAt time.Time
+		if err := rows.Scan(&photo.URL, &photo.Type, &photo.EntityID, &photo.EntityName, &createdAt); err != nil {
+			utils.Error(w, http.StatusInternalServerError, "could not scan photo row", err)
 			return
 		}
-		defer rows.Close()
-
-		for rows.Next() {
-			var photo Photo
-			var createdAt time.Time
-			err := rows.Scan(&photo.URL, &photo.Type, &photo.EntityID, &photo.EntityName, &createdAt)
-			if err != nil {
-				continue
-			}
-			photo.CreatedAt = createdAt.Format(time.RFC3339)
-			photos = append(photos, photo)
-		}
-	}
-
-	// Appliquer la pagination sur le tableau de résultats
-	totalCount = len(photos)
-
-	start := offset
-	end := offset + limit
-
-	if start > len(photos) {
-		start = len(photos)
-	}
-	if end > len(photos) {
-		end = len(photos)
+		// Précision complète : la date sert de clé au curseur
+		photo.CreatedAt = pagination.TimeKey(createdAt)
+		photos = append(photos, photo)
 	}
 
-	paginatedPhotos := photos[start:end]
-
-	// Retourner le résultat avec métadonnées de pagination
-	result := map[string]interface{}{
-		"photos": paginatedPhotos,
-		"pagination": map[string]interface{}{
-			"total":  totalCount,
-			"limit":  limit,
-			"offset": offset,
-			"count":  len(paginatedPhotos),
-		},
-	}
+	result := pagination.NewPage(photos, page, photoSort, func(p Photo) ([]string, string) {
+		return []string{p.CreatedAt}, p.EntityID
+	})
+	result.Total = &totalCount
 
-	utils.Success(w, result)
+	utils.Success(w, adminPhotoPage{Page: result, Photos: result.Items, Pagination: result.Legacy()})
 }
 
 // DeleteAdminPhoto supprime une photo et met à jour la base de données
@@ -784,7 +724,43 @@ func GetAdminAnalytics(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, analytics)
 }
 
-// GetAdminUsers retourne la liste des utilisateurs avec options de filtrage
+// adminUserSorts tris disponibles pour GetAdminUsers (paramètre sort)
+var adminUserSorts = map[string]pagination.Sort{
+	"name":     {Name: "name", Columns: []pagination.Column{{Expr: "au.name", Cast: "text"}}},
+	"email":    {Name: "email", Columns: []pagination.Column{{Expr: "au.email", Cast: "text"}}},
+	"score":    {Name: "score", Columns: []pagination.Column{{Expr: "au.score", Cast: "int"}}, Desc: true},
+	"workouts": {Name: "workouts", Columns: []pagination.Column{{Expr: "au.total_workouts", Cast: "bigint"}}, Desc: true},
+	"joined":   {Name: "joined", Columns: []pagination.Column{{Expr: "au.join_date", Cast: "timestamp"}}, Desc: true},
+}
+
+// adminUserSortKeys clés du curseur suivant pour un tri de adminUserSorts
+func adminUserSortKeys(sortName string) func(model.AdminUserListItem) ([]string, string) {
+	return func(u model.AdminUserListItem) ([]string, string) {
+		var key string
+		switch sortName {
+		case "name":
+			key = u.Name
+		case "email":
+			key = u.Email
+		case "score":
+			key = pagination.IntKey(u.Score)
+		case "workouts":
+			key = pagination.IntKey(u.TotalWorkouts)
+		default:
+			key = pagination.TimeKey(u.JoinDate)
+		}
+		return []string{key}, u.ID
+	}
+}
+
+// adminUserPage page de GetAdminUsers ; users et pagination gardent l'ancienne forme de la réponse
+type adminUserPage struct {
+	pagination.Page[model.AdminUserListItem]
+	Users      []model.AdminUserListItem `json:"users"`
+	Pagination pagination.LegacyMeta     `json:"pagination"`
+}
+
+// GetAdminUsers retourne la liste des utilisateurs avec options de filtrage (pagination par curseur ou offset)
 func GetAdminUsers(w http.ResponseWriter, r *http.Request) {
 	if !middleware.IsAdmin(r) {
 		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
@@ -795,24 +771,17 @@ func GetAdminUsers(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
 	// Pagination
-	limit := 50
-	offset := 0
-	if limitStr := query.Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-	if offsetStr := query.Get("offset"); offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	// Filtres
 	search := query.Get("search")
-	sortBy := query.Get("sort") // "name", "email", "score", "workouts", "joined"
-	if sortBy == "" {
-		sortBy = "joined"
+	sort, ok := adminUserSorts[query.Get("sort")] // "name", "email", "score", "workouts", "joined"
+	if !ok {
+		sort = adminUserSorts["joined"]
 	}
 
 	sqlQuery := `
@@ -843,23 +812,14 @@ func GetAdminUsers(w http.ResponseWriter, r *http.Request) {
 
 	sqlQuery += " GROUP BY u.id, u.name, u.email, u.avatar, u.is_admin, u.score, u.created_at, u.deleted_at"
 
-	// Tri
-	switch sortBy {
-	case "name":
-		sqlQuery += " ORDER BY u.name ASC"
-	case "email":
-		sqlQuery += " ORDER BY u.email ASC"
-	case "score":
-		sqlQuery += " ORDER BY score DESC"
-	case "workouts":
-		sqlQuery += " ORDER BY total_workouts DESC"
-	default:
-		sqlQuery += " ORDER BY join_date DESC"
+	// Tri et pagination sur les lignes agrégées
+	sqlQuery = "SELECT * FROM (" + sqlQuery + ") au WHERE TRUE"
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, sort, "au.id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
-	sqlQuery += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
-	args = append(args, limit, offset)
-
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "could not fetch users", err)
@@ -895,17 +855,10 @@ func GetAdminUsers(w http.ResponseWriter, r *http.Request) {
 		database.DB.QueryRow(ctx, countQuery).Scan(&total)
 	}
 
-	result := map[string]interface{}{
-		"users": users,
-		"pagination": map[string]interface{}{
-			"total":  total,
-			"limit":  limit,
-			"offset": offset,
-			"count":  len(users),
-		},
-	}
+	result := pagination.NewPage(users, page, sort, adminUserSortKeys(sort.Name))
+	result.Total = &total
 
-	utils.Success(w, result)
+	utils.Success(w, adminUserPage{Page: result, Users: result.Items, Pagination: result.Legacy()})
 }
 
 // PromoteUserToAdmin promeut un utilisateur en admin
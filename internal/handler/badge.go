@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// GetBadgeCatalog retourne le catalogue des badges disponibles
+func GetBadgeCatalog(w http.ResponseWriter, r *http.Request) {
+	utils.Success(w, utils.BadgeCatalog())
+}
+
+// GetUserBadges récupère les badges obtenus par un utilisateur
+func GetUserBadges(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+	ctx := context.Background()
+
+	badges, err := utils.GetUserBadges(ctx, userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch user badges", err)
+		return
+	}
+
+	utils.Success(w, badges)
+}
+
+// GetUserBadgeProgress retourne le catalogue des badges avec la progression d'un utilisateur
+func GetUserBadgeProgress(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+	ctx := context.Background()
+
+	progress, err := utils.GetBadgeCatalogWithProgress(ctx, userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not compute badge progress", err)
+		return
+	}
+
+	utils.Success(w, progress)
+}
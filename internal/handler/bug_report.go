@@ -9,8 +9,10 @@ import (
 	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
 	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/scanner"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
 	"github.com/gorilla/mux"
 )
 
@@ -22,9 +24,8 @@ func CreateBugReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validation
-	if req.Title == "" || req.Description == "" || req.Category == "" {
-		utils.ErrorSimple(w, http.StatusBadRequest, "titre, description et catégorie requis")
+	if err := validation.ValidateBugReport(&req); err != nil {
+		utils.ValidationError(w, err)
 		return
 	}
 
@@ -68,14 +69,25 @@ func CreateBugReport(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, report)
 }
 
-// GetBugReports récupère tous les signalements avec filtres
+// bugReportSort tri de GetBugReports : signalements les plus récents d'abord
+var bugReportSort = pagination.Sort{
+	Name:    "created",
+	Columns: []pagination.Column{{Expr: "created_at", Cast: "timestamp"}},
+	Desc:    true,
+}
+
+// GetBugReports récupère tous les signalements avec filtres (pagination par curseur ou offset)
 func GetBugReports(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	status := query.Get("status")
 	category := query.Get("category")
 	severity := query.Get("severity")
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
 	ctx := context.Background()
 
@@ -109,21 +121,11 @@ func GetBugReports(w http.ResponseWriter, r *http.Request) {
 		argCount++
 	}
 
-	sqlQuery += " ORDER BY created_at DESC"
-
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-		}
+	// Tri et pagination
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, bugReportSort, "id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -143,7 +145,9 @@ func GetBugReports(w http.ResponseWriter, r *http.Request) {
 		reports = append(reports, *report)
 	}
 
-	utils.Success(w, reports)
+	utils.Success(w, pagination.NewPage(reports, page, bugReportSort, func(b model.BugReport) ([]string, string) {
+		return []string{pagination.TimeKey(b.CreatedAt)}, b.ID
+	}))
 }
 
 // GetBugReportById récupère un signalement par son ID
@@ -182,6 +186,11 @@ func UpdateBugReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validation.ValidateBugReportUpdate(&req); err != nil {
+		utils.ValidationError(w, err)
+		return
+	}
+
 	user, err := middleware.GetUserFromContext(r)
 	if err != nil {
 		utils.Error(w, http.StatusUnauthorized, "authentification requise", err)
@@ -3,17 +3,21 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
 	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/scanner"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v5"
 	"github.com/lib/pq"
@@ -83,6 +87,13 @@ func loadChallengeTasks(ctx context.Context, challengeID string, userID *string)
 		tasks = append(tasks, *task)
 	}
 
+	// Verrouillage calculé selon la date de démarrage du participant
+	unlock, err := utils.LoadTaskUnlockContext(ctx, challengeID, userID)
+	if err != nil {
+		logger.Warning("Impossible de charger le contexte de déverrouillage du challenge %s: %v", challengeID, err)
+	}
+	utils.ApplyTaskUnlocks(tasks, unlock, time.Now())
+
 	return tasks, nil
 }
 
@@ -138,7 +149,55 @@ func loadChallengeTask(ctx context.Context, challengeTaskId string, userID *stri
 	return task, nil
 }
 
-// GetChallenges récupère tous les challenges avec filtres optionnels
+// challengeSorts tris disponibles pour GetChallenges (paramètre sortBy)
+var challengeSorts = map[string]pagination.Sort{
+	"CREATED":    {Name: "created", Columns: []pagination.Column{{Expr: "c.created_at", Cast: "timestamp"}}, Desc: true},
+	"POPULAR":    {Name: "popular", Columns: []pagination.Column{{Expr: "COALESCE(c.completions, 0)", Cast: "int"}}, Desc: true},
+	"LIKED":      {Name: "liked", Columns: []pagination.Column{{Expr: "COALESCE(c.likes, 0)", Cast: "int"}}, Desc: true},
+	"RECENT":     {Name: "recent", Columns: []pagination.Column{{Expr: "COALESCE(c.start_date, '-infinity'::timestamp)", Cast: "timestamp"}}, Desc: true},
+	"DIFFICULTY": {Name: "difficulty", Columns: []pagination.Column{{Expr: "CASE c.difficulty WHEN 'BEGINNER' THEN 1 WHEN 'INTERMEDIATE' THEN 2 WHEN 'ADVANCED' THEN 3 ELSE 4 END", Cast: "int"}}},
+	"POINTS":     {Name: "points", Columns: []pagination.Column{{Expr: "COALESCE(c.points, 0)", Cast: "int"}}, Desc: true},
+}
+
+// challengeDifficultyRank ordre des difficultés (même CASE que le tri DIFFICULTY)
+func challengeDifficultyRank(difficulty string) int {
+	switch difficulty {
+	case "BEGINNER":
+		return 1
+	case "INTERMEDIATE":
+		return 2
+	case "ADVANCED":
+		return 3
+	}
+	return 4
+}
+
+// challengeSortKeys clés du curseur suivant pour un tri de challengeSorts
+func challengeSortKeys(sortName string) func(model.Challenge) ([]string, string) {
+	return func(c model.Challenge) ([]string, string) {
+		var key string
+		switch sortName {
+		case "popular":
+			key = pagination.IntKey(c.Completions)
+		case "liked":
+			key = pagination.IntKey(c.Likes)
+		case "recent":
+			key = "-infinity"
+			if c.StartDate != nil {
+				key = pagination.TimeKey(*c.StartDate)
+			}
+		case "difficulty":
+			key = pagination.IntKey(challengeDifficultyRank(c.Difficulty))
+		case "points":
+			key = pagination.IntKey(c.Points)
+		default:
+			key = pagination.TimeKey(c.CreatedAt)
+		}
+		return []string{key}, c.ID
+	}
+}
+
+// GetChallenges récupère tous les challenges avec filtres optionnels (pagination par curseur ou offset)
 func GetChallenges(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 	query := r.URL.Query()
@@ -160,8 +219,12 @@ func GetChallenges(w http.ResponseWriter, r *http.Request) {
 
 	searchQuery := query.Get("searchQuery")
 	sortBy := query.Get("sortBy")
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
 	args := []interface{}{}
 	argCount := 1
@@ -173,7 +236,7 @@ func GetChallenges(w http.ResponseWriter, r *http.Request) {
 				c.type, c.variant, c.difficulty, c.target_reps, c.duration,
 				c.sets, c.reps_per_set, c.image_url, c.icon_name, c.icon_color,
 				c.participants, c.completions, c.likes, c.points, c.badge,
-				c.start_date, c.end_date, c.status, c.tags, c.is_official,
+				c.start_date, c.end_date, c.status, c.tags, c.is_official, c.unlock_mode, c.progress_mode, c.visibility,
 				c.created_by, c.updated_by, c.created_at, c.updated_at,
 				c.deleted_by, c.deleted_at,
 	`
@@ -220,6 +283,18 @@ func GetChallenges(w http.ResponseWriter, r *http.Request) {
 		WHERE deleted_at IS NULL
 	`
 
+	// Visibilité : challenges publics, plus ceux créés par l'utilisateur ou auxquels il est invité
+	if userID == nil {
+		sqlQuery += " AND c.visibility = 'public'"
+	} else if !user.IsAdmin {
+		sqlQuery += ` AND (
+			c.visibility = 'public'
+			OR c.created_by = $1
+			OR EXISTS(SELECT 1 FROM challenge_allowed_users cau WHERE cau.challenge_id = c.id AND cau.user_id = $1)
+			OR EXISTS(SELECT 1 FROM user_challenge_progress ucp WHERE ucp.challenge_id = c.id AND ucp.user_id = $1)
+		)`
+	}
+
 	// Filtres dynamiques
 	for col, val := range filters {
 		if val != "" {
@@ -238,39 +313,15 @@ func GetChallenges(w http.ResponseWriter, r *http.Request) {
 		argCount += 2
 	}
 
-	// Tri
-	sortMap := map[string]string{
-		"POPULAR":    "completions DESC",
-		"LIKED":      "likes DESC",
-		"RECENT":     "start_date DESC NULLS LAST",
-		"DIFFICULTY": "CASE difficulty WHEN 'BEGINNER' THEN 1 WHEN 'INTERMEDIATE' THEN 2 WHEN 'ADVANCED' THEN 3 END",
-		"POINTS":     "points DESC",
-	}
-
-	if order, ok := sortMap[strings.ToUpper(sortBy)]; ok {
-		sqlQuery += " ORDER BY " + order
-	} else {
-		sqlQuery += " ORDER BY created_at DESC"
-	}
-
-	// Pagination
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	} else {
-		// Limite par défaut de 50 pour éviter de retourner trop de données
-		sqlQuery += " LIMIT 50"
+	// Tri et pagination
+	sort, ok := challengeSorts[strings.ToUpper(sortBy)]
+	if !ok {
+		sort = challengeSorts["CREATED"]
 	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-			argCount++
-		}
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, sort, "c.id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -307,7 +358,7 @@ func GetChallenges(w http.ResponseWriter, r *http.Request) {
 		challenges = append(challenges, *challenge)
 	}
 
-	utils.Success(w, challenges)
+	utils.Success(w, pagination.NewPage(challenges, page, sort, challengeSortKeys(sort.Name)))
 }
 
 // GetChallengeById récupère un challenge par son ID
@@ -319,6 +370,11 @@ func GetChallengeById(w http.ResponseWriter, r *http.Request) {
 	user, _ := middleware.GetUserFromContext(r)
 	ctx := context.Background()
 
+	access, ok := authorizeChallengeView(ctx, w, r, challengeID, r.URL.Query().Get("inviteCode"))
+	if !ok {
+		return
+	}
+
 	var row pgx.Row
 	if user.ID != "" {
 		// Utilisateur connecté : vérifier les valeurs user_completed / user_participated
@@ -328,7 +384,7 @@ func GetChallengeById(w http.ResponseWriter, r *http.Request) {
 				c.type, c.variant, c.difficulty, c.target_reps, c.duration,
 				c.sets, c.reps_per_set, c.image_url, c.icon_name, c.icon_color,
 				c.participants, c.completions, c.likes, c.points, c.badge,
-				c.start_date, c.end_date, c.status, c.tags, c.is_official,
+				c.start_date, c.end_date, c.status, c.tags, c.is_official, c.unlock_mode, c.progress_mode, c.visibility,
 				c.created_by, c.updated_by, c.created_at, c.updated_at,
 				c.deleted_by, c.deleted_at,
 
@@ -368,7 +424,7 @@ func GetChallengeById(w http.ResponseWriter, r *http.Request) {
 				c.type, c.variant, c.difficulty, c.target_reps, c.duration,
 				c.sets, c.reps_per_set, c.image_url, c.icon_name, c.icon_color,
 				c.participants, c.completions, c.likes, c.points, c.badge,
-				c.start_date, c.end_date, c.status, c.tags, c.is_official,
+				c.start_date, c.end_date, c.status, c.tags, c.is_official, c.unlock_mode, c.progress_mode, c.visibility,
 				c.created_by, c.updated_by, c.created_at, c.updated_at,
 				c.deleted_by, c.deleted_at,
 				FALSE AS user_completed,
@@ -407,6 +463,11 @@ func GetChallengeById(w http.ResponseWriter, r *http.Request) {
 	// Load creator information
 	utils.EnrichChallengeWithCreator(ctx, challenge)
 
+	// Le lien d'invitation n'est exposé qu'au créateur et aux admins
+	if user.ID != "" && (user.ID == access.OwnerID || user.IsAdmin) {
+		utils.SetChallengeInviteFields(challenge, access.InviteCode)
+	}
+
 	utils.Success(w, challenge)
 }
 
@@ -418,17 +479,82 @@ func CreateChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validation.ValidateChallenge(&challenge); err != nil {
+		utils.ValidationError(w, err)
+		return
+	}
+
+	unlockMode, err := utils.NormalizeUnlockMode(challenge.UnlockMode)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "unlockMode invalide", err)
+		return
+	}
+	challenge.UnlockMode = unlockMode
+
+	progressMode, err := utils.NormalizeProgressMode(challenge.ProgressMode)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "progressMode invalide", err)
+		return
+	}
+	challenge.ProgressMode = progressMode
+
+	visibility, err := utils.NormalizeChallengeVisibility(challenge.Visibility)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "visibility invalide", err)
+		return
+	}
+	challenge.Visibility = visibility
+
+	// Tâches explicites ou générées depuis le template
+	tasks := challenge.Tasks
+	if len(tasks) == 0 && challenge.TaskTemplate != nil {
+		generated, err := utils.GenerateTasksFromTemplate(*challenge.TaskTemplate)
+		if err != nil {
+			utils.Error(w, http.StatusBadRequest, "template de tâches invalide", err)
+			return
+		}
+		tasks = generated
+	}
+	if len(tasks) > 0 {
+		if err := utils.ValidateChallengeTaskDays(tasks); err != nil {
+			utils.Error(w, http.StatusBadRequest, "planning de tâches invalide", err)
+			return
+		}
+	}
+
+	var actorID string
+	user, err := middleware.GetUserFromContext(r)
+	if err == nil {
+		actorID = user.ID
+		if challenge.CreatedBy == nil {
+			challenge.CreatedBy = &actorID
+		}
+	}
+
+	if err := utils.ValidateChallengePrivileges(challenge.Visibility, user.IsAdmin, challenge.IsOfficial, challenge.Points); err != nil {
+		utils.Error(w, http.StatusForbidden, "challenge privé non autorisé", err)
+		return
+	}
+
 	ctx := context.Background()
 
-	err := database.DB.QueryRow(ctx, `
+	// Le challenge et son planning sont créés dans une même transaction
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not start transaction", err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
 		INSERT INTO challenges(
 			title, description, category, type, variant, difficulty,
 			target_reps, duration, sets, reps_per_set, image_url,
 			icon_name, icon_color, participants, completions, likes, points,
 			badge, start_date, end_date, status, tags, is_official,
-			created_by, created_at, updated_at
+			unlock_mode, progress_mode, visibility, created_by, created_at, updated_at
 		) VALUES(
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, NOW(), NOW()
 		)
 		RETURNING id, created_at, updated_at
 	`,
@@ -437,7 +563,8 @@ func CreateChallenge(w http.ResponseWriter, r *http.Request) {
 		challenge.Sets, challenge.RepsPerSet, challenge.ImageURL, challenge.IconName,
 		challenge.IconColor, challenge.Participants, challenge.Completions, challenge.Likes,
 		challenge.Points, challenge.Badge, challenge.StartDate, challenge.EndDate,
-		challenge.Status, pq.Array(challenge.Tags), challenge.IsOfficial, challenge.CreatedBy,
+		challenge.Status, pq.Array(challenge.Tags), challenge.IsOfficial,
+		challenge.UnlockMode, challenge.ProgressMode, challenge.Visibility, challenge.CreatedBy,
 	).Scan(&challenge.ID, &challenge.CreatedAt, &challenge.UpdatedAt)
 
 	if err != nil {
@@ -445,6 +572,33 @@ func CreateChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(tasks) > 0 {
+		if _, err := utils.InsertChallengeTasks(ctx, tx, challenge.ID, tasks, actorID); err != nil {
+			utils.Error(w, http.StatusBadRequest, "could not create challenge tasks", err)
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not create challenge", err)
+		return
+	}
+
+	challenge.TaskTemplate = nil
+	challenge.Tasks, err = loadChallengeTasks(ctx, challenge.ID, nil)
+	if err != nil {
+		logger.Warning("Impossible de charger les tâches du challenge %s: %v", challenge.ID, err)
+	}
+
+	// Lien d'invitation pour les challenges non publics
+	if challenge.Visibility != utils.ChallengeVisibilityPublic {
+		code, err := utils.EnsureChallengeInviteCode(ctx, challenge.ID)
+		if err != nil {
+			logger.Warning("Impossible de générer le code d'invitation du challenge %s: %v", challenge.ID, err)
+		}
+		utils.SetChallengeInviteFields(&challenge, code)
+	}
+
 	utils.Success(w, challenge)
 }
 
@@ -459,20 +613,59 @@ func UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := validation.ValidateChallenge(&challenge); err != nil {
+		utils.ValidationError(w, err)
+		return
+	}
+
 	ctx := context.Background()
 
-	// Récupérer le created_by du challenge pour vérifier la propriété
+	// Récupérer le created_by du challenge pour vérifier la propriété, ainsi que les modes enregistrés
 	var createdBy sql.NullString
-	err := database.DB.QueryRow(ctx,
-		`SELECT created_by FROM challenges WHERE id=$1 AND deleted_at IS NULL`,
-		id,
-	).Scan(&createdBy)
+	var points int
+	var storedUnlockMode, storedProgressMode, storedVisibility string
+	err := database.DB.QueryRow(ctx, `
+		SELECT created_by, points, unlock_mode, progress_mode, visibility
+		FROM challenges WHERE id=$1 AND deleted_at IS NULL
+	`, id).Scan(&createdBy, &points, &storedUnlockMode, &storedProgressMode, &storedVisibility)
 
 	if err != nil {
 		utils.ErrorSimple(w, http.StatusNotFound, "challenge not found")
 		return
 	}
 
+	// Un champ omis conserve la valeur enregistrée plutôt que la valeur par défaut de la création
+	if challenge.UnlockMode == "" {
+		challenge.UnlockMode = storedUnlockMode
+	}
+	if challenge.ProgressMode == "" {
+		challenge.ProgressMode = storedProgressMode
+	}
+	if challenge.Visibility == "" {
+		challenge.Visibility = storedVisibility
+	}
+
+	unlockMode, err := utils.NormalizeUnlockMode(challenge.UnlockMode)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "unlockMode invalide", err)
+		return
+	}
+	challenge.UnlockMode = unlockMode
+
+	progressMode, err := utils.NormalizeProgressMode(challenge.ProgressMode)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "progressMode invalide", err)
+		return
+	}
+	challenge.ProgressMode = progressMode
+
+	visibility, err := utils.NormalizeChallengeVisibility(challenge.Visibility)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "visibility invalide", err)
+		return
+	}
+	challenge.Visibility = visibility
+
 	// Vérifier que l'utilisateur est admin OU propriétaire du challenge
 	var ownerID string
 	if createdBy.Valid {
@@ -483,19 +676,26 @@ func UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Les points ne sont pas modifiables ici : on contrôle ceux déjà attribués
+	if err := utils.ValidateChallengePrivileges(challenge.Visibility, middleware.IsAdmin(r), challenge.IsOfficial, points); err != nil {
+		utils.Error(w, http.StatusForbidden, "challenge privé non autorisé", err)
+		return
+	}
+
 	_, err = database.DB.Exec(ctx, `
 		UPDATE challenges SET
 			title=$1, description=$2, category=$3, type=$4, variant=$5, difficulty=$6,
 			target_reps=$7, duration=$8, sets=$9, reps_per_set=$10, image_url=$11,
 			icon_name=$12, icon_color=$13, badge=$14, start_date=$15, end_date=$16,
-			status=$17, tags=$18, is_official=$19, updated_by=$20, updated_at=NOW()
-		WHERE id=$21 AND deleted_at IS NULL
+			status=$17, tags=$18, is_official=$19, unlock_mode=$20, progress_mode=$21, visibility=$22,
+			updated_by=$23, updated_at=NOW()
+		WHERE id=$24 AND deleted_at IS NULL
 	`,
 		challenge.Title, challenge.Description, challenge.Category, challenge.Type,
 		challenge.Variant, challenge.Difficulty, challenge.TargetReps, challenge.Duration,
 		challenge.Sets, challenge.RepsPerSet, challenge.ImageURL, challenge.IconName,
 		challenge.IconColor, challenge.Badge, challenge.StartDate, challenge.EndDate,
-		challenge.Status, pq.Array(challenge.Tags), challenge.IsOfficial, challenge.UpdatedBy, id,
+		challenge.Status, pq.Array(challenge.Tags), challenge.IsOfficial, challenge.UnlockMode, challenge.ProgressMode, challenge.Visibility, challenge.UpdatedBy, id,
 	)
 
 	if err != nil {
@@ -504,6 +704,14 @@ func UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 	}
 
 	challenge.ID = id
+	challenge.Points = points
+	if challenge.Visibility != utils.ChallengeVisibilityPublic {
+		code, err := utils.EnsureChallengeInviteCode(ctx, id)
+		if err != nil {
+			logger.Warning("Impossible de générer le code d'invitation du challenge %s: %v", id, err)
+		}
+		utils.SetChallengeInviteFields(&challenge, code)
+	}
 	utils.Success(w, challenge)
 }
 
@@ -588,7 +796,7 @@ func LikeChallenge(w http.ResponseWriter, r *http.Request) {
 			id, title, description, category, type, variant, difficulty,
 			target_reps, duration, sets, reps_per_set, image_url,
 			icon_name, icon_color, participants, completions, likes, points,
-			badge, start_date, end_date, status, tags, is_official,
+			badge, start_date, end_date, status, tags, is_official, unlock_mode, progress_mode, visibility,
 			created_by, updated_by, deleted_by, created_at, updated_at, deleted_at,
 			COALESCE((
 				SELECT TRUE
@@ -652,7 +860,7 @@ func UnlikeChallenge(w http.ResponseWriter, r *http.Request) {
 			id, title, description, category, type, variant, difficulty,
 			target_reps, duration, sets, reps_per_set, image_url,
 			icon_name, icon_color, participants, completions, likes, points,
-			badge, start_date, end_date, status, tags, is_official,
+			badge, start_date, end_date, status, tags, is_official, unlock_mode, progress_mode, visibility,
 			created_by, updated_by, deleted_by, created_at, updated_at, deleted_at,
 			COALESCE((
 				SELECT TRUE
@@ -696,33 +904,60 @@ func StartChallenge(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	challengeID := vars["id"]
 
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
 	var payload struct {
-		UserID string `json:"userId"`
+		InviteCode string `json:"inviteCode,omitempty"`
 	}
-	if err := utils.DecodeJSON(r, &payload); err != nil {
-		utils.ErrorSimple(w, http.StatusBadRequest, "invalid JSON body")
-		return
+	if r.ContentLength != 0 {
+		if err := utils.DecodeJSON(r, &payload); err != nil {
+			utils.ErrorSimple(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
 	}
 
 	ctx := context.Background()
 
-	// Récupérer les infos du challenge
-	var targetReps int
-	err := database.DB.QueryRow(ctx,
-		`SELECT target_reps FROM challenges WHERE id=$1 AND deleted_at IS NULL`,
+	access, ok := authorizeChallengeView(ctx, w, r, challengeID, payload.InviteCode)
+	if !ok {
+		return
+	}
+
+	// Un code d'invitation valide inscrit l'utilisateur sur la liste des invités
+	if payload.InviteCode != "" && strings.EqualFold(payload.InviteCode, access.InviteCode) {
+		if err := utils.AddChallengeAllowedUsers(ctx, challengeID, []string{user.ID}, nil); err != nil {
+			logger.Warning("Impossible d'ajouter %s aux invités du challenge %s: %v", user.ID, challengeID, err)
+		}
+	}
+
+	// Récupérer les infos du challenge (target_reps est NULL pour les challenges à tâches)
+	var targetReps sql.NullInt64
+	var ended bool
+	err = database.DB.QueryRow(ctx,
+		`SELECT target_reps, (finalized_at IS NOT NULL OR (end_date IS NOT NULL AND end_date <= NOW()))
+		FROM challenges WHERE id=$1 AND deleted_at IS NULL`,
 		challengeID,
-	).Scan(&targetReps)
+	).Scan(&targetReps, &ended)
 
 	if err != nil {
 		utils.Error(w, http.StatusNotFound, "challenge not found", err)
 		return
 	}
 
+	if ended {
+		utils.ErrorSimple(w, http.StatusConflict, "challenge terminé")
+		return
+	}
+
 	// Vérifier si l'utilisateur a déjà commencé ce challenge
 	var exists bool
 	err = database.DB.QueryRow(ctx,
 		`SELECT EXISTS(SELECT 1 FROM user_challenge_progress WHERE challenge_id=$1 AND user_id=$2)`,
-		challengeID, payload.UserID,
+		challengeID, user.ID,
 	).Scan(&exists)
 
 	if err != nil {
@@ -739,8 +974,8 @@ func StartChallenge(w http.ResponseWriter, r *http.Request) {
 	row := database.DB.QueryRow(ctx, `
 		INSERT INTO user_challenge_progress(challenge_id, user_id, progress, current_reps, target_reps, attempts, completed_at, created_at, updated_at)
 		VALUES($1, $2, 0, 0, $3, 0, NULL, NOW(), NOW())
-		RETURNING id, challenge_id, user_id, progress, current_reps, target_reps, attempts, completed_at, created_at, updated_at
-	`, challengeID, payload.UserID, targetReps)
+		RETURNING id, challenge_id, user_id, progress, current_reps, target_reps, attempts, completed_at, status, created_at, updated_at
+	`, challengeID, user.ID, targetReps.Int64)
 
 	progress, err := scanner.ScanUserChallengeProgress(row)
 	if err != nil {
@@ -762,61 +997,38 @@ func StartChallenge(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, progress)
 }
 
-// CompleteChallenge marque un challenge comme complété
+// CompleteChallenge vérifie la progression de l'utilisateur connecté et complète le challenge
+// uniquement si l'objectif est atteint (calculé depuis ses séances ou ses tâches)
 func CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	challengeID := vars["id"]
 
-	var payload struct {
-		UserID string `json:"userId"`
-	}
-	if err := utils.DecodeJSON(r, &payload); err != nil {
-		utils.ErrorSimple(w, http.StatusBadRequest, "invalid JSON body")
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
 		return
 	}
 
 	ctx := context.Background()
 
-	// Mettre à jour la progression
-	row := database.DB.QueryRow(ctx, `
-		UPDATE user_challenge_progress
-		SET progress=100, completed_at=NOW(), updated_at=NOW()
-		WHERE challenge_id=$1 AND user_id=$2
-		RETURNING id, challenge_id, user_id, progress, current_reps, target_reps, attempts, completed_at, created_at, updated_at
-	`, challengeID, payload.UserID)
-
-	progress, err := scanner.ScanUserChallengeProgress(row)
+	progress, _, err := utils.RecomputeChallengeProgress(ctx, user.ID, challengeID)
 	if err != nil {
-		utils.Error(w, http.StatusNotFound, "progress not found", err)
+		switch {
+		case errors.Is(err, utils.ErrChallengeNotFound):
+			utils.Error(w, http.StatusNotFound, "challenge not found", err)
+		case errors.Is(err, utils.ErrChallengeNotStarted):
+			utils.Error(w, http.StatusNotFound, "progress not found", err)
+		default:
+			utils.Error(w, http.StatusInternalServerError, "could not compute challenge progress", err)
+		}
 		return
 	}
 
-	// Incrémenter le nombre de complétions
-	_, err = database.DB.Exec(ctx,
-		`UPDATE challenges SET completions = completions + 1 WHERE id=$1`,
-		challengeID,
-	)
-
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "could not increment completions", err)
+	if progress.CompletedAt == nil {
+		utils.ErrorSimple(w, http.StatusConflict, fmt.Sprintf("objectif non atteint (%d%%, %d/%d)", progress.Progress, progress.CurrentReps, progress.TargetReps))
 		return
 	}
 
-	// Récupérer les points du challenge et les ajouter au score de l'utilisateur
-	var challengePoints int
-	err = database.DB.QueryRow(ctx, `
-		SELECT points FROM challenges WHERE id = $1
-	`, challengeID).Scan(&challengePoints)
-
-	if err == nil && challengePoints > 0 {
-		// Incrémenter le score de l'utilisateur
-		if err := utils.IncrementUserScore(ctx, payload.UserID, challengePoints); err != nil {
-			// Log l'erreur mais ne pas bloquer la complétion du challenge
-			utils.Error(w, http.StatusInternalServerError, "could not update user score", err)
-			return
-		}
-	}
-
 	utils.Success(w, progress)
 }
 
@@ -829,7 +1041,7 @@ func GetUserChallengeProgress(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 
 	row := database.DB.QueryRow(ctx, `
-		SELECT id, challenge_id, user_id, progress, current_reps, target_reps, attempts, completed_at, created_at, updated_at
+		SELECT id, challenge_id, user_id, progress, current_reps, target_reps, attempts, completed_at, status, created_at, updated_at
 		FROM user_challenge_progress
 		WHERE challenge_id=$1 AND user_id=$2
 	`, challengeID, userID)
@@ -855,7 +1067,7 @@ func GetUserActiveChallenges(w http.ResponseWriter, r *http.Request) {
 			c.id, c.title, c.description, c.category, c.type, c.variant, c.difficulty,
 			c.target_reps, c.duration, c.sets, c.reps_per_set, c.image_url,
 			c.icon_name, c.icon_color, c.participants, c.completions, c.likes, c.points,
-			c.badge, c.start_date, c.end_date, c.status, c.tags, c.is_official,
+			c.badge, c.start_date, c.end_date, c.status, c.tags, c.is_official, c.unlock_mode, c.progress_mode, c.visibility,
 			c.created_by, c.updated_by, c.deleted_by, c.created_at, c.updated_at, c.deleted_at,
 			TRUE AS user_completed,
 			COALESCE((
@@ -915,7 +1127,7 @@ func GetUserCompletedChallenges(w http.ResponseWriter, r *http.Request) {
 			c.id, c.title, c.description, c.category, c.type, c.variant, c.difficulty,
 			c.target_reps, c.duration, c.sets, c.reps_per_set, c.image_url,
 			c.icon_name, c.icon_color, c.participants, c.completions, c.likes, c.points,
-			c.badge, c.start_date, c.end_date, c.status, c.tags, c.is_official,
+			c.badge, c.start_date, c.end_date, c.status, c.tags, c.is_official, c.unlock_mode, c.progress_mode, c.visibility,
 			c.created_by, c.updated_by, c.deleted_by, c.created_at, c.updated_at, c.deleted_at,
 			TRUE AS user_completed,
 			COALESCE((
@@ -963,7 +1175,30 @@ func GetUserCompletedChallenges(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, challenges)
 }
 
-// CompleteTask marque une task comme complétée
+// requireUnlockedChallengeTask calcule l'état de verrouillage d'une tâche pour un participant.
+// Écrit la réponse d'erreur et retourne false si la tâche n'appartient pas au challenge ou est verrouillée.
+func requireUnlockedChallengeTask(ctx context.Context, w http.ResponseWriter, challengeID, taskID, userID string) (*model.ChallengeTask, bool) {
+	challengeTasks, err := loadChallengeTasks(ctx, challengeID, &userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load challenge tasks", err)
+		return nil, false
+	}
+	for i, t := range challengeTasks {
+		if t.ID != taskID {
+			continue
+		}
+		if t.IsLocked {
+			utils.ErrorSimple(w, http.StatusForbidden, utils.TaskLockedMessage(t))
+			return nil, false
+		}
+		return &challengeTasks[i], true
+	}
+	utils.ErrorSimple(w, http.StatusNotFound, "task not found")
+	return nil, false
+}
+
+// CompleteTask marque une task comme complétée. La tâche doit avoir été réalisée
+// par une séance complétée liée à la tâche (sauf jour de repos, qui n'a rien à vérifier).
 func CompleteTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	taskID := vars["taskId"]
@@ -983,99 +1218,84 @@ func CompleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Vérifier si c'est la première task que l'utilisateur complète pour ce challenge
-	var isFirstTask bool
-	err = database.DB.QueryRow(ctx, `
-		SELECT NOT EXISTS(
-			SELECT 1 FROM user_challenge_task_progress
-			WHERE user_id = $1 AND challenge_id = $2
-		)
-	`, user.ID, task.ChallengeID).Scan(&isFirstTask)
-
-	if err != nil {
-		logger.Error("Could not check if first task: %v", err)
-		isFirstTask = false
+	if _, ok := authorizeChallengeView(ctx, w, r, task.ChallengeID, ""); !ok {
+		return
 	}
 
-	_, err = database.DB.Exec(ctx, `
-		INSERT INTO user_challenge_task_progress(user_id, task_id, challenge_id, completed, completed_at, score, attempts, created_at, updated_at)
-		VALUES($1, $2, $3, true, NOW(), $4, 1, NOW(), NOW())
-		ON CONFLICT (user_id, task_id)
-		DO UPDATE SET
-			completed = true,
-			completed_at = NOW(),
-			attempts = user_challenge_task_progress.attempts + 1,
-			updated_at = NOW()
-	`, user.ID, task.ID, task.ChallengeID, task.Score)
+	// Les résultats d'un challenge clôturé ou terminé sont figés
+	ended, err := utils.IsChallengeEnded(ctx, task.ChallengeID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "could not create progress", err)
+		utils.Error(w, http.StatusInternalServerError, "could not check challenge status", err)
 		return
 	}
-
-	// Si c'est la première task de ce challenge pour cet utilisateur, incrémenter participants
-	if isFirstTask {
-		_, err = database.DB.Exec(ctx, `
-			UPDATE challenges SET participants = participants + 1 WHERE id = $1
-		`, task.ChallengeID)
-
-		if err != nil {
-			logger.Error("Could not increment participants for challenge %s: %v", task.ChallengeID, err)
-		}
+	if ended {
+		utils.ErrorSimple(w, http.StatusConflict, "challenge terminé")
+		return
 	}
 
-	// Vérifier si toutes les tasks du challenge sont complétées par cet utilisateur
-	var totalTasks, completedTasks int
-	err = database.DB.QueryRow(ctx, `
-		SELECT
-			COUNT(*) as total_tasks,
-			COUNT(uctp.id) FILTER (WHERE uctp.completed = true) as completed_tasks
-		FROM challenge_tasks ct
-		LEFT JOIN user_challenge_task_progress uctp
-			ON ct.id = uctp.task_id AND uctp.user_id = $1
-		WHERE ct.challenge_id = $2 AND ct.deleted_at IS NULL
-	`, user.ID, task.ChallengeID).Scan(&totalTasks, &completedTasks)
-
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "could not check challenge completion", err)
+	// Vérifier que la tâche est déverrouillée pour ce participant
+	unlocked, ok := requireUnlockedChallengeTask(ctx, w, task.ChallengeID, task.ID, user.ID)
+	if !ok {
 		return
 	}
+	task.IsLocked = unlocked.IsLocked
+	task.UnlocksAt = unlocked.UnlocksAt
 
-	// Si toutes les tasks sont complétées, incrémenter les completions
-	if totalTasks > 0 && completedTasks == totalTasks {
-		// Vérifier si on n'a pas déjà incrémenté les completions pour cet utilisateur
-		var alreadyCounted bool
+	// Seule une séance complétée liée à la tâche la valide
+	if task.Type == nil || *task.Type != utils.ChallengeTaskRestType {
+		var verified bool
 		err = database.DB.QueryRow(ctx, `
 			SELECT EXISTS(
-				SELECT 1 FROM user_challenge_progress
-				WHERE challenge_id = $1 AND user_id = $2 AND progress = 100
+				SELECT 1 FROM workout_sessions
+				WHERE user_id = $1 AND challenge_task_id = $2 AND completed = TRUE
 			)
-		`, task.ChallengeID, user.ID).Scan(&alreadyCounted)
+		`, user.ID, task.ID).Scan(&verified)
+		if err != nil {
+			utils.Error(w, http.StatusInternalServerError, "could not check task sessions", err)
+			return
+		}
+		if !verified {
+			utils.ErrorSimple(w, http.StatusConflict, "aucune séance complétée pour cette tâche")
+			return
+		}
+	}
 
-		if err == nil && !alreadyCounted {
-			// Incrémenter les completions du challenge
-			_, err = database.DB.Exec(ctx, `
-				UPDATE challenges SET completions = completions + 1 WHERE id = $1
-			`, task.ChallengeID)
+	// Inscrire l'utilisateur au challenge s'il ne l'était pas : seule une inscription
+	// effective compte un nouveau participant
+	res, err := database.DB.Exec(ctx, `
+		INSERT INTO user_challenge_progress(challenge_id, user_id, progress, current_reps, target_reps, attempts, status, created_at, updated_at)
+		VALUES($1, $2, 0, 0, 0, 0, 'in_progress', NOW(), NOW())
+		ON CONFLICT (challenge_id, user_id) DO NOTHING
+	`, task.ChallengeID, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not create challenge progress", err)
+		return
+	}
+	if res.RowsAffected() > 0 {
+		if _, err := database.DB.Exec(ctx, `
+			UPDATE challenges SET participants = participants + 1 WHERE id = $1
+		`, task.ChallengeID); err != nil {
+			logger.Error("Could not increment participants for challenge %s: %v", task.ChallengeID, err)
+		}
+	}
 
-			if err != nil {
-				logger.Error("Could not increment completions for challenge %s: %v", task.ChallengeID, err)
-			}
+	_, err = database.DB.Exec(ctx, `
+		INSERT INTO user_challenge_task_progress(user_id, task_id, challenge_id, completed, completed_at, score, attempts, created_at, updated_at)
+		VALUES($1, $2, $3, true, NOW(), $4, 0, NOW(), NOW())
+		ON CONFLICT (user_id, task_id)
+		DO UPDATE SET
+			completed = true,
+			completed_at = COALESCE(user_challenge_task_progress.completed_at, NOW()),
+			updated_at = NOW()
+	`, user.ID, task.ID, task.ChallengeID, task.Score)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not create progress", err)
+		return
+	}
 
-			// Mettre à jour la progression de l'utilisateur à 100%
-			_, err = database.DB.Exec(ctx, `
-				INSERT INTO user_challenge_progress(challenge_id, user_id, progress, current_reps, target_reps, attempts, completed_at, created_at, updated_at)
-				VALUES($1, $2, 100, 0, 0, 1, NOW(), NOW(), NOW())
-				ON CONFLICT (challenge_id, user_id)
-				DO UPDATE SET
-					progress = 100,
-					completed_at = NOW(),
-					updated_at = NOW()
-			`, task.ChallengeID, user.ID)
-
-			if err != nil {
-				logger.Error("Could not update user challenge progress: %v", err)
-			}
-		}
+	// Recalculer la progression (le challenge est complété automatiquement quand toutes les tâches le sont)
+	if _, _, err := utils.RecomputeChallengeProgress(ctx, user.ID, task.ChallengeID); err != nil {
+		logger.Error("Could not update user challenge progress: %v", err)
 	}
 
 	utils.Success(w, task)
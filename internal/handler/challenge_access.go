@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// authorizeChallengeView vérifie que l'utilisateur courant (éventuellement anonyme) peut accéder au challenge.
+// Un code d'invitation valide (inviteCode) donne accès à un challenge privé.
+// Écrit la réponse d'erreur et retourne false si l'accès est refusé.
+func authorizeChallengeView(ctx context.Context, w http.ResponseWriter, r *http.Request, challengeID, inviteCode string) (utils.ChallengeAccessInfo, bool) {
+	user, _ := middleware.GetUserFromContext(r)
+
+	info, err := utils.LoadChallengeAccess(ctx, challengeID, user.ID)
+	if err != nil {
+		if errors.Is(err, utils.ErrChallengeNotFound) {
+			utils.ErrorSimple(w, http.StatusNotFound, "challenge not found")
+		} else {
+			utils.Error(w, http.StatusInternalServerError, "could not check challenge access", err)
+		}
+		return info, false
+	}
+
+	isManager := user.ID != "" && (user.ID == info.OwnerID || user.IsAdmin)
+	allowed := info.Allowed || (inviteCode != "" && info.InviteCode != "" && strings.EqualFold(inviteCode, info.InviteCode))
+
+	if !utils.CanViewChallenge(info.Visibility, isManager, allowed) {
+		// Un challenge privé n'est pas révélé aux personnes non invitées
+		utils.ErrorSimple(w, http.StatusNotFound, "challenge not found")
+		return info, false
+	}
+
+	return info, true
+}
+
+// JoinChallengeByInvite ajoute l'utilisateur connecté aux invités d'un challenge via son code d'invitation
+func JoinChallengeByInvite(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var payload struct {
+		InviteCode string `json:"inviteCode"`
+	}
+	if err := utils.DecodeJSON(r, &payload); err != nil || strings.TrimSpace(payload.InviteCode) == "" {
+		utils.ErrorSimple(w, http.StatusBadRequest, "inviteCode requis")
+		return
+	}
+
+	ctx := context.Background()
+	challengeID, err := utils.JoinChallengeByInviteCode(ctx, payload.InviteCode, user.ID)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidChallengeInvite) {
+			utils.Error(w, http.StatusNotFound, "code d'invitation invalide", err)
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "impossible de rejoindre le challenge", err)
+		return
+	}
+
+	logger.Info("%s a rejoint le challenge %s via une invitation", user.ID, challengeID)
+	utils.Success(w, map[string]string{"challengeId": challengeID})
+}
+
+// GetChallengeAllowedUsers liste les invités d'un challenge (propriétaire ou admin)
+func GetChallengeAllowedUsers(w http.ResponseWriter, r *http.Request) {
+	challengeID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	if _, ok := authorizeChallengeTaskEdit(ctx, w, r, challengeID); !ok {
+		return
+	}
+
+	users, err := utils.GetChallengeAllowedUsers(ctx, challengeID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load allowed users", err)
+		return
+	}
+
+	utils.Success(w, users)
+}
+
+// AddChallengeAllowedUsers ajoute des utilisateurs aux invités d'un challenge (propriétaire ou admin)
+func AddChallengeAllowedUsers(w http.ResponseWriter, r *http.Request) {
+	challengeID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	user, ok := authorizeChallengeTaskEdit(ctx, w, r, challengeID)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		UserIDs []string `json:"userIds"`
+	}
+	if err := utils.DecodeJSON(r, &payload); err != nil || len(payload.UserIDs) == 0 {
+		utils.ErrorSimple(w, http.StatusBadRequest, "userIds requis")
+		return
+	}
+
+	if err := utils.AddChallengeAllowedUsers(ctx, challengeID, payload.UserIDs, &user.ID); err != nil {
+		utils.Error(w, http.StatusBadRequest, "impossible d'ajouter les invités", err)
+		return
+	}
+
+	users, err := utils.GetChallengeAllowedUsers(ctx, challengeID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load allowed users", err)
+		return
+	}
+
+	utils.Success(w, users)
+}
+
+// RemoveChallengeAllowedUser retire un utilisateur des invités d'un challenge (propriétaire ou admin)
+func RemoveChallengeAllowedUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	challengeID := vars["id"]
+	ctx := context.Background()
+
+	if _, ok := authorizeChallengeTaskEdit(ctx, w, r, challengeID); !ok {
+		return
+	}
+
+	if err := utils.RemoveChallengeAllowedUser(ctx, challengeID, vars["userId"]); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "impossible de retirer l'invité", err)
+		return
+	}
+
+	utils.Message(w, "invité retiré")
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/config"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/services"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// GetChallengeCertificate renvoie le certificat de réussite d'un challenge terminé (params: format=png|pdf).
+// Un admin peut récupérer le certificat d'un autre utilisateur via userId.
+func GetChallengeCertificate(w http.ResponseWriter, r *http.Request) {
+	challengeID := mux.Vars(r)["id"]
+
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	userID := user.ID
+	if requested := r.URL.Query().Get("userId"); requested != "" && requested != user.ID {
+		if !user.IsAdmin {
+			utils.ErrorSimple(w, http.StatusForbidden, "you can only access your own certificates")
+			return
+		}
+		userID = requested
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "pdf" {
+		utils.ErrorSimple(w, http.StatusBadRequest, "format invalide (png ou pdf)")
+		return
+	}
+
+	ctx := context.Background()
+
+	if _, ok := authorizeChallengeView(ctx, w, r, challengeID, ""); !ok {
+		return
+	}
+
+	cert, err := utils.GetOrCreateChallengeCertificate(ctx, challengeID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrChallengeNotFound), errors.Is(err, utils.ErrChallengeNotStarted):
+			utils.Error(w, http.StatusNotFound, "progress not found", err)
+		case errors.Is(err, utils.ErrChallengeNotCompleted):
+			utils.Error(w, http.StatusConflict, "challenge non terminé", err)
+		default:
+			utils.Error(w, http.StatusInternalServerError, "could not generate certificate", err)
+		}
+		return
+	}
+
+	if cert.Generated {
+		uploadCertificate(ctx, cert)
+	}
+
+	etag := fmt.Sprintf(`"%s-%s"`, utils.CertificateReference(cert.Fingerprint), format)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if cert.ImageURL != nil {
+		w.Header().Set("X-Certificate-URL", *cert.ImageURL)
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, contentType := cert.PNG, "image/png"
+	if format == "pdf" {
+		body, contentType = cert.PDF, "application/pdf"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="certificat-%s.%s"`, challengeID, format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// certificateUploader service d'upload des certificats (nil : upload désactivé)
+var certificateUploader *services.CloudinaryService
+
+// ConfigureCertificateUpload active l'upload des certificats sur Cloudinary si l'option est activée
+// et les identifiants Cloudinary renseignés
+func ConfigureCertificateUpload(cfg *config.Config) {
+	certificateUploader = nil
+	if !cfg.CertificateUpload {
+		return
+	}
+
+	cloudinaryService, err := services.NewCloudinaryService(cfg)
+	if err != nil {
+		logger.Warning("Upload des certificats désactivé, Cloudinary indisponible: %v", err)
+		return
+	}
+	certificateUploader = cloudinaryService
+}
+
+// uploadCertificate publie le PNG sur Cloudinary si l'option est activée (les erreurs sont journalisées)
+func uploadCertificate(ctx context.Context, cert *model.ChallengeCertificate) {
+	if certificateUploader == nil {
+		return
+	}
+
+	url, err := certificateUploader.UploadCertificate(ctx, bytes.NewReader(cert.PNG), cert.Data.ChallengeID, cert.Data.UserID)
+	if err != nil {
+		logger.Warning("Impossible d'uploader le certificat de %s (challenge %s): %v", cert.Data.UserID, cert.Data.ChallengeID, err)
+		return
+	}
+
+	if err := utils.SetChallengeCertificateURL(ctx, cert.Data.ChallengeID, cert.Data.UserID, cert.Fingerprint, url); err != nil {
+		logger.Warning("Impossible d'enregistrer l'URL du certificat: %v", err)
+		return
+	}
+	cert.ImageURL = &url
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// challengeTaskErrorStatus convertit une erreur de planning en code HTTP
+func challengeTaskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrChallengeNotFound), errors.Is(err, utils.ErrChallengeTaskNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// authorizeChallengeTaskEdit vérifie que l'utilisateur est propriétaire du challenge ou admin.
+// Écrit la réponse d'erreur et retourne false si l'accès est refusé.
+func authorizeChallengeTaskEdit(ctx context.Context, w http.ResponseWriter, r *http.Request, challengeID string) (model.UserProfile, bool) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return user, false
+	}
+
+	var createdBy sql.NullString
+	err = database.DB.QueryRow(ctx,
+		`SELECT created_by FROM challenges WHERE id=$1 AND deleted_at IS NULL`,
+		challengeID,
+	).Scan(&createdBy)
+	if err != nil {
+		utils.ErrorSimple(w, http.StatusNotFound, "challenge not found")
+		return user, false
+	}
+
+	if !middleware.IsOwnerOrAdmin(r, utils.NullStringToString(createdBy)) {
+		utils.ErrorSimple(w, http.StatusForbidden, "you are not authorized to edit this challenge's tasks")
+		return user, false
+	}
+
+	return user, true
+}
+
+// respondChallengeTasks renvoie le planning complet du challenge après modification
+func respondChallengeTasks(ctx context.Context, w http.ResponseWriter, challengeID string) {
+	tasks, err := loadChallengeTasks(ctx, challengeID, nil)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load challenge tasks", err)
+		return
+	}
+	if tasks == nil {
+		tasks = []model.ChallengeTask{}
+	}
+	utils.Success(w, tasks)
+}
+
+// CreateChallengeTask ajoute une tâche au planning d'un challenge (day vide = à la fin)
+func CreateChallengeTask(w http.ResponseWriter, r *http.Request) {
+	challengeID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	user, ok := authorizeChallengeTaskEdit(ctx, w, r, challengeID)
+	if !ok {
+		return
+	}
+
+	var task model.ChallengeTask
+	if err := utils.DecodeJSON(r, &task); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+	task.Title = strings.TrimSpace(task.Title)
+
+	if _, err := utils.CreateChallengeTask(ctx, challengeID, task, user.ID); err != nil {
+		utils.Error(w, challengeTaskErrorStatus(err), "could not create challenge task", err)
+		return
+	}
+
+	respondChallengeTasks(ctx, w, challengeID)
+}
+
+// BulkCreateChallengeTasks ajoute plusieurs tâches (liste explicite ou template de progression).
+// Avec replace=true, le planning existant est remplacé.
+func BulkCreateChallengeTasks(w http.ResponseWriter, r *http.Request) {
+	challengeID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	user, ok := authorizeChallengeTaskEdit(ctx, w, r, challengeID)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Tasks    []model.ChallengeTask        `json:"tasks"`
+		Template *model.ChallengeTaskTemplate `json:"template"`
+		Replace  bool                         `json:"replace"`
+	}
+	if err := utils.DecodeJSON(r, &body); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	tasks := body.Tasks
+	if len(tasks) == 0 && body.Template != nil {
+		generated, err := utils.GenerateTasksFromTemplate(*body.Template)
+		if err != nil {
+			utils.Error(w, http.StatusBadRequest, "template invalide", err)
+			return
+		}
+		tasks = generated
+	}
+	if len(tasks) == 0 {
+		utils.ErrorSimple(w, http.StatusBadRequest, "tasks ou template requis")
+		return
+	}
+
+	if _, err := utils.BulkCreateChallengeTasks(ctx, challengeID, tasks, body.Replace, user.ID); err != nil {
+		utils.Error(w, challengeTaskErrorStatus(err), "could not create challenge tasks", err)
+		return
+	}
+
+	respondChallengeTasks(ctx, w, challengeID)
+}
+
+// UpdateChallengeTask met à jour une tâche (le jour se modifie via /tasks/order)
+func UpdateChallengeTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	challengeID := vars["id"]
+	taskID := vars["taskId"]
+	ctx := context.Background()
+
+	user, ok := authorizeChallengeTaskEdit(ctx, w, r, challengeID)
+	if !ok {
+		return
+	}
+
+	var task model.ChallengeTask
+	if err := utils.DecodeJSON(r, &task); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+	task.Title = strings.TrimSpace(task.Title)
+
+	if err := utils.UpdateChallengeTask(ctx, challengeID, taskID, task, user.ID); err != nil {
+		utils.Error(w, challengeTaskErrorStatus(err), "could not update challenge task", err)
+		return
+	}
+
+	updated, err := loadChallengeTask(ctx, taskID, nil)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load challenge task", err)
+		return
+	}
+
+	utils.Success(w, updated)
+}
+
+// DeleteChallengeTask supprime une tâche, les jours suivants sont décalés
+func DeleteChallengeTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	challengeID := vars["id"]
+	taskID := vars["taskId"]
+	ctx := context.Background()
+
+	user, ok := authorizeChallengeTaskEdit(ctx, w, r, challengeID)
+	if !ok {
+		return
+	}
+
+	if err := utils.DeleteChallengeTask(ctx, challengeID, taskID, user.ID); err != nil {
+		utils.Error(w, challengeTaskErrorStatus(err), "could not delete challenge task", err)
+		return
+	}
+
+	respondChallengeTasks(ctx, w, challengeID)
+}
+
+// ReorderChallengeTasks réordonne le planning (taskIds dans l'ordre des jours)
+func ReorderChallengeTasks(w http.ResponseWriter, r *http.Request) {
+	challengeID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	user, ok := authorizeChallengeTaskEdit(ctx, w, r, challengeID)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		TaskIDs []string `json:"taskIds"`
+	}
+	if err := utils.DecodeJSON(r, &body); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	if err := utils.ReorderChallengeTasks(ctx, challengeID, body.TaskIDs, user.ID); err != nil {
+		utils.Error(w, challengeTaskErrorStatus(err), "could not reorder challenge tasks", err)
+		return
+	}
+
+	respondChallengeTasks(ctx, w, challengeID)
+}
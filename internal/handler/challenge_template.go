@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// maxTemplatePreview nombre maximum d'occurrences renvoyées par l'aperçu
+const maxTemplatePreview = 52
+
+// GetChallengeTemplates liste les modèles de challenges récurrents (admin)
+func GetChallengeTemplates(w http.ResponseWriter, r *http.Request) {
+	if !middleware.IsAdmin(r) {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	activeOnly := r.URL.Query().Get("active") == "true"
+	templates, err := utils.ListChallengeTemplates(context.Background(), activeOnly)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not query challenge templates", err)
+		return
+	}
+
+	utils.Success(w, templates)
+}
+
+// GetChallengeTemplate récupère un modèle de challenge récurrent (admin)
+func GetChallengeTemplate(w http.ResponseWriter, r *http.Request) {
+	if !middleware.IsAdmin(r) {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	tpl, err := utils.GetChallengeTemplate(context.Background(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, utils.ErrChallengeTemplateNotFound) {
+			utils.Error(w, http.StatusNotFound, "challenge template not found", err)
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "could not load challenge template", err)
+		return
+	}
+
+	utils.Success(w, tpl)
+}
+
+// CreateChallengeTemplate crée un modèle de challenge récurrent (admin)
+func CreateChallengeTemplate(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+	if !user.IsAdmin {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	var tpl model.ChallengeTemplate
+	if err := utils.DecodeJSON(r, &tpl); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+	tpl.Active = true
+
+	created, err := utils.CreateChallengeTemplate(context.Background(), &tpl, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "could not create challenge template", err)
+		return
+	}
+
+	utils.Success(w, created)
+}
+
+// UpdateChallengeTemplate met à jour un modèle de challenge récurrent (admin)
+func UpdateChallengeTemplate(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+	if !user.IsAdmin {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	var tpl model.ChallengeTemplate
+	if err := utils.DecodeJSON(r, &tpl); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	updated, err := utils.UpdateChallengeTemplate(context.Background(), mux.Vars(r)["id"], &tpl, user.ID)
+	if err != nil {
+		if errors.Is(err, utils.ErrChallengeTemplateNotFound) {
+			utils.Error(w, http.StatusNotFound, "challenge template not found", err)
+			return
+		}
+		utils.Error(w, http.StatusBadRequest, "could not update challenge template", err)
+		return
+	}
+
+	utils.Success(w, updated)
+}
+
+// DeleteChallengeTemplate supprime un modèle ; les challenges déjà créés sont conservés (admin)
+func DeleteChallengeTemplate(w http.ResponseWriter, r *http.Request) {
+	if !middleware.IsAdmin(r) {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	if err := utils.DeleteChallengeTemplate(context.Background(), mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, utils.ErrChallengeTemplateNotFound) {
+			utils.Error(w, http.StatusNotFound, "challenge template not found", err)
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "could not delete challenge template", err)
+		return
+	}
+
+	utils.Message(w, "modèle supprimé")
+}
+
+// PreviewChallengeTemplate calcule les prochaines occurrences d'un modèle sans les créer (admin)
+func PreviewChallengeTemplate(w http.ResponseWriter, r *http.Request) {
+	if !middleware.IsAdmin(r) {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	count := 10
+	if countStr := r.URL.Query().Get("count"); countStr != "" {
+		if c, err := strconv.Atoi(countStr); err == nil && c > 0 {
+			count = c
+		}
+	}
+	if count > maxTemplatePreview {
+		count = maxTemplatePreview
+	}
+
+	ctx := context.Background()
+	tpl, err := utils.GetChallengeTemplate(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, utils.ErrChallengeTemplateNotFound) {
+			utils.Error(w, http.StatusNotFound, "challenge template not found", err)
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "could not load challenge template", err)
+		return
+	}
+
+	occurrences, err := utils.PreviewChallengeTemplate(*tpl, time.Now(), count)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "could not preview challenge template", err)
+		return
+	}
+
+	utils.Success(w, occurrences)
+}
+
+// SpawnChallengeTemplates crée immédiatement les occurrences dues de tous les modèles actifs (admin)
+func SpawnChallengeTemplates(w http.ResponseWriter, r *http.Request) {
+	if !middleware.IsAdmin(r) {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	created, err := utils.SpawnChallengeInstances(context.Background(), time.Now())
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not spawn challenges", err)
+		return
+	}
+
+	utils.Success(w, map[string]int{"created": created})
+}
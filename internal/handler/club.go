@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// clubErrorStatus convertit une erreur métier de club en code HTTP
+func clubErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrClubNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrClubFull), errors.Is(err, utils.ErrClubOwnerLeaving):
+		return http.StatusConflict
+	case errors.Is(err, utils.ErrNotClubMember):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateClub crée un club, l'utilisateur connecté en devient propriétaire
+func CreateClub(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var club model.Club
+	if err := utils.DecodeJSON(r, &club); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	club.Name = strings.TrimSpace(club.Name)
+	if club.Name == "" {
+		utils.ErrorSimple(w, http.StatusBadRequest, "le nom du club est requis")
+		return
+	}
+
+	ctx := context.Background()
+
+	created, err := utils.CreateClub(ctx, &club, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "could not create club", err)
+		return
+	}
+
+	utils.Success(w, created)
+}
+
+// GetClub récupère un club par son ID
+func GetClub(w http.ResponseWriter, r *http.Request) {
+	clubID := mux.Vars(r)["id"]
+
+	var userID *string
+	if user, err := middleware.GetUserFromContext(r); err == nil {
+		userID = &user.ID
+	}
+
+	ctx := context.Background()
+
+	club, err := utils.GetClub(ctx, clubID, userID)
+	if err != nil {
+		utils.Error(w, clubErrorStatus(err), "could not fetch club", err)
+		return
+	}
+
+	utils.Success(w, club)
+}
+
+// GetMyClubs récupère les clubs de l'utilisateur connecté
+func GetMyClubs(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	clubs, err := utils.GetUserClubs(ctx, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch clubs", err)
+		return
+	}
+
+	utils.Success(w, clubs)
+}
+
+// JoinClub rejoint un club via son code d'invitation
+func JoinClub(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var body struct {
+		InviteCode string `json:"inviteCode"`
+	}
+	if err := utils.DecodeJSON(r, &body); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	inviteCode := strings.ToUpper(strings.TrimSpace(body.InviteCode))
+	if inviteCode == "" {
+		utils.ErrorSimple(w, http.StatusBadRequest, "code d'invitation manquant")
+		return
+	}
+
+	ctx := context.Background()
+
+	club, err := utils.JoinClubByInviteCode(ctx, inviteCode, user.ID)
+	if err != nil {
+		utils.Error(w, clubErrorStatus(err), "could not join club", err)
+		return
+	}
+
+	utils.Success(w, club)
+}
+
+// LeaveClub quitte un club (le propriétaire seul supprime le club)
+func LeaveClub(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	clubID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	if err := utils.RemoveClubMember(ctx, clubID, user.ID); err != nil {
+		utils.Error(w, clubErrorStatus(err), "could not leave club", err)
+		return
+	}
+
+	utils.Message(w, "club quitté avec succès")
+}
+
+// RemoveClubMember retire un membre d'un club (propriétaire ou admin)
+func RemoveClubMember(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	clubID := vars["id"]
+	memberID := vars["userId"]
+
+	ctx := context.Background()
+
+	role, err := utils.GetClubMemberRole(ctx, clubID, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not check club role", err)
+		return
+	}
+	if role != model.ClubRoleOwner && !user.IsAdmin {
+		utils.ErrorSimple(w, http.StatusForbidden, "seul le propriétaire peut retirer un membre")
+		return
+	}
+	if memberID == user.ID {
+		utils.ErrorSimple(w, http.StatusBadRequest, "utilisez /clubs/{id}/leave pour quitter le club")
+		return
+	}
+
+	if err := utils.RemoveClubMember(ctx, clubID, memberID); err != nil {
+		utils.Error(w, clubErrorStatus(err), "could not remove club member", err)
+		return
+	}
+
+	utils.Message(w, "membre retiré avec succès")
+}
+
+// GetClubMembers récupère les membres d'un club
+func GetClubMembers(w http.ResponseWriter, r *http.Request) {
+	clubID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	members, err := utils.GetClubMembers(ctx, clubID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch club members", err)
+		return
+	}
+
+	utils.Success(w, members)
+}
+
+// GetClubLeaderboard classe les membres d'un club
+func GetClubLeaderboard(w http.ResponseWriter, r *http.Request) {
+	clubID := mux.Vars(r)["id"]
+	query := r.URL.Query()
+	period := query.Get("period") // daily, weekly, monthly, all-time
+	limitStr := query.Get("limit")
+
+	limit := 50
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	// Calculer la date de début selon la période
+	var startDate time.Time
+	now := time.Now()
+
+	switch period {
+	case "daily":
+		startDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	case "weekly":
+		startDate = now.AddDate(0, 0, -7)
+	case "monthly":
+		startDate = now.AddDate(0, 0, -30)
+	default:
+		startDate = time.Time{}
+	}
+
+	ctx := context.Background()
+
+	leaderboard, err := utils.GetClubMemberLeaderboard(ctx, clubID, startDate, limit)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not query club leaderboard", err)
+		return
+	}
+
+	utils.Success(w, leaderboard)
+}
+
+// GetClubsLeaderboard classe les clubs par répétitions cumulées (mode=total) ou moyennes par membre (mode=average)
+func GetClubsLeaderboard(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	period := query.Get("period") // daily, weekly, monthly, all-time
+	mode := query.Get("mode")
+	limitStr := query.Get("limit")
+
+	if mode == "" {
+		mode = utils.ClubLeaderboardModeTotal
+	}
+	if mode != utils.ClubLeaderboardModeTotal && mode != utils.ClubLeaderboardModeAverage {
+		utils.ErrorSimple(w, http.StatusBadRequest, "mode invalide (total ou average)")
+		return
+	}
+
+	limit := 50
+	if limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	// Calculer la date de début selon la période
+	var startDate time.Time
+	now := time.Now()
+
+	switch period {
+	case "daily":
+		startDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	case "weekly":
+		startDate = now.AddDate(0, 0, -7)
+	case "monthly":
+		startDate = now.AddDate(0, 0, -30)
+	default:
+		startDate = time.Time{}
+	}
+
+	ctx := context.Background()
+
+	leaderboard, err := utils.GetClubsLeaderboard(ctx, startDate, mode, limit)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not query clubs leaderboard", err)
+		return
+	}
+
+	utils.Success(w, leaderboard)
+}
+
+// CreateClubChallenge crée un challenge de club avec un objectif de répétitions cumulé (propriétaire ou admin)
+func CreateClubChallenge(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	clubID := mux.Vars(r)["id"]
+
+	var challenge model.ClubChallenge
+	if err := utils.DecodeJSON(r, &challenge); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	if strings.TrimSpace(challenge.Title) == "" {
+		utils.ErrorSimple(w, http.StatusBadRequest, "le titre est requis")
+		return
+	}
+
+	ctx := context.Background()
+
+	role, err := utils.GetClubMemberRole(ctx, clubID, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not check club role", err)
+		return
+	}
+	if role != model.ClubRoleOwner && !user.IsAdmin {
+		utils.ErrorSimple(w, http.StatusForbidden, "seul le propriétaire peut créer un challenge de club")
+		return
+	}
+
+	challenge.ClubID = clubID
+	if err := utils.CreateClubChallenge(ctx, &challenge, user.ID); err != nil {
+		utils.Error(w, http.StatusBadRequest, "could not create club challenge", err)
+		return
+	}
+
+	utils.Success(w, challenge)
+}
+
+// GetClubChallenges récupère les challenges d'un club avec la progression cumulée
+func GetClubChallenges(w http.ResponseWriter, r *http.Request) {
+	clubID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	challenges, err := utils.GetClubChallenges(ctx, clubID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch club challenges", err)
+		return
+	}
+
+	utils.Success(w, challenges)
+}
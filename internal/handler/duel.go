@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// duelErrorStatus convertit une erreur métier de duel en code HTTP
+func duelErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrDuelNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrDuelNotParticipant):
+		return http.StatusForbidden
+	case errors.Is(err, utils.ErrDuelNotPending), errors.Is(err, utils.ErrDuelAlreadyPending):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateDuel invite un utilisateur à un duel
+func CreateDuel(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var body struct {
+		OpponentID    string `json:"opponentId"`
+		Type          string `json:"type"`
+		TargetReps    *int   `json:"targetReps"`
+		DurationHours int    `json:"durationHours"`
+	}
+	if err := utils.DecodeJSON(r, &body); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	if body.OpponentID == "" {
+		utils.ErrorSimple(w, http.StatusBadRequest, "opponentId est requis")
+		return
+	}
+
+	duel := model.Duel{
+		Type:          body.Type,
+		TargetReps:    body.TargetReps,
+		DurationHours: body.DurationHours,
+	}
+
+	ctx := context.Background()
+
+	created, err := utils.CreateDuel(ctx, &duel, user.ID, body.OpponentID)
+	if err != nil {
+		status := duelErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusBadRequest
+		}
+		utils.Error(w, status, "could not create duel", err)
+		return
+	}
+
+	utils.Success(w, created)
+}
+
+// GetDuel récupère un duel avec le score en direct (participants uniquement)
+func GetDuel(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	duelID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	duel, err := utils.GetDuel(ctx, duelID, user.ID)
+	if err != nil {
+		utils.Error(w, duelErrorStatus(err), "could not fetch duel", err)
+		return
+	}
+
+	utils.Success(w, duel)
+}
+
+// GetMyDuels récupère les duels de l'utilisateur connecté (?state=pending|active|won|lost|draw|declined|expired)
+func GetMyDuels(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	ctx := context.Background()
+
+	duels, err := utils.GetUserDuels(ctx, user.ID, state)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch duels", err)
+		return
+	}
+
+	utils.Success(w, duels)
+}
+
+// AcceptDuel accepte une invitation de duel, le chrono démarre immédiatement
+func AcceptDuel(w http.ResponseWriter, r *http.Request) {
+	respondToDuel(w, r, true)
+}
+
+// DeclineDuel refuse une invitation de duel
+func DeclineDuel(w http.ResponseWriter, r *http.Request) {
+	respondToDuel(w, r, false)
+}
+
+func respondToDuel(w http.ResponseWriter, r *http.Request, accept bool) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	duelID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	duel, err := utils.RespondToDuel(ctx, duelID, user.ID, accept)
+	if err != nil {
+		utils.Error(w, duelErrorStatus(err), "could not respond to duel", err)
+		return
+	}
+
+	utils.Success(w, duel)
+}
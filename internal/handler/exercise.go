@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// GetExercises liste le catalogue d'exercices (filtre optionnel: family)
+func GetExercises(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+
+	exercises, err := utils.GetExercises(ctx, r.URL.Query().Get("family"))
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch exercises", err)
+		return
+	}
+
+	utils.Success(w, exercises)
+}
+
+// GetExercise récupère un exercice par son ID ou son slug
+func GetExercise(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+
+	exercise, err := utils.GetExercise(ctx, mux.Vars(r)["id"])
+	if errors.Is(err, utils.ErrExerciseNotFound) {
+		utils.Error(w, http.StatusNotFound, "exercise not found", err)
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch exercise", err)
+		return
+	}
+
+	utils.Success(w, exercise)
+}
+
+// exerciseFilter lit le paramètre "exercise" (slug ou famille, pompes par défaut)
+func exerciseFilter(ctx context.Context, w http.ResponseWriter, r *http.Request) (model.ExerciseFilter, bool) {
+	filter, err := utils.ResolveExerciseFilter(ctx, r.URL.Query().Get("exercise"))
+	if errors.Is(err, utils.ErrExerciseNotFound) {
+		utils.Error(w, http.StatusBadRequest, "exercice inconnu", err)
+		return filter, false
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not resolve exercise", err)
+		return filter, false
+	}
+	return filter, true
+}
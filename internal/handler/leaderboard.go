@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -31,6 +32,11 @@ func GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	// Calculer la date de début selon la période
 	var startDate time.Time
 	now := time.Now()
@@ -61,17 +67,15 @@ func GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 					COUNT(*) as total_sessions,
 					MAX(ws.total_reps) as best_session_reps
 				FROM workout_sessions ws
-				WHERE ws.completed = TRUE
+				WHERE ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(2) + `
 				GROUP BY ws.user_id
 			),
 			user_streaks AS (
 				SELECT
-					ws.user_id,
-					COUNT(DISTINCT DATE(ws.start_time)) as current_streak
-				FROM workout_sessions ws
-				WHERE ws.completed = TRUE
-				AND DATE(ws.start_time) >= CURRENT_DATE - INTERVAL '365 days'
-				GROUP BY ws.user_id
+					usi.user_id,
+					usi.length as current_streak
+				FROM user_streak_islands usi
+				WHERE usi.is_current
 			),
 			ranked_users AS (
 				SELECT
@@ -102,7 +106,7 @@ func GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 			ORDER BY ru.rank
 			LIMIT $1
 		`
-		args = []interface{}{limit}
+		args = []interface{}{limit, filter.Value}
 	} else {
 		sqlQuery = `
 			WITH user_stats AS (
@@ -112,17 +116,15 @@ func GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 					COUNT(*) as total_sessions,
 					MAX(ws.total_reps) as best_session_reps
 				FROM workout_sessions ws
-				WHERE ws.start_time >= $1 AND ws.completed = TRUE
+				WHERE ws.start_time >= $1 AND ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(3) + `
 				GROUP BY ws.user_id
 			),
 			user_streaks AS (
 				SELECT
-					ws.user_id,
-					COUNT(DISTINCT DATE(ws.start_time)) as current_streak
-				FROM workout_sessions ws
-				WHERE ws.completed = TRUE
-				AND DATE(ws.start_time) >= $1
-				GROUP BY ws.user_id
+					usi.user_id,
+					usi.length as current_streak
+				FROM user_streak_islands usi
+				WHERE usi.is_current
 			),
 			ranked_users AS (
 				SELECT
@@ -153,7 +155,7 @@ func GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 			ORDER BY ru.rank
 			LIMIT $2
 		`
-		args = []interface{}{startDate, limit}
+		args = []interface{}{startDate, limit, filter.Value}
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -176,12 +178,15 @@ func GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Récupérer les badges de l'utilisateur (à implémenter selon votre logique)
-		entry.Badges = []string{} // Placeholder
-
 		leaderboard = append(leaderboard, entry)
 	}
 
+	// Récupérer les badges des utilisateurs
+	if err := utils.AttachLeaderboardBadges(ctx, leaderboard); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load user badges", err)
+		return
+	}
+
 	utils.Success(w, leaderboard)
 }
 
@@ -197,6 +202,11 @@ func GetUserRank(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	// Calculer la date de début selon la période
 	var startDate time.Time
 	now := time.Now()
@@ -225,7 +235,7 @@ func GetUserRank(w http.ResponseWriter, r *http.Request) {
 					ws.user_id,
 					SUM(ws.total_reps) as score
 				FROM workout_sessions ws
-				WHERE ws.completed = TRUE
+				WHERE ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(2) + `
 				GROUP BY ws.user_id
 			),
 			ranked_users AS (
@@ -245,7 +255,7 @@ func GetUserRank(w http.ResponseWriter, r *http.Request) {
 			FROM ranked_users ru
 			RIGHT JOIN (SELECT $1::uuid as uid) u ON ru.user_id = u.uid
 		`
-		args = []interface{}{userID}
+		args = []interface{}{userID, filter.Value}
 	} else {
 		sqlQuery = `
 			WITH user_scores AS (
@@ -253,7 +263,7 @@ func GetUserRank(w http.ResponseWriter, r *http.Request) {
 					ws.user_id,
 					SUM(ws.total_reps) as score
 				FROM workout_sessions ws
-				WHERE ws.start_time >= $1 AND ws.completed = TRUE
+				WHERE ws.start_time >= $1 AND ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(3) + `
 				GROUP BY ws.user_id
 			),
 			ranked_users AS (
@@ -273,7 +283,7 @@ func GetUserRank(w http.ResponseWriter, r *http.Request) {
 			FROM ranked_users ru
 			RIGHT JOIN (SELECT $2::uuid as uid) u ON ru.user_id = u.uid
 		`
-		args = []interface{}{startDate, userID}
+		args = []interface{}{startDate, userID, filter.Value}
 	}
 
 	err := database.DB.QueryRow(ctx, sqlQuery, args...).Scan(
@@ -321,6 +331,11 @@ func GetNearbyUsers(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	// Calculer la date de début selon la période
 	var startDate time.Time
 	now := time.Now()
@@ -348,7 +363,7 @@ func GetNearbyUsers(w http.ResponseWriter, r *http.Request) {
 					ws.user_id,
 					SUM(ws.total_reps) as score
 				FROM workout_sessions ws
-				WHERE ws.completed = TRUE
+				WHERE ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(3) + `
 				GROUP BY ws.user_id
 			),
 			ranked_users AS (
@@ -374,7 +389,7 @@ func GetNearbyUsers(w http.ResponseWriter, r *http.Request) {
 			AND ru.rank BETWEEN (SELECT rank FROM target_rank) - $2 AND (SELECT rank FROM target_rank) + $2
 			ORDER BY ru.rank
 		`
-		args = []interface{}{userID, rangeVal}
+		args = []interface{}{userID, rangeVal, filter.Value}
 	} else {
 		sqlQuery = `
 			WITH user_scores AS (
@@ -382,7 +397,7 @@ func GetNearbyUsers(w http.ResponseWriter, r *http.Request) {
 					ws.user_id,
 					SUM(ws.total_reps) as score
 				FROM workout_sessions ws
-				WHERE ws.start_time >= $1 AND ws.completed = TRUE
+				WHERE ws.start_time >= $1 AND ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(4) + `
 				GROUP BY ws.user_id
 			),
 			ranked_users AS (
@@ -408,7 +423,7 @@ func GetNearbyUsers(w http.ResponseWriter, r *http.Request) {
 			AND ru.rank BETWEEN (SELECT rank FROM target_rank) - $3 AND (SELECT rank FROM target_rank) + $3
 			ORDER BY ru.rank
 		`
-		args = []interface{}{startDate, userID, rangeVal}
+		args = []interface{}{startDate, userID, rangeVal, filter.Value}
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -429,10 +444,14 @@ func GetNearbyUsers(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		entry.Badges = []string{} // Placeholder
 		nearby = append(nearby, entry)
 	}
 
+	if err := utils.AttachLeaderboardBadges(ctx, nearby); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load user badges", err)
+		return
+	}
+
 	utils.Success(w, nearby)
 }
 
@@ -446,6 +465,11 @@ func GetTopPerformers(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	// Calculer la date de début selon la période
 	var startDate time.Time
 	now := time.Now()
@@ -473,7 +497,7 @@ func GetTopPerformers(w http.ResponseWriter, r *http.Request) {
 					ws.user_id,
 					SUM(ws.total_reps) as score
 				FROM workout_sessions ws
-				WHERE ws.completed = TRUE
+				WHERE ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(1) + `
 				GROUP BY ws.user_id
 			),
 			ranked_users AS (
@@ -496,7 +520,7 @@ func GetTopPerformers(w http.ResponseWriter, r *http.Request) {
 			ORDER BY ru.rank
 			LIMIT 3
 		`
-		args = []interface{}{}
+		args = []interface{}{filter.Value}
 	} else {
 		sqlQuery = `
 			WITH user_scores AS (
@@ -504,7 +528,7 @@ func GetTopPerformers(w http.ResponseWriter, r *http.Request) {
 					ws.user_id,
 					SUM(ws.total_reps) as score
 				FROM workout_sessions ws
-				WHERE ws.start_time >= $1 AND ws.completed = TRUE
+				WHERE ws.start_time >= $1 AND ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(2) + `
 				GROUP BY ws.user_id
 			),
 			ranked_users AS (
@@ -527,7 +551,7 @@ func GetTopPerformers(w http.ResponseWriter, r *http.Request) {
 			ORDER BY ru.rank
 			LIMIT 3
 		`
-		args = []interface{}{startDate}
+		args = []interface{}{startDate, filter.Value}
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -579,12 +603,38 @@ func GetChallengeLeaderboard(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
-	// Classement basé sur la progression du challenge
+	if _, ok := authorizeChallengeView(ctx, w, r, challengeID, r.URL.Query().Get("inviteCode")); !ok {
+		return
+	}
+
+	// Challenge clôturé : classement figé
+	finalized, err := utils.IsChallengeFinalized(ctx, challengeID)
+	if err != nil && !errors.Is(err, utils.ErrChallengeNotFound) {
+		utils.Error(w, http.StatusInternalServerError, "could not check challenge status", err)
+		return
+	}
+	if finalized {
+		leaderboard, err := utils.GetFrozenChallengeLeaderboard(ctx, challengeID, limit)
+		if err != nil {
+			utils.Error(w, http.StatusInternalServerError, "could not query challenge leaderboard", err)
+			return
+		}
+		if err := utils.AttachLeaderboardBadges(ctx, leaderboard); err != nil {
+			utils.Error(w, http.StatusInternalServerError, "could not load user badges", err)
+			return
+		}
+		utils.Success(w, leaderboard)
+		return
+	}
+
+	// Classement basé sur la progression du challenge, égalités départagées par la date de fin
+	// puis l'utilisateur pour un rang stable (il entre dans l'empreinte des certificats)
 	rows, err := database.DB.Query(ctx, `
 		WITH user_progress AS (
 			SELECT
 				ucp.user_id,
-				ucp.progress as score
+				COALESCE(ucp.progress, 0) as score,
+				ucp.completed_at
 			FROM user_challenge_progress ucp
 			WHERE ucp.challenge_id = $1
 		),
@@ -592,7 +642,7 @@ func GetChallengeLeaderboard(w http.ResponseWriter, r *http.Request) {
 			SELECT
 				up.user_id,
 				up.score,
-				ROW_NUMBER() OVER (ORDER BY up.score DESC) as rank
+				ROW_NUMBER() OVER (ORDER BY up.score DESC, up.completed_at ASC NULLS LAST, up.user_id) as rank
 			FROM user_progress up
 		)
 		SELECT
@@ -626,10 +676,14 @@ func GetChallengeLeaderboard(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		entry.Badges = []string{} // Placeholder
 		leaderboard = append(leaderboard, entry)
 	}
 
+	if err := utils.AttachLeaderboardBadges(ctx, leaderboard); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load user badges", err)
+		return
+	}
+
 	utils.Success(w, leaderboard)
 }
 
@@ -645,6 +699,11 @@ func GetFriendsLeaderboard(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	// Pour l'instant, retourner le top 10 global comme placeholder
 	// En production, filtrer par les amis de l'utilisateur
 	var startDate time.Time
@@ -673,7 +732,7 @@ func GetFriendsLeaderboard(w http.ResponseWriter, r *http.Request) {
 					ws.user_id,
 					SUM(ws.total_reps) as score
 				FROM workout_sessions ws
-				WHERE ws.completed = TRUE
+				WHERE ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(1) + `
 				GROUP BY ws.user_id
 			),
 			ranked_users AS (
@@ -696,7 +755,7 @@ func GetFriendsLeaderboard(w http.ResponseWriter, r *http.Request) {
 			ORDER BY ru.rank
 			LIMIT 10
 		`
-		args = []interface{}{}
+		args = []interface{}{filter.Value}
 	} else {
 		sqlQuery = `
 			WITH user_scores AS (
@@ -704,7 +763,7 @@ func GetFriendsLeaderboard(w http.ResponseWriter, r *http.Request) {
 					ws.user_id,
 					SUM(ws.total_reps) as score
 				FROM workout_sessions ws
-				WHERE ws.start_time >= $1 AND ws.completed = TRUE
+				WHERE ws.start_time >= $1 AND ws.completed = TRUE AND ` + utils.ExerciseSessionCondition(2) + `
 				GROUP BY ws.user_id
 			),
 			ranked_users AS (
@@ -727,7 +786,7 @@ func GetFriendsLeaderboard(w http.ResponseWriter, r *http.Request) {
 			ORDER BY ru.rank
 			LIMIT 10
 		`
-		args = []interface{}{startDate}
+		args = []interface{}{startDate, filter.Value}
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -748,10 +807,14 @@ func GetFriendsLeaderboard(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		entry.Badges = []string{} // Placeholder
 		leaderboard = append(leaderboard, entry)
 	}
 
+	if err := utils.AttachLeaderboardBadges(ctx, leaderboard); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load user badges", err)
+		return
+	}
+
 	// Éviter erreur unused variable
 	_ = userID
 
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+)
+
+// GetMyLeague retourne le groupe de ligue de la semaine de l'utilisateur connecté
+func GetMyLeague(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	league, err := utils.GetUserLeague(ctx, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch league", err)
+		return
+	}
+
+	utils.Success(w, league)
+}
+
+// GetMyLeagueHistory retourne l'historique des semaines de ligue de l'utilisateur connecté
+func GetMyLeagueHistory(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 20
+	offset := 0
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	ctx := context.Background()
+
+	history, err := utils.GetLeagueHistory(ctx, user.ID, limit, offset)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch league history", err)
+		return
+	}
+
+	utils.Success(w, history)
+}
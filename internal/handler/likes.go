@@ -2,11 +2,12 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
 	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
 	"github.com/gorilla/mux"
 )
@@ -106,23 +107,26 @@ func GetUserLikedEntities(w http.ResponseWriter, r *http.Request) {
 // GetTopLiked récupère les entités les plus likées
 func GetTopLiked(w http.ResponseWriter, r *http.Request) {
 	entityType := model.EntityType(r.URL.Query().Get("type"))
-	limitStr := r.URL.Query().Get("limit")
 
 	if entityType == "" {
 		entityType = model.EntityTypeChallenge
 	}
 
-	limit := 10
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	// Limite par défaut de 10 entités
+	page, err := pagination.FromRequest(r, 10, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	ctx := context.Background()
 
 	// Récupérer les entités les plus likées
-	topLiked, err := utils.GetTopLikedEntities(ctx, entityType, limit)
+	topLiked, err := utils.GetTopLikedEntities(ctx, entityType, page)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "impossible de récupérer les top likes", err)
 		return
@@ -2,20 +2,60 @@ package handler
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
 	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/scanner"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
 	"github.com/gorilla/mux"
 )
 
-// GetPrograms récupère tous les programmes avec filtres optionnels
+// programListSorts tris disponibles pour GetPrograms (paramètre sort) :
+//   - featured (défaut) : programmes en vedette, puis les plus utilisés, puis les plus récents
+//   - rating : moyenne bayésienne des notes, puis les plus utilisés
+var programListSorts = map[string]pagination.Sort{
+	"featured": {
+		Name: "featured",
+		Columns: []pagination.Column{
+			{Expr: "COALESCE(wp.is_featured, FALSE)", Cast: "boolean"},
+			{Expr: "COALESCE(wp.usage_count, 0)", Cast: "int"},
+			{Expr: "wp.created_at", Cast: "timestamp"},
+		},
+		Desc: true,
+	},
+	"rating": {
+		Name: "rating",
+		Columns: []pagination.Column{
+			{Expr: "COALESCE(wp.rating_score, 3)", Cast: "float8"},
+			{Expr: "COALESCE(wp.usage_count, 0)", Cast: "int"},
+		},
+		Desc: true,
+	},
+}
+
+// programListSortKeys clés du curseur suivant pour un tri de programListSorts ou popularProgramSorts
+func programListSortKeys(sortName string) func(model.WorkoutProgram) ([]string, string) {
+	return func(p model.WorkoutProgram) ([]string, string) {
+		switch sortName {
+		case "rating":
+			return []string{strconv.FormatFloat(p.RatingScore, 'g', -1, 64), pagination.IntKey(p.UsageCount)}, p.ID
+		case "usage":
+			return []string{pagination.IntKey(p.UsageCount)}, p.ID
+		}
+		return []string{pagination.BoolKey(p.IsFeatured), pagination.IntKey(p.UsageCount), pagination.TimeKey(p.CreatedAt)}, p.ID
+	}
+}
+
+// GetPrograms récupère tous les programmes avec filtres optionnels (pagination par curseur ou offset)
 func GetPrograms(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 
@@ -33,8 +73,17 @@ func GetPrograms(w http.ResponseWriter, r *http.Request) {
 	variant := query.Get("variant")
 	isCustomStr := query.Get("isCustom")
 	searchQuery := query.Get("searchQuery")
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+
+	sort, ok := programListSorts[strings.ToLower(query.Get("sort"))]
+	if !ok {
+		sort = programListSorts["featured"]
+	}
+
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
 	sqlQuery := `
 		SELECT
@@ -43,7 +92,8 @@ func GetPrograms(w http.ResponseWriter, r *http.Request) {
 			wp.reps_sequence, wp.reps_per_minute, wp.total_minutes,
 			wp.is_custom, wp.is_featured, wp.usage_count, COALESCE(wp.likes, 0) as likes,
 			wp.created_by, wp.updated_by, wp.deleted_by, wp.created_at, wp.updated_at, wp.deleted_at,
-			u.id as creator_id, u.name as creator_name, u.avatar as creator_avatar
+			u.id as creator_id, u.name as creator_name, u.avatar as creator_avatar,
+			COALESCE(wp.rating_score, 3)::float8
 		FROM workout_programs wp
 		LEFT JOIN users u ON wp.created_by = u.id AND u.deleted_at IS NULL
 		WHERE wp.deleted_at IS NULL
@@ -85,26 +135,11 @@ func GetPrograms(w http.ResponseWriter, r *http.Request) {
 		argCount++
 	}
 
-	sqlQuery += " ORDER BY is_featured DESC, usage_count DESC, created_at DESC"
-
-	// Pagination
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	} else {
-		// Limite par défaut de 50 pour éviter de retourner trop de données
-		sqlQuery += " LIMIT 50"
-	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-			argCount++
-		}
+	// Tri et pagination
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, sort, "wp.id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -115,8 +150,10 @@ func GetPrograms(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	var programs []model.WorkoutProgram
+	var ratingScores []float64
 	for rows.Next() {
-		program, err := scanner.ScanWorkoutProgramWithCreator(rows, json.Unmarshal)
+		var ratingScore float64
+		program, err := scanner.ScanWorkoutProgramWithCreator(rows, json.Unmarshal, &ratingScore)
 		if err != nil {
 			utils.Error(w, http.StatusInternalServerError, "could not scan program row", err)
 			return
@@ -131,9 +168,18 @@ func GetPrograms(w http.ResponseWriter, r *http.Request) {
 		}
 
 		programs = append(programs, *program)
+		ratingScores = append(ratingScores, ratingScore)
 	}
 
-	utils.Success(w, programs)
+	if err := utils.AttachProgramDetails(ctx, programs); err != nil {
+		logger.Error("Impossible de charger le détail des programmes: %v", err)
+	}
+	// Le curseur repose sur le score lu par la requête paginée, pas sur une relecture ultérieure
+	for i := range programs {
+		programs[i].RatingScore = ratingScores[i]
+	}
+
+	utils.Success(w, pagination.NewPage(programs, page, sort, programListSortKeys(sort.Name)))
 }
 
 // GetProgramById récupère un programme par son ID
@@ -175,33 +221,166 @@ func GetProgramById(w http.ResponseWriter, r *http.Request) {
 		if err == nil {
 			program.UserLiked = likeInfo.UserLiked
 		}
+
+		// Fusionner les paramètres ajustés par la progression adaptative
+		override, err := utils.GetProgramOverride(ctx, *userID, program.ID)
+		if err == nil {
+			utils.ApplyProgramOverride(program, override)
+		}
+	}
+
+	if err := utils.AttachProgramDetail(ctx, program); err != nil {
+		logger.Error("Impossible de charger le détail du programme %s: %v", program.ID, err)
+	}
+
+	if err := utils.LoadCircuitStructure(ctx, program); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch program blocks", err)
+		return
+	}
+
+	// Origine d'un programme importé et nombre d'imports
+	if err := utils.LoadProgramSharing(ctx, program); err != nil {
+		logger.Error("Impossible de charger le partage du programme %s: %v", program.ID, err)
 	}
 
 	utils.Success(w, program)
 }
 
-// CreateProgram crée un nouveau programme
+// programErrorStatus convertit une erreur de programme en code HTTP
+func programErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrProgramNotFound), errors.Is(err, utils.ErrProgramVersionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrProgramForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, utils.ErrInvalidProgramShare), errors.Is(err, utils.ErrProgramShareSignature):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// programActor construit l'acteur à partir de l'utilisateur authentifié
+func programActor(w http.ResponseWriter, r *http.Request) (utils.ProgramActor, bool) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return utils.ProgramActor{}, false
+	}
+	return utils.ProgramActor{UserID: user.ID, IsAdmin: user.IsAdmin}, true
+}
+
+// authorizeProgramAction charge la propriété d'un programme et vérifie que l'acteur peut agir dessus
+func authorizeProgramAction(ctx context.Context, w http.ResponseWriter, actor utils.ProgramActor, programID string, action utils.ProgramAction) (utils.ProgramFlags, bool) {
+	ownership, flags, err := utils.LoadProgramOwnership(ctx, programID)
+	if err != nil {
+		utils.Error(w, programErrorStatus(err), "could not fetch program", err)
+		return flags, false
+	}
+	if !utils.CanPerformProgramAction(actor, ownership, action) {
+		utils.ErrorSimple(w, http.StatusForbidden, "you are not authorized to modify this program")
+		return flags, false
+	}
+	return flags, true
+}
+
+// resolveProgramExercise renseigne l'exercice du programme et vérifie ceux de ses blocs
+// (erreur de validation sur exerciseId sinon)
+func resolveProgramExercise(ctx context.Context, w http.ResponseWriter, program *model.WorkoutProgram) bool {
+	err := utils.ResolveProgramExercise(ctx, program)
+	if errors.Is(err, utils.ErrExerciseNotFound) || errors.Is(err, utils.ErrExerciseProgramType) {
+		utils.ValidationError(w, validation.Errors{{Field: "exerciseId", Message: err.Error()}})
+		return false
+	}
+	if err == nil {
+		err = utils.ResolveProgramBlockExercises(ctx, program.Blocks)
+		var fieldErrors validation.Errors
+		if errors.As(err, &fieldErrors) {
+			utils.ValidationError(w, err)
+			return false
+		}
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not resolve program exercise", err)
+		return false
+	}
+	return true
+}
+
+// loadProgramWithCreator charge un programme avec son créateur
+func loadProgramWithCreator(ctx context.Context, programID string) (*model.WorkoutProgram, error) {
+	row := database.DB.QueryRow(ctx, `
+		SELECT
+			wp.id, wp.name, wp.description, wp.type, wp.variant, wp.difficulty, wp.rest_between_sets,
+			wp.target_reps, wp.time_limit, wp.duration, wp.allow_rest, wp.sets, wp.reps_per_set,
+			wp.reps_sequence, wp.reps_per_minute, wp.total_minutes,
+			wp.is_custom, wp.is_featured, wp.usage_count, COALESCE(wp.likes, 0) as likes,
+			wp.created_by, wp.updated_by, wp.deleted_by, wp.created_at, wp.updated_at, wp.deleted_at,
+			u.id as creator_id, u.name as creator_name, u.avatar as creator_avatar
+		FROM workout_programs wp
+		LEFT JOIN users u ON wp.created_by = u.id AND u.deleted_at IS NULL
+		WHERE wp.id = $1
+	`, programID)
+
+	return scanner.ScanWorkoutProgramWithCreator(row, json.Unmarshal)
+}
+
+// CreateProgram crée un nouveau programme (seuls les admins créent des programmes officiels ou en vedette)
 func CreateProgram(w http.ResponseWriter, r *http.Request) {
+	actor, ok := programActor(w, r)
+	if !ok {
+		return
+	}
+
 	var program model.WorkoutProgram
 	if err := utils.DecodeJSON(r, &program); err != nil {
 		utils.ErrorSimple(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
 
+	if err := validation.ValidateProgram(&program); err != nil {
+		utils.ValidationError(w, err)
+		return
+	}
+
+	flags, err := utils.ResolveProgramFlags(actor,
+		utils.ProgramFlags{IsCustom: program.IsCustom, IsFeatured: program.IsFeatured},
+		utils.ProgramFlags{IsCustom: true},
+	)
+	if err != nil {
+		utils.Error(w, programErrorStatus(err), "seuls les admins peuvent mettre un programme en avant", err)
+		return
+	}
+	program.IsCustom = flags.IsCustom
+	program.IsFeatured = flags.IsFeatured
+	program.UsageCount = 0
+	program.CreatedBy = &actor.UserID
+
 	ctx := context.Background()
 
+	if !resolveProgramExercise(ctx, w, &program) {
+		return
+	}
+
 	// Encoder reps_sequence en JSON
 	repsSequenceJSON, _ := json.Marshal(program.RepsSequence)
 
-	err := database.DB.QueryRow(ctx, `
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not start transaction", err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
 		INSERT INTO workout_programs(
 			name, description, type, variant, difficulty, rest_between_sets,
 			target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
 			reps_sequence, reps_per_minute, total_minutes,
-			is_custom, is_featured, usage_count,
+			is_custom, is_featured, usage_count, exercise_id,
 			created_by, created_at, updated_at
 		) VALUES(
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, NOW(), NOW()
 		)
 		RETURNING id, created_at, updated_at
 	`,
@@ -209,7 +388,7 @@ func CreateProgram(w http.ResponseWriter, r *http.Request) {
 		program.RestBetweenSets, program.TargetReps, program.TimeLimit, program.Duration,
 		program.AllowRest, program.Sets, program.RepsPerSet, repsSequenceJSON,
 		program.RepsPerMinute, program.TotalMinutes, program.IsCustom, program.IsFeatured,
-		program.UsageCount, program.CreatedBy,
+		program.UsageCount, program.ExerciseID, program.CreatedBy,
 	).Scan(&program.ID, &program.CreatedAt, &program.UpdatedAt)
 
 	if err != nil {
@@ -217,61 +396,104 @@ func CreateProgram(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if program.Blocks, err = utils.SaveProgramBlocks(ctx, tx, program.ID, program.Blocks); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not save program blocks", err)
+		return
+	}
+
+	if _, err := utils.CreateProgramVersion(ctx, tx, program.ID, utils.ProgramSnapshotOf(&program), actor.UserID, nil); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not create program version", err)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not create program", err)
+		return
+	}
+
 	utils.Success(w, program)
 }
 
-// UpdateProgram met à jour un programme existant
+// UpdateProgram met à jour un programme existant (propriétaire d'un programme personnalisé ou admin)
 func UpdateProgram(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
+	actor, ok := programActor(w, r)
+	if !ok {
+		return
+	}
+
 	var program model.WorkoutProgram
 	if err := utils.DecodeJSON(r, &program); err != nil {
 		utils.ErrorSimple(w, http.StatusBadRequest, "invalid JSON body")
 		return
 	}
 
+	if err := validation.ValidateProgram(&program); err != nil {
+		utils.ValidationError(w, err)
+		return
+	}
+
 	ctx := context.Background()
 
-	// Vérifier que c'est un programme custom (seuls les custom peuvent être modifiés)
-	// et récupérer le created_by pour vérifier la propriété
-	var isCustom bool
-	var createdBy sql.NullString
-	err := database.DB.QueryRow(ctx,
-		`SELECT is_custom, created_by FROM workout_programs WHERE id=$1 AND deleted_at IS NULL`,
-		id,
-	).Scan(&isCustom, &createdBy)
+	current, ok := authorizeProgramAction(ctx, w, actor, id, utils.ProgramActionUpdate)
+	if !ok {
+		return
+	}
 
+	flags, err := utils.ResolveProgramFlags(actor,
+		utils.ProgramFlags{IsCustom: program.IsCustom, IsFeatured: program.IsFeatured},
+		current,
+	)
 	if err != nil {
-		utils.ErrorSimple(w, http.StatusNotFound, "program not found")
+		utils.Error(w, programErrorStatus(err), "seuls les admins peuvent mettre un programme en avant", err)
 		return
 	}
-
-	// Vérifier que l'utilisateur est admin OU propriétaire du programme
-	var ownerID string
-	if createdBy.Valid {
-		ownerID = createdBy.String
+	program.IsCustom = flags.IsCustom
+	program.IsFeatured = flags.IsFeatured
+	program.UpdatedBy = &actor.UserID
+
+	// Sans exerciseId, un programme de pompes suit sa variante, les autres gardent leur exercice
+	if program.ExerciseID == nil {
+		existing := model.WorkoutProgram{ID: id}
+		if err := utils.AttachProgramExercise(ctx, &existing); err != nil {
+			utils.Error(w, http.StatusInternalServerError, "could not fetch program exercise", err)
+			return
+		}
+		if existing.Exercise != nil && existing.Exercise.Family != model.ExerciseFamilyPushUp {
+			program.ExerciseID = existing.ExerciseID
+		}
 	}
-	if !middleware.IsOwnerOrAdmin(r, ownerID) {
-		utils.ErrorSimple(w, http.StatusForbidden, "you are not authorized to modify this program")
+	if !resolveProgramExercise(ctx, w, &program) {
 		return
 	}
 
 	// Encoder reps_sequence en JSON
 	repsSequenceJSON, _ := json.Marshal(program.RepsSequence)
 
-	_, err = database.DB.Exec(ctx, `
+	// La mise à jour crée une nouvelle version : les séances passées gardent la leur
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not start transaction", err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
 		UPDATE workout_programs SET
 			name=$1, description=$2, type=$3, variant=$4, difficulty=$5, rest_between_sets=$6,
 			target_reps=$7, time_limit=$8, duration=$9, allow_rest=$10, sets=$11, reps_per_set=$12,
 			reps_sequence=$13, reps_per_minute=$14, total_minutes=$15,
-			updated_by=$16, updated_at=NOW()
-		WHERE id=$17 AND deleted_at IS NULL
+			is_custom=$16, is_featured=$17, exercise_id=$18,
+			updated_by=$19, updated_at=NOW()
+		WHERE id=$20 AND deleted_at IS NULL
 	`,
 		program.Name, program.Description, program.Type, program.Variant, program.Difficulty,
 		program.RestBetweenSets, program.TargetReps, program.TimeLimit, program.Duration,
 		program.AllowRest, program.Sets, program.RepsPerSet, repsSequenceJSON,
-		program.RepsPerMinute, program.TotalMinutes, program.UpdatedBy, id,
+		program.RepsPerMinute, program.TotalMinutes, program.IsCustom, program.IsFeatured,
+		program.ExerciseID, program.UpdatedBy, id,
 	)
 
 	if err != nil {
@@ -279,52 +501,45 @@ func UpdateProgram(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if program.Blocks, err = utils.SaveProgramBlocks(ctx, tx, id, program.Blocks); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not save program blocks", err)
+		return
+	}
+
+	if _, err := utils.CreateProgramVersion(ctx, tx, id, utils.ProgramSnapshotOf(&program), actor.UserID, nil); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not create program version", err)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not update program", err)
+		return
+	}
+
 	program.ID = id
 	utils.Success(w, program)
 }
 
-// DeleteProgram soft delete un programme
+// DeleteProgram soft delete un programme (propriétaire d'un programme personnalisé ou admin)
 func DeleteProgram(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var payload struct {
-		DeletedBy *string `json:"deletedBy"`
-	}
-	if err := utils.DecodeJSON(r, &payload); err != nil {
-		utils.ErrorSimple(w, http.StatusBadRequest, "invalid JSON body")
+	actor, ok := programActor(w, r)
+	if !ok {
 		return
 	}
 
 	ctx := context.Background()
 
-	// Vérifier que c'est un programme custom et récupérer le created_by
-	var isCustom bool
-	var createdBy sql.NullString
-	err := database.DB.QueryRow(ctx,
-		`SELECT is_custom, created_by FROM workout_programs WHERE id=$1 AND deleted_at IS NULL`,
-		id,
-	).Scan(&isCustom, &createdBy)
-
-	if err != nil {
-		utils.ErrorSimple(w, http.StatusNotFound, "program not found")
-		return
-	}
-
-	// Vérifier que l'utilisateur est admin OU propriétaire du programme
-	var ownerID string
-	if createdBy.Valid {
-		ownerID = createdBy.String
-	}
-	if !middleware.IsOwnerOrAdmin(r, ownerID) {
-		utils.ErrorSimple(w, http.StatusForbidden, "you are not authorized to delete this program")
+	if _, ok := authorizeProgramAction(ctx, w, actor, id, utils.ProgramActionDelete); !ok {
 		return
 	}
 
 	res, err := database.DB.Exec(ctx, `
 		UPDATE workout_programs SET deleted_at=NOW(), deleted_by=$2
 		WHERE id=$1 AND deleted_at IS NULL
-	`, id, payload.DeletedBy)
+	`, id, actor.UserID)
 
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "could not delete program", err)
@@ -339,18 +554,44 @@ func DeleteProgram(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, map[string]bool{"success": true})
 }
 
-// GetRecommendedPrograms récupère des programmes recommandés pour un utilisateur
+// GetRecommendedPrograms recommande des programmes à partir de l'historique de l'utilisateur
+// (niveau estimé, taux de réussite, variantes essayées, likes et co-usage), chacun avec sa raison
 func GetRecommendedPrograms(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID := vars["userId"]
 
+	// Les recommandations exposent l'historique et les programmes personnels de l'utilisateur
+	if !middleware.IsOwnerOrAdmin(r, userID) {
+		utils.ErrorSimple(w, http.StatusForbidden, "accès refusé")
+		return
+	}
+
 	ctx := context.Background()
 
 	query := r.URL.Query()
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+	limit := 20
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+	if limit > pagination.MaxLimit {
+		limit = pagination.MaxLimit
+	}
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o > 0 {
+			offset = o
+		}
+	}
 
-	sqlQuery := `
+	profile, err := utils.LoadRecommendationProfile(ctx, userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not load user history", err)
+		return
+	}
+
+	rows, err := database.DB.Query(ctx, `
 		SELECT
 			id, name, description, type, variant, difficulty, rest_between_sets,
 			target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
@@ -358,76 +599,52 @@ func GetRecommendedPrograms(w http.ResponseWriter, r *http.Request) {
 			is_custom, is_featured, usage_count, COALESCE(likes, 0) as likes,
 			created_by, updated_by, deleted_by, created_at, updated_at, deleted_at
 		FROM workout_programs
-		WHERE deleted_at IS NULL AND difficulty='INTERMEDIATE'
-		ORDER BY usage_count DESC, is_featured DESC
-	`
-
-	args := []interface{}{}
-	argCount := 1
-
-	// Pagination
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	} else {
-		// Limite par défaut de 20 pour les recommandations
-		sqlQuery += " LIMIT 20"
-	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-			argCount++
-		}
-	}
-
-	// Pour l'instant, on retourne les programmes les plus populaires de niveau intermédiaire
-	// En production, cela devrait être basé sur l'historique et le niveau de l'utilisateur
-	rows, err := database.DB.Query(ctx, sqlQuery, args...)
-
+		WHERE deleted_at IS NULL
+		  AND (is_custom = FALSE OR created_by = $1)
+		ORDER BY is_featured DESC, usage_count DESC
+		LIMIT $2
+	`, userID, utils.RecommendationCandidateLimit)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "could not query programs", err)
 		return
 	}
 	defer rows.Close()
 
-	var programs []model.WorkoutProgram
+	var candidates []model.WorkoutProgram
 	for rows.Next() {
-		var p model.WorkoutProgram
-		var repsSequenceJSON []byte
-
-		if err := rows.Scan(
-			&p.ID, &p.Name, &p.Description, &p.Type, &p.Variant, &p.Difficulty, &p.RestBetweenSets,
-			&p.TargetReps, &p.TimeLimit, &p.Duration, &p.AllowRest, &p.Sets, &p.RepsPerSet,
-			&repsSequenceJSON, &p.RepsPerMinute, &p.TotalMinutes,
-			&p.IsCustom, &p.IsFeatured, &p.UsageCount, &p.Likes,
-			&p.CreatedBy, &p.UpdatedBy, &p.DeletedBy, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt,
-		); err != nil {
+		program, err := scanner.ScanWorkoutProgram(rows)
+		if err != nil {
 			utils.Error(w, http.StatusInternalServerError, "could not scan program row", err)
 			return
 		}
+		candidates = append(candidates, *program)
+	}
+	if err := utils.AttachProgramDetails(ctx, candidates); err != nil {
+		logger.Error("Impossible de charger le détail des programmes: %v", err)
+	}
 
-		if repsSequenceJSON != nil {
-			json.Unmarshal(repsSequenceJSON, &p.RepsSequence)
-		}
+	recommendations := utils.RankProgramRecommendations(profile, candidates)
+	if offset >= len(recommendations) {
+		recommendations = recommendations[:0]
+	} else {
+		recommendations = recommendations[offset:]
+	}
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
 
-		// Populate UserLiked field
-		likeInfo, err := utils.GetLikeInfo(ctx, &userID, model.EntityTypeProgram, p.ID)
-		if err == nil {
-			p.UserLiked = likeInfo.UserLiked
-		}
+	liked := make(map[string]bool, len(profile.LikedProgramIDs))
+	for _, id := range profile.LikedProgramIDs {
+		liked[id] = true
+	}
+	for i := range recommendations {
+		recommendations[i].UserLiked = liked[recommendations[i].ID]
 
 		// Load creator information
-		utils.EnrichWorkoutProgramWithCreator(ctx, &p)
-
-		programs = append(programs, p)
+		utils.EnrichWorkoutProgramWithCreator(ctx, &recommendations[i].WorkoutProgram)
 	}
 
-	utils.Success(w, programs)
+	utils.Success(w, recommendations)
 }
 
 // GetProgramsByDifficulty récupère les programmes par niveau de difficulté
@@ -496,9 +713,20 @@ func GetProgramsByDifficulty(w http.ResponseWriter, r *http.Request) {
 		programs = append(programs, p)
 	}
 
+	if err := utils.AttachProgramDetails(ctx, programs); err != nil {
+		logger.Error("Impossible de charger le détail des programmes: %v", err)
+	}
+
 	utils.Success(w, programs)
 }
 
+// userProgramSort tri de GetUserCustomPrograms : les programmes modifiés le plus récemment d'abord
+var userProgramSort = pagination.Sort{
+	Name:    "updated",
+	Columns: []pagination.Column{{Expr: "updated_at", Cast: "timestamp"}},
+	Desc:    true,
+}
+
 // GetUserCustomPrograms récupère les programmes personnalisés d'un utilisateur
 func GetUserCustomPrograms(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -506,9 +734,11 @@ func GetUserCustomPrograms(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
-	query := r.URL.Query()
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
 	sqlQuery := `
 		SELECT
@@ -519,30 +749,16 @@ func GetUserCustomPrograms(w http.ResponseWriter, r *http.Request) {
 			created_by, updated_by, deleted_by, created_at, updated_at, deleted_at
 		FROM workout_programs
 		WHERE deleted_at IS NULL AND is_custom=true AND created_by=$1
-		ORDER BY updated_at DESC
 	`
 
 	args := []interface{}{userID}
 	argCount := 2
 
-	// Pagination
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	} else {
-		// Limite par défaut de 50 pour éviter de retourner trop de données
-		sqlQuery += " LIMIT 50"
-	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-			argCount++
-		}
+	// Tri et pagination
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, userProgramSort, "id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -582,7 +798,13 @@ func GetUserCustomPrograms(w http.ResponseWriter, r *http.Request) {
 		programs = append(programs, p)
 	}
 
-	utils.Success(w, programs)
+	if err := utils.AttachProgramDetails(ctx, programs); err != nil {
+		logger.Error("Impossible de charger le détail des programmes: %v", err)
+	}
+
+	utils.Success(w, pagination.NewPage(programs, page, userProgramSort, func(p model.WorkoutProgram) ([]string, string) {
+		return []string{pagination.TimeKey(p.UpdatedAt)}, p.ID
+	}))
 }
 
 // DuplicateProgram duplique un programme existant
@@ -590,16 +812,17 @@ func DuplicateProgram(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	programID := vars["id"]
 
-	var payload struct {
-		UserID string `json:"userId"`
-	}
-	if err := utils.DecodeJSON(r, &payload); err != nil {
-		utils.ErrorSimple(w, http.StatusBadRequest, "invalid JSON body")
+	actor, ok := programActor(w, r)
+	if !ok {
 		return
 	}
 
 	ctx := context.Background()
 
+	if _, ok := authorizeProgramAction(ctx, w, actor, programID, utils.ProgramActionDuplicate); !ok {
+		return
+	}
+
 	// Récupérer le programme source
 	var program model.WorkoutProgram
 	var repsSequenceJSON []byte
@@ -608,7 +831,7 @@ func DuplicateProgram(w http.ResponseWriter, r *http.Request) {
 		SELECT
 			name, description, type, variant, difficulty, rest_between_sets,
 			target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
-			reps_sequence, reps_per_minute, total_minutes
+			reps_sequence, reps_per_minute, total_minutes, exercise_id
 		FROM workout_programs
 		WHERE id=$1 AND deleted_at IS NULL
 	`, programID).Scan(
@@ -616,7 +839,7 @@ func DuplicateProgram(w http.ResponseWriter, r *http.Request) {
 		&program.Difficulty, &program.RestBetweenSets,
 		&program.TargetReps, &program.TimeLimit, &program.Duration, &program.AllowRest,
 		&program.Sets, &program.RepsPerSet, &repsSequenceJSON, &program.RepsPerMinute,
-		&program.TotalMinutes,
+		&program.TotalMinutes, &program.ExerciseID,
 	)
 
 	if err != nil {
@@ -628,6 +851,11 @@ func DuplicateProgram(w http.ResponseWriter, r *http.Request) {
 		json.Unmarshal(repsSequenceJSON, &program.RepsSequence)
 	}
 
+	if program.Blocks, err = utils.LoadProgramBlocks(ctx, programID); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch program blocks", err)
+		return
+	}
+
 	// Créer la copie
 	program.Name = program.Name + " (Copie)"
 	program.IsCustom = true
@@ -636,23 +864,30 @@ func DuplicateProgram(w http.ResponseWriter, r *http.Request) {
 
 	repsSequenceJSONCopy, _ := json.Marshal(program.RepsSequence)
 
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not start transaction", err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
 	var newProgram model.WorkoutProgram
-	err = database.DB.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		INSERT INTO workout_programs(
 			name, description, type, variant, difficulty, rest_between_sets,
 			target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
 			reps_sequence, reps_per_minute, total_minutes,
-			is_custom, is_featured, usage_count,
+			is_custom, is_featured, usage_count, exercise_id,
 			created_by, created_at, updated_at
 		) VALUES(
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, true, false, 0, $16, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, true, false, 0, $16, $17, NOW(), NOW()
 		)
 		RETURNING id, created_at, updated_at
 	`,
 		program.Name, program.Description, program.Type, program.Variant, program.Difficulty,
 		program.RestBetweenSets, program.TargetReps, program.TimeLimit, program.Duration,
 		program.AllowRest, program.Sets, program.RepsPerSet, repsSequenceJSONCopy,
-		program.RepsPerMinute, program.TotalMinutes, payload.UserID,
+		program.RepsPerMinute, program.TotalMinutes, program.ExerciseID, actor.UserID,
 	).Scan(&newProgram.ID, &newProgram.CreatedAt, &newProgram.UpdatedAt)
 
 	if err != nil {
@@ -660,6 +895,21 @@ func DuplicateProgram(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if program.Blocks, err = utils.SaveProgramBlocks(ctx, tx, newProgram.ID, program.Blocks); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not save program blocks", err)
+		return
+	}
+
+	if _, err := utils.CreateProgramVersion(ctx, tx, newProgram.ID, utils.ProgramSnapshotOf(&program), actor.UserID, nil); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not create program version", err)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not duplicate program", err)
+		return
+	}
+
 	// Copier les autres champs
 	newProgram.Name = program.Name
 	newProgram.Description = program.Description
@@ -676,9 +926,16 @@ func DuplicateProgram(w http.ResponseWriter, r *http.Request) {
 	newProgram.RepsSequence = program.RepsSequence
 	newProgram.RepsPerMinute = program.RepsPerMinute
 	newProgram.TotalMinutes = program.TotalMinutes
+	newProgram.ExerciseID = program.ExerciseID
+	newProgram.Blocks = program.Blocks
 	newProgram.IsCustom = true
 	newProgram.IsFeatured = false
 	newProgram.UsageCount = 0
+	newProgram.CreatedBy = &actor.UserID
+
+	if err := utils.AttachProgramDetail(ctx, &newProgram); err != nil {
+		logger.Error("Impossible de charger le détail du programme %s: %v", newProgram.ID, err)
+	}
 
 	utils.Success(w, newProgram)
 }
@@ -775,16 +1032,47 @@ func GetFeaturedPrograms(w http.ResponseWriter, r *http.Request) {
 		programs = append(programs, p)
 	}
 
+	if err := utils.AttachProgramDetails(ctx, programs); err != nil {
+		logger.Error("Impossible de charger le détail des programmes: %v", err)
+	}
+
 	utils.Success(w, programs)
 }
 
-// GetPopularPrograms récupère les programmes les plus utilisés
+// popularProgramSorts tris disponibles pour GetPopularPrograms (paramètre sort) :
+//   - usage (défaut) : les plus utilisés
+//   - rating : moyenne bayésienne des notes, puis les plus utilisés
+var popularProgramSorts = map[string]pagination.Sort{
+	"usage": {
+		Name:    "usage",
+		Columns: []pagination.Column{{Expr: "COALESCE(usage_count, 0)", Cast: "int"}},
+		Desc:    true,
+	},
+	"rating": {
+		Name: "rating",
+		Columns: []pagination.Column{
+			{Expr: "COALESCE(rating_score, 3)", Cast: "float8"},
+			{Expr: "COALESCE(usage_count, 0)", Cast: "int"},
+		},
+		Desc: true,
+	},
+}
+
+// GetPopularPrograms récupère les programmes les plus utilisés (sort=rating : les mieux notés)
 func GetPopularPrograms(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 
-	query := r.URL.Query()
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+	sort, ok := popularProgramSorts[strings.ToLower(r.URL.Query().Get("sort"))]
+	if !ok {
+		sort = popularProgramSorts["usage"]
+	}
+
+	// Limite par défaut de 20 pour les programmes populaires
+	page, err := pagination.FromRequest(r, 20, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
 	// Get optional authenticated user
 	user, _ := middleware.GetUserFromContext(r)
@@ -799,33 +1087,20 @@ func GetPopularPrograms(w http.ResponseWriter, r *http.Request) {
 			target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
 			reps_sequence, reps_per_minute, total_minutes,
 			is_custom, is_featured, usage_count, COALESCE(likes, 0) as likes,
-			created_by, updated_by, deleted_by, created_at, updated_at, deleted_at
+			created_by, updated_by, deleted_by, created_at, updated_at, deleted_at,
+			COALESCE(rating_score, 3)::float8
 		FROM workout_programs
 		WHERE deleted_at IS NULL
-		ORDER BY usage_count DESC
 	`
 
 	args := []interface{}{}
 	argCount := 1
 
-	// Pagination
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	} else {
-		// Limite par défaut de 20 pour les programmes populaires
-		sqlQuery += " LIMIT 20"
-	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-			argCount++
-		}
+	// Tri et pagination
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, sort, "id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -837,9 +1112,11 @@ func GetPopularPrograms(w http.ResponseWriter, r *http.Request) {
 	defer rows.Close()
 
 	var programs []model.WorkoutProgram
+	var ratingScores []float64
 	for rows.Next() {
 		var p model.WorkoutProgram
 		var repsSequenceJSON []byte
+		var ratingScore float64
 
 		if err := rows.Scan(
 			&p.ID, &p.Name, &p.Description, &p.Type, &p.Variant, &p.Difficulty, &p.RestBetweenSets,
@@ -847,6 +1124,7 @@ func GetPopularPrograms(w http.ResponseWriter, r *http.Request) {
 			&repsSequenceJSON, &p.RepsPerMinute, &p.TotalMinutes,
 			&p.IsCustom, &p.IsFeatured, &p.UsageCount, &p.Likes,
 			&p.CreatedBy, &p.UpdatedBy, &p.DeletedBy, &p.CreatedAt, &p.UpdatedAt, &p.DeletedAt,
+			&ratingScore,
 		); err != nil {
 			utils.Error(w, http.StatusInternalServerError, "could not scan program row", err)
 			return
@@ -868,9 +1146,18 @@ func GetPopularPrograms(w http.ResponseWriter, r *http.Request) {
 		utils.EnrichWorkoutProgramWithCreator(ctx, &p)
 
 		programs = append(programs, p)
+		ratingScores = append(ratingScores, ratingScore)
 	}
 
-	utils.Success(w, programs)
+	if err := utils.AttachProgramDetails(ctx, programs); err != nil {
+		logger.Error("Impossible de charger le détail des programmes: %v", err)
+	}
+	// Le curseur repose sur le score lu par la requête paginée
+	for i := range programs {
+		programs[i].RatingScore = ratingScores[i]
+	}
+
+	utils.Success(w, pagination.NewPage(programs, page, sort, programListSortKeys(sort.Name)))
 }
 
 // LikeProgram ajoute un like à un programme
@@ -975,3 +1262,99 @@ func UnlikeProgram(w http.ResponseWriter, r *http.Request) {
 	}
 	utils.Success(w, program)
 }
+
+// loadProgressionProgramType vérifie que le programme existe et renvoie son type
+func loadProgressionProgramType(ctx context.Context, w http.ResponseWriter, programID string) (model.ProgramType, bool) {
+	var programType model.ProgramType
+	err := database.DB.QueryRow(ctx,
+		`SELECT type FROM workout_programs WHERE id = $1 AND deleted_at IS NULL`,
+		programID,
+	).Scan(&programType)
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, "program not found", err)
+		return "", false
+	}
+	return programType, true
+}
+
+// GetProgramProgression récupère la progression adaptative de l'utilisateur connecté sur un programme
+func GetProgramProgression(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	programID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	if _, ok := loadProgressionProgramType(ctx, w, programID); !ok {
+		return
+	}
+
+	override, err := utils.GetProgramOverride(ctx, user.ID, programID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch progression", err)
+		return
+	}
+	if override == nil {
+		override = &model.ProgramOverride{ProgramID: programID, LastAction: model.ProgressionHold}
+	}
+
+	utils.Success(w, override)
+}
+
+// UpdateProgramProgression active ou désactive la progression adaptative (body: enabled)
+func UpdateProgramProgression(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var body struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := utils.DecodeJSON(r, &body); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	programID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	programType, ok := loadProgressionProgramType(ctx, w, programID)
+	if !ok {
+		return
+	}
+	if body.Enabled && !utils.IsProgressionType(programType) {
+		utils.ErrorSimple(w, http.StatusBadRequest, "ce type de programme n'a pas d'objectifs ajustables")
+		return
+	}
+
+	override, err := utils.SetProgramProgression(ctx, user.ID, programID, body.Enabled)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not update progression", err)
+		return
+	}
+
+	utils.Success(w, override)
+}
+
+// ResetProgramProgression supprime la progression et revient aux objectifs d'origine du programme
+func ResetProgramProgression(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	if err := utils.ResetProgramProgression(ctx, user.ID, mux.Vars(r)["id"]); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not reset progression", err)
+		return
+	}
+
+	utils.Message(w, "progression réinitialisée")
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
+	"github.com/gorilla/mux"
+)
+
+// programReviewErrorStatus statut HTTP correspondant à une erreur d'avis
+func programReviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrProgramNotFound), errors.Is(err, utils.ErrProgramReviewNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrProgramReviewNotAllowed), errors.Is(err, utils.ErrProgramReviewOwnReport):
+		return http.StatusForbidden
+	case errors.Is(err, utils.ErrProgramReviewAlreadyReported):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetProgramReviews liste les avis d'un programme (les admins voient aussi les avis masqués)
+func GetProgramReviews(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
+
+	user, _ := middleware.GetUserFromContext(r)
+
+	reviews, err := utils.ListProgramReviews(context.Background(), mux.Vars(r)["id"], user.IsAdmin, page)
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not query program reviews", err)
+		return
+	}
+
+	utils.Success(w, reviews)
+}
+
+// GetMyProgramReview récupère l'avis de l'utilisateur connecté sur un programme
+func GetMyProgramReview(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	review, err := utils.GetUserProgramReview(context.Background(), mux.Vars(r)["id"], user.ID)
+	if err != nil {
+		utils.Error(w, programReviewErrorStatus(err), "could not fetch program review", err)
+		return
+	}
+
+	utils.Success(w, review)
+}
+
+// UpsertProgramReview note un programme (1 à 5 étoiles) avec un avis optionnel ;
+// réservé aux utilisateurs ayant au moins une séance sur ce programme
+func UpsertProgramReview(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var req model.ProgramReviewRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	if err := validation.ValidateProgramReview(&req); err != nil {
+		utils.ValidationError(w, err)
+		return
+	}
+
+	review, err := utils.UpsertProgramReview(context.Background(), mux.Vars(r)["id"], user.ID, req)
+	if err != nil {
+		utils.Error(w, programReviewErrorStatus(err), "could not save program review", err)
+		return
+	}
+
+	utils.Success(w, review)
+}
+
+// DeleteProgramReview supprime l'avis de l'utilisateur connecté sur un programme
+func DeleteProgramReview(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	if err := utils.DeleteProgramReview(context.Background(), mux.Vars(r)["id"], user.ID); err != nil {
+		utils.Error(w, programReviewErrorStatus(err), "could not delete program review", err)
+		return
+	}
+
+	utils.Success(w, map[string]bool{"success": true})
+}
+
+// ReportProgramReview signale un avis abusif à la modération
+func ReportProgramReview(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var req model.ProgramReviewReportRequest
+	if r.ContentLength != 0 {
+		if err := utils.DecodeJSON(r, &req); err != nil {
+			utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+			return
+		}
+	}
+
+	if err := validation.ValidateProgramReviewReport(&req); err != nil {
+		utils.ValidationError(w, err)
+		return
+	}
+
+	vars := mux.Vars(r)
+	report, err := utils.ReportProgramReview(context.Background(), vars["id"], vars["reviewId"], user.ID, req.Reason)
+	if err != nil {
+		utils.Error(w, programReviewErrorStatus(err), "could not report program review", err)
+		return
+	}
+
+	utils.Success(w, report)
+}
+
+// ModerateProgramReview masque ou rétablit un avis (admin)
+func ModerateProgramReview(w http.ResponseWriter, r *http.Request) {
+	if !middleware.IsAdmin(r) {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	var req model.ProgramReviewModerationRequest
+	if err := utils.DecodeJSON(r, &req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	review, err := utils.ModerateProgramReview(context.Background(), mux.Vars(r)["reviewId"], req.Hidden)
+	if err != nil {
+		utils.Error(w, programReviewErrorStatus(err), "could not moderate program review", err)
+		return
+	}
+
+	utils.Success(w, review)
+}
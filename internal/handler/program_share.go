@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
+	"github.com/gorilla/mux"
+)
+
+// ShareProgram produit le partage signé d'un programme (JSON et code compact pour QR code)
+func ShareProgram(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+
+	export, err := utils.BuildProgramShare(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, programErrorStatus(err), "could not share program", err)
+		return
+	}
+
+	utils.Success(w, export)
+}
+
+// ImportProgram crée un programme personnalisé à partir d'un partage (body: share ou code)
+func ImportProgram(w http.ResponseWriter, r *http.Request) {
+	actor, ok := programActor(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Share *model.ProgramShare `json:"share"`
+		Code  string              `json:"code"`
+	}
+	if err := utils.DecodeJSON(r, &body); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	var share model.ProgramShare
+	switch {
+	case body.Code != "":
+		decoded, err := utils.DecodeProgramShareCode(body.Code)
+		if err != nil {
+			utils.Error(w, http.StatusBadRequest, "code de partage invalide", err)
+			return
+		}
+		share = decoded
+	case body.Share != nil:
+		share = *body.Share
+	default:
+		utils.ErrorSimple(w, http.StatusBadRequest, "share ou code requis")
+		return
+	}
+
+	if err := utils.VerifyProgramShare(share); err != nil {
+		utils.Error(w, programErrorStatus(err), "partage invalide", err)
+		return
+	}
+
+	// Le partage est signé mais les règles de validation ont pu évoluer depuis
+	var program model.WorkoutProgram
+	utils.ApplyProgramSnapshot(&program, share.Program)
+	if err := validation.ValidateProgram(&program); err != nil {
+		utils.ValidationError(w, err)
+		return
+	}
+
+	ctx := context.Background()
+
+	// Les partages antérieurs au catalogue d'exercices n'ont pas d'exerciseId
+	if !resolveProgramExercise(ctx, w, &program) {
+		return
+	}
+	share.Program.ExerciseID = program.ExerciseID
+
+	programID, err := utils.ImportProgramShare(ctx, share, actor.UserID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not import program", err)
+		return
+	}
+
+	imported, err := loadProgramWithCreator(ctx, programID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch imported program", err)
+		return
+	}
+	if err := utils.LoadProgramSharing(ctx, imported); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch program attribution", err)
+		return
+	}
+	if err := utils.AttachProgramExercise(ctx, imported); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch program exercise", err)
+		return
+	}
+	if err := utils.LoadCircuitStructure(ctx, imported); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch program blocks", err)
+		return
+	}
+
+	utils.Success(w, imported)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// GetProgramVersions liste les versions d'un programme, de la plus récente à la plus ancienne
+func GetProgramVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+
+	versions, err := utils.GetProgramVersions(ctx, mux.Vars(r)["id"])
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch program versions", err)
+		return
+	}
+
+	utils.Success(w, versions)
+}
+
+// GetProgramVersion récupère une version précise d'un programme
+func GetProgramVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "version invalide", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	programVersion, err := utils.GetProgramVersion(ctx, vars["id"], version)
+	if err != nil {
+		utils.Error(w, programErrorStatus(err), "could not fetch program version", err)
+		return
+	}
+
+	utils.Success(w, programVersion)
+}
+
+// DiffProgramVersions compare deux versions d'un programme (params: from, to)
+func DiffProgramVersions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, errFrom := strconv.Atoi(query.Get("from"))
+	to, errTo := strconv.Atoi(query.Get("to"))
+	if errFrom != nil || errTo != nil {
+		utils.ErrorSimple(w, http.StatusBadRequest, "paramètres from et to requis (numéros de version)")
+		return
+	}
+
+	ctx := context.Background()
+
+	diff, err := utils.DiffProgramVersions(ctx, mux.Vars(r)["id"], from, to)
+	if err != nil {
+		utils.Error(w, programErrorStatus(err), "could not compare program versions", err)
+		return
+	}
+
+	utils.Success(w, diff)
+}
+
+// RevertProgramVersion restaure une ancienne version (une nouvelle version est créée)
+func RevertProgramVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	programID := vars["id"]
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "version invalide", err)
+		return
+	}
+
+	actor, ok := programActor(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+
+	if _, ok := authorizeProgramAction(ctx, w, actor, programID, utils.ProgramActionUpdate); !ok {
+		return
+	}
+
+	created, err := utils.RevertProgramVersion(ctx, programID, version, actor.UserID)
+	if err != nil {
+		utils.Error(w, programErrorStatus(err), "could not revert program", err)
+		return
+	}
+
+	utils.Success(w, created)
+}
@@ -30,49 +30,105 @@ func RootHandler(w http.ResponseWriter, r *http.Request) {
 				{"method": "PUT", "path": "/users/{id}", "description": "Mettre à jour un utilisateur"},
 				{"method": "DELETE", "path": "/users/{id}", "description": "Supprimer un utilisateur (soft delete)"},
 				{"method": "POST", "path": "/users/{id}/avatar", "description": "Upload avatar utilisateur"},
-				{"method": "GET", "path": "/users/{userId}/stats/{period}", "description": "Statistiques utilisateur (daily/weekly/monthly/yearly)"},
-				{"method": "GET", "path": "/users/{userId}/charts/{period}", "description": "Données graphiques (week/month/year)"},
+				{"method": "GET", "path": "/users/{userId}/stats/{period}", "description": "Statistiques utilisateur (daily/weekly/monthly/yearly, params: exercise)"},
+				{"method": "GET", "path": "/users/{userId}/charts/{period}", "description": "Données graphiques (week/month/year, params: exercise)"},
+				{"method": "GET", "path": "/users/{userId}/streak", "description": "Série en cours, meilleure série et historique"},
+				{"method": "GET", "path": "/users/{userId}/xp", "description": "Niveau, XP et historique des gains"},
+				{"method": "GET", "path": "/users/{userId}/badges", "description": "Badges obtenus par un utilisateur"},
+				{"method": "GET", "path": "/users/{userId}/badges/catalog", "description": "Catalogue des badges avec la progression de l'utilisateur"},
+				{"method": "GET", "path": "/users/{userId}/streak/freezes", "description": "Gels de série d'un utilisateur"},
+				{"method": "POST", "path": "/users/{userId}/streak/freezes/use", "description": "Utiliser un gel de série pour un jour de repos"},
 				{"method": "GET", "path": "/users/{userId}/workouts", "description": "Sessions d'entraînement d'un utilisateur"},
-				{"method": "GET", "path": "/users/{userId}/workouts/stats", "description": "Statistiques d'entraînement"},
-				{"method": "GET", "path": "/users/{userId}/workouts/summary", "description": "Résumé des entraînements"},
-				{"method": "GET", "path": "/users/{userId}/workouts/records", "description": "Records personnels"},
+				{"method": "GET", "path": "/users/{userId}/workouts/stats", "description": "Statistiques d'entraînement (params: period, exercise)"},
+				{"method": "GET", "path": "/users/{userId}/workouts/summary", "description": "Résumé des entraînements (params: startDate, endDate, exercise)"},
+				{"method": "GET", "path": "/users/{userId}/workouts/records", "description": "Records personnels (params: exercise)"},
 				{"method": "GET", "path": "/users/{userId}/programs", "description": "Programmes personnalisés d'un utilisateur"},
-				{"method": "GET", "path": "/users/{userId}/programs/recommended", "description": "Programmes recommandés"},
+				{"method": "GET", "path": "/users/{userId}/programs/recommended", "description": "Programmes recommandés (utilisateur connecté ou admin)"},
 				{"method": "GET", "path": "/users/{userId}/challenges/active", "description": "Challenges actifs d'un utilisateur"},
 				{"method": "GET", "path": "/users/{userId}/challenges/completed", "description": "Challenges complétés"},
-				{"method": "GET", "path": "/users/{userId}/friends/leaderboard", "description": "Classement des amis"},
+				{"method": "GET", "path": "/users/{userId}/friends/leaderboard", "description": "Classement des amis (params: period, exercise)"},
+			},
+			"search": []map[string]string{
+				{"method": "GET", "path": "/search", "description": "Recherche globale (q, type, difficulty, variant, limit, cursor) avec facettes"},
+			},
+			"badges": []map[string]string{
+				{"method": "GET", "path": "/badges", "description": "Catalogue des badges"},
+			},
+			"leagues": []map[string]string{
+				{"method": "GET", "path": "/me/league", "description": "Groupe de ligue de la semaine et temps restant"},
+				{"method": "GET", "path": "/me/league/history", "description": "Historique des ligues de l'utilisateur"},
 			},
 			"challenges": []map[string]string{
 				{"method": "GET", "path": "/challenges", "description": "Récupérer tous les challenges"},
 				{"method": "GET", "path": "/challenges/{id}", "description": "Récupérer un challenge par ID"},
-				{"method": "POST", "path": "/challenges", "description": "Créer un challenge"},
+				{"method": "POST", "path": "/challenges", "description": "Créer un challenge (visibility: public, unlisted, private)"},
+				{"method": "POST", "path": "/challenges/join", "description": "Rejoindre un challenge privé via un code d'invitation"},
 				{"method": "PUT", "path": "/challenges/{id}", "description": "Mettre à jour un challenge"},
 				{"method": "DELETE", "path": "/challenges/{id}", "description": "Supprimer un challenge"},
 				{"method": "POST", "path": "/challenges/{id}/like", "description": "Liker un challenge"},
 				{"method": "DELETE", "path": "/challenges/{id}/like", "description": "Unliker un challenge"},
-				{"method": "POST", "path": "/challenges/{id}/start", "description": "Démarrer un challenge"},
-				{"method": "POST", "path": "/challenges/{id}/complete", "description": "Compléter un challenge"},
-				{"method": "POST", "path": "/challenges/{id}/tasks/{taskId}", "description": "Compléter une tâche de challenge"},
+				{"method": "POST", "path": "/challenges/{id}/start", "description": "Démarrer un challenge pour l'utilisateur connecté (body optionnel: inviteCode)"},
+				{"method": "POST", "path": "/challenges/{id}/complete", "description": "Valider un challenge (objectif atteint d'après les séances)"},
+				{"method": "POST", "path": "/challenges/{id}/tasks/{taskId}", "description": "Valider une tâche de challenge (séance complétée liée à la tâche requise)"},
+				{"method": "POST", "path": "/challenges/{id}/tasks", "description": "Ajouter une tâche au planning (propriétaire ou admin)"},
+				{"method": "POST", "path": "/challenges/{id}/tasks/bulk", "description": "Ajouter ou remplacer des tâches (liste ou template de progression)"},
+				{"method": "PUT", "path": "/challenges/{id}/tasks/order", "description": "Réordonner les jours du planning"},
+				{"method": "PUT", "path": "/challenges/{id}/tasks/{taskId}", "description": "Modifier une tâche de challenge"},
+				{"method": "DELETE", "path": "/challenges/{id}/tasks/{taskId}", "description": "Supprimer une tâche de challenge"},
 				{"method": "GET", "path": "/challenges/{id}/progress", "description": "Progression d'un challenge"},
+				{"method": "GET", "path": "/challenges/{id}/certificate", "description": "Certificat de réussite d'un challenge terminé (params: format=png|pdf)"},
 				{"method": "GET", "path": "/challenges/{challengeId}/leaderboard", "description": "Classement d'un challenge"},
+				{"method": "GET", "path": "/challenges/{id}/allowed-users", "description": "Invités d'un challenge privé (propriétaire ou admin)"},
+				{"method": "POST", "path": "/challenges/{id}/allowed-users", "description": "Inviter des utilisateurs (userIds)"},
+				{"method": "DELETE", "path": "/challenges/{id}/allowed-users/{userId}", "description": "Retirer un invité"},
 			},
 			"programs": []map[string]string{
-				{"method": "GET", "path": "/programs", "description": "Récupérer tous les programmes"},
-				{"method": "GET", "path": "/programs/{id}", "description": "Récupérer un programme par ID"},
+				{"method": "GET", "path": "/programs", "description": "Récupérer tous les programmes (sort: featured, rating)"},
+				{"method": "GET", "path": "/programs/{id}", "description": "Récupérer un programme par ID (blocs et durée estimée d'un CIRCUIT)"},
 				{"method": "POST", "path": "/programs", "description": "Créer un programme"},
 				{"method": "PUT", "path": "/programs/{id}", "description": "Mettre à jour un programme"},
 				{"method": "DELETE", "path": "/programs/{id}/like", "description": "Supprimer un like d'un programme"},
 				{"method": "POST", "path": "/programs/{id}/like", "description": "Ajouter un like à un programme"},
 				{"method": "DELETE", "path": "/programs/{id}", "description": "Supprimer un programme"},
 				{"method": "GET", "path": "/programs/featured", "description": "Programmes en vedette"},
-				{"method": "GET", "path": "/programs/popular", "description": "Programmes populaires"},
+				{"method": "GET", "path": "/programs/popular", "description": "Programmes populaires (sort=rating : les mieux notés)"},
 				{"method": "POST", "path": "/programs/{id}/duplicate", "description": "Dupliquer un programme"},
+				{"method": "GET", "path": "/programs/{id}/share", "description": "Partager un programme (JSON signé et code compact)"},
+				{"method": "POST", "path": "/programs/import", "description": "Importer un programme partagé (body: share ou code)"},
+				{"method": "GET", "path": "/programs/{id}/versions", "description": "Historique des versions d'un programme"},
+				{"method": "GET", "path": "/programs/{id}/versions/{version}", "description": "Paramètres d'une version"},
+				{"method": "GET", "path": "/programs/{id}/versions/diff", "description": "Différences entre deux versions (params: from, to)"},
+				{"method": "POST", "path": "/programs/{id}/versions/{version}/revert", "description": "Restaurer une ancienne version"},
+				{"method": "GET", "path": "/programs/{id}/reviews", "description": "Avis d'un programme"},
+				{"method": "GET", "path": "/programs/{id}/reviews/me", "description": "Son avis sur un programme"},
+				{"method": "PUT", "path": "/programs/{id}/reviews", "description": "Noter un programme (1 à 5 étoiles, avis optionnel, après une séance)"},
+				{"method": "DELETE", "path": "/programs/{id}/reviews", "description": "Supprimer son avis"},
+				{"method": "POST", "path": "/programs/{id}/reviews/{reviewId}/report", "description": "Signaler un avis abusif"},
+				{"method": "GET", "path": "/programs/{id}/progression", "description": "Progression adaptative de l'utilisateur sur un programme"},
+				{"method": "PUT", "path": "/programs/{id}/progression", "description": "Activer/désactiver la progression adaptative"},
+				{"method": "DELETE", "path": "/programs/{id}/progression", "description": "Réinitialiser la progression adaptative"},
 				{"method": "GET", "path": "/programs/difficulty/{difficulty}", "description": "Programmes par difficulté"},
 			},
+			"exercises": []map[string]string{
+				{"method": "GET", "path": "/exercises", "description": "Catalogue d'exercices (params: family)"},
+				{"method": "GET", "path": "/exercises/{id}", "description": "Détail d'un exercice (ID ou slug)"},
+			},
+			"training-plans": []map[string]string{
+				{"method": "GET", "path": "/training-plans", "description": "Plans d'entraînement sur plusieurs semaines"},
+				{"method": "POST", "path": "/training-plans", "description": "Créer un plan (semaines, jours, programmes ou repos)"},
+				{"method": "GET", "path": "/training-plans/{id}", "description": "Détail d'un plan avec ses jours"},
+				{"method": "PUT", "path": "/training-plans/{id}", "description": "Mettre à jour un plan (créateur ou admin)"},
+				{"method": "DELETE", "path": "/training-plans/{id}", "description": "Supprimer un plan (créateur ou admin)"},
+				{"method": "POST", "path": "/training-plans/{id}/enroll", "description": "Suivre un plan (startDate, missedDayPolicy shift/skip/repeat)"},
+				{"method": "GET", "path": "/me/training-plans", "description": "Plans suivis par l'utilisateur"},
+				{"method": "GET", "path": "/me/training-plans/today", "description": "Séance du jour de chaque plan suivi"},
+				{"method": "PATCH", "path": "/me/training-plans/{enrollmentId}", "description": "Changer la politique de jour manqué"},
+				{"method": "DELETE", "path": "/me/training-plans/{enrollmentId}", "description": "Abandonner un plan"},
+			},
 			"workouts": []map[string]string{
 				{"method": "GET", "path": "/workouts", "description": "Récupérer toutes les sessions"},
 				{"method": "GET", "path": "/workouts/{id}", "description": "Récupérer une session par ID"},
-				{"method": "POST", "path": "/workouts", "description": "Créer une session d'entraînement"},
+				{"method": "POST", "path": "/workouts", "description": "Créer une session d'entraînement (blockResults pour un CIRCUIT)"},
 				{"method": "PATCH", "path": "/workouts/{id}", "description": "Mettre à jour une session"},
 				{"method": "DELETE", "path": "/workouts/{id}", "description": "Supprimer une session"},
 				{"method": "POST", "path": "/workouts/{sessionId}/sets", "description": "Enregistrer les résultats des séries"},
@@ -81,10 +137,30 @@ func RootHandler(w http.ResponseWriter, r *http.Request) {
 				{"method": "DELETE", "path": "/workouts/{id}/like", "description": "Supprimer un like d'une session de travail"},
 			},
 			"leaderboard": []map[string]string{
-				{"method": "GET", "path": "/leaderboard", "description": "Classement général (params: period, limit)"},
-				{"method": "GET", "path": "/leaderboard/top", "description": "Top 3 performeurs (params: period)"},
-				{"method": "GET", "path": "/leaderboard/users/{userId}", "description": "Rang d'un utilisateur (params: period)"},
-				{"method": "GET", "path": "/leaderboard/users/{userId}/nearby", "description": "Utilisateurs proches dans le classement"},
+				{"method": "GET", "path": "/leaderboard", "description": "Classement général (params: period, limit, exercise)"},
+				{"method": "GET", "path": "/leaderboard/top", "description": "Top 3 performeurs (params: period, exercise)"},
+				{"method": "GET", "path": "/leaderboard/users/{userId}", "description": "Rang d'un utilisateur (params: period, exercise)"},
+				{"method": "GET", "path": "/leaderboard/users/{userId}/nearby", "description": "Utilisateurs proches dans le classement (params: period, range, exercise)"},
+				{"method": "GET", "path": "/leaderboard/clubs", "description": "Classement des clubs (params: period, mode=total|average, limit)"},
+			},
+			"clubs": []map[string]string{
+				{"method": "POST", "path": "/clubs", "description": "Créer un club"},
+				{"method": "POST", "path": "/clubs/join", "description": "Rejoindre un club via un code d'invitation"},
+				{"method": "GET", "path": "/me/clubs", "description": "Clubs de l'utilisateur connecté"},
+				{"method": "GET", "path": "/clubs/{id}", "description": "Récupérer un club"},
+				{"method": "GET", "path": "/clubs/{id}/members", "description": "Membres d'un club"},
+				{"method": "POST", "path": "/clubs/{id}/leave", "description": "Quitter un club"},
+				{"method": "DELETE", "path": "/clubs/{id}/members/{userId}", "description": "Retirer un membre (propriétaire)"},
+				{"method": "GET", "path": "/clubs/{id}/leaderboard", "description": "Classement des membres d'un club (params: period, limit)"},
+				{"method": "GET", "path": "/clubs/{id}/challenges", "description": "Challenges de club et progression cumulée"},
+				{"method": "POST", "path": "/clubs/{id}/challenges", "description": "Créer un challenge de club (objectif cumulé)"},
+			},
+			"duels": []map[string]string{
+				{"method": "POST", "path": "/duels", "description": "Défier un utilisateur (type: most_reps, first_to, best_set)"},
+				{"method": "GET", "path": "/me/duels", "description": "Duels de l'utilisateur connecté (params: state)"},
+				{"method": "GET", "path": "/duels/{id}", "description": "Duel avec le score en direct"},
+				{"method": "POST", "path": "/duels/{id}/accept", "description": "Accepter une invitation de duel"},
+				{"method": "POST", "path": "/duels/{id}/decline", "description": "Refuser une invitation de duel"},
 			},
 			"health": []map[string]string{
 				{"method": "GET", "path": "/health", "description": "Health check de l'API"},
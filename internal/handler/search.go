@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+)
+
+// Search recherche globale sur les challenges, programmes et utilisateurs
+// (params: q, type, difficulty, variant, limit, cursor)
+func Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	q, err := utils.NormalizeSearchQuery(query.Get("q"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "q doit contenir entre 2 et 100 caractères", err)
+		return
+	}
+
+	entityType, err := utils.NormalizeSearchEntityType(query.Get("type"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "type invalide (challenge, program ou user)", err)
+		return
+	}
+
+	params := utils.SearchParams{
+		Query:      q,
+		EntityType: entityType,
+		Difficulty: strings.ToUpper(query.Get("difficulty")),
+		Variant:    strings.ToUpper(query.Get("variant")),
+		Cursor:     query.Get("cursor"),
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			params.Limit = limit
+		}
+	}
+
+	if user, err := middleware.GetUserFromContext(r); err == nil {
+		params.ViewerID = user.ID
+		params.IsAdmin = user.IsAdmin
+	}
+
+	resp, err := utils.Search(context.Background(), params)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidSearchCursor) {
+			utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "could not search", err)
+		return
+	}
+
+	utils.Success(w, resp)
+}
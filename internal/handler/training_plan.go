@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// trainingPlanErrorStatus convertit une erreur métier de plan d'entraînement en code HTTP
+func trainingPlanErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrTrainingPlanNotFound), errors.Is(err, utils.ErrTrainingEnrollmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, utils.ErrAlreadyEnrolled):
+		return http.StatusConflict
+	case errors.Is(err, utils.ErrInvalidMissedDayPolicy):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetTrainingPlans liste les plans d'entraînement (params: difficulty, limit, offset, cursor)
+func GetTrainingPlans(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	plans, err := utils.ListTrainingPlans(ctx, strings.ToUpper(r.URL.Query().Get("difficulty")), page)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch training plans", err)
+		return
+	}
+
+	utils.Success(w, plans)
+}
+
+// GetTrainingPlan récupère un plan avec ses semaines et ses jours
+func GetTrainingPlan(w http.ResponseWriter, r *http.Request) {
+	planID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	plan, err := utils.GetTrainingPlan(ctx, planID)
+	if err != nil {
+		utils.Error(w, trainingPlanErrorStatus(err), "could not fetch training plan", err)
+		return
+	}
+
+	if creator, err := utils.LoadCreator(ctx, plan.CreatedBy); err == nil {
+		plan.Creator = creator
+	}
+
+	utils.Success(w, plan)
+}
+
+// CreateTrainingPlan crée un plan d'entraînement
+func CreateTrainingPlan(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var plan model.TrainingPlan
+	if err := utils.DecodeJSON(r, &plan); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	if err := utils.ValidateTrainingPlan(&plan); err != nil {
+		utils.Error(w, http.StatusBadRequest, "plan invalide", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	created, err := utils.CreateTrainingPlan(ctx, &plan, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "could not create training plan", err)
+		return
+	}
+
+	utils.Success(w, created)
+}
+
+// authorizeTrainingPlanEdit vérifie que l'utilisateur est le créateur du plan ou admin
+func authorizeTrainingPlanEdit(ctx context.Context, w http.ResponseWriter, r *http.Request, planID string) (model.UserProfile, bool) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return user, false
+	}
+
+	ownerID, err := utils.GetTrainingPlanOwner(ctx, planID)
+	if err != nil {
+		utils.Error(w, trainingPlanErrorStatus(err), "could not fetch training plan", err)
+		return user, false
+	}
+	if !middleware.IsOwnerOrAdmin(r, ownerID) {
+		utils.ErrorSimple(w, http.StatusForbidden, "seul le créateur du plan peut le modifier")
+		return user, false
+	}
+
+	return user, true
+}
+
+// UpdateTrainingPlan met à jour un plan (les jours sont remplacés)
+func UpdateTrainingPlan(w http.ResponseWriter, r *http.Request) {
+	planID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	user, ok := authorizeTrainingPlanEdit(ctx, w, r, planID)
+	if !ok {
+		return
+	}
+
+	var plan model.TrainingPlan
+	if err := utils.DecodeJSON(r, &plan); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	if err := utils.ValidateTrainingPlan(&plan); err != nil {
+		utils.Error(w, http.StatusBadRequest, "plan invalide", err)
+		return
+	}
+
+	updated, err := utils.UpdateTrainingPlan(ctx, planID, &plan, user.ID)
+	if err != nil {
+		status := trainingPlanErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusBadRequest
+		}
+		utils.Error(w, status, "could not update training plan", err)
+		return
+	}
+
+	utils.Success(w, updated)
+}
+
+// DeleteTrainingPlan supprime un plan (les inscriptions en cours sont abandonnées)
+func DeleteTrainingPlan(w http.ResponseWriter, r *http.Request) {
+	planID := mux.Vars(r)["id"]
+	ctx := context.Background()
+
+	user, ok := authorizeTrainingPlanEdit(ctx, w, r, planID)
+	if !ok {
+		return
+	}
+
+	if err := utils.DeleteTrainingPlan(ctx, planID, user.ID); err != nil {
+		utils.Error(w, trainingPlanErrorStatus(err), "could not delete training plan", err)
+		return
+	}
+
+	utils.Message(w, "plan supprimé avec succès")
+}
+
+// EnrollTrainingPlan inscrit l'utilisateur connecté à un plan
+// (body optionnel: startDate YYYY-MM-DD, missedDayPolicy shift|skip|repeat)
+func EnrollTrainingPlan(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var body struct {
+		StartDate       string `json:"startDate"`
+		MissedDayPolicy string `json:"missedDayPolicy"`
+	}
+	if r.ContentLength != 0 {
+		if err := utils.DecodeJSON(r, &body); err != nil {
+			utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+			return
+		}
+	}
+
+	var startDate *time.Time
+	if body.StartDate != "" {
+		parsed, err := time.Parse("2006-01-02", body.StartDate)
+		if err != nil {
+			utils.Error(w, http.StatusBadRequest, "startDate invalide (YYYY-MM-DD)", err)
+			return
+		}
+		startDate = &parsed
+	}
+
+	ctx := context.Background()
+
+	enrollment, err := utils.EnrollInTrainingPlan(ctx, mux.Vars(r)["id"], user.ID, startDate, body.MissedDayPolicy)
+	if err != nil {
+		utils.Error(w, trainingPlanErrorStatus(err), "could not enroll in training plan", err)
+		return
+	}
+
+	utils.Success(w, enrollment)
+}
+
+// GetMyTrainingPlans récupère les inscriptions de l'utilisateur connecté
+func GetMyTrainingPlans(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	enrollments, err := utils.GetUserTrainingEnrollments(ctx, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch training plans", err)
+		return
+	}
+
+	utils.Success(w, enrollments)
+}
+
+// GetMyTrainingPlansToday renvoie la séance du jour de chaque plan suivi
+func GetMyTrainingPlansToday(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	entries, err := utils.GetTrainingPlansToday(ctx, user.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch today's workout", err)
+		return
+	}
+
+	for i := range entries {
+		if entries[i].Program == nil {
+			continue
+		}
+		program, err := loadProgramWithCreator(ctx, entries[i].Program.ID)
+		if err != nil {
+			utils.Error(w, http.StatusInternalServerError, "could not fetch scheduled program", err)
+			return
+		}
+		entries[i].Program = program
+	}
+
+	utils.Success(w, entries)
+}
+
+// UpdateMyTrainingPlan change la politique de jour manqué d'une inscription (body: missedDayPolicy)
+func UpdateMyTrainingPlan(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	var body struct {
+		MissedDayPolicy string `json:"missedDayPolicy"`
+	}
+	if err := utils.DecodeJSON(r, &body); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	enrollment, err := utils.UpdateTrainingEnrollmentPolicy(ctx, mux.Vars(r)["enrollmentId"], user.ID, body.MissedDayPolicy)
+	if err != nil {
+		utils.Error(w, trainingPlanErrorStatus(err), "could not update training plan enrollment", err)
+		return
+	}
+
+	utils.Success(w, enrollment)
+}
+
+// LeaveTrainingPlan abandonne une inscription en cours
+func LeaveTrainingPlan(w http.ResponseWriter, r *http.Request) {
+	user, err := middleware.GetUserFromContext(r)
+	if err != nil {
+		utils.Error(w, http.StatusUnauthorized, "impossible de récupérer l'utilisateur", err)
+		return
+	}
+
+	ctx := context.Background()
+
+	if err := utils.AbandonTrainingEnrollment(ctx, mux.Vars(r)["enrollmentId"], user.ID); err != nil {
+		utils.Error(w, trainingPlanErrorStatus(err), "could not leave training plan", err)
+		return
+	}
+
+	utils.Message(w, "plan abandonné")
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
 	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/scanner"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/services"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
@@ -77,6 +79,13 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.Timezone != "" {
+		if _, err := time.LoadLocation(user.Timezone); err != nil {
+			utils.Error(w, http.StatusBadRequest, "fuseau horaire invalide", err)
+			return
+		}
+	}
+
 	ctx := context.Background()
 	_, err = database.DB.Exec(ctx,
 		`UPDATE users
@@ -87,11 +96,12 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		     height = COALESCE($5, height),
 		     goal = COALESCE(NULLIF($6, ''), goal),
 		     email = COALESCE(NULLIF($7, ''), email),
+		     timezone = COALESCE(NULLIF($8, ''), timezone),
 		     updated_at = NOW(),
-		     updated_by = $8
-		 WHERE id = $9 AND deleted_at IS NULL`,
+		     updated_by = $9
+		 WHERE id = $10 AND deleted_at IS NULL`,
 		user.Name, user.Avatar, user.Age, user.Weight, user.Height, user.Goal, user.Email,
-		userFromContext.ID, userFromContext.ID,
+		user.Timezone, userFromContext.ID, userFromContext.ID,
 	)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "could not update user", err)
@@ -101,44 +111,39 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, user)
 }
 
+// userListSort tri de GetUsers : inscrits les plus récents d'abord
+var userListSort = pagination.Sort{
+	Name:    "created",
+	Columns: []pagination.Column{{Expr: "created_at", Cast: "timestamp"}},
+	Desc:    true,
+}
+
 func GetUsers(w http.ResponseWriter, r *http.Request) {
 	ctx := context.Background()
 
-	query := r.URL.Query()
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
 	sqlQuery := `
 		SELECT
 			id, name, email, avatar, age, weight, height, goal, score, is_admin,
 			join_date, created_at, updated_at,
-			created_by, updated_by
+			created_by, updated_by, timezone, xp, level
 		FROM users
 		WHERE deleted_at IS NULL
-		ORDER BY created_at DESC
 	`
 
 	args := []interface{}{}
 	argCount := 1
 
-	// Pagination
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	} else {
-		// Limite par défaut de 50 pour éviter de retourner trop de données
-		sqlQuery += " LIMIT 50"
-	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-			argCount++
-		}
+	// Tri et pagination
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, userListSort, "id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -158,7 +163,9 @@ func GetUsers(w http.ResponseWriter, r *http.Request) {
 		users = append(users, *user)
 	}
 
-	utils.Success(w, users)
+	utils.Success(w, pagination.NewPage(users, page, userListSort, func(u model.UserProfile) ([]string, string) {
+		return []string{pagination.TimeKey(u.CreatedAt)}, u.ID
+	}))
 }
 
 func GetUser(w http.ResponseWriter, r *http.Request) {
@@ -170,7 +177,7 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 	row := database.DB.QueryRow(ctx,
 		`SELECT id, name, email, avatar, age, weight, height, goal, score, is_admin,
 			 join_date, created_at, updated_at,
-			 created_by, updated_by
+			 created_by, updated_by, timezone, xp, level
 		 FROM users WHERE id=$1 AND deleted_at IS NULL`,
 		id,
 	)
@@ -281,6 +288,11 @@ func GetUserStats(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	row := database.DB.QueryRow(ctx, `
 		SELECT
 			COUNT(*) as totalWorkouts,
@@ -289,9 +301,9 @@ func GetUserStats(w http.ResponseWriter, r *http.Request) {
 			COALESCE(MAX(total_reps), 0) as bestSession,
 			0 as totalCalories,
 			COALESCE(AVG(total_reps), 0) as averagePushUps
-		FROM workout_sessions
-		WHERE user_id = $1 AND start_time >= $2 AND start_time <= $3
-	`, userId, startDate, endDate)
+		FROM workout_sessions ws
+		WHERE user_id = $1 AND start_time >= $2 AND start_time <= $3 AND `+utils.ExerciseSessionCondition(4)+`
+	`, userId, startDate, endDate, filter.Value)
 
 	stats, err := scanner.ScanStats(row)
 	if err != nil {
@@ -304,6 +316,8 @@ func GetUserStats(w http.ResponseWriter, r *http.Request) {
 		stats.AveragePushUps = float64(stats.TotalPushUps) / float64(stats.TotalWorkouts)
 	}
 	stats.TotalCalories = float64(stats.TotalPushUps) * 0.29
+	stats.Exercise = filter.Value
+	stats.Unit = filter.Unit
 
 	utils.Success(w, stats)
 }
@@ -316,6 +330,11 @@ func GetChartData(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	var query string
 	var args []interface{}
 
@@ -336,11 +355,11 @@ func GetChartData(w http.ResponseWriter, r *http.Request) {
 				COALESCE(SUM(ws.total_reps) * 0.29, 0) AS calories
 			FROM date_range dr
 			LEFT JOIN workout_sessions ws
-				ON DATE(ws.start_time) = dr.date AND ws.user_id = $1
+				ON DATE(ws.start_time) = dr.date AND ws.user_id = $1 AND ` + utils.ExerciseSessionCondition(2) + `
 			GROUP BY dr.date
 			ORDER BY dr.date;
 		`
-		args = append(args, userID)
+		args = append(args, userID, filter.Value)
 
 	case "month":
 		query = `
@@ -358,12 +377,12 @@ func GetChartData(w http.ResponseWriter, r *http.Request) {
 				COALESCE(SUM(ws.total_reps) * 0.29, 0) AS calories
 			FROM date_range dr
 			LEFT JOIN workout_sessions ws
-				ON DATE(ws.start_time) = dr.date AND ws.user_id = $1
+				ON DATE(ws.start_time) = dr.date AND ws.user_id = $1 AND ` + utils.ExerciseSessionCondition(2) + `
 			GROUP BY dr.date
 			ORDER BY dr.date;
 
 		`
-		args = append(args, userID)
+		args = append(args, userID, filter.Value)
 
 	case "year":
 		query = `
@@ -381,12 +400,12 @@ func GetChartData(w http.ResponseWriter, r *http.Request) {
 				COALESCE(SUM(ws.total_reps) * 0.29, 0) AS calories
 			FROM month_range mr
 			LEFT JOIN workout_sessions ws
-				ON DATE_TRUNC('month', ws.start_time) = mr.month_start AND ws.user_id = $1
+				ON DATE_TRUNC('month', ws.start_time) = mr.month_start AND ws.user_id = $1 AND ` + utils.ExerciseSessionCondition(2) + `
 			GROUP BY mr.month_start
 			ORDER BY mr.month_start;
 
 		`
-		args = append(args, userID)
+		args = append(args, userID, filter.Value)
 
 	default:
 		utils.Error(w, http.StatusBadRequest, "invalid period (use week, month, year, total)", nil)
@@ -538,7 +557,7 @@ func UploadAvatar(w http.ResponseWriter, r *http.Request) {
 	// Récupérer le profil mis à jour
 	row := database.DB.QueryRow(ctx, `
 		SELECT id, name, email, avatar, age, weight, height, goal, score,
-		       is_admin, join_date, created_at, updated_at, created_by, updated_by
+		       is_admin, join_date, created_at, updated_at, created_by, updated_by, timezone, xp, level
 		FROM users WHERE id=$1 AND deleted_at IS NULL
 	`, user.ID)
 
@@ -645,8 +664,12 @@ func GetUsersWorkoutSessions(w http.ResponseWriter, r *http.Request) {
 	startDate := query.Get("startDate")
 	endDate := query.Get("endDate")
 	programType := query.Get("programType")
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
 	ctx := context.Background()
 
@@ -701,26 +724,11 @@ func GetUsersWorkoutSessions(w http.ResponseWriter, r *http.Request) {
 		argCount++
 	}
 
-	sqlQuery += " ORDER BY ws.start_time DESC"
-
-	// Pagination
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	} else {
-		// Limite par défaut de 50 pour éviter de retourner trop de données
-		sqlQuery += " LIMIT 50"
-	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-			argCount++
-		}
+	// Tri et pagination (même tri que GetWorkoutSessions)
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, workoutSessionSort, "ws.id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -740,7 +748,9 @@ func GetUsersWorkoutSessions(w http.ResponseWriter, r *http.Request) {
 		sessions = append(sessions, *session)
 	}
 
-	utils.Success(w, sessions)
+	utils.Success(w, pagination.NewPage(sessions, page, workoutSessionSort, func(s model.WorkoutSession) ([]string, string) {
+		return []string{pagination.TimeKey(s.StartTime)}, s.ID
+	}))
 }
 
 func GetUserStreak(w http.ResponseWriter, r *http.Request) {
@@ -749,88 +759,70 @@ func GetUserStreak(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
-	// Récupérer toutes les dates de workout distinctes, triées par ordre décroissant
-	rows, err := database.DB.Query(ctx, `
-		SELECT DISTINCT DATE(start_time) as workout_date
-		FROM workout_sessions
-		WHERE user_id = $1 AND deleted_at IS NULL
-		ORDER BY workout_date DESC
-	`, userID)
+	// Séries calculées en SQL (gaps and islands) dans le fuseau horaire de l'utilisateur
+	summary, err := utils.GetStreakSummary(ctx, userID)
+	if errors.Is(err, utils.ErrUserNotFound) {
+		utils.Error(w, http.StatusNotFound, "user not found", err)
+		return
+	}
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not compute streak", err)
+		return
+	}
+
+	utils.Success(w, summary)
+}
 
+func GetStreakFreezes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userId"]
+
+	if !middleware.IsOwnerOrAdmin(r, userID) {
+		utils.ErrorSimple(w, http.StatusForbidden, "accès refusé")
+		return
+	}
+
+	ctx := context.Background()
+
+	freezes, err := utils.GetStreakFreezes(ctx, userID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "could not query workout dates", err)
+		utils.Error(w, http.StatusInternalServerError, "could not fetch streak freezes", err)
 		return
 	}
-	defer rows.Close()
 
-	var dates []time.Time
-	for rows.Next() {
-		var date time.Time
-		if err := rows.Scan(&date); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "could not scan date", err)
-			return
-		}
-		dates = append(dates, date)
-	}
-
-	// Calculer le streak
-	currentStreak := 0
-	maxStreak := 0
-	var lastWorkoutDate *string
-
-	if len(dates) > 0 {
-		lastWorkoutDate = new(string)
-		*lastWorkoutDate = dates[0].Format("2006-01-02")
-
-		// Calculer le current streak
-		today := time.Now().UTC().Truncate(24 * time.Hour)
-		yesterday := today.AddDate(0, 0, -1)
-
-		// Le streak commence si le dernier workout était aujourd'hui ou hier
-		latestWorkout := dates[0].UTC().Truncate(24 * time.Hour)
-		if latestWorkout.Equal(today) || latestWorkout.Equal(yesterday) {
-			currentStreak = 1
-			expectedDate := latestWorkout.AddDate(0, 0, -1)
-
-			for i := 1; i < len(dates); i++ {
-				currentDate := dates[i].UTC().Truncate(24 * time.Hour)
-				if currentDate.Equal(expectedDate) {
-					currentStreak++
-					expectedDate = expectedDate.AddDate(0, 0, -1)
-				} else {
-					break
-				}
-			}
-		}
+	utils.Success(w, freezes)
+}
 
-		// Calculer le max streak
-		tempStreak := 1
-		maxStreak = 1
-		expectedDate := dates[0].UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
-
-		for i := 1; i < len(dates); i++ {
-			currentDate := dates[i].UTC().Truncate(24 * time.Hour)
-			if currentDate.Equal(expectedDate) {
-				tempStreak++
-				if tempStreak > maxStreak {
-					maxStreak = tempStreak
-				}
-				expectedDate = expectedDate.AddDate(0, 0, -1)
-			} else {
-				tempStreak = 1
-				expectedDate = currentDate.AddDate(0, 0, -1)
-			}
-		}
+func UseStreakFreeze(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	userID := vars["userId"]
+
+	if !middleware.IsOwnerOrAdmin(r, userID) {
+		utils.ErrorSimple(w, http.StatusForbidden, "accès refusé")
+		return
+	}
+
+	var body struct {
+		Date string `json:"date"` // YYYY-MM-DD, jour de repos à protéger
+	}
+	if err := utils.DecodeJSON(r, &body); err != nil {
+		utils.Error(w, http.StatusBadRequest, "JSON invalide", err)
+		return
 	}
 
-	response := map[string]interface{}{
-		"currentStreak": currentStreak,
-		"maxStreak":     maxStreak,
+	day, err := time.Parse("2006-01-02", body.Date)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "date invalide (format attendu: YYYY-MM-DD)", err)
+		return
 	}
 
-	if lastWorkoutDate != nil {
-		response["lastWorkoutDate"] = *lastWorkoutDate
+	ctx := context.Background()
+
+	freeze, err := utils.UseStreakFreeze(ctx, userID, day)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "impossible d'utiliser un gel de série", err)
+		return
 	}
 
-	utils.Success(w, response)
+	utils.Success(w, freeze)
 }
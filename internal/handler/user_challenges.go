@@ -42,7 +42,7 @@ func GetUserChallenges(w http.ResponseWriter, r *http.Request) {
 			c.id, c.title, c.description, c.category, c.type, c.variant, c.difficulty,
 			c.target_reps, c.duration, c.sets, c.reps_per_set, c.image_url,
 			c.icon_name, c.icon_color, c.participants, c.completions, c.likes, c.points,
-			c.badge, c.start_date, c.end_date, c.status, c.tags, c.is_official,
+			c.badge, c.start_date, c.end_date, c.status, c.tags, c.is_official, c.unlock_mode, c.progress_mode, c.visibility,
 			c.created_by, c.updated_by, c.deleted_by, c.created_at, c.updated_at, c.deleted_at,
 			-- Vérifier si l'utilisateur a complété le challenge
 			COALESCE((
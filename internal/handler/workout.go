@@ -8,10 +8,13 @@ import (
 	"time"
 
 	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
 	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/scanner"
 	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
 	"github.com/gorilla/mux"
 )
 
@@ -97,6 +100,10 @@ func validateWorkoutCompletion(program *model.WorkoutProgram, session *model.Wor
 		// En mode libre, la session est toujours considérée comme complétée
 		return true
 
+	case "CIRCUIT":
+		// Chaque bloc d'exercice de chaque tour doit atteindre sa cible
+		return utils.CircuitCompleted(program, session.BlockResults)
+
 	default:
 		// Type inconnu, considérer comme non complété
 		return false
@@ -122,19 +129,22 @@ func SaveWorkoutSession(w http.ResponseWriter, r *http.Request) {
 	// Récupérer le programme pour valider la complétion
 	var program model.WorkoutProgram
 	var repsSequenceJSON []byte
+	var exerciseUnit model.ExerciseUnit
 	err = database.DB.QueryRow(ctx, `
 		SELECT
-			id, name, type, variant, difficulty, rest_between_sets,
-			target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
-			reps_sequence, reps_per_minute, total_minutes
-		FROM workout_programs
-		WHERE id=$1 AND deleted_at IS NULL
+			wp.id, wp.name, wp.type, wp.variant, wp.difficulty, wp.rest_between_sets,
+			wp.target_reps, wp.time_limit, wp.duration, wp.allow_rest, wp.sets, wp.reps_per_set,
+			wp.reps_sequence, wp.reps_per_minute, wp.total_minutes,
+			wp.exercise_id, e.unit
+		FROM workout_programs wp
+		JOIN exercises e ON e.id = wp.exercise_id
+		WHERE wp.id=$1 AND wp.deleted_at IS NULL
 	`, session.ProgramID).Scan(
 		&program.ID, &program.Name, &program.Type, &program.Variant,
 		&program.Difficulty, &program.RestBetweenSets,
 		&program.TargetReps, &program.TimeLimit, &program.Duration, &program.AllowRest,
 		&program.Sets, &program.RepsPerSet, &repsSequenceJSON, &program.RepsPerMinute,
-		&program.TotalMinutes,
+		&program.TotalMinutes, &program.ExerciseID, &exerciseUnit,
 	)
 
 	if err != nil {
@@ -147,25 +157,92 @@ func SaveWorkoutSession(w http.ResponseWriter, r *http.Request) {
 		json.Unmarshal(repsSequenceJSON, &program.RepsSequence)
 	}
 
+	// Appliquer la progression adaptative de l'utilisateur : la séance est validée sur ses objectifs ajustés
+	baseProgram := program
+	override, err := utils.GetProgramOverride(ctx, user.ID, program.ID)
+	if err != nil {
+		logger.Error("Impossible de charger la progression de %s sur %s: %v", user.ID, program.ID, err)
+	}
+	utils.ApplyProgramOverride(&program, override)
+
+	// Exercice tenu (gainage) : le volume de la séance est la durée tenue, 1 rep = 1 seconde
+	session.ExerciseID = program.ExerciseID
+	if exerciseUnit == model.ExerciseUnitSeconds {
+		session.TotalReps = session.TotalDuration
+	}
+
+	// Circuit : résultats par bloc et par tour, le total de reps en découle
+	if program.Type == model.ProgramTypeCircuit {
+		if program.Blocks, err = utils.LoadProgramBlocks(ctx, program.ID); err != nil {
+			utils.Error(w, http.StatusInternalServerError, "could not fetch program blocks", err)
+			return
+		}
+		rounds := 1
+		if program.Sets != nil {
+			rounds = *program.Sets
+		}
+		if err := validation.ValidateSessionBlockResults(program.Blocks, rounds, session.BlockResults); err != nil {
+			utils.ValidationError(w, err)
+			return
+		}
+		if len(session.BlockResults) > 0 {
+			session.TotalReps = utils.CircuitTotalReps(program.Blocks, session.BlockResults)
+		}
+	} else if len(session.BlockResults) > 0 {
+		utils.ValidationError(w, validation.Errors{{Field: "blockResults", Message: "réservé aux programmes CIRCUIT"}})
+		return
+	}
+
+	// Une séance liée à une tâche de challenge obéit au même verrouillage que CompleteTask
+	if session.ChallengeID != nil && session.ChallengeTaskID != nil {
+		if _, ok := requireUnlockedChallengeTask(ctx, w, *session.ChallengeID, *session.ChallengeTaskID, user.ID); !ok {
+			return
+		}
+	}
+
 	// Valider si la session est complétée selon les critères du programme
 	isCompleted := validateWorkoutCompletion(&program, &session)
 
+	// La séance et ses résultats par bloc sont enregistrés ensemble
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not start transaction", err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
 	// Insérer la session avec le statut de complétion validé
-	err = database.DB.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		INSERT INTO workout_sessions(
-			program_id, user_id, start_time, end_time, total_reps, total_duration, completed, notes, created_at, created_by
-		) VALUES($1, $2, $3, NOW(), $4, $5, $6, $7, NOW(), $8)
-		RETURNING id, created_at, created_by
+			program_id, user_id, start_time, end_time, total_reps, total_duration, completed, notes,
+			challenge_id, challenge_task_id, exercise_id, program_version_id, created_at, created_by
+		) VALUES(
+			$1, $2, $3, NOW(), $4, $5, $6, $7, $8, $9, $10,
+			(SELECT id FROM program_versions WHERE program_id = $1 ORDER BY version DESC LIMIT 1),
+			NOW(), $11
+		)
+		RETURNING id, program_version_id, created_at, created_by
 	`,
 		session.ProgramID, user.ID, session.StartTime,
-		session.TotalReps, session.TotalDuration, isCompleted, session.Notes, user.ID,
-	).Scan(&session.ID, &session.CreatedAt, &session.CreatedBy)
+		session.TotalReps, session.TotalDuration, isCompleted, session.Notes,
+		session.ChallengeID, session.ChallengeTaskID, session.ExerciseID, user.ID,
+	).Scan(&session.ID, &session.ProgramVersionID, &session.CreatedAt, &session.CreatedBy)
 
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "could not save workout session", err)
 		return
 	}
 
+	if err := utils.SaveSessionBlockResults(ctx, tx, session.ID, session.BlockResults); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not save session block results", err)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not save workout session", err)
+		return
+	}
+
 	// Mettre à jour le champ completed de la session pour le retour
 	session.Completed = isCompleted
 
@@ -193,20 +270,24 @@ func SaveWorkoutSession(w http.ResponseWriter, r *http.Request) {
 		// Si la requête ne retourne rien (task pas encore dans la table), err != nil et alreadyCompleted = false
 		wasNotCompleted := (err != nil || !alreadyCompleted)
 
-		// Insérer ou mettre à jour la progression de la tâche
+		// Insérer ou mettre à jour la progression de la tâche : une séance non complétée
+		// compte comme tentative mais ne valide pas la tâche
 		_, err = database.DB.Exec(ctx, `
 			INSERT INTO user_challenge_task_progress(
 				user_id, task_id, challenge_id, completed, completed_at,
 				score, attempts, created_at, updated_at
 			)
-			VALUES($1, $2, $3, TRUE, NOW(), $4, 1, NOW(), NOW())
+			VALUES($1, $2, $3, $5, CASE WHEN $5 THEN NOW() END, $4, 1, NOW(), NOW())
 			ON CONFLICT (user_id, task_id)
 			DO UPDATE SET
-				completed = TRUE,
-				completed_at = NOW(),
+				completed = user_challenge_task_progress.completed OR $5,
+				completed_at = CASE
+					WHEN $5 AND NOT user_challenge_task_progress.completed THEN NOW()
+					ELSE user_challenge_task_progress.completed_at
+				END,
 				attempts = user_challenge_task_progress.attempts + 1,
 				updated_at = NOW()
-		`, user.ID, *session.ChallengeTaskID, *session.ChallengeID, taskScore)
+		`, user.ID, *session.ChallengeTaskID, *session.ChallengeID, taskScore, isCompleted)
 
 		if err != nil {
 			// Log l'erreur mais ne pas bloquer la création de la session
@@ -221,9 +302,43 @@ func SaveWorkoutSession(w http.ResponseWriter, r *http.Request) {
 				utils.Error(w, http.StatusInternalServerError, "could not update user score for task", err)
 				return
 			}
+			if _, err := utils.GrantFlatXP(ctx, user.ID, utils.XPSourceChallengeTask, session.ChallengeTaskID, taskScore); err != nil {
+				logger.Error("Impossible d'attribuer l'XP de la tâche à %s: %v", user.ID, err)
+			}
+		}
+	}
+
+	// Recalculer la progression des challenges concernés (challenge lié et challenges cumulatifs)
+	challengeProgress, err := utils.SyncChallengeProgressForSession(ctx, user.ID, session.ChallengeID)
+	if err != nil {
+		logger.Error("Impossible de mettre à jour la progression des challenges de %s: %v", user.ID, err)
+	} else {
+		session.ChallengeProgress = challengeProgress
+	}
+
+	// Faire avancer les plans d'entraînement dont c'est la séance du jour
+	if isCompleted {
+		trainingPlans, err := utils.AdvanceTrainingPlans(ctx, user.ID, session.ProgramID)
+		if err != nil {
+			logger.Error("Impossible de faire avancer les plans d'entraînement de %s: %v", user.ID, err)
+		} else {
+			session.TrainingPlans = trainingPlans
 		}
 	}
 
+	// Ajuster les objectifs de la prochaine séance (progression adaptative)
+	progression, err := utils.AdvanceProgramProgression(ctx, user.ID, &baseProgram, &program, isCompleted)
+	if err != nil {
+		logger.Error("Impossible de mettre à jour la progression de %s sur %s: %v", user.ID, program.ID, err)
+	} else {
+		session.Progression = progression
+	}
+
+	// Clôturer les duels dont l'issue est désormais connue (ex: objectif first_to atteint)
+	if _, err := utils.SettleUserDuels(ctx, user.ID); err != nil {
+		logger.Error("Impossible de mettre à jour les duels de %s: %v", user.ID, err)
+	}
+
 	// Incrémenter le usage_count du programme
 	_, err = database.DB.Exec(ctx,
 		`UPDATE workout_programs SET usage_count = usage_count + 1 WHERE id = $1`,
@@ -254,17 +369,64 @@ func SaveWorkoutSession(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Protéger la série avec les gels disponibles puis attribuer un éventuel nouveau gel
+	if _, err := utils.ProtectStreak(ctx, user.ID); err != nil {
+		logger.Error("Impossible de protéger la série de %s: %v", user.ID, err)
+	}
+	if _, err := utils.AwardStreakFreezes(ctx, user.ID); err != nil {
+		logger.Error("Impossible d'attribuer un gel de série à %s: %v", user.ID, err)
+	}
+
+	// Inscrire l'utilisateur dans la ligue de la semaine dès sa première séance
+	if _, err := utils.EnsureLeagueMembership(ctx, user.ID); err != nil {
+		logger.Error("Impossible d'inscrire %s dans une ligue: %v", user.ID, err)
+	}
+
+	// Évaluer les badges après la séance
+	if _, err := utils.EvaluateUserBadges(ctx, user.ID); err != nil {
+		logger.Error("Impossible d'évaluer les badges de %s: %v", user.ID, err)
+	}
+
+	// Attribuer l'XP de la séance (bonus de série, première séance du jour, record personnel)
+	xpInput, err := utils.BuildWorkoutXPInput(ctx, user.ID, session.ID, session.TotalReps, string(program.Difficulty), isCompleted)
+	if err != nil {
+		logger.Error("Impossible de calculer l'XP de la séance %s: %v", session.ID, err)
+	} else {
+		award := utils.ComputeWorkoutXP(xpInput)
+		levelUp, err := utils.GrantXP(ctx, user.ID, utils.XPSourceWorkout, &session.ID, award)
+		if err != nil {
+			logger.Error("Impossible d'attribuer l'XP à %s: %v", user.ID, err)
+		} else {
+			session.XP = &award
+			session.LevelUp = levelUp
+			if levelUp != nil {
+				logger.Success("%s passe au niveau %d", user.ID, levelUp.ToLevel)
+			}
+		}
+	}
+
 	utils.Success(w, session)
 }
 
-// GetWorkoutSessions récupère toutes les sessions d'entraînement avec filtres
+// workoutSessionSort tri de GetWorkoutSessions : sessions les plus récentes d'abord
+var workoutSessionSort = pagination.Sort{
+	Name:    "start_time",
+	Columns: []pagination.Column{{Expr: "ws.start_time", Cast: "timestamp"}},
+	Desc:    true,
+}
+
+// GetWorkoutSessions récupère toutes les sessions d'entraînement avec filtres (pagination par curseur ou offset)
 func GetWorkoutSessions(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	startDate := query.Get("startDate")
 	endDate := query.Get("endDate")
 	programType := query.Get("programType")
-	limitStr := query.Get("limit")
-	offsetStr := query.Get("offset")
+
+	page, err := pagination.FromRequest(r, pagination.DefaultLimit, pagination.MaxLimit)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
+	}
 
 	ctx := context.Background()
 
@@ -319,26 +481,11 @@ func GetWorkoutSessions(w http.ResponseWriter, r *http.Request) {
 		argCount++
 	}
 
-	sqlQuery += " ORDER BY ws.start_time DESC"
-
-	// Pagination
-	if limitStr != "" {
-		if limit, err := strconv.Atoi(limitStr); err == nil {
-			sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
-			args = append(args, limit)
-			argCount++
-		}
-	} else {
-		// Limite par défaut de 50 pour éviter de retourner trop de données
-		sqlQuery += " LIMIT 50"
-	}
-
-	if offsetStr != "" {
-		if offset, err := strconv.Atoi(offsetStr); err == nil {
-			sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
-			args = append(args, offset)
-			argCount++
-		}
+	// Tri et pagination
+	sqlQuery, args, err = page.Apply(sqlQuery, args, argCount, workoutSessionSort, "ws.id")
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "curseur invalide", err)
+		return
 	}
 
 	rows, err := database.DB.Query(ctx, sqlQuery, args...)
@@ -359,7 +506,9 @@ func GetWorkoutSessions(w http.ResponseWriter, r *http.Request) {
 		sessions = append(sessions, *session)
 	}
 
-	utils.Success(w, sessions)
+	utils.Success(w, pagination.NewPage(sessions, page, workoutSessionSort, func(s model.WorkoutSession) ([]string, string) {
+		return []string{pagination.TimeKey(s.StartTime)}, s.ID
+	}))
 }
 
 // GetWorkoutStats récupère les statistiques d'entraînement pour un utilisateur
@@ -387,7 +536,14 @@ func GetWorkoutStats(w http.ResponseWriter, r *http.Request) {
 		startDate = now.AddDate(0, 0, -7) // Par défaut: semaine
 	}
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	// totalPushUps conserve son nom pour les anciens clients : c'est le volume de l'exercice filtré
 	var stats struct {
+		model.ExerciseFilter
 		TotalPushUps   int     `json:"totalPushUps"`
 		TotalWorkouts  int     `json:"totalWorkouts"`
 		TotalTime      int     `json:"totalTime"`
@@ -402,9 +558,9 @@ func GetWorkoutStats(w http.ResponseWriter, r *http.Request) {
 			COUNT(*) as total_workouts,
 			COALESCE(SUM(total_duration), 0) as total_time,
 			COALESCE(MAX(total_reps), 0) as best_session
-		FROM workout_sessions
-		WHERE user_id = $1 AND start_time >= $2
-	`, userID, startDate).Scan(
+		FROM workout_sessions ws
+		WHERE user_id = $1 AND start_time >= $2 AND `+utils.ExerciseSessionCondition(3)+`
+	`, userID, startDate, filter.Value).Scan(
 		&stats.TotalPushUps,
 		&stats.TotalWorkouts,
 		&stats.TotalTime,
@@ -421,6 +577,7 @@ func GetWorkoutStats(w http.ResponseWriter, r *http.Request) {
 		stats.AveragePushUps = float64(stats.TotalPushUps) / float64(stats.TotalWorkouts)
 	}
 	stats.TotalCalories = float64(stats.TotalPushUps) * 0.29 // ~0.29 calories par pompe
+	stats.ExerciseFilter = filter
 
 	utils.Success(w, stats)
 }
@@ -516,6 +673,26 @@ func GetWorkoutSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Paramètres exacts du programme au moment de la séance
+	version, err := utils.GetSessionProgramVersion(ctx, session.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch program version", err)
+		return
+	}
+	if version != nil {
+		session.ProgramVersionID = &version.ID
+		session.ProgramVersion = version
+	}
+
+	blockResults, err := utils.LoadSessionBlockResults(ctx, session.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch block results", err)
+		return
+	}
+	if len(blockResults) > 0 {
+		session.BlockResults = blockResults
+	}
+
 	utils.Success(w, session)
 }
 
@@ -640,7 +817,13 @@ func GetWorkoutSummary(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	var summary struct {
+		model.ExerciseFilter
 		TotalSessions int     `json:"totalSessions"`
 		TotalReps     int     `json:"totalReps"`
 		TotalDuration int     `json:"totalDuration"`
@@ -655,9 +838,9 @@ func GetWorkoutSummary(w http.ResponseWriter, r *http.Request) {
 			COALESCE(SUM(total_reps), 0) as total_reps,
 			COALESCE(SUM(total_duration), 0) as total_duration,
 			COALESCE(MAX(total_reps), 0) as best_session
-		FROM workout_sessions
-		WHERE user_id = $1 AND start_time >= $2 AND start_time <= $3
-	`, userID, startDate, endDate).Scan(
+		FROM workout_sessions ws
+		WHERE user_id = $1 AND start_time >= $2 AND start_time <= $3 AND `+utils.ExerciseSessionCondition(4)+`
+	`, userID, startDate, endDate, filter.Value).Scan(
 		&summary.TotalSessions,
 		&summary.TotalReps,
 		&summary.TotalDuration,
@@ -674,6 +857,7 @@ func GetWorkoutSummary(w http.ResponseWriter, r *http.Request) {
 		summary.AverageReps = float64(summary.TotalReps) / float64(summary.TotalSessions)
 	}
 	summary.TotalCalories = float64(summary.TotalReps) * 0.29
+	summary.ExerciseFilter = filter
 
 	utils.Success(w, summary)
 }
@@ -685,7 +869,13 @@ func GetPersonalRecords(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
+	filter, ok := exerciseFilter(ctx, w, r)
+	if !ok {
+		return
+	}
+
 	var records struct {
+		model.ExerciseFilter
 		MaxRepsInSession  int `json:"maxRepsInSession"`
 		MaxRepsInSet      int `json:"maxRepsInSet"`
 		LongestSession    int `json:"longestSession"`
@@ -698,9 +888,9 @@ func GetPersonalRecords(w http.ResponseWriter, r *http.Request) {
 			COALESCE(MAX(total_reps), 0) as max_reps_in_session,
 			COALESCE(MAX(total_duration), 0) as longest_session,
 			COALESCE(SUM(total_reps), 0) as total_lifetime_reps
-		FROM workout_sessions
-		WHERE user_id = $1
-	`, userID).Scan(
+		FROM workout_sessions ws
+		WHERE user_id = $1 AND `+utils.ExerciseSessionCondition(2)+`
+	`, userID, filter.Value).Scan(
 		&records.MaxRepsInSession,
 		&records.LongestSession,
 		&records.TotalLifetimeReps,
@@ -710,6 +900,7 @@ func GetPersonalRecords(w http.ResponseWriter, r *http.Request) {
 		utils.Error(w, http.StatusInternalServerError, "could not fetch session records", err)
 		return
 	}
+	records.ExerciseFilter = filter
 
 	utils.Success(w, records)
 }
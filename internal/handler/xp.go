@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/middleware"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// GetUserXP récupère le niveau, l'XP et l'historique des gains d'un utilisateur
+func GetUserXP(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	ctx := context.Background()
+
+	userXP, err := utils.GetUserXP(ctx, userID, limit)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not fetch user xp", err)
+		return
+	}
+
+	utils.Success(w, userXP)
+}
+
+// RecalculateUserXP recalcule l'XP d'un utilisateur à partir de son historique (admin)
+func RecalculateUserXP(w http.ResponseWriter, r *http.Request) {
+	if !middleware.IsAdmin(r) {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	userID := mux.Vars(r)["userId"]
+	ctx := context.Background()
+
+	userXP, err := utils.RecalculateUserXP(ctx, userID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not recalculate user xp", err)
+		return
+	}
+
+	utils.Success(w, userXP)
+}
+
+// RecalculateAllUsersXP recalcule l'XP de tous les utilisateurs (admin)
+func RecalculateAllUsersXP(w http.ResponseWriter, r *http.Request) {
+	if !middleware.IsAdmin(r) {
+		utils.ErrorSimple(w, http.StatusForbidden, "admin privileges required")
+		return
+	}
+
+	ctx := context.Background()
+
+	count, err := utils.RecalculateAllUsersXP(ctx)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "could not recalculate xp", err)
+		return
+	}
+
+	utils.Success(w, map[string]interface{}{
+		"recalculatedUsers": count,
+	})
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+)
+
+// SpawnRecurringChallenges crée les challenges des modèles récurrents dont l'occurrence approche
+func SpawnRecurringChallenges(ctx context.Context) error {
+	created, err := utils.SpawnChallengeInstances(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		logger.Info("%d challenge(s) récurrent(s) créé(s)", created)
+	}
+	return nil
+}
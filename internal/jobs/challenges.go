@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+)
+
+// RunChallengeLifecycle met à jour les statuts des challenges selon leurs dates et clôture ceux qui sont terminés
+func RunChallengeLifecycle(ctx context.Context) error {
+	transitioned, err := utils.TransitionChallengeStatuses(ctx)
+	if err != nil {
+		return err
+	}
+	if transitioned > 0 {
+		logger.Info("%d challenge(s) ont changé de statut", transitioned)
+	}
+
+	finalized, err := utils.FinalizeEndedChallenges(ctx)
+	if err != nil {
+		return err
+	}
+	for _, result := range finalized {
+		logger.Success("Challenge %s clôturé (%s): %d terminé(s), %d échec(s), %d badge(s)",
+			result.ChallengeID, result.Status, result.Completers, result.Failed, result.BadgesGiven)
+	}
+	return nil
+}
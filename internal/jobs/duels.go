@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+)
+
+// RunDuels expire les invitations sans réponse et clôture les duels terminés
+func RunDuels(ctx context.Context) error {
+	expired, err := utils.ExpirePendingDuels(ctx)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		logger.Info("%d invitation(s) de duel expirée(s)", expired)
+	}
+
+	settled, err := utils.SettleEndedDuels(ctx)
+	if err != nil {
+		return err
+	}
+	if settled > 0 {
+		logger.Success("%d duel(s) clôturé(s)", settled)
+	}
+	return nil
+}
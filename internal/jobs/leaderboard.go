@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+)
+
+// leaderboardPeriods périodes du cache de classement
+var leaderboardPeriods = []string{"daily", "weekly", "monthly", "all-time"}
+
+// RefreshLeaderboards rafraîchit le cache des classements puis attribue les badges de podium
+func RefreshLeaderboards(ctx context.Context) error {
+	for _, period := range leaderboardPeriods {
+		if _, err := database.DB.Exec(ctx, `SELECT refresh_leaderboard_cache($1)`, period); err != nil {
+			return fmt.Errorf("impossible de rafraîchir le classement %s: %w", period, err)
+		}
+	}
+
+	awarded, err := utils.EvaluateWeeklyTopBadges(ctx)
+	if err != nil {
+		return fmt.Errorf("impossible d'attribuer les badges de podium: %w", err)
+	}
+	if awarded > 0 {
+		logger.Success("%d badge(s) de podium attribué(s)", awarded)
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+)
+
+// FinalizeLeagues clôture les semaines de ligue terminées (promotions et relégations)
+func FinalizeLeagues(ctx context.Context) error {
+	finalized, err := utils.FinalizeLeagueWeeks(ctx)
+	if err != nil {
+		return err
+	}
+
+	if finalized > 0 {
+		logger.Success("%d groupe(s) de ligue clôturé(s)", finalized)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/utils"
+)
+
+// RefreshProgramRatings recalcule les moyennes bayésiennes des programmes après l'évolution de la moyenne globale
+func RefreshProgramRatings(ctx context.Context) error {
+	updated, err := utils.RefreshProgramRatingScores(ctx)
+	if err != nil {
+		return err
+	}
+	if updated > 0 {
+		logger.Info("%d score(s) de programme recalculé(s)", updated)
+	}
+	return nil
+}
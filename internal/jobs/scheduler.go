@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+)
+
+// Job représente une tâche planifiée exécutée à intervalle régulier
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// LockKey clé du verrou consultatif Postgres : une seule instance exécute le job à la fois
+	LockKey int64
+	Run     func(ctx context.Context) error
+}
+
+// Clés des verrous consultatifs (une par job)
+const (
+	LockKeyLeagues     int64 = 270001
+	LockKeyLeaderboard int64 = 270002
+	LockKeyChallenges  int64 = 270003
+	LockKeyDuels       int64 = 270004
+	LockKeyTemplates   int64 = 270005
+	LockKeyRatings     int64 = 270006
+)
+
+// DefaultJobs retourne la liste des jobs planifiés de l'application
+func DefaultJobs() []Job {
+	return []Job{
+		{Name: "leagues", Interval: 15 * time.Minute, LockKey: LockKeyLeagues, Run: FinalizeLeagues},
+		{Name: "leaderboard", Interval: 10 * time.Minute, LockKey: LockKeyLeaderboard, Run: RefreshLeaderboards},
+		{Name: "challenges", Interval: 5 * time.Minute, LockKey: LockKeyChallenges, Run: RunChallengeLifecycle},
+		{Name: "duels", Interval: time.Minute, LockKey: LockKeyDuels, Run: RunDuels},
+		{Name: "challenge-templates", Interval: 30 * time.Minute, LockKey: LockKeyTemplates, Run: SpawnRecurringChallenges},
+		{Name: "program-ratings", Interval: time.Hour, LockKey: LockKeyRatings, Run: RefreshProgramRatings},
+	}
+}
+
+// Start lance chaque job dans sa propre goroutine jusqu'à l'annulation du contexte
+func Start(ctx context.Context, jobs []Job) {
+	for _, job := range jobs {
+		go runLoop(ctx, job)
+	}
+	logger.Info("%d job(s) planifié(s) démarré(s)", len(jobs))
+}
+
+func runLoop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		runOnce(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce exécute le job si le verrou consultatif est disponible
+func runOnce(ctx context.Context, job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Job %s: panic: %v", job.Name, r)
+		}
+	}()
+
+	conn, err := database.DB.Acquire(ctx)
+	if err != nil {
+		logger.Error("Job %s: impossible d'obtenir une connexion: %v", job.Name, err)
+		return
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, job.LockKey).Scan(&locked); err != nil {
+		logger.Error("Job %s: impossible d'obtenir le verrou: %v", job.Name, err)
+		return
+	}
+	if !locked {
+		// Une autre instance exécute déjà ce job
+		return
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, job.LockKey)
+
+	start := time.Now()
+	if err := job.Run(ctx); err != nil {
+		logger.Error("Job %s: %v", job.Name, err)
+		return
+	}
+	logger.Debug("Job %s terminé en %s", job.Name, time.Since(start))
+}
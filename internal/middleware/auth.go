@@ -107,7 +107,10 @@ func validateTokenAndGetUser(ctx context.Context, token string) (*model.UserProf
 		u.created_at,
 		u.updated_at,
 		u.created_by,
-		u.updated_by
+		u.updated_by,
+		u.timezone,
+		u.xp,
+		u.level
 	FROM users u
 	JOIN sessions s ON u.id = s.user_id
 	WHERE s.token = $1
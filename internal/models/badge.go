@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Métriques utilisées par les règles de badges
+const (
+	BadgeMetricTotalSessions      = "total_sessions"
+	BadgeMetricTotalReps          = "total_reps"
+	BadgeMetricMaxStreak          = "max_streak"
+	BadgeMetricWeeklyTop3         = "weekly_top3"
+	BadgeMetricOfficialChallenges = "official_challenges"
+	BadgeMetricVariantTried       = "variant_tried"
+)
+
+// BadgeDefinition décrit un badge du catalogue et la règle pour l'obtenir
+type BadgeDefinition struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Emoji       string `json:"emoji"`
+	Category    string `json:"category"` // workout, reps, streak, leaderboard, challenge, variant
+	Metric      string `json:"metric"`
+	Target      int    `json:"target"`
+	Variant     string `json:"variant,omitempty"` // Pour les badges variant_tried
+}
+
+// UserBadge représente un badge obtenu par un utilisateur
+type UserBadge struct {
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Emoji       string    `json:"emoji"`
+	EarnedAt    time.Time `json:"earnedAt"`
+}
+
+// BadgeProgress représente la progression d'un utilisateur vers un badge du catalogue
+type BadgeProgress struct {
+	BadgeDefinition
+	Current  int        `json:"current"`
+	Progress int        `json:"progress"` // pourcentage de 0 à 100
+	Earned   bool       `json:"earned"`
+	EarnedAt *time.Time `json:"earnedAt,omitempty"`
+}
+
+// BadgeStats regroupe les statistiques d'un utilisateur nécessaires à l'évaluation des badges
+type BadgeStats struct {
+	TotalSessions      int
+	TotalReps          int
+	MaxStreak          int
+	WeeklyTop3         bool
+	OfficialChallenges int
+	VariantsTried      map[string]bool
+}
@@ -10,7 +10,7 @@ type BugReport struct {
 	UserID       *string         `json:"userId,omitempty"`
 	Title        string          `json:"title"`
 	Description  string          `json:"description"`
-	Category     string          `json:"category"` // bug, crash, ui, feature-request, other
+	Category     string          `json:"category"` // bug, crash, ui, feature-request, abuse, other
 	Severity     string          `json:"severity"` // low, medium, high, critical
 	Status       string          `json:"status"`   // open, in-progress, resolved, closed
 	DeviceInfo   json.RawMessage `json:"deviceInfo,omitempty"`
@@ -43,3 +43,10 @@ type UpdateBugReportRequest struct {
 	Status     string `json:"status,omitempty"`
 	AdminNotes string `json:"adminNotes,omitempty"`
 }
+
+// Catégories, sévérités et statuts d'un signalement
+var (
+	BugReportCategories = []string{"bug", "crash", "ui", "feature-request", "abuse", "other"}
+	BugReportSeverities = []string{"low", "medium", "high", "critical"}
+	BugReportStatuses   = []string{"open", "in-progress", "resolved", "closed"}
+)
@@ -33,8 +33,16 @@ type Challenge struct {
 	OverallProgress  *int            `json:"overallProgress,omitempty"`
 	Tags             []string        `json:"tags,omitempty"`
 	IsOfficial       bool            `json:"isOfficial"`
+	UnlockMode       string          `json:"unlockMode"`           // daily, sequential
+	ProgressMode     string          `json:"progressMode"`         // linked, cumulative
+	Visibility       string          `json:"visibility"`           // public, unlisted, private
+	InviteCode       *string         `json:"inviteCode,omitempty"` // Visible par le créateur et les admins
+	InviteLink       *string         `json:"inviteLink,omitempty"`
 	Tasks            []ChallengeTask `json:"challengeTasks,omitempty"`
 
+	// TaskTemplate génère les tâches à la création (ignoré si challengeTasks est fourni)
+	TaskTemplate *ChallengeTaskTemplate `json:"taskTemplate,omitempty"`
+
 	Creator *UserCreator `json:"creator,omitempty"`
 
 	DateFields
@@ -53,7 +61,9 @@ type ChallengeTask struct {
 	Sets          *int                       `json:"sets,omitempty"`
 	RepsPerSet    *int                       `json:"repsPerSet,omitempty"`
 	ScheduledDate *time.Time                 `json:"scheduledDate,omitempty"`
-	IsLocked      bool                       `json:"isLocked"`
+	IsLocked      bool                       `json:"isLocked"` // Calculé pour l'utilisateur courant
+	UnlocksAt     *time.Time                 `json:"unlocksAt,omitempty"`
+	LockReason    *string                    `json:"lockReason,omitempty"` // not_yet_available, previous_task_incomplete
 	Score         *int                       `json:"score,omitempty"`
 	UserProgress  *UserChallengeTaskProgress `json:"userProgress,omitempty"`
 
@@ -71,6 +81,7 @@ type UserChallengeProgress struct {
 	TargetReps  int        `json:"targetReps"`
 	Attempts    int        `json:"attempts"`
 	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	Status      string     `json:"status"` // in_progress, completed, failed
 	DateFields
 }
 
@@ -93,3 +104,68 @@ type UserChallengeTaskProgress struct {
 	CreatedAt   time.Time  `json:"createdAt"`
 	UpdatedAt   time.Time  `json:"updatedAt"`
 }
+
+// ChallengeTaskTemplate décrit une progression multi-jours générée automatiquement
+// (ex: 30 jours, départ à 10 reps, +2 par jour, repos tous les 7 jours)
+type ChallengeTaskTemplate struct {
+	Days        int     `json:"days"`
+	StartReps   int     `json:"startReps"`
+	Increment   int     `json:"increment"`           // Reps ajoutées chaque jour
+	RestEvery   int     `json:"restEvery,omitempty"` // 0 = pas de jour de repos
+	Sets        *int    `json:"sets,omitempty"`      // Si défini, les reps sont réparties en séries
+	Type        *string `json:"type,omitempty"`
+	Variant     *string `json:"variant,omitempty"`
+	TitlePrefix string  `json:"titlePrefix,omitempty"`
+	Score       *int    `json:"score,omitempty"` // Score de chaque tâche
+}
+
+// ChallengeEvent événement du cycle de vie d'un challenge (destiné aux notifications)
+type ChallengeEvent struct {
+	ID          string         `json:"id"`
+	ChallengeID string         `json:"challengeId"`
+	UserID      *string        `json:"userId,omitempty"`
+	Type        string         `json:"type"`
+	Payload     map[string]any `json:"payload,omitempty"`
+	CreatedAt   time.Time      `json:"createdAt"`
+}
+
+// ChallengeFinalization résumé de la clôture d'un challenge
+type ChallengeFinalization struct {
+	ChallengeID  string `json:"challengeId"`
+	Status       string `json:"status"`
+	Completers   int    `json:"completers"`
+	Failed       int    `json:"failed"`
+	BadgesGiven  int    `json:"badgesGiven"`
+	Participants int    `json:"participants"`
+}
+
+// ChallengeAllowedUser utilisateur autorisé à participer à un challenge privé
+type ChallengeAllowedUser struct {
+	UserID   string    `json:"userId"`
+	UserName string    `json:"userName"`
+	Avatar   *string   `json:"avatar,omitempty"`
+	AddedAt  time.Time `json:"addedAt"`
+}
+
+// ChallengeCertificateData données affichées sur un certificat de réussite
+type ChallengeCertificateData struct {
+	UserID         string    `json:"userId"`
+	UserName       string    `json:"userName"`
+	ChallengeID    string    `json:"challengeId"`
+	ChallengeTitle string    `json:"challengeTitle"`
+	CompletedAt    time.Time `json:"completedAt"`
+	TotalReps      int       `json:"totalReps"`
+	Rank           int       `json:"rank"`
+	Participants   int       `json:"participants"`
+}
+
+// ChallengeCertificate certificat généré (PNG et PDF) et son éventuelle URL publique
+type ChallengeCertificate struct {
+	Data        ChallengeCertificateData `json:"data"`
+	Fingerprint string                   `json:"fingerprint"`
+	PNG         []byte                   `json:"-"`
+	PDF         []byte                   `json:"-"`
+	ImageURL    *string                  `json:"imageUrl,omitempty"`
+	Generated   bool                     `json:"-"` // true si le certificat vient d'être (re)généré
+	UpdatedAt   time.Time                `json:"updatedAt"`
+}
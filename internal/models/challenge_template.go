@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// ChallengeTemplate modèle de challenge récurrent à partir duquel le scheduler crée des challenges
+type ChallengeTemplate struct {
+	ID            string                 `json:"id"`
+	Title         string                 `json:"title"`
+	Description   string                 `json:"description"`
+	Category      string                 `json:"category"` // DAILY, WEEKLY, etc.
+	Type          string                 `json:"type"`
+	Variants      []string               `json:"variants"` // Utilisées à tour de rôle (ex: DIAMOND, WIDE, ARCHER)
+	Difficulty    string                 `json:"difficulty"`
+	Difficulties  []string               `json:"difficulties,omitempty"` // Rotation de difficulté (objectif mis à l'échelle)
+	TargetReps    int                    `json:"targetReps"`
+	RepsIncrement int                    `json:"repsIncrement"` // Reps ajoutées à chaque occurrence
+	MaxTargetReps *int                   `json:"maxTargetReps,omitempty"`
+	Sets          *int                   `json:"sets,omitempty"`
+	Points        int                    `json:"points"`
+	IconName      string                 `json:"iconName"`
+	IconColor     string                 `json:"iconColor"`
+	ImageURL      *string                `json:"imageUrl,omitempty"`
+	Tags          []string               `json:"tags,omitempty"`
+	IsOfficial    bool                   `json:"isOfficial"`
+	ProgressMode  string                 `json:"progressMode"`
+	TaskTemplate  *ChallengeTaskTemplate `json:"taskTemplate,omitempty"` // Planning multi-jours de chaque occurrence
+	Recurrence    string                 `json:"recurrence"`             // Ex: FREQ=WEEKLY;BYDAY=MO,WE,FR
+	DurationDays  *int                   `json:"durationDays,omitempty"` // Par défaut : la période de la règle
+	StartsOn      time.Time              `json:"startsOn"`
+	Until         *time.Time             `json:"until,omitempty"`
+	Active        bool                   `json:"active"`
+
+	OccurrenceCount  int        `json:"occurrenceCount"`
+	LastOccurrenceAt *time.Time `json:"lastOccurrenceAt,omitempty"`
+	NextOccurrenceAt *time.Time `json:"nextOccurrenceAt,omitempty"` // Calculé
+
+	CreatedBy *string   `json:"createdBy,omitempty"`
+	UpdatedBy *string   `json:"updatedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ChallengeOccurrence aperçu d'une occurrence d'un modèle récurrent
+type ChallengeOccurrence struct {
+	Occurrence int       `json:"occurrence"`
+	StartDate  time.Time `json:"startDate"`
+	EndDate    time.Time `json:"endDate"`
+	Variant    string    `json:"variant"`
+	Difficulty string    `json:"difficulty"`
+	TargetReps int       `json:"targetReps"`
+	Points     int       `json:"points"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// Rôles d'un membre de club
+const (
+	ClubRoleOwner  = "owner"
+	ClubRoleMember = "member"
+)
+
+type Club struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	Avatar      *string `json:"avatar,omitempty"`
+	InviteCode  string  `json:"inviteCode,omitempty"` // Visible uniquement par les membres
+	MaxMembers  int     `json:"maxMembers"`
+	MemberCount int     `json:"memberCount"`
+	UserRole    *string `json:"userRole,omitempty"` // Rôle de l'utilisateur connecté
+
+	DateFields
+}
+
+type ClubMember struct {
+	UserID   string    `json:"userId"`
+	UserName string    `json:"userName"`
+	Avatar   *string   `json:"avatar,omitempty"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+type ClubLeaderboardEntry struct {
+	ClubID        string  `json:"clubId"`
+	ClubName      string  `json:"clubName"`
+	Avatar        *string `json:"avatar,omitempty"`
+	Rank          int     `json:"rank"`
+	Score         float64 `json:"score"` // Total ou moyenne par membre selon le mode
+	TotalReps     int     `json:"totalReps"`
+	AverageReps   float64 `json:"averageReps"`
+	MemberCount   int     `json:"memberCount"`
+	ActiveMembers int     `json:"activeMembers"`
+	TotalSessions int     `json:"totalSessions"`
+}
+
+type ClubChallenge struct {
+	ID          string     `json:"id"`
+	ClubID      string     `json:"clubId"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description,omitempty"`
+	GoalReps    int        `json:"goalReps"`
+	CurrentReps int        `json:"currentReps"`
+	Progress    int        `json:"progress"` // pourcentage de 0 à 100
+	StartDate   time.Time  `json:"startDate"`
+	EndDate     time.Time  `json:"endDate"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	CreatedBy   *string    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// Types de duel
+const (
+	DuelTypeMostReps = "most_reps"
+	DuelTypeFirstTo  = "first_to"
+	DuelTypeBestSet  = "best_set"
+)
+
+// Statuts d'un duel (stockés en base)
+const (
+	DuelStatusPending   = "pending"
+	DuelStatusActive    = "active"
+	DuelStatusCompleted = "completed"
+	DuelStatusDeclined  = "declined"
+	DuelStatusExpired   = "expired"
+)
+
+// États d'un duel du point de vue de l'utilisateur connecté
+const (
+	DuelStatePending  = "pending"
+	DuelStateActive   = "active"
+	DuelStateWon      = "won"
+	DuelStateLost     = "lost"
+	DuelStateDraw     = "draw"
+	DuelStateDeclined = "declined"
+	DuelStateExpired  = "expired"
+)
+
+// DuelParticipant un des deux adversaires avec son score courant
+type DuelParticipant struct {
+	UserID    string     `json:"userId"`
+	UserName  string     `json:"userName"`
+	Avatar    *string    `json:"avatar,omitempty"`
+	Score     int        `json:"score"`
+	ReachedAt *time.Time `json:"reachedAt,omitempty"` // first_to : moment où l'objectif a été atteint
+}
+
+// Duel affrontement limité dans le temps entre deux utilisateurs
+type Duel struct {
+	ID                   string          `json:"id"`
+	Type                 string          `json:"type"`
+	TargetReps           *int            `json:"targetReps,omitempty"`
+	DurationHours        int             `json:"durationHours"`
+	WinnerPoints         int             `json:"winnerPoints"`
+	Status               string          `json:"status"`
+	State                string          `json:"state,omitempty"` // pending, active, won, lost, draw, declined, expired
+	Challenger           DuelParticipant `json:"challenger"`
+	Opponent             DuelParticipant `json:"opponent"`
+	WinnerID             *string         `json:"winnerId,omitempty"`
+	ExpiresAt            time.Time       `json:"expiresAt"`
+	StartsAt             *time.Time      `json:"startsAt,omitempty"`
+	EndsAt               *time.Time      `json:"endsAt,omitempty"`
+	TimeRemainingSeconds int64           `json:"timeRemainingSeconds,omitempty"`
+	RespondedAt          *time.Time      `json:"respondedAt,omitempty"`
+	CompletedAt          *time.Time      `json:"completedAt,omitempty"`
+	CreatedAt            time.Time       `json:"createdAt"`
+}
+
+// DuelSessionScore contribution d'une séance au score d'un duel
+type DuelSessionScore struct {
+	Reps    int
+	BestSet int
+	At      time.Time
+}
+
+// DuelOutcome résultat (provisoire ou final) d'un duel
+type DuelOutcome struct {
+	ChallengerScore     int
+	OpponentScore       int
+	ChallengerReachedAt *time.Time
+	OpponentReachedAt   *time.Time
+	Finished            bool
+	Winner              string // challenger, opponent, draw ou vide si non terminé
+}
@@ -0,0 +1,81 @@
+package model
+
+// ProgramType type d'entraînement d'un programme
+type ProgramType string
+
+const (
+	ProgramTypeFreeMode   ProgramType = "FREE_MODE"
+	ProgramTypeTargetReps ProgramType = "TARGET_REPS"
+	ProgramTypeMaxTime    ProgramType = "MAX_TIME"
+	ProgramTypeSetsReps   ProgramType = "SETS_REPS"
+	ProgramTypePyramid    ProgramType = "PYRAMID"
+	ProgramTypeEMOM       ProgramType = "EMOM"
+	ProgramTypeAMRAP      ProgramType = "AMRAP"
+	ProgramTypeCircuit    ProgramType = "CIRCUIT" // blocs ordonnés répétés sur plusieurs tours
+)
+
+// ProgramTypes liste des types de programme valides
+var ProgramTypes = []ProgramType{
+	ProgramTypeFreeMode, ProgramTypeTargetReps, ProgramTypeMaxTime, ProgramTypeSetsReps,
+	ProgramTypePyramid, ProgramTypeEMOM, ProgramTypeAMRAP, ProgramTypeCircuit,
+}
+
+// Valid indique si le type est connu
+func (t ProgramType) Valid() bool {
+	for _, known := range ProgramTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Variant variante de pompe
+type Variant string
+
+const (
+	VariantStandard Variant = "STANDARD"
+	VariantIncline  Variant = "INCLINE"
+	VariantDecline  Variant = "DECLINE"
+	VariantDiamond  Variant = "DIAMOND"
+	VariantWide     Variant = "WIDE"
+	VariantPike     Variant = "PIKE"
+	VariantArcher   Variant = "ARCHER"
+)
+
+// Variants liste des variantes valides
+var Variants = []Variant{
+	VariantStandard, VariantIncline, VariantDecline, VariantDiamond, VariantWide, VariantPike, VariantArcher,
+}
+
+// Valid indique si la variante est connue
+func (v Variant) Valid() bool {
+	for _, known := range Variants {
+		if v == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Difficulty niveau de difficulté
+type Difficulty string
+
+const (
+	DifficultyBeginner     Difficulty = "BEGINNER"
+	DifficultyIntermediate Difficulty = "INTERMEDIATE"
+	DifficultyAdvanced     Difficulty = "ADVANCED"
+)
+
+// Difficulties liste des difficultés valides, de la plus facile à la plus dure
+var Difficulties = []Difficulty{DifficultyBeginner, DifficultyIntermediate, DifficultyAdvanced}
+
+// Valid indique si la difficulté est connue
+func (d Difficulty) Valid() bool {
+	for _, known := range Difficulties {
+		if d == known {
+			return true
+		}
+	}
+	return false
+}
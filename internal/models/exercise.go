@@ -0,0 +1,31 @@
+package model
+
+// Unités de mesure d'un exercice
+type ExerciseUnit string
+
+const (
+	ExerciseUnitReps    ExerciseUnit = "reps"
+	ExerciseUnitSeconds ExerciseUnit = "seconds" // gainage : total_reps contient la durée tenue
+)
+
+// ExerciseFamilyPushUp famille par défaut des statistiques et classements
+const ExerciseFamilyPushUp = "push-up"
+
+// Exercise exercice du catalogue
+type Exercise struct {
+	ID                    string       `json:"id"`
+	Slug                  string       `json:"slug"`
+	Name                  string       `json:"name"`
+	Family                string       `json:"family"`
+	Variant               *Variant     `json:"variant,omitempty"`
+	Unit                  ExerciseUnit `json:"unit"`
+	MuscleGroups          []string     `json:"muscleGroups"`
+	DifficultyCoefficient float64      `json:"difficultyCoefficient"`
+	Description           *string      `json:"description,omitempty"`
+}
+
+// ExerciseFilter filtre des statistiques et classements : un exercice (slug) ou une famille
+type ExerciseFilter struct {
+	Value string       `json:"exercise"`
+	Unit  ExerciseUnit `json:"unit"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// LeagueTierNames noms des paliers de ligue, du plus bas au plus haut
+var LeagueTierNames = []string{
+	"Bronze", "Argent", "Or", "Saphir", "Rubis",
+	"Émeraude", "Améthyste", "Perle", "Obsidienne", "Diamant",
+}
+
+// LeagueStanding représente la position d'un utilisateur dans son groupe de ligue
+type LeagueStanding struct {
+	Rank          int     `json:"rank"`
+	UserID        string  `json:"userId"`
+	UserName      string  `json:"userName"`
+	Avatar        *string `json:"avatar,omitempty"`
+	WeeklyReps    int     `json:"weeklyReps"`
+	Zone          string  `json:"zone"` // promotion, safe, relegation
+	IsCurrentUser bool    `json:"isCurrentUser"`
+}
+
+// League représente le groupe de ligue de la semaine en cours d'un utilisateur
+type League struct {
+	CohortID             string           `json:"cohortId"`
+	Tier                 int              `json:"tier"`
+	TierName             string           `json:"tierName"`
+	WeekStart            time.Time        `json:"weekStart"`
+	WeekEnd              time.Time        `json:"weekEnd"`
+	TimeRemainingSeconds int64            `json:"timeRemainingSeconds"`
+	PromotionCount       int              `json:"promotionCount"`
+	RelegationCount      int              `json:"relegationCount"`
+	Standings            []LeagueStanding `json:"standings"`
+}
+
+// LeagueHistoryEntry représente le résultat d'une semaine de ligue pour un utilisateur
+type LeagueHistoryEntry struct {
+	CohortID   string  `json:"cohortId"`
+	WeekStart  string  `json:"weekStart"` // YYYY-MM-DD
+	Tier       int     `json:"tier"`
+	TierName   string  `json:"tierName"`
+	WeeklyReps int     `json:"weeklyReps"`
+	FinalRank  *int    `json:"finalRank,omitempty"`
+	Outcome    *string `json:"outcome,omitempty"` // promoted, relegated, stayed (nil = semaine en cours)
+}
@@ -6,31 +6,51 @@ import (
 )
 
 type WorkoutProgram struct {
-	ID              string  `json:"id"`
-	Name            string  `json:"name"`
-	Description     *string `json:"description,omitempty"`
-	Type            string  `json:"type"`       // FREE_MODE, TARGET_REPS, MAX_TIME, SETS_REPS, PYRAMID, EMOM, AMRAP
-	Variant         string  `json:"variant"`    // STANDARD, INCLINE, DECLINE, DIAMOND, WIDE, PIKE, ARCHER
-	Difficulty      string  `json:"difficulty"` // BEGINNER, INTERMEDIATE, ADVANCED
-	RestBetweenSets *int    `json:"restBetweenSets,omitempty"`
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	Description     *string     `json:"description,omitempty"`
+	Type            ProgramType `json:"type"`       // FREE_MODE, TARGET_REPS, MAX_TIME, SETS_REPS, PYRAMID, EMOM, AMRAP
+	Variant         Variant     `json:"variant"`    // STANDARD, INCLINE, DECLINE, DIAMOND, WIDE, PIKE, ARCHER
+	Difficulty      Difficulty  `json:"difficulty"` // BEGINNER, INTERMEDIATE, ADVANCED
+	RestBetweenSets *int        `json:"restBetweenSets,omitempty"`
+
+	// Exercice du programme (variante de pompes correspondante par défaut)
+	ExerciseID *string   `json:"exerciseId,omitempty"`
+	Exercise   *Exercise `json:"exercise,omitempty"`
 
 	// Champs spécifiques selon le type
 	TargetReps    *int         `json:"targetReps,omitempty"`    // Pour TARGET_REPS
 	TimeLimit     *int         `json:"timeLimit,omitempty"`     // Pour TARGET_REPS (optionnel)
 	Duration      *int         `json:"duration,omitempty"`      // Pour MAX_TIME, AMRAP
 	AllowRest     sql.NullBool `json:"allowRest,omitempty"`     // Pour MAX_TIME
-	Sets          *int         `json:"sets,omitempty"`          // Pour SETS_REPS
+	Sets          *int         `json:"sets,omitempty"`          // Pour SETS_REPS, CIRCUIT (nombre de tours)
 	RepsPerSet    *int         `json:"repsPerSet,omitempty"`    // Pour SETS_REPS
 	RepsSequence  []int        `json:"repsSequence,omitempty"`  // Pour PYRAMID
 	RepsPerMinute *int         `json:"repsPerMinute,omitempty"` // Pour EMOM
 	TotalMinutes  *int         `json:"totalMinutes,omitempty"`  // Pour EMOM
 
+	// Pour CIRCUIT : blocs ordonnés et durée totale estimée (secondes)
+	Blocks            []ProgramBlock `json:"blocks,omitempty"`
+	EstimatedDuration *int           `json:"estimatedDuration,omitempty"`
+
 	IsCustom   bool `json:"isCustom"`
 	IsFeatured bool `json:"isFeatured"`
 	UsageCount int  `json:"usageCount"` // Nombre de fois utilisé
 	Likes      int  `json:"likes"`
 	UserLiked  bool `json:"userLiked,omitempty"`
 
+	// Notes : moyenne brute, nombre de notes et moyenne bayésienne (tri)
+	RatingAverage float64 `json:"ratingAverage"`
+	RatingCount   int     `json:"ratingCount"`
+	RatingScore   float64 `json:"ratingScore"`
+
+	// Progression adaptative de l'utilisateur courant (paramètres déjà fusionnés)
+	Override *ProgramOverride `json:"override,omitempty"`
+
+	// Partage : origine d'un programme importé et nombre d'imports de ce programme
+	Attribution *ProgramAttribution `json:"attribution,omitempty"`
+	ImportCount int                 `json:"importCount,omitempty"`
+
 	Creator *UserCreator `json:"creator,omitempty"`
 
 	DateFields
@@ -42,6 +62,7 @@ type WorkoutSession struct {
 	UserID          string        `json:"userId"`
 	ChallengeID     *string       `json:"challengeId,omitempty"`
 	ChallengeTaskID *string       `json:"challengeTaskId,omitempty"`
+	ExerciseID      *string       `json:"exerciseId,omitempty"` // exercice du programme au moment de la séance
 	StartTime       time.Time     `json:"startTime"`
 	EndTime         *time.Time    `json:"endTime,omitempty"`
 	TotalReps       int           `json:"totalReps"`
@@ -52,6 +73,25 @@ type WorkoutSession struct {
 	UserLiked       bool          `json:"userLiked"`
 	Sets            []interface{} `json:"sets"`
 
+	// Résultats par bloc et par tour (programmes CIRCUIT)
+	BlockResults []SessionBlockResult `json:"blockResults,omitempty"`
+
+	XP      *XPAward `json:"xp,omitempty"`      // XP gagnée lors de l'enregistrement
+	LevelUp *LevelUp `json:"levelUp,omitempty"` // Passage de niveau déclenché par la session
+
+	// Progression des challenges mise à jour par la session
+	ChallengeProgress []UserChallengeProgress `json:"challengeProgress,omitempty"`
+
+	// Plans d'entraînement avancés par la session
+	TrainingPlans []TrainingPlanEnrollment `json:"trainingPlans,omitempty"`
+
+	// Version du programme au moment de la séance
+	ProgramVersionID *string         `json:"programVersionId,omitempty"`
+	ProgramVersion   *ProgramVersion `json:"programVersion,omitempty"`
+
+	// Paramètres de la prochaine séance (progression adaptative)
+	Progression *ProgramOverride `json:"progression,omitempty"`
+
 	Creator *UserCreator `json:"creator,omitempty"`
 	User    *UserCreator `json:"user,omitempty"` // L'utilisateur qui a fait la session
 
@@ -65,4 +105,38 @@ type Stats struct {
 	TotalTime      int     `json:"totalTime"`
 	BestSession    int     `json:"bestSession"`
 	AveragePushUps float64 `json:"averagePushUps"`
+
+	// Exercice filtré (pompes par défaut) : les totaux *PushUps portent sur cet exercice
+	Exercise string       `json:"exercise,omitempty"`
+	Unit     ExerciseUnit `json:"unit,omitempty"`
+}
+
+// ProgramRecommendation programme recommandé avec le score et les raisons du classement
+type ProgramRecommendation struct {
+	WorkoutProgram
+	Score   float64  `json:"score"`
+	Reason  string   `json:"reason"`
+	Reasons []string `json:"reasons"`
+}
+
+// Décisions de la progression adaptative
+const (
+	ProgressionIncrease = "increase"
+	ProgressionHold     = "hold"
+	ProgressionDeload   = "deload"
+)
+
+// ProgramOverride paramètres d'un programme ajustés pour un utilisateur (progression adaptative)
+type ProgramOverride struct {
+	ProgramID            string    `json:"programId"`
+	Enabled              bool      `json:"enabled"`
+	TargetReps           *int      `json:"targetReps,omitempty"`
+	RepsPerSet           *int      `json:"repsPerSet,omitempty"`
+	RepsSequence         []int     `json:"repsSequence,omitempty"`
+	RepsPerMinute        *int      `json:"repsPerMinute,omitempty"`
+	LastAction           string    `json:"lastAction"`
+	ConsecutiveSuccesses int       `json:"consecutiveSuccesses"`
+	ConsecutiveFailures  int       `json:"consecutiveFailures"`
+	SessionsCount        int       `json:"sessionsCount"`
+	UpdatedAt            time.Time `json:"updatedAt"`
 }
@@ -0,0 +1,32 @@
+package model
+
+// BlockKind type de bloc d'un circuit
+type BlockKind string
+
+const (
+	BlockKindReps BlockKind = "REPS" // répétitions d'une variante ou d'un exercice
+	BlockKindTime BlockKind = "TIME" // exercice tenu pendant une durée (gainage)
+	BlockKindRest BlockKind = "REST" // repos
+)
+
+// BlockKinds liste des types de bloc valides
+var BlockKinds = []BlockKind{BlockKindReps, BlockKindTime, BlockKindRest}
+
+// ProgramBlock bloc d'un programme CIRCUIT, dans l'ordre de position
+type ProgramBlock struct {
+	ID         string    `json:"id,omitempty"`
+	Position   int       `json:"position"`
+	Kind       BlockKind `json:"kind"`
+	Variant    *Variant  `json:"variant,omitempty"`
+	ExerciseID *string   `json:"exerciseId,omitempty"`
+	TargetReps *int      `json:"targetReps,omitempty"` // Pour REPS
+	Duration   *int      `json:"duration,omitempty"`   // Pour TIME et REST, en secondes
+}
+
+// SessionBlockResult résultat d'un bloc pour un tour d'une séance de circuit
+type SessionBlockResult struct {
+	Position int `json:"position"`
+	Round    int `json:"round"`
+	Reps     int `json:"reps"`
+	Duration int `json:"duration"` // en secondes
+}
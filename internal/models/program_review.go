@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// Bornes d'une note
+const (
+	MinProgramRating = 1
+	MaxProgramRating = 5
+)
+
+// ProgramReview note (1 à 5 étoiles) et avis optionnel d'un utilisateur sur un programme
+type ProgramReview struct {
+	ID           string       `json:"id"`
+	ProgramID    string       `json:"programId"`
+	UserID       string       `json:"userId"`
+	Rating       int          `json:"rating"`
+	Comment      *string      `json:"comment,omitempty"`
+	Hidden       bool         `json:"hidden,omitempty"`       // masqué par la modération
+	ReportsCount int          `json:"reportsCount,omitempty"` // visible des admins
+	User         *UserCreator `json:"user,omitempty"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	UpdatedAt    time.Time    `json:"updatedAt"`
+}
+
+// ProgramReviewRequest création ou mise à jour de son avis
+type ProgramReviewRequest struct {
+	Rating  int     `json:"rating"`
+	Comment *string `json:"comment,omitempty"`
+}
+
+// ProgramReviewReportRequest signalement d'un avis abusif
+type ProgramReviewReportRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ProgramReviewReport signalement enregistré, relié au signalement de modération
+type ProgramReviewReport struct {
+	ID          string    `json:"id"`
+	ReviewID    string    `json:"reviewId"`
+	BugReportID *string   `json:"bugReportId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// ProgramReviewModerationRequest décision d'un admin sur un avis
+type ProgramReviewModerationRequest struct {
+	Hidden bool `json:"hidden"`
+}
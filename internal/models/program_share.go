@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Format de partage des programmes
+const (
+	ProgramShareFormat        = "pumppro.program"
+	ProgramShareFormatVersion = 1
+)
+
+// ProgramShare programme partageable entre comptes, signé par le serveur
+type ProgramShare struct {
+	Format        string          `json:"format"`
+	Version       int             `json:"v"`
+	SourceID      string          `json:"sourceId"`
+	SourceVersion int             `json:"sourceVersion"`
+	Author        *UserCreator    `json:"author,omitempty"`
+	Program       ProgramSnapshot `json:"program"`
+	IssuedAt      int64           `json:"issuedAt"` // timestamp Unix
+	Signature     string          `json:"sig,omitempty"`
+}
+
+// ProgramShareExport partage au format JSON et sous forme compacte (base64url, pour QR code)
+type ProgramShareExport struct {
+	Share       ProgramShare `json:"share"`
+	Code        string       `json:"code"`
+	ImportCount int          `json:"importCount"`
+}
+
+// ProgramAttribution origine d'un programme importé
+type ProgramAttribution struct {
+	SourceProgramID *string      `json:"sourceProgramId,omitempty"`
+	SourceVersion   int          `json:"sourceVersion"`
+	OriginalCreator *UserCreator `json:"originalCreator,omitempty"`
+	ImportedAt      time.Time    `json:"importedAt"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// ProgramSnapshot paramètres figés d'un programme à une version donnée
+type ProgramSnapshot struct {
+	Name            string         `json:"name"`
+	Description     *string        `json:"description,omitempty"`
+	Type            ProgramType    `json:"type"`
+	Variant         Variant        `json:"variant"`
+	Difficulty      Difficulty     `json:"difficulty"`
+	ExerciseID      *string        `json:"exerciseId,omitempty"`
+	RestBetweenSets *int           `json:"restBetweenSets,omitempty"`
+	TargetReps      *int           `json:"targetReps,omitempty"`
+	TimeLimit       *int           `json:"timeLimit,omitempty"`
+	Duration        *int           `json:"duration,omitempty"`
+	AllowRest       sql.NullBool   `json:"allowRest,omitempty"`
+	Sets            *int           `json:"sets,omitempty"`
+	RepsPerSet      *int           `json:"repsPerSet,omitempty"`
+	RepsSequence    []int          `json:"repsSequence,omitempty"`
+	RepsPerMinute   *int           `json:"repsPerMinute,omitempty"`
+	TotalMinutes    *int           `json:"totalMinutes,omitempty"`
+	Blocks          []ProgramBlock `json:"blocks,omitempty"`
+}
+
+// ProgramVersion version immuable d'un programme
+type ProgramVersion struct {
+	ID           string          `json:"id"`
+	ProgramID    string          `json:"programId"`
+	Version      int             `json:"version"`
+	RevertedFrom *int            `json:"revertedFrom,omitempty"` // version restaurée
+	Snapshot     ProgramSnapshot `json:"snapshot"`
+	CreatedBy    *string         `json:"createdBy,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+// ProgramVersionChange différence d'un champ entre deux versions
+type ProgramVersionChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// ProgramVersionDiff différences entre deux versions d'un programme
+type ProgramVersionDiff struct {
+	ProgramID string                 `json:"programId"`
+	From      int                    `json:"from"`
+	To        int                    `json:"to"`
+	Changes   []ProgramVersionChange `json:"changes"`
+}
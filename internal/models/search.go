@@ -0,0 +1,36 @@
+package model
+
+// Types d'entités renvoyés par la recherche
+const (
+	SearchEntityChallenge = "challenge"
+	SearchEntityProgram   = "program"
+	SearchEntityUser      = "user"
+)
+
+// SearchResult résultat de recherche (challenge, programme ou utilisateur)
+type SearchResult struct {
+	EntityType  string  `json:"entityType"`
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+	Difficulty  *string `json:"difficulty,omitempty"`
+	Variant     *string `json:"variant,omitempty"`
+	ImageURL    *string `json:"imageUrl,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+// SearchFacets nombre de résultats par type d'entité, difficulté et variante
+type SearchFacets struct {
+	EntityTypes  map[string]int `json:"entityTypes"`
+	Difficulties map[string]int `json:"difficulties"`
+	Variants     map[string]int `json:"variants"`
+}
+
+// SearchResponse page de résultats de recherche
+type SearchResponse struct {
+	Query      string         `json:"query"`
+	Results    []SearchResult `json:"results"`
+	Facets     SearchFacets   `json:"facets"`
+	NextCursor *string        `json:"nextCursor,omitempty"`
+	HasMore    bool           `json:"hasMore"`
+}
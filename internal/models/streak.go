@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// StreakPeriod représente une série de jours d'entraînement consécutifs
+type StreakPeriod struct {
+	StartDate string `json:"startDate"` // Format YYYY-MM-DD dans le fuseau de l'utilisateur
+	EndDate   string `json:"endDate"`
+	Length    int    `json:"length"` // Nombre de jours entraînés (hors jours gelés)
+	IsCurrent bool   `json:"isCurrent"`
+}
+
+// StreakSummary regroupe la série en cours, la meilleure série et l'historique
+type StreakSummary struct {
+	UserID           string         `json:"userId"`
+	Timezone         string         `json:"timezone"`
+	CurrentStreak    int            `json:"currentStreak"`
+	MaxStreak        int            `json:"maxStreak"`
+	LastWorkoutDate  *string        `json:"lastWorkoutDate,omitempty"`
+	Current          *StreakPeriod  `json:"current,omitempty"`
+	Longest          *StreakPeriod  `json:"longest,omitempty"`
+	History          []StreakPeriod `json:"history"`
+	FreezesAvailable int            `json:"freezesAvailable"`
+}
+
+// StreakFreeze représente un gel de série gagné ou utilisé par un utilisateur
+type StreakFreeze struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"userId"`
+	Source    string     `json:"source"` // earned, admin
+	Milestone *int       `json:"milestone,omitempty"`
+	UsedOn    *string    `json:"usedOn,omitempty"` // Jour protégé (YYYY-MM-DD)
+	EarnedAt  time.Time  `json:"earnedAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
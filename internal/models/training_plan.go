@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// Politiques de gestion des jours d'entraînement manqués
+const (
+	MissedDayPolicyShift  = "shift"  // le programme glisse : la séance manquée devient celle du jour
+	MissedDayPolicySkip   = "skip"   // la séance manquée est abandonnée, le calendrier est conservé
+	MissedDayPolicyRepeat = "repeat" // la semaine en cours recommence
+)
+
+// Statuts d'une inscription à un plan
+const (
+	TrainingEnrollmentActive    = "active"
+	TrainingEnrollmentCompleted = "completed"
+	TrainingEnrollmentAbandoned = "abandoned"
+)
+
+// TrainingPlan plan d'entraînement composé de programmes répartis sur plusieurs semaines
+type TrainingPlan struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Description     *string           `json:"description,omitempty"`
+	Difficulty      string            `json:"difficulty"`
+	Weeks           int               `json:"weeks"`
+	MissedDayPolicy string            `json:"missedDayPolicy"`
+	WorkoutCount    int               `json:"workoutCount"`
+	Days            []TrainingPlanDay `json:"days,omitempty"`
+
+	Creator *UserCreator `json:"creator,omitempty"`
+
+	DateFields
+}
+
+// TrainingPlanDay jour d'un plan : un programme, ou repos si ProgramID est nil
+type TrainingPlanDay struct {
+	Week        int     `json:"week"`
+	Day         int     `json:"day"` // 1 à 7
+	ProgramID   *string `json:"programId,omitempty"`
+	ProgramName *string `json:"programName,omitempty"`
+	Notes       *string `json:"notes,omitempty"`
+}
+
+// TrainingPlanEnrollment suivi d'un plan par un utilisateur
+type TrainingPlanEnrollment struct {
+	ID                string     `json:"id"`
+	PlanID            string     `json:"planId"`
+	PlanName          string     `json:"planName"`
+	UserID            string     `json:"userId"`
+	Status            string     `json:"status"`
+	MissedDayPolicy   string     `json:"missedDayPolicy"`
+	StartDate         string     `json:"startDate"` // YYYY-MM-DD
+	Week              int        `json:"week"`
+	Day               int        `json:"day"`
+	ScheduledDate     string     `json:"scheduledDate"` // date prévue du jour courant
+	CompletedWorkouts int        `json:"completedWorkouts"`
+	MissedWorkouts    int        `json:"missedWorkouts"`
+	TotalWorkouts     int        `json:"totalWorkouts"`
+	CompletedAt       *time.Time `json:"completedAt,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+}
+
+// TrainingPlanToday séance du jour d'un plan suivi
+type TrainingPlanToday struct {
+	Enrollment      TrainingPlanEnrollment `json:"enrollment"`
+	Date            string                 `json:"date"`
+	IsRestDay       bool                   `json:"isRestDay"`
+	Done            bool                   `json:"done"` // séance du jour déjà réalisée
+	Program         *WorkoutProgram        `json:"program,omitempty"`
+	Notes           *string                `json:"notes,omitempty"`
+	NextWorkoutDate *string                `json:"nextWorkoutDate,omitempty"`
+}
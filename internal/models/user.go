@@ -15,18 +15,22 @@ type DateFields struct {
 }
 
 type UserProfile struct {
-	ID       string    `json:"id,omitempty"`
-	Name     string    `json:"name"`
-	Email    string    `json:"email"`
-	Avatar   string    `json:"avatar,omitempty"`
-	Age      int       `json:"age,omitempty"`
-	Weight   float64   `json:"weight,omitempty"`
-	Height   float64   `json:"height,omitempty"`
-	Goal     string    `json:"goal,omitempty"`
-	Provider string    `json:"provider,omitempty"` // email, google, apple
-	Score    int       `json:"score"`
-	IsAdmin  bool      `json:"isAdmin"`
-	JoinDate time.Time `json:"joinDate,omitempty"`
+	ID            string    `json:"id,omitempty"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	Avatar        string    `json:"avatar,omitempty"`
+	Age           int       `json:"age,omitempty"`
+	Weight        float64   `json:"weight,omitempty"`
+	Height        float64   `json:"height,omitempty"`
+	Goal          string    `json:"goal,omitempty"`
+	Provider      string    `json:"provider,omitempty"` // email, google, apple
+	Score         int       `json:"score"`
+	IsAdmin       bool      `json:"isAdmin"`
+	Timezone      string    `json:"timezone,omitempty"` // Fuseau IANA (ex: Europe/Paris)
+	Level         int       `json:"level"`
+	XP            int64     `json:"xp"`
+	XPToNextLevel int64     `json:"xpToNextLevel"`
+	JoinDate      time.Time `json:"joinDate,omitempty"`
 	DateFields
 }
 
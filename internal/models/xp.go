@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// XPAward détail d'un gain d'XP
+type XPAward struct {
+	BaseXP     int      `json:"baseXp"`
+	BonusXP    int      `json:"bonusXp"`
+	Multiplier float64  `json:"multiplier"`
+	TotalXP    int      `json:"totalXp"`
+	Reasons    []string `json:"reasons"`
+}
+
+// LevelUp événement de passage de niveau
+type LevelUp struct {
+	FromLevel int       `json:"fromLevel"`
+	ToLevel   int       `json:"toLevel"`
+	XP        int64     `json:"xp"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// XPEvent entrée du journal d'XP d'un utilisateur
+type XPEvent struct {
+	ID       string  `json:"id"`
+	Source   string  `json:"source"`
+	SourceID *string `json:"sourceId,omitempty"`
+	XPAward
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// UserXP progression d'XP et de niveau d'un utilisateur
+type UserXP struct {
+	UserID        string    `json:"userId"`
+	Level         int       `json:"level"`
+	XP            int64     `json:"xp"`
+	XPIntoLevel   int64     `json:"xpIntoLevel"`
+	XPToNextLevel int64     `json:"xpToNextLevel"`
+	RecentEvents  []XPEvent `json:"recentEvents"`
+	LevelUps      []LevelUp `json:"levelUps"`
+}
@@ -0,0 +1,224 @@
+// Package pagination fournit la pagination partagée des endpoints de liste :
+// curseurs opaques (clé de tri, id), tailles de page bornées et enveloppe de réponse.
+// L'ancienne pagination par offset reste acceptée pour les clients existants.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+// ErrInvalidCursor curseur illisible ou produit pour un autre tri
+var ErrInvalidCursor = errors.New("curseur de pagination invalide")
+
+// Cursor position après le dernier élément renvoyé
+type Cursor struct {
+	Sort string   `json:"s"`
+	Keys []string `json:"k"`
+	ID   string   `json:"id"`
+}
+
+// Encode sérialise le curseur en base64url (opaque pour les clients)
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor relit un curseur produit par Cursor.Encode
+func DecodeCursor(s string) (Cursor, error) {
+	var c Cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Params paramètres de pagination d'une requête (limit, offset ou cursor)
+type Params struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor
+}
+
+// FromRequest lit limit, offset et cursor. La limite est bornée à maxLimit ;
+// un curseur présent prend le pas sur l'offset.
+func FromRequest(r *http.Request, defaultLimit, maxLimit int) (Params, error) {
+	query := r.URL.Query()
+	p := Params{Limit: defaultLimit}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			p.Limit = limit
+		}
+	}
+	if p.Limit > maxLimit {
+		p.Limit = maxLimit
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		c, err := DecodeCursor(cursorStr)
+		if err != nil {
+			return p, err
+		}
+		p.Cursor = &c
+		return p, nil
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if offset, err := strconv.Atoi(offsetStr); err == nil && offset > 0 {
+			p.Offset = offset
+		}
+	}
+
+	return p, nil
+}
+
+// Column clé de tri : expression SQL et type utilisé pour relire la valeur du curseur
+type Column struct {
+	Expr string
+	Cast string
+}
+
+// Sort tri keyset : colonnes puis id comme départage, toutes dans le même sens
+type Sort struct {
+	Name    string
+	Columns []Column
+	Desc    bool
+}
+
+// OrderBy clause ORDER BY du tri (sans le mot-clé)
+func (s Sort) OrderBy(idExpr string) string {
+	dir := " ASC"
+	if s.Desc {
+		dir = " DESC"
+	}
+	parts := make([]string, 0, len(s.Columns)+1)
+	for _, col := range s.Columns {
+		parts = append(parts, col.Expr+dir)
+	}
+	parts = append(parts, idExpr+dir)
+	return strings.Join(parts, ", ")
+}
+
+// Apply complète la requête : condition keyset éventuelle, ORDER BY, LIMIT (+1 pour hasMore) et OFFSET.
+// argCount est le prochain numéro de paramètre disponible.
+func (p Params) Apply(sqlQuery string, args []interface{}, argCount int, sort Sort, idExpr string) (string, []interface{}, error) {
+	if p.Cursor != nil {
+		if p.Cursor.Sort != sort.Name || len(p.Cursor.Keys) != len(sort.Columns) {
+			return sqlQuery, args, ErrInvalidCursor
+		}
+
+		exprs := make([]string, 0, len(sort.Columns)+1)
+		params := make([]string, 0, len(sort.Columns)+1)
+		for i, col := range sort.Columns {
+			exprs = append(exprs, col.Expr)
+			params = append(params, "$"+strconv.Itoa(argCount)+"::"+col.Cast)
+			args = append(args, p.Cursor.Keys[i])
+			argCount++
+		}
+		exprs = append(exprs, idExpr)
+		params = append(params, "$"+strconv.Itoa(argCount)+"::uuid")
+		args = append(args, p.Cursor.ID)
+		argCount++
+
+		op := " > "
+		if sort.Desc {
+			op = " < "
+		}
+		sqlQuery += " AND (" + strings.Join(exprs, ", ") + ")" + op + "(" + strings.Join(params, ", ") + ")"
+	}
+
+	sqlQuery += " ORDER BY " + sort.OrderBy(idExpr)
+	sqlQuery += " LIMIT $" + strconv.Itoa(argCount)
+	args = append(args, p.Limit+1)
+	argCount++
+
+	if p.Cursor == nil && p.Offset > 0 {
+		sqlQuery += " OFFSET $" + strconv.Itoa(argCount)
+		args = append(args, p.Offset)
+	}
+
+	return sqlQuery, args, nil
+}
+
+// Page enveloppe de réponse des endpoints de liste
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"nextCursor"`
+	HasMore    bool    `json:"hasMore"`
+	Limit      int     `json:"limit"`
+	Offset     *int    `json:"offset,omitempty"`
+	Total      *int    `json:"total,omitempty"`
+}
+
+// NewPage construit la page à partir des lignes lues (jusqu'à Limit+1) ;
+// keyOf renvoie les clés de tri et l'id d'un élément pour le curseur suivant.
+func NewPage[T any](items []T, p Params, sort Sort, keyOf func(T) ([]string, string)) Page[T] {
+	page := Page[T]{Items: items, Limit: p.Limit}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if p.Cursor == nil {
+		offset := p.Offset
+		page.Offset = &offset
+	}
+
+	if len(page.Items) > p.Limit {
+		page.Items = page.Items[:p.Limit]
+		page.HasMore = true
+		keys, id := keyOf(page.Items[p.Limit-1])
+		next := Cursor{Sort: sort.Name, Keys: keys, ID: id}.Encode()
+		page.NextCursor = &next
+	}
+
+	return page
+}
+
+// TimeKey formate une date pour un curseur (relue avec ::timestamp ou ::timestamptz)
+func TimeKey(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// IntKey formate un entier pour un curseur
+func IntKey(n int) string {
+	return strconv.Itoa(n)
+}
+
+// BoolKey formate un booléen pour un curseur
+func BoolKey(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+// LegacyMeta métadonnées de l'ancienne enveloppe {<liste>, pagination} des endpoints admin
+type LegacyMeta struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Count  int `json:"count"`
+}
+
+// Legacy métadonnées "pagination" de l'ancienne enveloppe, conservées pour les clients existants
+func (p Page[T]) Legacy() LegacyMeta {
+	meta := LegacyMeta{Limit: p.Limit, Count: len(p.Items)}
+	if p.Total != nil {
+		meta.Total = *p.Total
+	}
+	if p.Offset != nil {
+		meta.Offset = *p.Offset
+	}
+	return meta
+}
@@ -0,0 +1,279 @@
+package pagination
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+var testSort = Sort{
+	Name:    "recent",
+	Columns: []Column{{Expr: "t.created_at", Cast: "timestamp"}, {Expr: "t.score", Cast: "int"}},
+	Desc:    true,
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{name: "clés multiples", cursor: Cursor{Sort: "recent", Keys: []string{"2025-03-30T10:00:00.123456Z", "42"}, ID: "a1b2"}},
+		{name: "sans clé", cursor: Cursor{Sort: "id", Keys: []string{}, ID: "a1b2"}},
+		{name: "caractères spéciaux", cursor: Cursor{Sort: "name", Keys: []string{"Élodie & co/+?"}, ID: "a1b2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeCursor() err = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.cursor) {
+				t.Errorf("DecodeCursor() = %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "base64 invalide", input: "!!!"},
+		{name: "json invalide", input: "bm90LWpzb24"},
+		{name: "id manquant", input: Cursor{Sort: "recent", Keys: []string{"1"}}.Encode()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.input); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	cursor := Cursor{Sort: "recent", Keys: []string{"k"}, ID: "id-1"}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    Params
+		wantErr error
+	}{
+		{name: "valeurs par défaut", query: "", want: Params{Limit: 20}},
+		{name: "limite bornée", query: "limit=500", want: Params{Limit: 50}},
+		{name: "limite invalide ignorée", query: "limit=-3", want: Params{Limit: 20}},
+		{name: "offset", query: "limit=10&offset=30", want: Params{Limit: 10, Offset: 30}},
+		{name: "offset négatif ignoré", query: "offset=-5", want: Params{Limit: 20}},
+		{name: "curseur prioritaire sur l'offset", query: "offset=30&cursor=" + cursor.Encode(), want: Params{Limit: 20, Cursor: &cursor}},
+		{name: "curseur invalide", query: "cursor=!!!", want: Params{Limit: 20}, wantErr: ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/items?"+tt.query, nil)
+			got, err := FromRequest(r, 20, 50)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamsApply(t *testing.T) {
+	const base = "SELECT * FROM t WHERE t.user_id = $1"
+	ascSort := Sort{Name: "name", Columns: []Column{{Expr: "t.name", Cast: "text"}}}
+
+	tests := []struct {
+		name     string
+		params   Params
+		sort     Sort
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		{
+			name:     "première page",
+			params:   Params{Limit: 10},
+			sort:     testSort,
+			wantSQL:  base + " ORDER BY t.created_at DESC, t.score DESC, t.id DESC LIMIT $2",
+			wantArgs: []interface{}{"u1", 11},
+		},
+		{
+			name:     "offset des anciens clients",
+			params:   Params{Limit: 10, Offset: 40},
+			sort:     testSort,
+			wantSQL:  base + " ORDER BY t.created_at DESC, t.score DESC, t.id DESC LIMIT $2 OFFSET $3",
+			wantArgs: []interface{}{"u1", 11, 40},
+		},
+		{
+			name:   "curseur en tri décroissant",
+			params: Params{Limit: 10, Cursor: &Cursor{Sort: "recent", Keys: []string{"2025-03-30T10:00:00Z", "7"}, ID: "id-9"}},
+			sort:   testSort,
+			wantSQL: base + " AND (t.created_at, t.score, t.id) < ($2::timestamp, $3::int, $4::uuid)" +
+				" ORDER BY t.created_at DESC, t.score DESC, t.id DESC LIMIT $5",
+			wantArgs: []interface{}{"u1", "2025-03-30T10:00:00Z", "7", "id-9", 11},
+		},
+		{
+			name:     "curseur en tri croissant sans offset",
+			params:   Params{Limit: 5, Offset: 40, Cursor: &Cursor{Sort: "name", Keys: []string{"bob"}, ID: "id-2"}},
+			sort:     ascSort,
+			wantSQL:  base + " AND (t.name, t.id) > ($2::text, $3::uuid) ORDER BY t.name ASC, t.id ASC LIMIT $4",
+			wantArgs: []interface{}{"u1", "bob", "id-2", 6},
+		},
+		{
+			name:     "curseur d'un autre tri",
+			params:   Params{Limit: 10, Cursor: &Cursor{Sort: "name", Keys: []string{"a", "b"}, ID: "id-1"}},
+			sort:     testSort,
+			wantSQL:  base,
+			wantArgs: []interface{}{"u1"},
+			wantErr:  ErrInvalidCursor,
+		},
+		{
+			name:     "nombre de clés incohérent",
+			params:   Params{Limit: 10, Cursor: &Cursor{Sort: "recent", Keys: []string{"2025-03-30T10:00:00Z"}, ID: "id-1"}},
+			sort:     testSort,
+			wantSQL:  base,
+			wantArgs: []interface{}{"u1"},
+			wantErr:  ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSQL, gotArgs, err := tt.params.Apply(base, []interface{}{"u1"}, 2, tt.sort, "t.id")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if gotSQL != tt.wantSQL {
+				t.Errorf("sql = %q, want %q", gotSQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+type testItem struct {
+	ID    string
+	Score int
+}
+
+func TestNewPage(t *testing.T) {
+	keyOf := func(it testItem) ([]string, string) { return []string{IntKey(it.Score)}, it.ID }
+	scoreSort := Sort{Name: "score", Columns: []Column{{Expr: "t.score", Cast: "int"}}, Desc: true}
+	items := []testItem{{"a", 30}, {"b", 20}, {"c", 10}}
+
+	tests := []struct {
+		name       string
+		items      []testItem
+		params     Params
+		wantItems  []testItem
+		wantMore   bool
+		wantCursor *Cursor
+		wantOffset *int
+	}{
+		{
+			name:       "page incomplète",
+			items:      items,
+			params:     Params{Limit: 5, Offset: 10},
+			wantItems:  items,
+			wantOffset: intPtr(10),
+		},
+		{
+			name:       "ligne supplémentaire tronquée",
+			items:      items,
+			params:     Params{Limit: 2},
+			wantItems:  items[:2],
+			wantMore:   true,
+			wantCursor: &Cursor{Sort: "score", Keys: []string{"20"}, ID: "b"},
+			wantOffset: intPtr(0),
+		},
+		{
+			name:       "mode curseur sans offset",
+			items:      items,
+			params:     Params{Limit: 2, Cursor: &Cursor{Sort: "score", Keys: []string{"40"}, ID: "z"}},
+			wantItems:  items[:2],
+			wantMore:   true,
+			wantCursor: &Cursor{Sort: "score", Keys: []string{"20"}, ID: "b"},
+		},
+		{
+			name:       "aucun élément",
+			items:      nil,
+			params:     Params{Limit: 5},
+			wantItems:  []testItem{},
+			wantOffset: intPtr(0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewPage(tt.items, tt.params, scoreSort, keyOf)
+			if !reflect.DeepEqual(page.Items, tt.wantItems) {
+				t.Errorf("items = %v, want %v", page.Items, tt.wantItems)
+			}
+			if page.HasMore != tt.wantMore {
+				t.Errorf("hasMore = %v, want %v", page.HasMore, tt.wantMore)
+			}
+			if page.Limit != tt.params.Limit {
+				t.Errorf("limit = %d, want %d", page.Limit, tt.params.Limit)
+			}
+			if !reflect.DeepEqual(page.Offset, tt.wantOffset) {
+				t.Errorf("offset = %v, want %v", page.Offset, tt.wantOffset)
+			}
+
+			if tt.wantCursor == nil {
+				if page.NextCursor != nil {
+					t.Errorf("nextCursor = %q, want nil", *page.NextCursor)
+				}
+				return
+			}
+			if page.NextCursor == nil {
+				t.Fatalf("nextCursor = nil, want %+v", *tt.wantCursor)
+			}
+			got, err := DecodeCursor(*page.NextCursor)
+			if err != nil || !reflect.DeepEqual(got, *tt.wantCursor) {
+				t.Errorf("nextCursor = %+v (err %v), want %+v", got, err, *tt.wantCursor)
+			}
+		})
+	}
+}
+
+func intPtr(n int) *int { return &n }
+
+func TestPageLegacy(t *testing.T) {
+	total := 42
+
+	tests := []struct {
+		name string
+		page Page[int]
+		want LegacyMeta
+	}{
+		{
+			name: "mode offset avec total",
+			page: Page[int]{Items: []int{1, 2, 3}, Limit: 10, Offset: intPtr(20), Total: &total},
+			want: LegacyMeta{Total: 42, Limit: 10, Offset: 20, Count: 3},
+		},
+		{
+			name: "mode curseur sans total",
+			page: Page[int]{Items: []int{1}, Limit: 10},
+			want: LegacyMeta{Limit: 10, Count: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.page.Legacy(); got != tt.want {
+				t.Errorf("Legacy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -19,13 +19,13 @@ func ScanUserProfile(scanner interface {
 	var avatar, goal sql.NullString
 	var age, score sql.NullInt64
 	var weight, height sql.NullFloat64
-	var updatedBy sql.NullString
+	var updatedBy, timezone sql.NullString
 
 	err := scanner.Scan(
 		&user.ID, &user.Name, &user.Email, &avatar,
 		&age, &weight, &height, &goal, &score, &user.IsAdmin,
 		&user.JoinDate, &user.CreatedAt, &user.UpdatedAt,
-		&user.CreatedBy, &updatedBy,
+		&user.CreatedBy, &updatedBy, &timezone, &user.XP, &user.Level,
 	)
 	if err != nil {
 		return nil, err
@@ -38,6 +38,8 @@ func ScanUserProfile(scanner interface {
 	user.Height = utils.NullFloat64ToFloat64(height)
 	user.Score = utils.NullInt64ToInt(score)
 	user.UpdatedBy = utils.NullStringToPointer(updatedBy)
+	user.Timezone = utils.NullStringToString(timezone)
+	_, _, user.XPToNextLevel = utils.LevelFromXP(user.XP)
 
 	return &user, nil
 }
@@ -56,7 +58,7 @@ func ScanChallenge(scanner interface {
 		&c.ID, &c.Title, &c.Description, &c.Category, &c.Type, &c.Variant, &c.Difficulty,
 		&c.TargetReps, &c.Duration, &c.Sets, &c.RepsPerSet, &c.ImageURL,
 		&c.IconName, &c.IconColor, &c.Participants, &c.Completions, &c.Likes, &c.Points,
-		&c.Badge, &startDate, &endDate, &c.Status, &tagsNull, &c.IsOfficial,
+		&c.Badge, &startDate, &endDate, &c.Status, &tagsNull, &c.IsOfficial, &c.UnlockMode, &c.ProgressMode, &c.Visibility,
 		&createdBy, &updatedBy, &createdAt, &updatedAt, &deletedBy, &deletedAt,
 		&userCompleted, &userLiked, &userParticipated,
 	)
@@ -123,7 +125,7 @@ func ScanChallengeWithPqArray(scanner interface {
 		&c.ID, &c.Title, &c.Description, &c.Category, &c.Type, &c.Variant, &c.Difficulty,
 		&c.TargetReps, &c.Duration, &c.Sets, &c.RepsPerSet, &c.ImageURL,
 		&c.IconName, &c.IconColor, &c.Participants, &c.Completions, &c.Likes, &c.Points,
-		&c.Badge, &startDate, &endDate, &c.Status, pq.Array(&c.Tags), &c.IsOfficial,
+		&c.Badge, &startDate, &endDate, &c.Status, pq.Array(&c.Tags), &c.IsOfficial, &c.UnlockMode, &c.ProgressMode, &c.Visibility,
 		&createdBy, &updatedBy, &deletedBy, &createdAt, &updatedAt, &deletedAt,
 		&userCompleted, &userLiked, &userParticipated,
 	)
@@ -294,24 +296,26 @@ func ScanWorkoutProgram(scanner interface {
 	return &p, nil
 }
 
-// ScanWorkoutProgramWithCreator scanne une ligne SQL vers un WorkoutProgram avec informations du créateur
+// ScanWorkoutProgramWithCreator scanne une ligne SQL vers un WorkoutProgram avec informations du créateur ;
+// extra reçoit les colonnes sélectionnées après celles du créateur
 func ScanWorkoutProgramWithCreator(scanner interface {
 	Scan(dest ...interface{}) error
-}, unmarshalJSON func(data []byte, v interface{}) error) (*model.WorkoutProgram, error) {
+}, unmarshalJSON func(data []byte, v interface{}) error, extra ...interface{}) (*model.WorkoutProgram, error) {
 	var p model.WorkoutProgram
 	var repsSequenceJSON []byte
 	var createdBy, updatedBy, deletedBy sql.NullString
 	var createdAt, updatedAt, deletedAt sql.NullTime
 	var creatorID, creatorName, creatorAvatar sql.NullString
 
-	err := scanner.Scan(
+	dest := []interface{}{
 		&p.ID, &p.Name, &p.Description, &p.Type, &p.Variant, &p.Difficulty, &p.RestBetweenSets,
 		&p.TargetReps, &p.TimeLimit, &p.Duration, &p.AllowRest, &p.Sets, &p.RepsPerSet,
 		&repsSequenceJSON, &p.RepsPerMinute, &p.TotalMinutes,
 		&p.IsCustom, &p.IsFeatured, &p.UsageCount, &p.Likes,
 		&createdBy, &updatedBy, &deletedBy, &createdAt, &updatedAt, &deletedAt,
 		&creatorID, &creatorName, &creatorAvatar,
-	)
+	}
+	err := scanner.Scan(append(dest, extra...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -381,7 +385,7 @@ func ScanUserChallengeProgress(scanner interface {
 	err := scanner.Scan(
 		&progress.ID, &progress.ChallengeID, &progress.UserID, &progress.Progress,
 		&progress.CurrentReps, &progress.TargetReps, &progress.Attempts,
-		&completedAt, &createdAt, &updatedAt,
+		&completedAt, &progress.Status, &createdAt, &updatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 
 	"github.com/MassBabyGeek/PumpPro-backend/internal/config"
@@ -95,6 +96,24 @@ func (s *CloudinaryService) UploadBugReportScreenshot(ctx context.Context, file
 	return uploadResult.SecureURL, nil
 }
 
+// UploadCertificate uploads a challenge completion certificate (PNG) to Cloudinary
+func (s *CloudinaryService) UploadCertificate(ctx context.Context, file io.Reader, challengeID, userID string) (string, error) {
+	publicID := fmt.Sprintf("certificates/%s_%s", challengeID, userID)
+	overwrite := true
+
+	uploadResult, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
+		PublicID:     publicID,
+		Folder:       "pumppro/certificates",
+		Overwrite:    &overwrite,
+		ResourceType: "image",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload certificate: %w", err)
+	}
+
+	return uploadResult.SecureURL, nil
+}
+
 // DeleteImage deletes an image from Cloudinary by its public ID
 func (s *CloudinaryService) DeleteImage(ctx context.Context, publicID string) error {
 	_, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
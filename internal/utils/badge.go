@@ -0,0 +1,332 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+// PushUpVariants liste des variantes de pompes reconnues
+var PushUpVariants = []string{"STANDARD", "INCLINE", "DECLINE", "DIAMOND", "WIDE", "PIKE", "ARCHER"}
+
+// badgeCatalog catalogue des badges (l'ordre est celui d'affichage)
+var badgeCatalog = buildBadgeCatalog()
+
+func buildBadgeCatalog() []model.BadgeDefinition {
+	catalog := []model.BadgeDefinition{
+		{Code: "first_workout", Name: "Premier pas", Description: "Terminer sa première séance", Emoji: "🌟", Category: "workout", Metric: model.BadgeMetricTotalSessions, Target: 1},
+		{Code: "reps_100", Name: "Centurion", Description: "Atteindre 100 pompes au total", Emoji: "💯", Category: "reps", Metric: model.BadgeMetricTotalReps, Target: 100},
+		{Code: "reps_1000", Name: "Millénaire", Description: "Atteindre 1 000 pompes au total", Emoji: "💪", Category: "reps", Metric: model.BadgeMetricTotalReps, Target: 1000},
+		{Code: "reps_10000", Name: "Légende", Description: "Atteindre 10 000 pompes au total", Emoji: "🏆", Category: "reps", Metric: model.BadgeMetricTotalReps, Target: 10000},
+		{Code: "streak_7", Name: "Semaine de feu", Description: "S'entraîner 7 jours d'affilée", Emoji: "🔥", Category: "streak", Metric: model.BadgeMetricMaxStreak, Target: 7},
+		{Code: "streak_30", Name: "Inarrêtable", Description: "S'entraîner 30 jours d'affilée", Emoji: "⚡", Category: "streak", Metric: model.BadgeMetricMaxStreak, Target: 30},
+		{Code: "top3_weekly", Name: "Podium", Description: "Finir dans le top 3 du classement hebdomadaire", Emoji: "👑", Category: "leaderboard", Metric: model.BadgeMetricWeeklyTop3, Target: 1},
+		{Code: "first_official_challenge", Name: "Challenger", Description: "Terminer un premier challenge officiel", Emoji: "🎯", Category: "challenge", Metric: model.BadgeMetricOfficialChallenges, Target: 1},
+	}
+
+	for _, variant := range PushUpVariants {
+		catalog = append(catalog, model.BadgeDefinition{
+			Code:        "variant_" + strings.ToLower(variant),
+			Name:        "Variante " + variant,
+			Description: fmt.Sprintf("Terminer une séance en variante %s", variant),
+			Emoji:       "🧭",
+			Category:    "variant",
+			Metric:      model.BadgeMetricVariantTried,
+			Target:      1,
+			Variant:     variant,
+		})
+	}
+
+	return catalog
+}
+
+// BadgeCatalog retourne une copie du catalogue des badges
+func BadgeCatalog() []model.BadgeDefinition {
+	catalog := make([]model.BadgeDefinition, len(badgeCatalog))
+	copy(catalog, badgeCatalog)
+	return catalog
+}
+
+// FindBadgeDefinition retourne la définition d'un badge par son code
+func FindBadgeDefinition(code string) (model.BadgeDefinition, bool) {
+	for _, def := range badgeCatalog {
+		if def.Code == code {
+			return def, true
+		}
+	}
+	return model.BadgeDefinition{}, false
+}
+
+// badgeCurrentValue retourne la valeur actuelle de la métrique d'un badge
+func badgeCurrentValue(def model.BadgeDefinition, stats model.BadgeStats) int {
+	switch def.Metric {
+	case model.BadgeMetricTotalSessions:
+		return stats.TotalSessions
+	case model.BadgeMetricTotalReps:
+		return stats.TotalReps
+	case model.BadgeMetricMaxStreak:
+		return stats.MaxStreak
+	case model.BadgeMetricWeeklyTop3:
+		if stats.WeeklyTop3 {
+			return 1
+		}
+	case model.BadgeMetricOfficialChallenges:
+		return stats.OfficialChallenges
+	case model.BadgeMetricVariantTried:
+		if stats.VariantsTried[def.Variant] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// EvaluateBadges calcule la progression vers chaque badge du catalogue (sans accès base de données)
+func EvaluateBadges(stats model.BadgeStats) []model.BadgeProgress {
+	progress := make([]model.BadgeProgress, 0, len(badgeCatalog))
+	for _, def := range badgeCatalog {
+		current := badgeCurrentValue(def, stats)
+		pct := 100
+		if current < def.Target {
+			pct = current * 100 / def.Target
+		}
+		progress = append(progress, model.BadgeProgress{
+			BadgeDefinition: def,
+			Current:         current,
+			Progress:        pct,
+			Earned:          current >= def.Target,
+		})
+	}
+	return progress
+}
+
+// LoadBadgeStats charge les statistiques d'un utilisateur utilisées par les règles de badges
+func LoadBadgeStats(ctx context.Context, userID string) (model.BadgeStats, error) {
+	stats := model.BadgeStats{VariantsTried: map[string]bool{}}
+
+	err := database.DB.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM workout_sessions WHERE user_id = $1 AND completed = TRUE)::int,
+			(SELECT COALESCE(SUM(ws.total_reps), 0) FROM workout_sessions ws
+				WHERE ws.user_id = $1 AND ws.completed = TRUE AND `+ExerciseSessionCondition(2)+`)::int,
+			(SELECT COALESCE(MAX(length), 0) FROM user_streak_islands WHERE user_id = $1)::int,
+			EXISTS(SELECT 1 FROM leaderboard_cache WHERE period = 'weekly' AND user_id = $1 AND rank <= 3),
+			(SELECT COUNT(*) FROM user_challenge_progress ucp
+				INNER JOIN challenges c ON c.id = ucp.challenge_id
+				WHERE ucp.user_id = $1 AND ucp.completed_at IS NOT NULL AND c.is_official = TRUE)::int
+	`, userID, model.ExerciseFamilyPushUp).Scan(
+		&stats.TotalSessions, &stats.TotalReps, &stats.MaxStreak, &stats.WeeklyTop3, &stats.OfficialChallenges,
+	)
+	if err != nil {
+		return stats, err
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT DISTINCT wp.variant
+		FROM workout_sessions ws
+		INNER JOIN workout_programs wp ON wp.id = ws.program_id
+		WHERE ws.user_id = $1 AND ws.completed = TRUE
+	`, userID)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var variant sql.NullString
+		if err := rows.Scan(&variant); err != nil {
+			return stats, err
+		}
+		if variant.Valid {
+			stats.VariantsTried[variant.String] = true
+		}
+	}
+
+	return stats, rows.Err()
+}
+
+// AwardBadge attribue un badge à un utilisateur. Retourne true s'il vient d'être obtenu.
+func AwardBadge(ctx context.Context, userID string, def model.BadgeDefinition) (bool, error) {
+	res, err := database.DB.Exec(ctx, `
+		INSERT INTO user_badges(user_id, badge_code, badge_emoji, earned_at)
+		VALUES($1, $2, $3, NOW())
+		ON CONFLICT (user_id, badge_code) DO NOTHING
+	`, userID, def.Code, def.Emoji)
+	if err != nil {
+		return false, fmt.Errorf("impossible d'attribuer le badge %s: %w", def.Code, err)
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// EvaluateUserBadges évalue toutes les règles pour un utilisateur et attribue les badges obtenus.
+// Retourne les badges nouvellement gagnés.
+func EvaluateUserBadges(ctx context.Context, userID string) ([]model.UserBadge, error) {
+	stats, err := LoadBadgeStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	awarded := []model.UserBadge{}
+	for _, progress := range EvaluateBadges(stats) {
+		if !progress.Earned {
+			continue
+		}
+		isNew, err := AwardBadge(ctx, userID, progress.BadgeDefinition)
+		if err != nil {
+			return awarded, err
+		}
+		if isNew {
+			awarded = append(awarded, model.UserBadge{
+				Code:        progress.Code,
+				Name:        progress.Name,
+				Description: progress.Description,
+				Emoji:       progress.Emoji,
+				EarnedAt:    time.Now(),
+			})
+		}
+	}
+
+	return awarded, nil
+}
+
+// EvaluateWeeklyTopBadges attribue le badge podium aux 3 premiers du classement hebdomadaire en cache
+func EvaluateWeeklyTopBadges(ctx context.Context) (int, error) {
+	def, _ := FindBadgeDefinition("top3_weekly")
+
+	res, err := database.DB.Exec(ctx, `
+		INSERT INTO user_badges(user_id, badge_code, badge_emoji, earned_at)
+		SELECT user_id, $1, $2, NOW()
+		FROM leaderboard_cache
+		WHERE period = 'weekly' AND rank <= 3
+		ON CONFLICT (user_id, badge_code) DO NOTHING
+	`, def.Code, def.Emoji)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.RowsAffected()), nil
+}
+
+// GetUserBadges récupère les badges obtenus par un utilisateur
+func GetUserBadges(ctx context.Context, userID string) ([]model.UserBadge, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT ub.badge_code, ub.badge_emoji, ub.earned_at, c.title
+		FROM user_badges ub
+		LEFT JOIN challenges c ON ub.badge_code = $2 || c.id::text
+		WHERE ub.user_id = $1
+		ORDER BY ub.earned_at DESC
+	`, userID, ChallengeBadgeCodePrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	badges := []model.UserBadge{}
+	for rows.Next() {
+		var badge model.UserBadge
+		var emoji, challengeTitle sql.NullString
+		if err := rows.Scan(&badge.Code, &emoji, &badge.EarnedAt, &challengeTitle); err != nil {
+			return nil, err
+		}
+		badge.Emoji = NullStringToString(emoji)
+		if challengeTitle.Valid {
+			// Badge attribué à la clôture d'un challenge
+			badge.Name = challengeTitle.String
+			badge.Description = fmt.Sprintf("Challenge « %s » terminé", challengeTitle.String)
+		} else if def, ok := FindBadgeDefinition(badge.Code); ok {
+			badge.Name = def.Name
+			badge.Description = def.Description
+			if badge.Emoji == "" {
+				badge.Emoji = def.Emoji
+			}
+		}
+		badges = append(badges, badge)
+	}
+
+	return badges, rows.Err()
+}
+
+// GetBadgeCatalogWithProgress retourne le catalogue avec la progression de l'utilisateur
+func GetBadgeCatalogWithProgress(ctx context.Context, userID string) ([]model.BadgeProgress, error) {
+	stats, err := LoadBadgeStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	earned, err := GetUserBadges(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	earnedAt := map[string]time.Time{}
+	for _, badge := range earned {
+		earnedAt[badge.Code] = badge.EarnedAt
+	}
+
+	catalog := EvaluateBadges(stats)
+	for i := range catalog {
+		// Un badge déjà obtenu reste acquis même si la métrique a changé (ex: podium hebdomadaire)
+		if at, ok := earnedAt[catalog[i].Code]; ok {
+			at := at
+			catalog[i].Earned = true
+			catalog[i].Progress = 100
+			catalog[i].EarnedAt = &at
+		} else {
+			catalog[i].Earned = false
+			if catalog[i].Progress >= 100 {
+				catalog[i].Progress = 99
+			}
+		}
+	}
+
+	return catalog, nil
+}
+
+// AttachLeaderboardBadges renseigne les badges (emojis) de chaque entrée du classement
+func AttachLeaderboardBadges(ctx context.Context, entries []model.LeaderboardEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	userIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		userIDs = append(userIDs, entry.UserID)
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT user_id, COALESCE(badge_emoji, '')
+		FROM user_badges
+		WHERE user_id = ANY($1::uuid[])
+		ORDER BY earned_at ASC
+	`, userIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	badgesByUser := map[string][]string{}
+	for rows.Next() {
+		var userID, emoji string
+		if err := rows.Scan(&userID, &emoji); err != nil {
+			return err
+		}
+		if emoji != "" {
+			badgesByUser[userID] = append(badgesByUser[userID], emoji)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range entries {
+		if badges, ok := badgesByUser[entries[i].UserID]; ok {
+			entries[i].Badges = badges
+		} else {
+			entries[i].Badges = []string{}
+		}
+	}
+
+	return nil
+}
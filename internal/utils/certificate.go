@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Dimensions du certificat : A4 paysage à 150 dpi
+const (
+	certificateWidth  = 1754
+	certificateHeight = 1240
+	// Page PDF A4 paysage en points
+	certificatePDFWidth  = 842
+	certificatePDFHeight = 595
+)
+
+var (
+	certificateBackground = color.RGBA{0xFF, 0xFD, 0xF7, 0xFF}
+	certificateNavy       = color.RGBA{0x1E, 0x2A, 0x44, 0xFF}
+	certificateGold       = color.RGBA{0xC9, 0xA2, 0x27, 0xFF}
+	certificateGrey       = color.RGBA{0x6B, 0x72, 0x80, 0xFF}
+)
+
+// Polices embarquées (Go fonts), chargées une seule fois
+var (
+	certificateFontsOnce sync.Once
+	certificateRegular   *opentype.Font
+	certificateBold      *opentype.Font
+	certificateFontsErr  error
+)
+
+func loadCertificateFonts() error {
+	certificateFontsOnce.Do(func() {
+		certificateRegular, certificateFontsErr = opentype.Parse(goregular.TTF)
+		if certificateFontsErr != nil {
+			return
+		}
+		certificateBold, certificateFontsErr = opentype.Parse(gobold.TTF)
+	})
+	return certificateFontsErr
+}
+
+var frenchMonths = []string{
+	"janvier", "février", "mars", "avril", "mai", "juin",
+	"juillet", "août", "septembre", "octobre", "novembre", "décembre",
+}
+
+// FormatFrenchDate formate une date en toutes lettres (ex: 2 janvier 2026)
+func FormatFrenchDate(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), frenchMonths[t.Month()-1], t.Year())
+}
+
+// FormatThousands formate un entier avec une espace comme séparateur de milliers (ex: 12 345)
+func FormatThousands(n int) string {
+	if n < 0 {
+		return "-" + FormatThousands(-n)
+	}
+	s := strconv.Itoa(n)
+	var out []byte
+	for i := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			out = append(out, ' ')
+		}
+		out = append(out, s[i])
+	}
+	return string(out)
+}
+
+// CertificateStatsLine ligne de statistiques du certificat (répétitions et rang)
+func CertificateStatsLine(data model.ChallengeCertificateData) string {
+	line := FormatThousands(data.TotalReps) + " répétitions"
+	if data.Rank > 0 {
+		line += "  •  Rang " + strconv.Itoa(data.Rank)
+		if data.Participants > 0 {
+			line += " sur " + FormatThousands(data.Participants)
+		}
+	}
+	return line
+}
+
+// certificateFace crée une police à la taille donnée, réduite si le texte dépasse maxWidth
+func certificateFace(f *opentype.Font, size float64, text string, maxWidth int) (font.Face, error) {
+	for {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return nil, err
+		}
+		if size <= 20 || font.MeasureString(face, text).Ceil() <= maxWidth {
+			return face, nil
+		}
+		face.Close()
+		size *= 0.9
+	}
+}
+
+// drawCenteredText dessine un texte centré horizontalement sur la ligne de base y
+func drawCenteredText(img *image.RGBA, f *opentype.Font, size float64, text string, y int, c color.Color) error {
+	face, err := certificateFace(f, size, text, certificateWidth-360)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	drawer := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face}
+	width := drawer.MeasureString(text)
+	drawer.Dot = fixed.Point26_6{X: (fixed.I(certificateWidth) - width) / 2, Y: fixed.I(y)}
+	drawer.DrawString(text)
+	return nil
+}
+
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.Color) {
+	draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// drawFrame dessine un cadre d'épaisseur thickness à inset pixels du bord
+func drawFrame(img *image.RGBA, inset, thickness int, c color.Color) {
+	w, h := certificateWidth, certificateHeight
+	fillRect(img, image.Rect(inset, inset, w-inset, inset+thickness), c)
+	fillRect(img, image.Rect(inset, h-inset-thickness, w-inset, h-inset), c)
+	fillRect(img, image.Rect(inset, inset, inset+thickness, h-inset), c)
+	fillRect(img, image.Rect(w-inset-thickness, inset, w-inset, h-inset), c)
+}
+
+// RenderCertificateImage dessine le certificat de réussite
+func RenderCertificateImage(data model.ChallengeCertificateData, reference string) (*image.RGBA, error) {
+	if err := loadCertificateFonts(); err != nil {
+		return nil, fmt.Errorf("impossible de charger les polices: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, certificateWidth, certificateHeight))
+	fillRect(img, img.Bounds(), certificateBackground)
+	drawFrame(img, 40, 24, certificateNavy)
+	drawFrame(img, 84, 4, certificateGold)
+
+	lines := []struct {
+		font  *opentype.Font
+		size  float64
+		text  string
+		y     int
+		color color.Color
+	}{
+		{certificateBold, 36, "PUMPPRO", 200, certificateGold},
+		{certificateBold, 76, "CERTIFICAT DE RÉUSSITE", 320, certificateNavy},
+		{certificateRegular, 38, "Décerné à", 430, certificateGrey},
+		{certificateBold, 100, data.UserName, 560, certificateNavy},
+		{certificateRegular, 38, "pour avoir terminé le challenge", 660, certificateGrey},
+		{certificateBold, 64, data.ChallengeTitle, 770, certificateNavy},
+		{certificateRegular, 38, "le " + FormatFrenchDate(data.CompletedAt), 850, certificateGrey},
+		{certificateBold, 44, CertificateStatsLine(data), 990, certificateNavy},
+		{certificateRegular, 24, "Certificat n° " + reference, 1120, certificateGrey},
+	}
+	for _, line := range lines {
+		if err := drawCenteredText(img, line.font, line.size, line.text, line.y, line.color); err != nil {
+			return nil, err
+		}
+	}
+
+	// Séparateur entre la date et les statistiques
+	fillRect(img, image.Rect(certificateWidth/2-200, 900, certificateWidth/2+200, 904), certificateGold)
+
+	return img, nil
+}
+
+// EncodeCertificatePNG encode le certificat en PNG
+func EncodeCertificatePNG(img *image.RGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfTextString encode une chaîne en UTF-16BE hexadécimal (chaîne de texte PDF)
+func pdfTextString(s string) string {
+	var buf bytes.Buffer
+	buf.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&buf, "%04X", u)
+	}
+	buf.WriteString(">")
+	return buf.String()
+}
+
+// EncodeCertificatePDF produit un PDF d'une page A4 paysage contenant le certificat
+func EncodeCertificatePDF(img *image.RGBA, title string) ([]byte, error) {
+	bounds := img.Bounds()
+
+	// Image RGB compressée (FlateDecode)
+	var pixels bytes.Buffer
+	zw := zlib.NewWriter(&pixels)
+	row := make([]byte, 0, bounds.Dx()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			offset := img.PixOffset(x, y)
+			row = append(row, img.Pix[offset], img.Pix[offset+1], img.Pix[offset+2])
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", certificatePDFWidth, certificatePDFHeight)
+
+	var buf bytes.Buffer
+	offsets := []int{}
+	writeObject := func(body string, stream []byte) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\n", len(offsets), body)
+		if stream != nil {
+			buf.WriteString("stream\n")
+			buf.Write(stream)
+			buf.WriteString("\nendstream\n")
+		}
+		buf.WriteString("endobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	writeObject("<< /Type /Pages /Kids [3 0 R] /Count 1 >>", nil)
+	writeObject(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 4 0 R >> >> /Contents 5 0 R >>",
+		certificatePDFWidth, certificatePDFHeight), nil)
+	writeObject(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>",
+		bounds.Dx(), bounds.Dy(), pixels.Len()), pixels.Bytes())
+	writeObject(fmt.Sprintf("<< /Length %d >>", len(content)), []byte(content))
+	writeObject(fmt.Sprintf("<< /Title %s /Producer (PumpPro) >>", pdfTextString(title)), nil)
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, len(offsets), xref)
+
+	return buf.Bytes(), nil
+}
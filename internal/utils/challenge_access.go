@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Visibilités d'un challenge
+const (
+	ChallengeVisibilityPublic   = "public"
+	ChallengeVisibilityUnlisted = "unlisted"
+	ChallengeVisibilityPrivate  = "private"
+)
+
+var (
+	ErrChallengeAccessDenied  = errors.New("accès au challenge refusé")
+	ErrInvalidChallengeInvite = errors.New("code d'invitation invalide")
+)
+
+var (
+	challengeInviteURL        = "pumppro://challenges/join"
+	privateChallengeMaxPoints = 100
+)
+
+// ConfigureChallengeAccess définit la base des liens d'invitation et le plafond de points des challenges privés
+func ConfigureChallengeAccess(inviteURL string, maxPoints int) {
+	if inviteURL != "" {
+		challengeInviteURL = strings.TrimRight(inviteURL, "/")
+	}
+	if maxPoints >= 0 {
+		privateChallengeMaxPoints = maxPoints
+	}
+}
+
+// PrivateChallengeMaxPoints plafond de points d'un challenge privé créé par un non-admin
+func PrivateChallengeMaxPoints() int {
+	return privateChallengeMaxPoints
+}
+
+// ChallengeInviteLink construit le lien d'invitation d'un challenge
+func ChallengeInviteLink(code string) string {
+	return challengeInviteURL + "/" + code
+}
+
+// NormalizeChallengeVisibility valide une visibilité (public par défaut)
+func NormalizeChallengeVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return ChallengeVisibilityPublic, nil
+	case ChallengeVisibilityPublic, ChallengeVisibilityUnlisted, ChallengeVisibilityPrivate:
+		return visibility, nil
+	default:
+		return "", fmt.Errorf("visibilité invalide (public, unlisted ou private)")
+	}
+}
+
+// ValidateChallengePrivileges vérifie les champs réservés aux admins sur un challenge privé
+func ValidateChallengePrivileges(visibility string, isAdmin, isOfficial bool, points int) error {
+	if isAdmin || visibility != ChallengeVisibilityPrivate {
+		return nil
+	}
+	if isOfficial {
+		return fmt.Errorf("un challenge privé ne peut pas être officiel")
+	}
+	if points > privateChallengeMaxPoints {
+		return fmt.Errorf("un challenge privé ne peut pas attribuer plus de %d points", privateChallengeMaxPoints)
+	}
+	return nil
+}
+
+// CanViewChallenge indique si un utilisateur peut consulter un challenge (sans accès base de données).
+// isManager : créateur ou admin ; isAllowed : invité, participant ou code d'invitation valide.
+func CanViewChallenge(visibility string, isManager, isAllowed bool) bool {
+	if visibility != ChallengeVisibilityPrivate {
+		return true
+	}
+	return isManager || isAllowed
+}
+
+// ChallengeAccessInfo informations nécessaires aux contrôles d'accès d'un challenge
+type ChallengeAccessInfo struct {
+	Visibility string
+	OwnerID    string
+	InviteCode string
+	Allowed    bool // Invité ou participant
+}
+
+// LoadChallengeAccess charge la visibilité d'un challenge et l'accès de l'utilisateur (userID peut être vide)
+func LoadChallengeAccess(ctx context.Context, challengeID, userID string) (ChallengeAccessInfo, error) {
+	var info ChallengeAccessInfo
+	var ownerID, inviteCode sql.NullString
+
+	err := database.DB.QueryRow(ctx, `
+		SELECT c.visibility, c.created_by, c.invite_code,
+			$2::text <> '' AND (
+				EXISTS(SELECT 1 FROM challenge_allowed_users WHERE challenge_id = c.id AND user_id::text = $2)
+				OR EXISTS(SELECT 1 FROM user_challenge_progress WHERE challenge_id = c.id AND user_id::text = $2)
+			)
+		FROM challenges c
+		WHERE c.id = $1 AND c.deleted_at IS NULL
+	`, challengeID, userID).Scan(&info.Visibility, &ownerID, &inviteCode, &info.Allowed)
+	if err == pgx.ErrNoRows {
+		return info, ErrChallengeNotFound
+	}
+	if err != nil {
+		return info, err
+	}
+
+	info.OwnerID = NullStringToString(ownerID)
+	info.InviteCode = NullStringToString(inviteCode)
+	return info, nil
+}
+
+// EnsureChallengeInviteCode retourne le code d'invitation d'un challenge, en le générant si besoin
+func EnsureChallengeInviteCode(ctx context.Context, challengeID string) (string, error) {
+	var existing sql.NullString
+	err := database.DB.QueryRow(ctx,
+		`SELECT invite_code FROM challenges WHERE id = $1`,
+		challengeID,
+	).Scan(&existing)
+	if err == pgx.ErrNoRows {
+		return "", ErrChallengeNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if existing.Valid && existing.String != "" {
+		return existing.String, nil
+	}
+
+	// Quelques tentatives en cas de collision sur la contrainte d'unicité
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := GenerateClubInviteCode()
+		if err != nil {
+			return "", err
+		}
+		res, err := database.DB.Exec(ctx, `
+			UPDATE challenges SET invite_code = $2
+			WHERE id = $1 AND invite_code IS NULL
+			  AND NOT EXISTS(SELECT 1 FROM challenges WHERE invite_code = $2)
+		`, challengeID, code)
+		if err != nil {
+			return "", err
+		}
+		if res.RowsAffected() > 0 {
+			return code, nil
+		}
+		// Code déjà pris, ou généré entre-temps par une autre requête
+		if err := database.DB.QueryRow(ctx,
+			`SELECT invite_code FROM challenges WHERE id = $1`,
+			challengeID,
+		).Scan(&existing); err == nil && existing.Valid {
+			return existing.String, nil
+		}
+	}
+
+	return "", fmt.Errorf("impossible de générer un code d'invitation unique")
+}
+
+// SetChallengeInviteFields renseigne le code et le lien d'invitation (réservé au créateur et aux admins)
+func SetChallengeInviteFields(challenge *model.Challenge, code string) {
+	if code == "" {
+		return
+	}
+	link := ChallengeInviteLink(code)
+	challenge.InviteCode = &code
+	challenge.InviteLink = &link
+}
+
+// JoinChallengeByInviteCode ajoute l'utilisateur à la liste des invités du challenge correspondant au code
+func JoinChallengeByInviteCode(ctx context.Context, code, userID string) (string, error) {
+	var challengeID string
+	err := database.DB.QueryRow(ctx,
+		`SELECT id FROM challenges WHERE invite_code = $1 AND deleted_at IS NULL`,
+		strings.ToUpper(strings.TrimSpace(code)),
+	).Scan(&challengeID)
+	if err == pgx.ErrNoRows {
+		return "", ErrInvalidChallengeInvite
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := AddChallengeAllowedUsers(ctx, challengeID, []string{userID}, nil); err != nil {
+		return "", err
+	}
+	return challengeID, nil
+}
+
+// AddChallengeAllowedUsers ajoute des utilisateurs à la liste des invités
+func AddChallengeAllowedUsers(ctx context.Context, challengeID string, userIDs []string, addedBy *string) error {
+	_, err := database.DB.Exec(ctx, `
+		INSERT INTO challenge_allowed_users(challenge_id, user_id, added_by, created_at)
+		SELECT $1, u.id, $3, NOW()
+		FROM users u
+		WHERE u.id = ANY($2::uuid[]) AND u.deleted_at IS NULL
+		ON CONFLICT (challenge_id, user_id) DO NOTHING
+	`, challengeID, userIDs, addedBy)
+	return err
+}
+
+// RemoveChallengeAllowedUser retire un utilisateur de la liste des invités
+func RemoveChallengeAllowedUser(ctx context.Context, challengeID, userID string) error {
+	_, err := database.DB.Exec(ctx,
+		`DELETE FROM challenge_allowed_users WHERE challenge_id = $1 AND user_id = $2`,
+		challengeID, userID,
+	)
+	return err
+}
+
+// GetChallengeAllowedUsers récupère la liste des invités d'un challenge
+func GetChallengeAllowedUsers(ctx context.Context, challengeID string) ([]model.ChallengeAllowedUser, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT u.id, u.name, u.avatar, cau.created_at
+		FROM challenge_allowed_users cau
+		INNER JOIN users u ON u.id = cau.user_id
+		WHERE cau.challenge_id = $1 AND u.deleted_at IS NULL
+		ORDER BY cau.created_at ASC
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []model.ChallengeAllowedUser{}
+	for rows.Next() {
+		var user model.ChallengeAllowedUser
+		var avatar sql.NullString
+		if err := rows.Scan(&user.UserID, &user.UserName, &avatar, &user.AddedAt); err != nil {
+			return nil, err
+		}
+		user.Avatar = NullStringToPointer(avatar)
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
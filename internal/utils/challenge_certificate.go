@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrChallengeNotCompleted l'utilisateur n'a pas terminé le challenge
+var ErrChallengeNotCompleted = errors.New("challenge non terminé")
+
+// certificateVersion à incrémenter quand la mise en page change pour invalider le cache
+const certificateVersion = "1"
+
+// CertificateFingerprint empreinte des données affichées sur le certificat
+func CertificateFingerprint(data model.ChallengeCertificateData) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s|%s|%s|%d|%d|%d",
+		certificateVersion, data.UserID, data.UserName, data.ChallengeID, data.ChallengeTitle,
+		data.CompletedAt.UTC().Format(time.RFC3339), data.TotalReps, data.Rank, data.Participants,
+	)))
+	return hex.EncodeToString(sum[:])
+}
+
+// CertificateReference numéro court affiché sur le certificat
+func CertificateReference(fingerprint string) string {
+	if len(fingerprint) < 12 {
+		return fingerprint
+	}
+	return fingerprint[:12]
+}
+
+// GetChallengeUserRank calcule le rang d'un utilisateur au classement d'un challenge
+// (classement figé si le challenge est clôturé) et le nombre de participants classés
+func GetChallengeUserRank(ctx context.Context, challengeID, userID string) (int, int, error) {
+	finalized, err := IsChallengeFinalized(ctx, challengeID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var rank, total int
+	if finalized {
+		err = database.DB.QueryRow(ctx, `
+			SELECT
+				COALESCE((SELECT rank FROM challenge_leaderboard_snapshots WHERE challenge_id = $1 AND user_id = $2), 0),
+				(SELECT COUNT(*) FROM challenge_leaderboard_snapshots WHERE challenge_id = $1)::int
+		`, challengeID, userID).Scan(&rank, &total)
+		return rank, total, err
+	}
+
+	// Même ordre que le classement en direct du challenge
+	err = database.DB.QueryRow(ctx, `
+		WITH ranked_users AS (
+			SELECT
+				ucp.user_id,
+				ROW_NUMBER() OVER (ORDER BY COALESCE(ucp.progress, 0) DESC, ucp.completed_at ASC NULLS LAST, ucp.user_id) AS rank
+			FROM user_challenge_progress ucp
+			WHERE ucp.challenge_id = $1
+		)
+		SELECT
+			COALESCE((SELECT rank FROM ranked_users WHERE user_id = $2), 0)::int,
+			(SELECT COUNT(*) FROM ranked_users)::int
+	`, challengeID, userID).Scan(&rank, &total)
+	return rank, total, err
+}
+
+// LoadChallengeCertificateData récupère les données du certificat d'un utilisateur ayant terminé le challenge
+func LoadChallengeCertificateData(ctx context.Context, challengeID, userID string) (model.ChallengeCertificateData, error) {
+	data := model.ChallengeCertificateData{UserID: userID, ChallengeID: challengeID}
+
+	var completedAt *time.Time
+	err := database.DB.QueryRow(ctx, `
+		SELECT u.name, c.title, ucp.completed_at,
+			CASE
+				WHEN COALESCE(c.target_reps, 0) > 0 OR (COALESCE(c.sets, 0) > 0 AND COALESCE(c.reps_per_set, 0) > 0)
+					THEN ucp.current_reps
+				ELSE (
+					SELECT COALESCE(SUM(ws.total_reps), 0)::int
+					FROM workout_sessions ws
+					WHERE ws.user_id = ucp.user_id AND ws.challenge_id = c.id AND `+ExerciseSessionCondition(3)+`
+				)
+			END
+		FROM user_challenge_progress ucp
+		INNER JOIN challenges c ON c.id = ucp.challenge_id
+		INNER JOIN users u ON u.id = ucp.user_id
+		WHERE ucp.challenge_id = $1 AND ucp.user_id = $2
+		  AND c.deleted_at IS NULL AND u.deleted_at IS NULL
+	`, challengeID, userID, model.ExerciseFamilyPushUp).Scan(&data.UserName, &data.ChallengeTitle, &completedAt, &data.TotalReps)
+	if err == pgx.ErrNoRows {
+		return data, ErrChallengeNotStarted
+	}
+	if err != nil {
+		return data, err
+	}
+	if completedAt == nil {
+		return data, ErrChallengeNotCompleted
+	}
+	data.CompletedAt = *completedAt
+
+	data.Rank, data.Participants, err = GetChallengeUserRank(ctx, challengeID, userID)
+	if err != nil {
+		return data, err
+	}
+
+	return data, nil
+}
+
+// GetOrCreateChallengeCertificate retourne le certificat en cache ou le (re)génère si les données ont changé
+func GetOrCreateChallengeCertificate(ctx context.Context, challengeID, userID string) (*model.ChallengeCertificate, error) {
+	data, err := LoadChallengeCertificateData(ctx, challengeID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cert := &model.ChallengeCertificate{Data: data, Fingerprint: CertificateFingerprint(data)}
+
+	var cachedFingerprint string
+	err = database.DB.QueryRow(ctx, `
+		SELECT fingerprint, png, pdf, image_url, updated_at
+		FROM challenge_certificates
+		WHERE user_id = $1 AND challenge_id = $2
+	`, userID, challengeID).Scan(&cachedFingerprint, &cert.PNG, &cert.PDF, &cert.ImageURL, &cert.UpdatedAt)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, err
+	}
+	if err == nil && cachedFingerprint == cert.Fingerprint {
+		return cert, nil
+	}
+
+	img, err := RenderCertificateImage(data, CertificateReference(cert.Fingerprint))
+	if err != nil {
+		return nil, err
+	}
+	if cert.PNG, err = EncodeCertificatePNG(img); err != nil {
+		return nil, err
+	}
+	if cert.PDF, err = EncodeCertificatePDF(img, fmt.Sprintf("Certificat - %s - %s", data.ChallengeTitle, data.UserName)); err != nil {
+		return nil, err
+	}
+
+	// L'ancienne URL publique ne correspond plus au nouveau rendu
+	cert.ImageURL = nil
+	cert.Generated = true
+	err = database.DB.QueryRow(ctx, `
+		INSERT INTO challenge_certificates(user_id, challenge_id, fingerprint, png, pdf, image_url, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, NULL, NOW(), NOW())
+		ON CONFLICT (user_id, challenge_id) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, png = EXCLUDED.png, pdf = EXCLUDED.pdf,
+			image_url = NULL, updated_at = NOW()
+		RETURNING updated_at
+	`, userID, challengeID, cert.Fingerprint, cert.PNG, cert.PDF).Scan(&cert.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// SetChallengeCertificateURL enregistre l'URL publique (Cloudinary) d'un certificat
+func SetChallengeCertificateURL(ctx context.Context, challengeID, userID, fingerprint, url string) error {
+	_, err := database.DB.Exec(ctx, `
+		UPDATE challenge_certificates
+		SET image_url = $4, updated_at = NOW()
+		WHERE user_id = $1 AND challenge_id = $2 AND fingerprint = $3
+	`, userID, challengeID, fingerprint, url)
+	return err
+}
@@ -0,0 +1,394 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
+	"github.com/jackc/pgx/v5"
+)
+
+// Statuts d'un challenge
+const (
+	ChallengeStatusUpcoming  = "UPCOMING"
+	ChallengeStatusActive    = "ACTIVE"
+	ChallengeStatusCompleted = "COMPLETED"
+	ChallengeStatusExpired   = "EXPIRED"
+)
+
+// Statuts d'une participation
+const (
+	ProgressStatusInProgress = "in_progress"
+	ProgressStatusCompleted  = "completed"
+	ProgressStatusFailed     = "failed"
+)
+
+// Types d'événements du cycle de vie
+const (
+	ChallengeEventStarted   = "challenge_started"
+	ChallengeEventEnded     = "challenge_ended"
+	ChallengeEventCompleted = "challenge_completed"
+	ChallengeEventFailed    = "challenge_failed"
+)
+
+// ChallengeBadgeCodePrefix préfixe du code des badges attribués par un challenge
+const ChallengeBadgeCodePrefix = "challenge_"
+
+// ChallengeStatusAt calcule le statut d'un challenge à partir de ses dates (sans accès base de données).
+// Un challenge terminé est EXPIRED tant que ses résultats ne sont pas clôturés (voir FinalChallengeStatus).
+func ChallengeStatusAt(startDate, endDate *time.Time, now time.Time) string {
+	if startDate != nil && now.Before(*startDate) {
+		return ChallengeStatusUpcoming
+	}
+	if endDate != nil && !now.Before(*endDate) {
+		return ChallengeStatusExpired
+	}
+	return ChallengeStatusActive
+}
+
+// FinalChallengeStatus statut d'un challenge clôturé : COMPLETED si au moins un participant l'a terminé
+func FinalChallengeStatus(completers int) string {
+	if completers > 0 {
+		return ChallengeStatusCompleted
+	}
+	return ChallengeStatusExpired
+}
+
+// EmitChallengeEvent enregistre un événement du cycle de vie (consommé par les notifications)
+func EmitChallengeEvent(ctx context.Context, db dbExecutor, challengeID string, userID *string, eventType string, payload map[string]any) error {
+	if payload == nil {
+		payload = map[string]any{}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, `
+		INSERT INTO challenge_events(challenge_id, user_id, event_type, payload, created_at)
+		VALUES($1, $2, $3, $4::jsonb, NOW())
+	`, challengeID, userID, eventType, string(data))
+	return err
+}
+
+// TransitionChallengeStatuses met à jour le statut des challenges datés non clôturés.
+// Retourne le nombre de challenges dont le statut a changé.
+func TransitionChallengeStatuses(ctx context.Context) (int, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Les challenges sans dates gardent leur statut manuel
+	rows, err := tx.Query(ctx, `
+		WITH computed AS (
+			SELECT id, status AS old_status,
+				CASE
+					WHEN start_date IS NOT NULL AND NOW() < start_date THEN $1::text
+					WHEN end_date IS NOT NULL AND NOW() >= end_date THEN $3::text
+					ELSE $2::text
+				END AS new_status
+			FROM challenges
+			WHERE deleted_at IS NULL
+			  AND finalized_at IS NULL
+			  AND (start_date IS NOT NULL OR end_date IS NOT NULL)
+			FOR UPDATE
+		)
+		UPDATE challenges c
+		SET status = computed.new_status, updated_at = NOW()
+		FROM computed
+		WHERE c.id = computed.id AND computed.old_status IS DISTINCT FROM computed.new_status
+		RETURNING c.id, c.title, computed.old_status, computed.new_status
+	`, ChallengeStatusUpcoming, ChallengeStatusActive, ChallengeStatusExpired)
+	if err != nil {
+		return 0, err
+	}
+
+	type transition struct {
+		id, title, newStatus string
+		oldStatus            sql.NullString
+	}
+	var transitions []transition
+	for rows.Next() {
+		var t transition
+		if err := rows.Scan(&t.id, &t.title, &t.oldStatus, &t.newStatus); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		transitions = append(transitions, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, t := range transitions {
+		if t.newStatus == ChallengeStatusActive && t.oldStatus.String == ChallengeStatusUpcoming {
+			if err := EmitChallengeEvent(ctx, tx, t.id, nil, ChallengeEventStarted, map[string]any{"title": t.title}); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(transitions), nil
+}
+
+// FinalizeEndedChallenges clôture les challenges dont la date de fin est passée
+func FinalizeEndedChallenges(ctx context.Context) ([]model.ChallengeFinalization, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT id FROM challenges
+		WHERE deleted_at IS NULL AND finalized_at IS NULL
+		  AND end_date IS NOT NULL AND end_date <= NOW()
+		ORDER BY end_date ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := []model.ChallengeFinalization{}
+	for _, id := range ids {
+		result, err := FinalizeChallenge(ctx, id)
+		if err != nil {
+			// Un challenge en échec ne bloque pas la clôture des autres
+			logger.Error("Impossible de clôturer le challenge %s: %v", id, err)
+			continue
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+
+	return results, nil
+}
+
+// FinalizeChallenge fige le classement, attribue le badge aux participants ayant terminé
+// et marque les participations inachevées comme échouées. Retourne nil si déjà clôturé.
+func FinalizeChallenge(ctx context.Context, challengeID string) (*model.ChallengeFinalization, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var title string
+	var badge sql.NullString
+	var finalizedAt sql.NullTime
+	err = tx.QueryRow(ctx, `
+		SELECT title, badge, finalized_at FROM challenges
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, challengeID).Scan(&title, &badge, &finalizedAt)
+	if err == pgx.ErrNoRows {
+		return nil, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if finalizedAt.Valid {
+		return nil, nil
+	}
+
+	result := model.ChallengeFinalization{ChallengeID: challengeID}
+
+	// Figer le classement (même ordre que GetChallengeLeaderboard : égalités départagées par la date de fin, puis l'utilisateur)
+	res, err := tx.Exec(ctx, `
+		INSERT INTO challenge_leaderboard_snapshots(challenge_id, user_id, rank, score, completed, created_at)
+		SELECT challenge_id, user_id,
+			ROW_NUMBER() OVER (ORDER BY COALESCE(progress, 0) DESC, completed_at ASC NULLS LAST, user_id),
+			COALESCE(progress, 0), completed_at IS NOT NULL, NOW()
+		FROM user_challenge_progress
+		WHERE challenge_id = $1
+		ON CONFLICT (challenge_id, user_id) DO NOTHING
+	`, challengeID)
+	if err != nil {
+		return nil, fmt.Errorf("impossible de figer le classement: %w", err)
+	}
+	result.Participants = int(res.RowsAffected())
+
+	// Participants ayant terminé : badge + événement
+	completers, err := collectUserIDs(ctx, tx, `
+		SELECT ucp.user_id, s.rank
+		FROM user_challenge_progress ucp
+		INNER JOIN challenge_leaderboard_snapshots s
+			ON s.challenge_id = ucp.challenge_id AND s.user_id = ucp.user_id
+		WHERE ucp.challenge_id = $1 AND ucp.completed_at IS NOT NULL
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	result.Completers = len(completers)
+
+	// Un badge saisi avant la limite de validation ne doit pas bloquer la clôture
+	badgeEmoji := strings.TrimSpace(NullStringToString(badge))
+	if runes := []rune(badgeEmoji); len(runes) > validation.MaxChallengeBadgeLength {
+		badgeEmoji = string(runes[:validation.MaxChallengeBadgeLength])
+	}
+	for userID, rank := range completers {
+		userID := userID
+		if badgeEmoji != "" {
+			res, err := tx.Exec(ctx, `
+				INSERT INTO user_badges(user_id, badge_code, badge_emoji, earned_at)
+				VALUES($1, $2, $3, NOW())
+				ON CONFLICT (user_id, badge_code) DO NOTHING
+			`, userID, ChallengeBadgeCodePrefix+challengeID, badgeEmoji)
+			if err != nil {
+				return nil, fmt.Errorf("impossible d'attribuer le badge: %w", err)
+			}
+			result.BadgesGiven += int(res.RowsAffected())
+		}
+
+		payload := map[string]any{"title": title, "rank": rank}
+		if badgeEmoji != "" {
+			payload["badge"] = badgeEmoji
+		}
+		if err := EmitChallengeEvent(ctx, tx, challengeID, &userID, ChallengeEventCompleted, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	// Participations inachevées : échec + événement
+	failed, err := collectUserIDs(ctx, tx, `
+		UPDATE user_challenge_progress
+		SET status = 'failed', updated_at = NOW()
+		WHERE challenge_id = $1 AND completed_at IS NULL AND status <> 'failed'
+		RETURNING user_id, progress
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	result.Failed = len(failed)
+
+	for userID, progress := range failed {
+		userID := userID
+		payload := map[string]any{"title": title, "progress": progress}
+		if err := EmitChallengeEvent(ctx, tx, challengeID, &userID, ChallengeEventFailed, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Status = FinalChallengeStatus(result.Completers)
+	_, err = tx.Exec(ctx, `
+		UPDATE challenges SET status = $2, finalized_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, challengeID, result.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	err = EmitChallengeEvent(ctx, tx, challengeID, nil, ChallengeEventEnded, map[string]any{
+		"title":        title,
+		"status":       result.Status,
+		"participants": result.Participants,
+		"completers":   result.Completers,
+		"failed":       result.Failed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// collectUserIDs exécute une requête retournant (user_id, valeur entière) et les indexe par utilisateur
+func collectUserIDs(ctx context.Context, tx pgx.Tx, query string, args ...any) (map[string]int, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := map[string]int{}
+	for rows.Next() {
+		var userID string
+		var value sql.NullInt64
+		if err := rows.Scan(&userID, &value); err != nil {
+			return nil, err
+		}
+		values[userID] = int(value.Int64)
+	}
+	return values, rows.Err()
+}
+
+// IsChallengeFinalized indique si les résultats d'un challenge sont clôturés
+func IsChallengeFinalized(ctx context.Context, challengeID string) (bool, error) {
+	var finalized bool
+	err := database.DB.QueryRow(ctx,
+		`SELECT finalized_at IS NOT NULL FROM challenges WHERE id = $1`,
+		challengeID,
+	).Scan(&finalized)
+	if err == pgx.ErrNoRows {
+		return false, ErrChallengeNotFound
+	}
+	return finalized, err
+}
+
+// IsChallengeEnded indique si un challenge est clôturé ou si sa date de fin est passée
+func IsChallengeEnded(ctx context.Context, challengeID string) (bool, error) {
+	var ended bool
+	err := database.DB.QueryRow(ctx,
+		`SELECT finalized_at IS NOT NULL OR (end_date IS NOT NULL AND end_date <= NOW()) FROM challenges WHERE id = $1`,
+		challengeID,
+	).Scan(&ended)
+	if err == pgx.ErrNoRows {
+		return false, ErrChallengeNotFound
+	}
+	return ended, err
+}
+
+// GetFrozenChallengeLeaderboard lit le classement figé d'un challenge clôturé
+func GetFrozenChallengeLeaderboard(ctx context.Context, challengeID string, limit int) ([]model.LeaderboardEntry, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT s.user_id, u.name, u.avatar, s.rank, s.score, 0 AS change
+		FROM challenge_leaderboard_snapshots s
+		INNER JOIN users u ON u.id = s.user_id
+		WHERE s.challenge_id = $1 AND u.deleted_at IS NULL
+		ORDER BY s.rank ASC
+		LIMIT $2
+	`, challengeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaderboard := []model.LeaderboardEntry{}
+	for rows.Next() {
+		var entry model.LeaderboardEntry
+		if err := rows.Scan(
+			&entry.UserID, &entry.UserName, &entry.Avatar,
+			&entry.Rank, &entry.Score, &entry.Change,
+		); err != nil {
+			return nil, err
+		}
+		leaderboard = append(leaderboard, entry)
+	}
+
+	return leaderboard, rows.Err()
+}
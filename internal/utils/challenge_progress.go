@@ -0,0 +1,343 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Modes de calcul de la progression d'un challenge
+const (
+	ProgressModeLinked     = "linked"
+	ProgressModeCumulative = "cumulative"
+)
+
+// ErrChallengeNotStarted l'utilisateur n'a pas démarré le challenge
+var ErrChallengeNotStarted = errors.New("challenge non démarré")
+
+// NormalizeProgressMode valide un mode de progression (linked par défaut)
+func NormalizeProgressMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return ProgressModeLinked, nil
+	case ProgressModeLinked, ProgressModeCumulative:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("progressMode invalide (linked ou cumulative)")
+	}
+}
+
+// ChallengeRepsTarget retourne l'objectif de répétitions d'un challenge (0 si le challenge n'est pas basé sur les reps)
+func ChallengeRepsTarget(targetReps, sets, repsPerSet *int) int {
+	if targetReps != nil && *targetReps > 0 {
+		return *targetReps
+	}
+	if sets != nil && repsPerSet != nil && *sets > 0 && *repsPerSet > 0 {
+		return *sets * *repsPerSet
+	}
+	return 0
+}
+
+// ComputeChallengeProgress calcule le pourcentage de progression (plafonné à 100)
+func ComputeChallengeProgress(current, target int) (int, bool) {
+	if target <= 0 {
+		return 0, false
+	}
+	if current >= target {
+		return 100, true
+	}
+	if current < 0 {
+		current = 0
+	}
+	return current * 100 / target, false
+}
+
+const userChallengeProgressColumns = `id, challenge_id, user_id, progress, current_reps, target_reps, attempts, completed_at, status, created_at, updated_at`
+
+// scanChallengeProgress lit une ligne de user_challenge_progress
+func scanChallengeProgress(row pgx.Row) (*model.UserChallengeProgress, error) {
+	var progress model.UserChallengeProgress
+	var completedAt sql.NullTime
+	var current, target, pct, attempts sql.NullInt64
+
+	err := row.Scan(
+		&progress.ID, &progress.ChallengeID, &progress.UserID, &pct,
+		&current, &target, &attempts,
+		&completedAt, &progress.Status, &progress.CreatedAt, &progress.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	progress.Progress = int(pct.Int64)
+	progress.CurrentReps = int(current.Int64)
+	progress.TargetReps = int(target.Int64)
+	progress.Attempts = int(attempts.Int64)
+	progress.CompletedAt = NullTimeToPointer(completedAt)
+	return &progress, nil
+}
+
+// RecomputeChallengeProgress recalcule la progression d'un utilisateur sur un challenge à partir
+// de ses séances (liées au challenge, ou toutes celles de la période en mode cumulatif) ou,
+// pour un challenge sans objectif de reps, de ses tâches complétées.
+// Le challenge est complété automatiquement quand l'objectif est atteint.
+// Retourne la progression et true si le challenge vient d'être complété.
+func RecomputeChallengeProgress(ctx context.Context, userID, challengeID string) (*model.UserChallengeProgress, bool, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var targetReps, sets, repsPerSet *int
+	var progressMode string
+	var startDate, endDate sql.NullTime
+	var finalized bool
+	err = tx.QueryRow(ctx, `
+		SELECT target_reps, sets, reps_per_set, progress_mode, start_date, end_date, finalized_at IS NOT NULL
+		FROM challenges
+		WHERE id = $1 AND deleted_at IS NULL
+	`, challengeID).Scan(&targetReps, &sets, &repsPerSet, &progressMode, &startDate, &endDate, &finalized)
+	if err == pgx.ErrNoRows {
+		return nil, false, ErrChallengeNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	progress, err := scanChallengeProgress(tx.QueryRow(ctx, `
+		SELECT `+userChallengeProgressColumns+`
+		FROM user_challenge_progress
+		WHERE challenge_id = $1 AND user_id = $2
+		FOR UPDATE
+	`, challengeID, userID))
+	if err == pgx.ErrNoRows {
+		return nil, false, ErrChallengeNotStarted
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Participation déjà terminée ou challenge clôturé : rien à recalculer
+	if progress.CompletedAt != nil || progress.Status == ProgressStatusFailed || finalized {
+		return progress, false, nil
+	}
+
+	target := ChallengeRepsTarget(targetReps, sets, repsPerSet)
+	current := 0
+
+	if target > 0 {
+		if progressMode == ProgressModeCumulative {
+			// Toutes les séances de la période du challenge (à défaut, depuis l'inscription)
+			windowStart := progress.CreatedAt
+			if startDate.Valid {
+				windowStart = startDate.Time
+			}
+			windowEnd := time.Now()
+			if endDate.Valid && endDate.Time.Before(windowEnd) {
+				windowEnd = endDate.Time
+			}
+			err = tx.QueryRow(ctx, `
+				SELECT COALESCE(SUM(ws.total_reps), 0)::int
+				FROM workout_sessions ws
+				WHERE ws.user_id = $1 AND ws.start_time >= $2 AND ws.start_time <= $3 AND `+ExerciseSessionCondition(4)+`
+			`, userID, windowStart, windowEnd, model.ExerciseFamilyPushUp).Scan(&current)
+		} else {
+			err = tx.QueryRow(ctx, `
+				SELECT COALESCE(SUM(ws.total_reps), 0)::int
+				FROM workout_sessions ws
+				WHERE ws.user_id = $1 AND ws.challenge_id = $2 AND `+ExerciseSessionCondition(3)+`
+			`, userID, challengeID, model.ExerciseFamilyPushUp).Scan(&current)
+		}
+	} else {
+		// Challenge basé sur les tâches : progression = tâches complétées
+		err = tx.QueryRow(ctx, `
+			SELECT
+				COUNT(*)::int,
+				COUNT(uctp.id) FILTER (WHERE uctp.completed = TRUE)::int
+			FROM challenge_tasks ct
+			LEFT JOIN user_challenge_task_progress uctp
+				ON uctp.task_id = ct.id AND uctp.user_id = $1
+			WHERE ct.challenge_id = $2 AND ct.deleted_at IS NULL
+		`, userID, challengeID).Scan(&target, &current)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	pct, completed := ComputeChallengeProgress(current, target)
+
+	status := ProgressStatusInProgress
+	if completed {
+		status = ProgressStatusCompleted
+	}
+	progress, err = scanChallengeProgress(tx.QueryRow(ctx, `
+		UPDATE user_challenge_progress
+		SET progress = $3, current_reps = $4, target_reps = $5, status = $6,
+			completed_at = CASE WHEN $7 THEN NOW() ELSE completed_at END,
+			updated_at = NOW()
+		WHERE challenge_id = $1 AND user_id = $2
+		RETURNING `+userChallengeProgressColumns,
+		challengeID, userID, pct, current, target, status, completed,
+	))
+	if err != nil {
+		return nil, false, err
+	}
+
+	if completed {
+		_, err = tx.Exec(ctx, `UPDATE challenges SET completions = completions + 1 WHERE id = $1`, challengeID)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, err
+	}
+
+	if completed {
+		rewardChallengeCompletion(ctx, userID, challengeID)
+	}
+
+	return progress, completed, nil
+}
+
+// rewardChallengeCompletion attribue les points, l'XP et les badges d'un challenge complété
+func rewardChallengeCompletion(ctx context.Context, userID, challengeID string) {
+	var points int
+	if err := database.DB.QueryRow(ctx,
+		`SELECT points FROM challenges WHERE id = $1`,
+		challengeID,
+	).Scan(&points); err != nil {
+		logger.Error("Impossible de récupérer les points du challenge %s: %v", challengeID, err)
+		return
+	}
+
+	if points > 0 {
+		if err := IncrementUserScore(ctx, userID, points); err != nil {
+			logger.Error("Impossible de mettre à jour le score de %s: %v", userID, err)
+		}
+		if _, err := GrantFlatXP(ctx, userID, XPSourceChallenge, &challengeID, points); err != nil {
+			logger.Error("Impossible d'attribuer l'XP du challenge à %s: %v", userID, err)
+		}
+	}
+
+	// Évaluer les badges (premier challenge officiel, etc.)
+	if _, err := EvaluateUserBadges(ctx, userID); err != nil {
+		logger.Error("Impossible d'évaluer les badges de %s: %v", userID, err)
+	}
+
+	logger.Success("%s a terminé le challenge %s", userID, challengeID)
+}
+
+// SyncChallengeProgressForSession met à jour les challenges concernés par une séance :
+// le challenge lié (démarré automatiquement si besoin) et les challenges cumulatifs en cours.
+func SyncChallengeProgressForSession(ctx context.Context, userID string, linkedChallengeID *string) ([]model.UserChallengeProgress, error) {
+	challengeIDs := []string{}
+
+	if linkedChallengeID != nil && *linkedChallengeID != "" {
+		ok, err := canSyncLinkedChallenge(ctx, userID, *linkedChallengeID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			logger.Warning("Séance de %s ignorée pour le challenge %s (inaccessible ou terminé)", userID, *linkedChallengeID)
+			linkedChallengeID = nil
+		}
+	}
+
+	if linkedChallengeID != nil && *linkedChallengeID != "" {
+		// Une séance liée vaut inscription au challenge
+		res, err := database.DB.Exec(ctx, `
+			INSERT INTO user_challenge_progress(challenge_id, user_id, progress, current_reps, target_reps, attempts, status, created_at, updated_at)
+			SELECT id, $2, 0, 0, COALESCE(target_reps, 0), 0, 'in_progress', NOW(), NOW()
+			FROM challenges
+			WHERE id = $1 AND deleted_at IS NULL AND finalized_at IS NULL
+			ON CONFLICT (challenge_id, user_id) DO NOTHING
+		`, *linkedChallengeID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() > 0 {
+			if _, err := database.DB.Exec(ctx,
+				`UPDATE challenges SET participants = participants + 1 WHERE id = $1`,
+				*linkedChallengeID,
+			); err != nil {
+				logger.Error("Could not increment participants for challenge %s: %v", *linkedChallengeID, err)
+			}
+		}
+		challengeIDs = append(challengeIDs, *linkedChallengeID)
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT c.id
+		FROM user_challenge_progress ucp
+		INNER JOIN challenges c ON c.id = ucp.challenge_id
+		WHERE ucp.user_id = $1
+		  AND ucp.completed_at IS NULL AND ucp.status = 'in_progress'
+		  AND c.progress_mode = 'cumulative'
+		  AND c.deleted_at IS NULL AND c.finalized_at IS NULL
+		  AND ($2::uuid IS NULL OR c.id <> $2::uuid)
+	`, userID, linkedChallengeID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		challengeIDs = append(challengeIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	updated := []model.UserChallengeProgress{}
+	for _, challengeID := range challengeIDs {
+		progress, _, err := RecomputeChallengeProgress(ctx, userID, challengeID)
+		if err != nil {
+			logger.Error("Impossible de recalculer la progression de %s sur %s: %v", userID, challengeID, err)
+			continue
+		}
+		updated = append(updated, *progress)
+	}
+
+	return updated, nil
+}
+
+// canSyncLinkedChallenge applique à une séance liée les contrôles de StartChallenge :
+// challenge visible par l'utilisateur (privé : propriétaire, admin, invité ou participant) et non terminé
+func canSyncLinkedChallenge(ctx context.Context, userID, challengeID string) (bool, error) {
+	access, err := LoadChallengeAccess(ctx, challengeID, userID)
+	if errors.Is(err, ErrChallengeNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var isAdmin, ended bool
+	err = database.DB.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT is_admin FROM users WHERE id = $2), FALSE),
+			finalized_at IS NOT NULL OR (end_date IS NOT NULL AND end_date <= NOW())
+		FROM challenges
+		WHERE id = $1
+	`, challengeID, userID).Scan(&isAdmin, &ended)
+	if err != nil {
+		return false, err
+	}
+
+	isManager := userID == access.OwnerID || isAdmin
+	return CanViewChallenge(access.Visibility, isManager, access.Allowed) && !ended, nil
+}
@@ -0,0 +1,375 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ChallengeTaskRestType type d'une tâche de repos
+const ChallengeTaskRestType = "REST"
+
+// MaxChallengeTaskDays nombre maximum de jours d'un challenge
+const MaxChallengeTaskDays = 366
+
+var (
+	ErrChallengeNotFound     = errors.New("challenge introuvable")
+	ErrChallengeTaskNotFound = errors.New("tâche introuvable")
+)
+
+// dbExecutor interface commune au pool et aux transactions
+type dbExecutor interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// GenerateTasksFromTemplate génère les tâches d'une progression multi-jours
+func GenerateTasksFromTemplate(tpl model.ChallengeTaskTemplate) ([]model.ChallengeTask, error) {
+	if tpl.Days <= 0 || tpl.Days > MaxChallengeTaskDays {
+		return nil, fmt.Errorf("le nombre de jours doit être compris entre 1 et %d", MaxChallengeTaskDays)
+	}
+	if tpl.StartReps <= 0 {
+		return nil, fmt.Errorf("le nombre de reps de départ doit être positif")
+	}
+	if tpl.RestEvery < 0 || tpl.RestEvery == 1 {
+		return nil, fmt.Errorf("restEvery doit être 0 (aucun repos) ou supérieur à 1")
+	}
+	if tpl.Sets != nil && *tpl.Sets <= 0 {
+		return nil, fmt.Errorf("le nombre de séries doit être positif")
+	}
+
+	prefix := tpl.TitlePrefix
+	if prefix == "" {
+		prefix = "Jour"
+	}
+
+	tasks := make([]model.ChallengeTask, 0, tpl.Days)
+	for day := 1; day <= tpl.Days; day++ {
+		task := model.ChallengeTask{Day: day, Score: tpl.Score}
+
+		if tpl.RestEvery > 0 && day%tpl.RestEvery == 0 {
+			restType := ChallengeTaskRestType
+			description := "Jour de repos"
+			task.Title = fmt.Sprintf("%s %d - Repos", prefix, day)
+			task.Type = &restType
+			task.Description = &description
+			tasks = append(tasks, task)
+			continue
+		}
+
+		reps := tpl.StartReps + tpl.Increment*(day-1)
+		if reps < 1 {
+			reps = 1
+		}
+
+		task.Title = fmt.Sprintf("%s %d - %d reps", prefix, day, reps)
+		task.Type = tpl.Type
+		task.Variant = tpl.Variant
+		task.TargetReps = &reps
+		if tpl.Sets != nil {
+			sets := *tpl.Sets
+			repsPerSet := (reps + sets - 1) / sets
+			task.Sets = &sets
+			task.RepsPerSet = &repsPerSet
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// ValidateChallengeTaskDays vérifie que les jours des tâches sont contigus de 1 à N, sans doublon
+func ValidateChallengeTaskDays(tasks []model.ChallengeTask) error {
+	days := make([]int, 0, len(tasks))
+	for _, task := range tasks {
+		days = append(days, task.Day)
+	}
+	sort.Ints(days)
+
+	for i, day := range days {
+		if day != i+1 {
+			if i > 0 && day == days[i-1] {
+				return fmt.Errorf("le jour %d est utilisé par plusieurs tâches", day)
+			}
+			return fmt.Errorf("les jours des tâches doivent être contigus à partir de 1 (jour %d attendu, %d trouvé)", i+1, day)
+		}
+	}
+
+	return nil
+}
+
+// ValidateChallengeTask vérifie les champs d'une tâche
+func ValidateChallengeTask(task model.ChallengeTask) error {
+	if task.Title == "" {
+		return fmt.Errorf("le titre de la tâche est requis")
+	}
+	if task.TargetReps != nil && *task.TargetReps < 0 {
+		return fmt.Errorf("targetReps ne peut pas être négatif")
+	}
+	if task.Sets != nil && *task.Sets < 0 {
+		return fmt.Errorf("sets ne peut pas être négatif")
+	}
+	if task.RepsPerSet != nil && *task.RepsPerSet < 0 {
+		return fmt.Errorf("repsPerSet ne peut pas être négatif")
+	}
+	if task.Duration != nil && *task.Duration < 0 {
+		return fmt.Errorf("duration ne peut pas être négative")
+	}
+	return nil
+}
+
+// InsertChallengeTasks insère des tâches pour un challenge (dans une transaction ou non).
+// actorID vide pour les tâches créées par le système.
+func InsertChallengeTasks(ctx context.Context, db dbExecutor, challengeID string, tasks []model.ChallengeTask, actorID string) ([]string, error) {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		if err := ValidateChallengeTask(task); err != nil {
+			return nil, fmt.Errorf("jour %d: %w", task.Day, err)
+		}
+
+		var id string
+		err := db.QueryRow(ctx, `
+			INSERT INTO challenge_tasks(
+				challenge_id, day, title, description, type, variant,
+				target_reps, duration, sets, reps_per_set, score,
+				scheduled_date, is_locked, created_by, created_at, updated_at
+			) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE($11, 0), $12, $13, NULLIF($14, '')::uuid, NOW(), NOW())
+			RETURNING id
+		`,
+			challengeID, task.Day, task.Title, task.Description, task.Type, task.Variant,
+			task.TargetReps, task.Duration, task.Sets, task.RepsPerSet, task.Score,
+			task.ScheduledDate, task.IsLocked, actorID,
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("impossible de créer la tâche du jour %d: %w", task.Day, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// countChallengeTasks compte les tâches actives d'un challenge en verrouillant le challenge
+func countChallengeTasks(ctx context.Context, tx pgx.Tx, challengeID string) (int, error) {
+	// Verrou sur le challenge pour sérialiser les modifications de planning
+	var id string
+	if err := tx.QueryRow(ctx,
+		`SELECT id FROM challenges WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		challengeID,
+	).Scan(&id); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, ErrChallengeNotFound
+		}
+		return 0, err
+	}
+
+	var count int
+	err := tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM challenge_tasks WHERE challenge_id = $1 AND deleted_at IS NULL`,
+		challengeID,
+	).Scan(&count)
+	return count, err
+}
+
+// CreateChallengeTask ajoute une tâche au jour demandé (les jours suivants sont décalés).
+// Un jour à 0 ajoute la tâche à la fin du planning.
+func CreateChallengeTask(ctx context.Context, challengeID string, task model.ChallengeTask, actorID string) (string, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := countChallengeTasks(ctx, tx, challengeID)
+	if err != nil {
+		return "", err
+	}
+
+	if task.Day == 0 {
+		task.Day = count + 1
+	}
+	if task.Day < 1 || task.Day > count+1 {
+		return "", fmt.Errorf("le jour doit être compris entre 1 et %d pour rester contigu", count+1)
+	}
+
+	// Décaler les tâches suivantes
+	_, err = tx.Exec(ctx, `
+		UPDATE challenge_tasks SET day = day + 1, updated_at = NOW()
+		WHERE challenge_id = $1 AND deleted_at IS NULL AND day >= $2
+	`, challengeID, task.Day)
+	if err != nil {
+		return "", err
+	}
+
+	ids, err := InsertChallengeTasks(ctx, tx, challengeID, []model.ChallengeTask{task}, actorID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return ids[0], nil
+}
+
+// BulkCreateChallengeTasks ajoute des tâches à la suite du planning, ou le remplace si replace est vrai.
+// Les jours fournis sont relatifs (1 = premier jour ajouté).
+func BulkCreateChallengeTasks(ctx context.Context, challengeID string, tasks []model.ChallengeTask, replace bool, actorID string) ([]string, error) {
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("aucune tâche à créer")
+	}
+	if err := ValidateChallengeTaskDays(tasks); err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := countChallengeTasks(ctx, tx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := count
+	if replace {
+		_, err = tx.Exec(ctx, `
+			UPDATE challenge_tasks SET deleted_at = NOW(), deleted_by = $2
+			WHERE challenge_id = $1 AND deleted_at IS NULL
+		`, challengeID, actorID)
+		if err != nil {
+			return nil, err
+		}
+		offset = 0
+	}
+	if offset+len(tasks) > MaxChallengeTaskDays {
+		return nil, fmt.Errorf("un challenge ne peut pas dépasser %d jours", MaxChallengeTaskDays)
+	}
+
+	for i := range tasks {
+		tasks[i].Day += offset
+	}
+
+	ids, err := InsertChallengeTasks(ctx, tx, challengeID, tasks, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// UpdateChallengeTask met à jour le contenu d'une tâche (le jour se modifie via ReorderChallengeTasks)
+func UpdateChallengeTask(ctx context.Context, challengeID, taskID string, task model.ChallengeTask, actorID string) error {
+	if err := ValidateChallengeTask(task); err != nil {
+		return err
+	}
+
+	res, err := database.DB.Exec(ctx, `
+		UPDATE challenge_tasks SET
+			title = $1, description = $2, type = $3, variant = $4,
+			target_reps = $5, duration = $6, sets = $7, reps_per_set = $8,
+			score = COALESCE($9, score), scheduled_date = $10, is_locked = $11,
+			updated_by = $12, updated_at = NOW()
+		WHERE id = $13 AND challenge_id = $14 AND deleted_at IS NULL
+	`,
+		task.Title, task.Description, task.Type, task.Variant,
+		task.TargetReps, task.Duration, task.Sets, task.RepsPerSet,
+		task.Score, task.ScheduledDate, task.IsLocked,
+		actorID, taskID, challengeID,
+	)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrChallengeTaskNotFound
+	}
+	return nil
+}
+
+// DeleteChallengeTask supprime une tâche et décale les jours suivants pour garder un planning contigu
+func DeleteChallengeTask(ctx context.Context, challengeID, taskID, actorID string) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := countChallengeTasks(ctx, tx, challengeID); err != nil {
+		return err
+	}
+
+	var day int
+	err = tx.QueryRow(ctx, `
+		UPDATE challenge_tasks SET deleted_at = NOW(), deleted_by = $3
+		WHERE id = $1 AND challenge_id = $2 AND deleted_at IS NULL
+		RETURNING day
+	`, taskID, challengeID, actorID).Scan(&day)
+	if err == pgx.ErrNoRows {
+		return ErrChallengeTaskNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE challenge_tasks SET day = day - 1, updated_at = NOW()
+		WHERE challenge_id = $1 AND deleted_at IS NULL AND day > $2
+	`, challengeID, day)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ReorderChallengeTasks réattribue les jours selon l'ordre des identifiants fournis
+// (toutes les tâches du challenge doivent être présentes)
+func ReorderChallengeTasks(ctx context.Context, challengeID string, taskIDs []string, actorID string) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := countChallengeTasks(ctx, tx, challengeID)
+	if err != nil {
+		return err
+	}
+	if len(taskIDs) != count {
+		return fmt.Errorf("l'ordre doit contenir les %d tâches du challenge", count)
+	}
+
+	seen := map[string]bool{}
+	for i, taskID := range taskIDs {
+		if seen[taskID] {
+			return fmt.Errorf("la tâche %s apparaît plusieurs fois", taskID)
+		}
+		seen[taskID] = true
+
+		res, err := tx.Exec(ctx, `
+			UPDATE challenge_tasks SET day = $1, updated_by = $2, updated_at = NOW()
+			WHERE id = $3 AND challenge_id = $4 AND deleted_at IS NULL
+		`, i+1, actorID, taskID, challengeID)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return fmt.Errorf("tâche %s introuvable dans ce challenge", taskID)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
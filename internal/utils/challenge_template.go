@@ -0,0 +1,529 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+)
+
+// ChallengeTemplateLookahead avance avec laquelle les occurrences sont créées (visibles en UPCOMING)
+const ChallengeTemplateLookahead = 24 * time.Hour
+
+// maxOccurrencesPerRun limite le rattrapage d'un modèle en une seule exécution du scheduler
+const maxOccurrencesPerRun = 31
+
+// DefaultChallengeVariant variante utilisée quand le modèle n'en définit aucune
+const DefaultChallengeVariant = "STANDARD"
+
+var ErrChallengeTemplateNotFound = errors.New("modèle de challenge introuvable")
+
+// challengeDifficultyScale facteur appliqué à l'objectif selon la difficulté
+var challengeDifficultyScale = map[string]float64{
+	"BEGINNER":     1.0,
+	"INTERMEDIATE": 1.5,
+	"ADVANCED":     2.0,
+}
+
+// ScaleForDifficulty met à l'échelle une valeur définie pour baseDifficulty vers difficulty
+func ScaleForDifficulty(value int, baseDifficulty, difficulty string) int {
+	base, ok := challengeDifficultyScale[baseDifficulty]
+	target, ok2 := challengeDifficultyScale[difficulty]
+	if !ok || !ok2 || base == target {
+		return value
+	}
+	return int(math.Round(float64(value) * target / base))
+}
+
+// ValidateChallengeTemplate vérifie un modèle de challenge récurrent
+func ValidateChallengeTemplate(tpl *model.ChallengeTemplate) error {
+	if tpl.Title == "" {
+		return fmt.Errorf("le titre est requis")
+	}
+	if tpl.Category == "" || tpl.Type == "" {
+		return fmt.Errorf("category et type sont requis")
+	}
+	if tpl.TargetReps <= 0 {
+		return fmt.Errorf("targetReps doit être positif")
+	}
+	if tpl.MaxTargetReps != nil && *tpl.MaxTargetReps < tpl.TargetReps {
+		return fmt.Errorf("maxTargetReps doit être supérieur ou égal à targetReps")
+	}
+	if tpl.Sets != nil && *tpl.Sets <= 0 {
+		return fmt.Errorf("le nombre de séries doit être positif")
+	}
+	if tpl.Points < 0 {
+		return fmt.Errorf("points ne peut pas être négatif")
+	}
+
+	if tpl.Difficulty == "" {
+		tpl.Difficulty = "BEGINNER"
+	}
+	if _, ok := challengeDifficultyScale[tpl.Difficulty]; !ok {
+		return fmt.Errorf("difficulté invalide: %s", tpl.Difficulty)
+	}
+	for _, difficulty := range tpl.Difficulties {
+		if _, ok := challengeDifficultyScale[difficulty]; !ok {
+			return fmt.Errorf("difficulté invalide: %s", difficulty)
+		}
+	}
+	for _, variant := range tpl.Variants {
+		if !isPushUpVariant(variant) {
+			return fmt.Errorf("variante invalide: %s", variant)
+		}
+	}
+
+	progressMode := tpl.ProgressMode
+	if progressMode == "" {
+		progressMode = ProgressModeCumulative
+	}
+	progressMode, err := NormalizeProgressMode(progressMode)
+	if err != nil {
+		return err
+	}
+	tpl.ProgressMode = progressMode
+
+	if _, err := ParseRecurrenceRule(tpl.Recurrence); err != nil {
+		return err
+	}
+	if tpl.DurationDays != nil && (*tpl.DurationDays < 1 || *tpl.DurationDays > MaxChallengeTaskDays) {
+		return fmt.Errorf("durationDays doit être compris entre 1 et %d", MaxChallengeTaskDays)
+	}
+	if tpl.StartsOn.IsZero() {
+		return fmt.Errorf("startsOn est requis")
+	}
+	tpl.StartsOn = truncateToDay(tpl.StartsOn)
+	if tpl.Until != nil && tpl.Until.Before(tpl.StartsOn) {
+		return fmt.Errorf("until doit être postérieur à startsOn")
+	}
+
+	if tpl.TaskTemplate != nil {
+		if _, err := GenerateTasksFromTemplate(*tpl.TaskTemplate); err != nil {
+			return fmt.Errorf("taskTemplate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func isPushUpVariant(variant string) bool {
+	for _, v := range PushUpVariants {
+		if v == variant {
+			return true
+		}
+	}
+	return false
+}
+
+// PlanChallengeOccurrence calcule la variante, la difficulté et l'objectif d'une occurrence (0 = première)
+func PlanChallengeOccurrence(tpl model.ChallengeTemplate, occurrence int, start, end time.Time) model.ChallengeOccurrence {
+	variant := DefaultChallengeVariant
+	if len(tpl.Variants) > 0 {
+		variant = tpl.Variants[occurrence%len(tpl.Variants)]
+	}
+	difficulty := tpl.Difficulty
+	if len(tpl.Difficulties) > 0 {
+		difficulty = tpl.Difficulties[occurrence%len(tpl.Difficulties)]
+	}
+
+	// Progression linéaire d'une occurrence à l'autre, plafonnée
+	target := tpl.TargetReps + tpl.RepsIncrement*occurrence
+	if tpl.MaxTargetReps != nil && target > *tpl.MaxTargetReps {
+		target = *tpl.MaxTargetReps
+	}
+	if target < 1 {
+		target = 1
+	}
+
+	return model.ChallengeOccurrence{
+		Occurrence: occurrence,
+		StartDate:  start,
+		EndDate:    end,
+		Variant:    variant,
+		Difficulty: difficulty,
+		TargetReps: ScaleForDifficulty(target, tpl.Difficulty, difficulty),
+		Points:     ScaleForDifficulty(tpl.Points, tpl.Difficulty, difficulty),
+	}
+}
+
+// BuildChallengeInstance construit le challenge et les tâches d'une occurrence
+func BuildChallengeInstance(tpl model.ChallengeTemplate, occ model.ChallengeOccurrence, now time.Time) (model.Challenge, []model.ChallengeTask, error) {
+	start, end := occ.StartDate, occ.EndDate
+	target := occ.TargetReps
+
+	challenge := model.Challenge{
+		Title:        fmt.Sprintf("%s - %s", tpl.Title, start.Format("02/01/2006")),
+		Description:  tpl.Description,
+		Category:     tpl.Category,
+		Type:         tpl.Type,
+		Variant:      occ.Variant,
+		Difficulty:   occ.Difficulty,
+		TargetReps:   &target,
+		ImageURL:     tpl.ImageURL,
+		IconName:     tpl.IconName,
+		IconColor:    tpl.IconColor,
+		Points:       occ.Points,
+		StartDate:    &start,
+		EndDate:      &end,
+		Status:       ChallengeStatusAt(&start, &end, now),
+		Tags:         tpl.Tags,
+		IsOfficial:   tpl.IsOfficial,
+		UnlockMode:   UnlockModeDaily,
+		ProgressMode: tpl.ProgressMode,
+		Visibility:   ChallengeVisibilityPublic,
+	}
+	if tpl.Sets != nil {
+		sets := *tpl.Sets
+		repsPerSet := (target + sets - 1) / sets
+		challenge.Sets = &sets
+		challenge.RepsPerSet = &repsPerSet
+	}
+
+	var tasks []model.ChallengeTask
+	if tpl.TaskTemplate != nil {
+		// Planning multi-jours : même rotation de variante et même mise à l'échelle
+		taskTpl := *tpl.TaskTemplate
+		taskTpl.Variant = &occ.Variant
+		taskTpl.StartReps = ScaleForDifficulty(taskTpl.StartReps, tpl.Difficulty, occ.Difficulty)
+		generated, err := GenerateTasksFromTemplate(taskTpl)
+		if err != nil {
+			return challenge, nil, err
+		}
+		tasks = generated
+	} else {
+		challengeType := tpl.Type
+		tasks = []model.ChallengeTask{{
+			Day:        1,
+			Title:      fmt.Sprintf("%d reps %s", target, occ.Variant),
+			Type:       &challengeType,
+			Variant:    &occ.Variant,
+			TargetReps: &target,
+			Sets:       challenge.Sets,
+			RepsPerSet: challenge.RepsPerSet,
+		}}
+	}
+
+	for i := range tasks {
+		scheduled := start.AddDate(0, 0, tasks[i].Day-1)
+		tasks[i].ScheduledDate = &scheduled
+	}
+
+	return challenge, tasks, nil
+}
+
+// PreviewChallengeTemplate calcule les prochaines occurrences d'un modèle à partir de from
+func PreviewChallengeTemplate(tpl model.ChallengeTemplate, from time.Time, count int) ([]model.ChallengeOccurrence, error) {
+	rule, err := ParseRecurrenceRule(tpl.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []model.ChallengeOccurrence{}
+	occurrence := tpl.OccurrenceCount
+	cursor := from
+	if tpl.LastOccurrenceAt != nil && !tpl.LastOccurrenceAt.Before(cursor) {
+		cursor = tpl.LastOccurrenceAt.AddDate(0, 0, 1)
+	}
+
+	for len(occurrences) < count {
+		if rule.Count > 0 && occurrence >= rule.Count {
+			break
+		}
+		start, ok := rule.NextOccurrence(tpl.StartsOn, cursor)
+		if !ok || (tpl.Until != nil && start.After(*tpl.Until)) {
+			break
+		}
+		end := rule.OccurrenceEnd(tpl.StartsOn, start, tpl.DurationDays)
+		occurrences = append(occurrences, PlanChallengeOccurrence(tpl, occurrence, start, end))
+		occurrence++
+		cursor = start.AddDate(0, 0, 1)
+	}
+
+	return occurrences, nil
+}
+
+const challengeTemplateColumns = `id, title, description, category, type, variants, difficulty, difficulties,
+	target_reps, reps_increment, max_target_reps, sets, points, icon_name, icon_color, image_url, tags,
+	is_official, progress_mode, task_template, recurrence, duration_days, starts_on, until, active,
+	occurrence_count, last_occurrence_at, created_by, updated_by, created_at, updated_at`
+
+func scanChallengeTemplate(row pgx.Row) (*model.ChallengeTemplate, error) {
+	var tpl model.ChallengeTemplate
+	var taskTemplate []byte
+
+	err := row.Scan(
+		&tpl.ID, &tpl.Title, &tpl.Description, &tpl.Category, &tpl.Type, pq.Array(&tpl.Variants),
+		&tpl.Difficulty, pq.Array(&tpl.Difficulties), &tpl.TargetReps, &tpl.RepsIncrement, &tpl.MaxTargetReps,
+		&tpl.Sets, &tpl.Points, &tpl.IconName, &tpl.IconColor, &tpl.ImageURL, pq.Array(&tpl.Tags),
+		&tpl.IsOfficial, &tpl.ProgressMode, &taskTemplate, &tpl.Recurrence, &tpl.DurationDays,
+		&tpl.StartsOn, &tpl.Until, &tpl.Active, &tpl.OccurrenceCount, &tpl.LastOccurrenceAt,
+		&tpl.CreatedBy, &tpl.UpdatedBy, &tpl.CreatedAt, &tpl.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(taskTemplate) > 0 {
+		if err := json.Unmarshal(taskTemplate, &tpl.TaskTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	// Prochaine occurrence (informative)
+	if tpl.Active {
+		if next, err := PreviewChallengeTemplate(tpl, time.Now(), 1); err == nil && len(next) > 0 {
+			tpl.NextOccurrenceAt = &next[0].StartDate
+		}
+	}
+
+	return &tpl, nil
+}
+
+// marshalTaskTemplate sérialise le planning d'un modèle (NULL si absent)
+func marshalTaskTemplate(taskTemplate *model.ChallengeTaskTemplate) ([]byte, error) {
+	if taskTemplate == nil {
+		return nil, nil
+	}
+	return json.Marshal(taskTemplate)
+}
+
+// CreateChallengeTemplate enregistre un nouveau modèle de challenge récurrent
+func CreateChallengeTemplate(ctx context.Context, tpl *model.ChallengeTemplate, actorID string) (*model.ChallengeTemplate, error) {
+	if err := ValidateChallengeTemplate(tpl); err != nil {
+		return nil, err
+	}
+	taskTemplate, err := marshalTaskTemplate(tpl.TaskTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanChallengeTemplate(database.DB.QueryRow(ctx, `
+		INSERT INTO challenge_templates(
+			title, description, category, type, variants, difficulty, difficulties,
+			target_reps, reps_increment, max_target_reps, sets, points, icon_name, icon_color,
+			image_url, tags, is_official, progress_mode, task_template, recurrence, duration_days,
+			starts_on, until, active, created_by, created_at, updated_at
+		) VALUES(
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21,
+			$22, $23, $24, $25, NOW(), NOW()
+		)
+		RETURNING `+challengeTemplateColumns,
+		tpl.Title, tpl.Description, tpl.Category, tpl.Type, pq.Array(tpl.Variants), tpl.Difficulty,
+		pq.Array(tpl.Difficulties), tpl.TargetReps, tpl.RepsIncrement, tpl.MaxTargetReps, tpl.Sets,
+		tpl.Points, tpl.IconName, tpl.IconColor, tpl.ImageURL, pq.Array(tpl.Tags), tpl.IsOfficial,
+		tpl.ProgressMode, taskTemplate, tpl.Recurrence, tpl.DurationDays, tpl.StartsOn, tpl.Until,
+		tpl.Active, actorID,
+	))
+}
+
+// UpdateChallengeTemplate met à jour un modèle (les occurrences déjà créées ne sont pas modifiées)
+func UpdateChallengeTemplate(ctx context.Context, id string, tpl *model.ChallengeTemplate, actorID string) (*model.ChallengeTemplate, error) {
+	if err := ValidateChallengeTemplate(tpl); err != nil {
+		return nil, err
+	}
+	taskTemplate, err := marshalTaskTemplate(tpl.TaskTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := scanChallengeTemplate(database.DB.QueryRow(ctx, `
+		UPDATE challenge_templates SET
+			title = $2, description = $3, category = $4, type = $5, variants = $6, difficulty = $7,
+			difficulties = $8, target_reps = $9, reps_increment = $10, max_target_reps = $11, sets = $12,
+			points = $13, icon_name = $14, icon_color = $15, image_url = $16, tags = $17, is_official = $18,
+			progress_mode = $19, task_template = $20, recurrence = $21, duration_days = $22, starts_on = $23,
+			until = $24, active = $25, updated_by = $26, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+challengeTemplateColumns,
+		id, tpl.Title, tpl.Description, tpl.Category, tpl.Type, pq.Array(tpl.Variants), tpl.Difficulty,
+		pq.Array(tpl.Difficulties), tpl.TargetReps, tpl.RepsIncrement, tpl.MaxTargetReps, tpl.Sets,
+		tpl.Points, tpl.IconName, tpl.IconColor, tpl.ImageURL, pq.Array(tpl.Tags), tpl.IsOfficial,
+		tpl.ProgressMode, taskTemplate, tpl.Recurrence, tpl.DurationDays, tpl.StartsOn, tpl.Until,
+		tpl.Active, actorID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrChallengeTemplateNotFound
+	}
+	return updated, err
+}
+
+// GetChallengeTemplate récupère un modèle par son ID
+func GetChallengeTemplate(ctx context.Context, id string) (*model.ChallengeTemplate, error) {
+	tpl, err := scanChallengeTemplate(database.DB.QueryRow(ctx,
+		`SELECT `+challengeTemplateColumns+` FROM challenge_templates WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrChallengeTemplateNotFound
+	}
+	return tpl, err
+}
+
+// ListChallengeTemplates liste les modèles (activeOnly pour ne garder que les modèles actifs)
+func ListChallengeTemplates(ctx context.Context, activeOnly bool) ([]model.ChallengeTemplate, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT `+challengeTemplateColumns+`
+		FROM challenge_templates
+		WHERE deleted_at IS NULL AND (NOT $1 OR active)
+		ORDER BY created_at DESC
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []model.ChallengeTemplate{}
+	for rows.Next() {
+		tpl, err := scanChallengeTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tpl)
+	}
+	return templates, rows.Err()
+}
+
+// DeleteChallengeTemplate supprime (soft delete) un modèle ; les challenges déjà créés sont conservés
+func DeleteChallengeTemplate(ctx context.Context, id string) error {
+	res, err := database.DB.Exec(ctx,
+		`UPDATE challenge_templates SET deleted_at = NOW(), active = FALSE, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrChallengeTemplateNotFound
+	}
+	return nil
+}
+
+// SpawnChallengeInstances crée les challenges des modèles actifs dont l'occurrence démarre avant now + lookahead.
+// Retourne le nombre de challenges créés.
+func SpawnChallengeInstances(ctx context.Context, now time.Time) (int, error) {
+	templates, err := ListChallengeTemplates(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, tpl := range templates {
+		n, err := spawnTemplateOccurrences(ctx, tpl, now)
+		created += n
+		if err != nil {
+			logger.Error("Modèle de challenge %s: %v", tpl.ID, err)
+		}
+	}
+	return created, nil
+}
+
+// spawnTemplateOccurrences crée les occurrences dues d'un modèle, chacune dans sa transaction
+func spawnTemplateOccurrences(ctx context.Context, tpl model.ChallengeTemplate, now time.Time) (int, error) {
+	rule, err := ParseRecurrenceRule(tpl.Recurrence)
+	if err != nil {
+		return 0, err
+	}
+
+	// Repartir d'une période en arrière pour créer une occurrence en cours manquée (job interrompu)
+	lookback := rule.PeriodDays()
+	if tpl.DurationDays != nil {
+		lookback = *tpl.DurationDays
+	}
+	from := truncateToDay(now).AddDate(0, 0, -lookback)
+	horizon := now.Add(ChallengeTemplateLookahead)
+
+	occurrences, err := PreviewChallengeTemplate(tpl, from, lookback+maxOccurrencesPerRun)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, occ := range occurrences {
+		if occ.StartDate.After(horizon) {
+			break
+		}
+		// Occurrence déjà terminée : on ne la rattrape pas
+		if !occ.EndDate.After(now) {
+			continue
+		}
+		ok, err := createChallengeOccurrence(ctx, tpl, occ, now)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
+
+// createChallengeOccurrence insère le challenge et les tâches d'une occurrence puis avance le modèle.
+// Retourne false si l'occurrence existait déjà (exécution concurrente ou rejouée).
+func createChallengeOccurrence(ctx context.Context, tpl model.ChallengeTemplate, occ model.ChallengeOccurrence, now time.Time) (bool, error) {
+	challenge, tasks, err := BuildChallengeInstance(tpl, occ, now)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	var challengeID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO challenges(
+			title, description, category, type, variant, difficulty,
+			target_reps, sets, reps_per_set, image_url, icon_name, icon_color,
+			participants, completions, likes, points, start_date, end_date, status, tags,
+			is_official, unlock_mode, progress_mode, visibility, template_id, occurrence,
+			created_by, created_at, updated_at
+		) VALUES(
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, 0, 0, 0, $13, $14, $15, $16, $17,
+			$18, $19, $20, $21, $22, $23, $24, NOW(), NOW()
+		)
+		ON CONFLICT (template_id, start_date) WHERE template_id IS NOT NULL DO NOTHING
+		RETURNING id
+	`,
+		challenge.Title, challenge.Description, challenge.Category, challenge.Type, challenge.Variant,
+		challenge.Difficulty, challenge.TargetReps, challenge.Sets, challenge.RepsPerSet, challenge.ImageURL,
+		challenge.IconName, challenge.IconColor, challenge.Points, challenge.StartDate, challenge.EndDate,
+		challenge.Status, pq.Array(challenge.Tags), challenge.IsOfficial, challenge.UnlockMode,
+		challenge.ProgressMode, challenge.Visibility, tpl.ID, occ.Occurrence, tpl.CreatedBy,
+	).Scan(&challengeID)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := InsertChallengeTasks(ctx, tx, challengeID, tasks, ""); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE challenge_templates
+		SET occurrence_count = GREATEST(occurrence_count, $2 + 1), last_occurrence_at = $3, updated_at = NOW()
+		WHERE id = $1
+	`, tpl.ID, occ.Occurrence, occ.StartDate)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+
+	logger.Info("Challenge %s créé depuis le modèle %s (occurrence %d, %s)", challengeID, tpl.ID, occ.Occurrence+1, occ.Variant)
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// Modes de déverrouillage des tâches de challenge
+const (
+	UnlockModeDaily      = "daily"
+	UnlockModeSequential = "sequential"
+)
+
+// Raisons de verrouillage d'une tâche
+const (
+	LockReasonNotYetAvailable        = "not_yet_available"
+	LockReasonPreviousTaskIncomplete = "previous_task_incomplete"
+)
+
+// NormalizeUnlockMode valide un mode de déverrouillage (daily par défaut)
+func NormalizeUnlockMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return UnlockModeDaily, nil
+	case UnlockModeDaily, UnlockModeSequential:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unlockMode invalide (daily ou sequential)")
+	}
+}
+
+// TaskUnlockContext informations nécessaires au calcul du verrouillage pour un participant
+type TaskUnlockContext struct {
+	Mode      string
+	StartedAt *time.Time // nil si l'utilisateur n'a pas démarré le challenge
+	Location  *time.Location
+}
+
+// ApplyTaskUnlocks calcule l'état de verrouillage de chaque tâche pour un participant (sans accès base de données).
+// La tâche du jour N s'ouvre à minuit (heure locale) N-1 jours après le démarrage.
+// Si le participant n'a pas démarré, le calcul se fait comme s'il démarrait maintenant.
+// Les tâches doivent être triées par jour.
+func ApplyTaskUnlocks(tasks []model.ChallengeTask, unlock TaskUnlockContext, now time.Time) {
+	loc := unlock.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	start := now
+	if unlock.StartedAt != nil {
+		start = *unlock.StartedAt
+	}
+	start = start.In(loc)
+	firstDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+
+	previousCompleted := true
+	for i := range tasks {
+		task := &tasks[i]
+
+		day := task.Day
+		if day < 1 {
+			day = 1
+		}
+		unlocksAt := firstDay.AddDate(0, 0, day-1)
+		task.UnlocksAt = &unlocksAt
+		task.IsLocked = false
+		task.LockReason = nil
+
+		switch {
+		case now.Before(unlocksAt):
+			reason := LockReasonNotYetAvailable
+			task.IsLocked = true
+			task.LockReason = &reason
+		case unlock.Mode == UnlockModeSequential && !previousCompleted:
+			reason := LockReasonPreviousTaskIncomplete
+			task.IsLocked = true
+			task.LockReason = &reason
+		}
+
+		// Un jour de repos n'est jamais complété par une séance : il est considéré comme passé
+		// et transmet l'état de la tâche précédente
+		if task.Type != nil && *task.Type == ChallengeTaskRestType {
+			continue
+		}
+		previousCompleted = task.UserProgress != nil && task.UserProgress.Completed
+	}
+}
+
+// LoadTaskUnlockContext charge le mode du challenge, la date de démarrage et le fuseau horaire du participant
+func LoadTaskUnlockContext(ctx context.Context, challengeID string, userID *string) (TaskUnlockContext, error) {
+	unlock := TaskUnlockContext{Mode: UnlockModeDaily, Location: time.UTC}
+
+	var mode sql.NullString
+	err := database.DB.QueryRow(ctx,
+		`SELECT unlock_mode FROM challenges WHERE id = $1`,
+		challengeID,
+	).Scan(&mode)
+	if err != nil {
+		return unlock, err
+	}
+	if mode.Valid && mode.String != "" {
+		unlock.Mode = mode.String
+	}
+
+	if userID == nil || *userID == "" {
+		return unlock, nil
+	}
+
+	timezone, err := GetUserTimezone(ctx, *userID)
+	if err == nil {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			unlock.Location = loc
+		}
+	}
+
+	var startedAt time.Time
+	err = database.DB.QueryRow(ctx,
+		`SELECT created_at FROM user_challenge_progress WHERE challenge_id = $1 AND user_id = $2`,
+		challengeID, *userID,
+	).Scan(&startedAt)
+	if err == pgx.ErrNoRows {
+		return unlock, nil
+	}
+	if err != nil {
+		return unlock, err
+	}
+	unlock.StartedAt = &startedAt
+
+	return unlock, nil
+}
+
+// TaskLockedMessage retourne un message explicite pour une tâche verrouillée
+func TaskLockedMessage(task model.ChallengeTask) string {
+	if task.LockReason != nil && *task.LockReason == LockReasonPreviousTaskIncomplete {
+		return fmt.Sprintf("tâche du jour %d verrouillée : complétez d'abord la tâche du jour %d", task.Day, task.Day-1)
+	}
+	if task.UnlocksAt != nil {
+		return fmt.Sprintf("tâche du jour %d verrouillée : disponible à partir du %s", task.Day, task.UnlocksAt.Format("02/01/2006 15:04 MST"))
+	}
+	return fmt.Sprintf("tâche du jour %d verrouillée", task.Day)
+}
@@ -0,0 +1,553 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultClubMaxMembers taille maximale d'un club par défaut
+const DefaultClubMaxMembers = 50
+
+// ClubMaxMembersLimit taille maximale autorisée pour un club
+const ClubMaxMembersLimit = 200
+
+// Modes de classement des clubs
+const (
+	ClubLeaderboardModeTotal   = "total"
+	ClubLeaderboardModeAverage = "average"
+)
+
+var (
+	ErrClubNotFound     = errors.New("club introuvable")
+	ErrClubFull         = errors.New("le club est complet")
+	ErrNotClubMember    = errors.New("l'utilisateur n'est pas membre du club")
+	ErrClubOwnerLeaving = errors.New("le propriétaire ne peut pas quitter un club qui a encore des membres")
+)
+
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// GenerateClubInviteCode génère un code d'invitation lisible (sans caractères ambigus)
+func GenerateClubInviteCode() (string, error) {
+	code := make([]byte, 8)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(inviteCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+const clubColumns = `c.id, c.name, c.description, c.avatar, c.invite_code, c.max_members, c.member_count,
+	c.created_by, c.updated_by, c.created_at, c.updated_at`
+
+func scanClub(row pgx.Row) (*model.Club, error) {
+	var club model.Club
+	var description, avatar, createdBy, updatedBy sql.NullString
+
+	err := row.Scan(
+		&club.ID, &club.Name, &description, &avatar, &club.InviteCode, &club.MaxMembers, &club.MemberCount,
+		&createdBy, &updatedBy, &club.CreatedAt, &club.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	club.Description = NullStringToPointer(description)
+	club.Avatar = NullStringToPointer(avatar)
+	club.CreatedBy = NullStringToPointer(createdBy)
+	club.UpdatedBy = NullStringToPointer(updatedBy)
+
+	return &club, nil
+}
+
+// CreateClub crée un club dont l'utilisateur devient propriétaire
+func CreateClub(ctx context.Context, club *model.Club, ownerID string) (*model.Club, error) {
+	if club.MaxMembers <= 0 {
+		club.MaxMembers = DefaultClubMaxMembers
+	}
+	if club.MaxMembers > ClubMaxMembersLimit {
+		return nil, fmt.Errorf("un club ne peut pas dépasser %d membres", ClubMaxMembersLimit)
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	inviteCode, err := GenerateClubInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := scanClub(tx.QueryRow(ctx, `
+		INSERT INTO clubs AS c (name, description, avatar, invite_code, max_members, member_count, created_by, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, 1, $6, NOW(), NOW())
+		RETURNING `+clubColumns,
+		club.Name, club.Description, club.Avatar, inviteCode, club.MaxMembers, ownerID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("impossible de créer le club: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO club_members(club_id, user_id, role, joined_at)
+		VALUES($1, $2, $3, NOW())
+	`, created.ID, ownerID, model.ClubRoleOwner)
+	if err != nil {
+		return nil, fmt.Errorf("impossible d'ajouter le propriétaire: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	role := model.ClubRoleOwner
+	created.UserRole = &role
+	return created, nil
+}
+
+// GetClubMemberRole retourne le rôle d'un utilisateur dans un club ("" s'il n'est pas membre)
+func GetClubMemberRole(ctx context.Context, clubID, userID string) (string, error) {
+	var role string
+	err := database.DB.QueryRow(ctx,
+		`SELECT role FROM club_members WHERE club_id = $1 AND user_id = $2`,
+		clubID, userID,
+	).Scan(&role)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// GetClub récupère un club ; le code d'invitation n'est renvoyé qu'aux membres
+func GetClub(ctx context.Context, clubID string, userID *string) (*model.Club, error) {
+	club, err := scanClub(database.DB.QueryRow(ctx,
+		`SELECT `+clubColumns+` FROM clubs c WHERE c.id = $1 AND c.deleted_at IS NULL`,
+		clubID,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrClubNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	club.InviteCode = ""
+	if userID != nil {
+		role, err := GetClubMemberRole(ctx, clubID, *userID)
+		if err != nil {
+			return nil, err
+		}
+		if role != "" {
+			club.UserRole = &role
+			err = database.DB.QueryRow(ctx, `SELECT invite_code FROM clubs WHERE id = $1`, clubID).Scan(&club.InviteCode)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return club, nil
+}
+
+// GetUserClubs récupère les clubs dont l'utilisateur est membre
+func GetUserClubs(ctx context.Context, userID string) ([]model.Club, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT `+clubColumns+`, cm.role
+		FROM clubs c
+		INNER JOIN club_members cm ON cm.club_id = c.id
+		WHERE cm.user_id = $1 AND c.deleted_at IS NULL
+		ORDER BY cm.joined_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clubs := []model.Club{}
+	for rows.Next() {
+		var club model.Club
+		var description, avatar, createdBy, updatedBy sql.NullString
+		var role string
+		if err := rows.Scan(
+			&club.ID, &club.Name, &description, &avatar, &club.InviteCode, &club.MaxMembers, &club.MemberCount,
+			&createdBy, &updatedBy, &club.CreatedAt, &club.UpdatedAt, &role,
+		); err != nil {
+			return nil, err
+		}
+		club.Description = NullStringToPointer(description)
+		club.Avatar = NullStringToPointer(avatar)
+		club.CreatedBy = NullStringToPointer(createdBy)
+		club.UpdatedBy = NullStringToPointer(updatedBy)
+		club.UserRole = &role
+		clubs = append(clubs, club)
+	}
+
+	return clubs, rows.Err()
+}
+
+// JoinClubByInviteCode ajoute l'utilisateur au club correspondant au code d'invitation
+func JoinClubByInviteCode(ctx context.Context, inviteCode, userID string) (*model.Club, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	club, err := scanClub(tx.QueryRow(ctx,
+		`SELECT `+clubColumns+` FROM clubs c WHERE c.invite_code = $1 AND c.deleted_at IS NULL FOR UPDATE`,
+		inviteCode,
+	))
+	if err == pgx.ErrNoRows {
+		return nil, ErrClubNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.Exec(ctx, `
+		INSERT INTO club_members(club_id, user_id, role, joined_at)
+		SELECT $1, $2, $3, NOW()
+		WHERE (SELECT member_count FROM clubs WHERE id = $1) < (SELECT max_members FROM clubs WHERE id = $1)
+		ON CONFLICT (club_id, user_id) DO NOTHING
+	`, club.ID, userID, model.ClubRoleMember)
+	if err != nil {
+		return nil, fmt.Errorf("impossible de rejoindre le club: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		role, err := GetClubMemberRole(ctx, club.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if role == "" {
+			return nil, ErrClubFull
+		}
+		// Déjà membre
+		club.UserRole = &role
+		return club, nil
+	}
+
+	err = tx.QueryRow(ctx,
+		`UPDATE clubs SET member_count = member_count + 1, updated_at = NOW() WHERE id = $1 RETURNING member_count`,
+		club.ID,
+	).Scan(&club.MemberCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	role := model.ClubRoleMember
+	club.UserRole = &role
+	return club, nil
+}
+
+// RemoveClubMember retire un membre du club. Le propriétaire ne peut partir que s'il est seul,
+// auquel cas le club est supprimé.
+func RemoveClubMember(ctx context.Context, clubID, userID string) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var memberCount int
+	err = tx.QueryRow(ctx,
+		`SELECT member_count FROM clubs WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		clubID,
+	).Scan(&memberCount)
+	if err == pgx.ErrNoRows {
+		return ErrClubNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var role string
+	err = tx.QueryRow(ctx,
+		`SELECT role FROM club_members WHERE club_id = $1 AND user_id = $2`,
+		clubID, userID,
+	).Scan(&role)
+	if err == pgx.ErrNoRows {
+		return ErrNotClubMember
+	}
+	if err != nil {
+		return err
+	}
+
+	if role == model.ClubRoleOwner && memberCount > 1 {
+		return ErrClubOwnerLeaving
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM club_members WHERE club_id = $1 AND user_id = $2`, clubID, userID)
+	if err != nil {
+		return err
+	}
+
+	if role == model.ClubRoleOwner {
+		_, err = tx.Exec(ctx,
+			`UPDATE clubs SET member_count = 0, deleted_at = NOW(), updated_at = NOW() WHERE id = $1`,
+			clubID,
+		)
+	} else {
+		_, err = tx.Exec(ctx,
+			`UPDATE clubs SET member_count = member_count - 1, updated_at = NOW() WHERE id = $1`,
+			clubID,
+		)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetClubMembers récupère les membres d'un club
+func GetClubMembers(ctx context.Context, clubID string) ([]model.ClubMember, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT cm.user_id, u.name, u.avatar, cm.role, cm.joined_at
+		FROM club_members cm
+		INNER JOIN users u ON u.id = cm.user_id
+		WHERE cm.club_id = $1 AND u.deleted_at IS NULL
+		ORDER BY cm.role = 'owner' DESC, cm.joined_at ASC
+	`, clubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []model.ClubMember{}
+	for rows.Next() {
+		var member model.ClubMember
+		var avatar sql.NullString
+		if err := rows.Scan(&member.UserID, &member.UserName, &avatar, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		member.Avatar = NullStringToPointer(avatar)
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// GetClubMemberLeaderboard classe les membres d'un club par répétitions depuis startDate
+func GetClubMemberLeaderboard(ctx context.Context, clubID string, startDate time.Time, limit int) ([]model.LeaderboardEntry, error) {
+	rows, err := database.DB.Query(ctx, `
+		WITH member_stats AS (
+			SELECT
+				cm.user_id,
+				COALESCE(SUM(ws.total_reps), 0) as total_reps,
+				COUNT(ws.id) as total_sessions,
+				COALESCE(MAX(ws.total_reps), 0) as best_session_reps
+			FROM club_members cm
+			LEFT JOIN workout_sessions ws ON ws.user_id = cm.user_id
+				AND ws.completed = TRUE
+				AND ws.start_time >= $2
+				AND `+ExerciseSessionCondition(4)+`
+			WHERE cm.club_id = $1
+			GROUP BY cm.user_id
+		)
+		SELECT
+			ms.user_id,
+			u.name,
+			u.avatar,
+			RANK() OVER (ORDER BY ms.total_reps DESC)::int as rank,
+			ms.total_reps::int,
+			0::float as total_calories,
+			ms.total_sessions::int,
+			ms.best_session_reps::int,
+			COALESCE(usi.length, 0) as current_streak
+		FROM member_stats ms
+		INNER JOIN users u ON u.id = ms.user_id
+		LEFT JOIN user_streak_islands usi ON usi.user_id = ms.user_id AND usi.is_current
+		WHERE u.deleted_at IS NULL
+		ORDER BY rank ASC, u.name ASC
+		LIMIT $3
+	`, clubID, startDate, limit, model.ExerciseFamilyPushUp)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaderboard := []model.LeaderboardEntry{}
+	for rows.Next() {
+		var entry model.LeaderboardEntry
+		if err := rows.Scan(
+			&entry.UserID, &entry.UserName, &entry.Avatar,
+			&entry.Rank, &entry.Score, &entry.TotalCalories,
+			&entry.TotalSessions, &entry.BestSessionReps, &entry.CurrentStreak,
+		); err != nil {
+			return nil, err
+		}
+		leaderboard = append(leaderboard, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := AttachLeaderboardBadges(ctx, leaderboard); err != nil {
+		return nil, err
+	}
+
+	return leaderboard, nil
+}
+
+// GetClubsLeaderboard classe les clubs par répétitions cumulées ou moyennes par membre depuis startDate
+func GetClubsLeaderboard(ctx context.Context, startDate time.Time, mode string, limit int) ([]model.ClubLeaderboardEntry, error) {
+	scoreExpr := "cs.total_reps::float"
+	if mode == ClubLeaderboardModeAverage {
+		scoreExpr = "cs.total_reps::float / GREATEST(c.member_count, 1)"
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		WITH club_stats AS (
+			SELECT
+				cm.club_id,
+				COALESCE(SUM(ws.total_reps), 0) as total_reps,
+				COUNT(ws.id) as total_sessions,
+				COUNT(DISTINCT ws.user_id) as active_members
+			FROM club_members cm
+			LEFT JOIN workout_sessions ws ON ws.user_id = cm.user_id
+				AND ws.completed = TRUE
+				AND ws.start_time >= $1
+				AND `+ExerciseSessionCondition(3)+`
+			GROUP BY cm.club_id
+		)
+		SELECT
+			c.id,
+			c.name,
+			c.avatar,
+			RANK() OVER (ORDER BY `+scoreExpr+` DESC)::int as rank,
+			`+scoreExpr+` as score,
+			cs.total_reps::int,
+			cs.total_reps::float / GREATEST(c.member_count, 1) as average_reps,
+			c.member_count,
+			cs.active_members::int,
+			cs.total_sessions::int
+		FROM club_stats cs
+		INNER JOIN clubs c ON c.id = cs.club_id
+		WHERE c.deleted_at IS NULL
+		ORDER BY rank ASC, c.name ASC
+		LIMIT $2
+	`, startDate, limit, model.ExerciseFamilyPushUp)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaderboard := []model.ClubLeaderboardEntry{}
+	for rows.Next() {
+		var entry model.ClubLeaderboardEntry
+		if err := rows.Scan(
+			&entry.ClubID, &entry.ClubName, &entry.Avatar, &entry.Rank, &entry.Score,
+			&entry.TotalReps, &entry.AverageReps, &entry.MemberCount, &entry.ActiveMembers, &entry.TotalSessions,
+		); err != nil {
+			return nil, err
+		}
+		leaderboard = append(leaderboard, entry)
+	}
+
+	return leaderboard, rows.Err()
+}
+
+// CreateClubChallenge crée un challenge avec un objectif de répétitions cumulé pour tout le club
+func CreateClubChallenge(ctx context.Context, challenge *model.ClubChallenge, creatorID string) error {
+	if challenge.GoalReps <= 0 {
+		return fmt.Errorf("l'objectif de répétitions doit être positif")
+	}
+	if !challenge.EndDate.After(challenge.StartDate) {
+		return fmt.Errorf("la date de fin doit être après la date de début")
+	}
+
+	challenge.CreatedBy = &creatorID
+	return database.DB.QueryRow(ctx, `
+		INSERT INTO club_challenges(club_id, title, description, goal_reps, start_date, end_date, created_by, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING id, created_at
+	`, challenge.ClubID, challenge.Title, challenge.Description, challenge.GoalReps,
+		challenge.StartDate, challenge.EndDate, creatorID,
+	).Scan(&challenge.ID, &challenge.CreatedAt)
+}
+
+// GetClubChallenges récupère les challenges d'un club avec la progression cumulée des membres
+func GetClubChallenges(ctx context.Context, clubID string) ([]model.ClubChallenge, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT
+			cc.id, cc.club_id, cc.title, cc.description, cc.goal_reps,
+			cc.start_date, cc.end_date, cc.completed_at, cc.created_by, cc.created_at,
+			COALESCE((
+				SELECT SUM(ws.total_reps)
+				FROM workout_sessions ws
+				INNER JOIN club_members cm ON cm.user_id = ws.user_id AND cm.club_id = cc.club_id
+				WHERE ws.completed = TRUE
+				AND ws.start_time >= cc.start_date
+				AND ws.start_time < cc.end_date
+				AND `+ExerciseSessionCondition(2)+`
+			), 0)::int as current_reps
+		FROM club_challenges cc
+		WHERE cc.club_id = $1 AND cc.deleted_at IS NULL
+		ORDER BY cc.end_date DESC
+	`, clubID, model.ExerciseFamilyPushUp)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	challenges := []model.ClubChallenge{}
+	for rows.Next() {
+		var challenge model.ClubChallenge
+		var description, createdBy sql.NullString
+		var completedAt sql.NullTime
+		if err := rows.Scan(
+			&challenge.ID, &challenge.ClubID, &challenge.Title, &description, &challenge.GoalReps,
+			&challenge.StartDate, &challenge.EndDate, &completedAt, &createdBy, &challenge.CreatedAt,
+			&challenge.CurrentReps,
+		); err != nil {
+			return nil, err
+		}
+		challenge.Description = NullStringToPointer(description)
+		challenge.CreatedBy = NullStringToPointer(createdBy)
+		challenge.CompletedAt = NullTimeToPointer(completedAt)
+		challenge.Progress = challenge.CurrentReps * 100 / challenge.GoalReps
+		if challenge.Progress > 100 {
+			challenge.Progress = 100
+		}
+		challenges = append(challenges, challenge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Marquer comme atteints les objectifs remplis
+	for i := range challenges {
+		if challenges[i].CompletedAt == nil && challenges[i].CurrentReps >= challenges[i].GoalReps {
+			var completedAt time.Time
+			err := database.DB.QueryRow(ctx,
+				`UPDATE club_challenges SET completed_at = NOW(), updated_at = NOW()
+				 WHERE id = $1 AND completed_at IS NULL
+				 RETURNING completed_at`,
+				challenges[i].ID,
+			).Scan(&completedAt)
+			if err == nil {
+				challenges[i].CompletedAt = &completedAt
+			}
+		}
+	}
+
+	return challenges, nil
+}
@@ -0,0 +1,542 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DuelInviteTTL délai de réponse à une invitation de duel
+const DuelInviteTTL = 48 * time.Hour
+
+// DefaultDuelDurationHours durée par défaut d'un duel
+const DefaultDuelDurationHours = 24
+
+// MaxDuelDurationHours durée maximale d'un duel (7 jours)
+const MaxDuelDurationHours = 168
+
+// DefaultDuelFirstToTarget objectif par défaut d'un duel first_to
+const DefaultDuelFirstToTarget = 500
+
+// DuelWinnerPoints points attribués au vainqueur d'un duel
+const DuelWinnerPoints = 50
+
+// Côtés d'un duel
+const (
+	DuelSideChallenger = "challenger"
+	DuelSideOpponent   = "opponent"
+	DuelSideDraw       = "draw"
+)
+
+var (
+	ErrDuelNotFound       = errors.New("duel introuvable")
+	ErrDuelNotParticipant = errors.New("l'utilisateur ne participe pas à ce duel")
+	ErrDuelNotPending     = errors.New("le duel n'est plus en attente de réponse")
+	ErrDuelAlreadyPending = errors.New("un duel est déjà en cours avec cet utilisateur")
+)
+
+// ValidateDuel vérifie et complète les paramètres d'un duel à créer
+func ValidateDuel(duel *model.Duel) error {
+	switch duel.Type {
+	case model.DuelTypeMostReps, model.DuelTypeBestSet:
+		duel.TargetReps = nil
+	case model.DuelTypeFirstTo:
+		if duel.TargetReps == nil {
+			target := DefaultDuelFirstToTarget
+			duel.TargetReps = &target
+		}
+		if *duel.TargetReps <= 0 {
+			return fmt.Errorf("targetReps doit être positif")
+		}
+	default:
+		return fmt.Errorf("type de duel invalide (most_reps, first_to ou best_set)")
+	}
+
+	if duel.DurationHours == 0 {
+		duel.DurationHours = DefaultDuelDurationHours
+	}
+	if duel.DurationHours < 1 || duel.DurationHours > MaxDuelDurationHours {
+		return fmt.Errorf("la durée doit être comprise entre 1 et %d heures", MaxDuelDurationHours)
+	}
+
+	return nil
+}
+
+// ResolveDuel calcule les scores et le vainqueur d'un duel (sans accès base de données)
+func ResolveDuel(duelType string, targetReps int, endsAt, now time.Time, challenger, opponent []model.DuelSessionScore) model.DuelOutcome {
+	var outcome model.DuelOutcome
+
+	score := func(sessions []model.DuelSessionScore) (int, *time.Time) {
+		sorted := make([]model.DuelSessionScore, len(sessions))
+		copy(sorted, sessions)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+
+		total := 0
+		var reachedAt *time.Time
+		for _, s := range sorted {
+			if duelType == model.DuelTypeBestSet {
+				if s.BestSet > total {
+					total = s.BestSet
+				}
+				continue
+			}
+			total += s.Reps
+			if duelType == model.DuelTypeFirstTo && reachedAt == nil && total >= targetReps {
+				at := s.At
+				reachedAt = &at
+			}
+		}
+		return total, reachedAt
+	}
+
+	outcome.ChallengerScore, outcome.ChallengerReachedAt = score(challenger)
+	outcome.OpponentScore, outcome.OpponentReachedAt = score(opponent)
+
+	// first_to : le premier à atteindre l'objectif gagne immédiatement
+	if duelType == model.DuelTypeFirstTo && (outcome.ChallengerReachedAt != nil || outcome.OpponentReachedAt != nil) {
+		outcome.Finished = true
+		switch {
+		case outcome.OpponentReachedAt == nil:
+			outcome.Winner = DuelSideChallenger
+		case outcome.ChallengerReachedAt == nil:
+			outcome.Winner = DuelSideOpponent
+		case outcome.ChallengerReachedAt.Before(*outcome.OpponentReachedAt):
+			outcome.Winner = DuelSideChallenger
+		case outcome.OpponentReachedAt.Before(*outcome.ChallengerReachedAt):
+			outcome.Winner = DuelSideOpponent
+		default:
+			outcome.Winner = DuelSideDraw
+		}
+		return outcome
+	}
+
+	if now.Before(endsAt) {
+		return outcome
+	}
+
+	// Temps écoulé : le meilleur score l'emporte
+	outcome.Finished = true
+	switch {
+	case outcome.ChallengerScore > outcome.OpponentScore:
+		outcome.Winner = DuelSideChallenger
+	case outcome.OpponentScore > outcome.ChallengerScore:
+		outcome.Winner = DuelSideOpponent
+	default:
+		outcome.Winner = DuelSideDraw
+	}
+	return outcome
+}
+
+// DuelStateFor calcule l'état d'un duel du point de vue d'un utilisateur
+func DuelStateFor(duel model.Duel, userID string) string {
+	switch duel.Status {
+	case model.DuelStatusPending:
+		return model.DuelStatePending
+	case model.DuelStatusActive:
+		return model.DuelStateActive
+	case model.DuelStatusDeclined:
+		return model.DuelStateDeclined
+	case model.DuelStatusExpired:
+		return model.DuelStateExpired
+	case model.DuelStatusCompleted:
+		if duel.WinnerID == nil {
+			return model.DuelStateDraw
+		}
+		if *duel.WinnerID == userID {
+			return model.DuelStateWon
+		}
+		return model.DuelStateLost
+	}
+	return duel.Status
+}
+
+const duelColumns = `d.id, d.type, d.target_reps, d.duration_hours, d.winner_points, d.status,
+	d.challenger_id, cu.name, cu.avatar, d.challenger_score,
+	d.opponent_id, ou.name, ou.avatar, d.opponent_score,
+	d.winner_id, d.expires_at, d.starts_at, d.ends_at, d.responded_at, d.completed_at, d.created_at`
+
+const duelFrom = `FROM duels d
+	INNER JOIN users cu ON cu.id = d.challenger_id
+	INNER JOIN users ou ON ou.id = d.opponent_id`
+
+func scanDuel(row pgx.Row) (*model.Duel, error) {
+	var duel model.Duel
+	var targetReps sql.NullInt64
+	var challengerAvatar, opponentAvatar, winnerID sql.NullString
+	var startsAt, endsAt, respondedAt, completedAt sql.NullTime
+
+	err := row.Scan(
+		&duel.ID, &duel.Type, &targetReps, &duel.DurationHours, &duel.WinnerPoints, &duel.Status,
+		&duel.Challenger.UserID, &duel.Challenger.UserName, &challengerAvatar, &duel.Challenger.Score,
+		&duel.Opponent.UserID, &duel.Opponent.UserName, &opponentAvatar, &duel.Opponent.Score,
+		&winnerID, &duel.ExpiresAt, &startsAt, &endsAt, &respondedAt, &completedAt, &duel.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	duel.TargetReps = NullInt64ToPointer(targetReps)
+	duel.Challenger.Avatar = NullStringToPointer(challengerAvatar)
+	duel.Opponent.Avatar = NullStringToPointer(opponentAvatar)
+	duel.WinnerID = NullStringToPointer(winnerID)
+	duel.StartsAt = NullTimeToPointer(startsAt)
+	duel.EndsAt = NullTimeToPointer(endsAt)
+	duel.RespondedAt = NullTimeToPointer(respondedAt)
+	duel.CompletedAt = NullTimeToPointer(completedAt)
+	return &duel, nil
+}
+
+// CreateDuel crée une invitation de duel
+func CreateDuel(ctx context.Context, duel *model.Duel, challengerID, opponentID string) (*model.Duel, error) {
+	if challengerID == opponentID {
+		return nil, fmt.Errorf("impossible de se défier soi-même")
+	}
+	if err := ValidateDuel(duel); err != nil {
+		return nil, err
+	}
+
+	var opponentExists bool
+	err := database.DB.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`,
+		opponentID,
+	).Scan(&opponentExists)
+	if err != nil {
+		return nil, err
+	}
+	if !opponentExists {
+		return nil, fmt.Errorf("adversaire introuvable")
+	}
+
+	// Un seul duel en attente ou actif par paire d'utilisateurs
+	var alreadyPending bool
+	err = database.DB.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM duels
+			WHERE status IN ('pending', 'active')
+			  AND ((challenger_id = $1 AND opponent_id = $2) OR (challenger_id = $2 AND opponent_id = $1))
+		)
+	`, challengerID, opponentID).Scan(&alreadyPending)
+	if err != nil {
+		return nil, err
+	}
+	if alreadyPending {
+		return nil, ErrDuelAlreadyPending
+	}
+
+	var id string
+	err = database.DB.QueryRow(ctx, `
+		INSERT INTO duels(challenger_id, opponent_id, type, target_reps, duration_hours, winner_points, status, expires_at, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, 'pending', $7, NOW(), NOW())
+		RETURNING id
+	`, challengerID, opponentID, duel.Type, duel.TargetReps, duel.DurationHours, DuelWinnerPoints,
+		time.Now().Add(DuelInviteTTL),
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetDuel(ctx, id, challengerID)
+}
+
+// GetDuel récupère un duel avec son score en direct (réservé aux participants)
+func GetDuel(ctx context.Context, duelID, userID string) (*model.Duel, error) {
+	duel, err := scanDuel(database.DB.QueryRow(ctx, `SELECT `+duelColumns+` `+duelFrom+` WHERE d.id = $1`, duelID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrDuelNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if duel.Challenger.UserID != userID && duel.Opponent.UserID != userID {
+		return nil, ErrDuelNotParticipant
+	}
+
+	if duel.Status == model.DuelStatusActive {
+		if _, err := applyLiveDuelScores(ctx, duel, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	finishDuelView(duel, userID, time.Now())
+	return duel, nil
+}
+
+// GetUserDuels récupère les duels d'un utilisateur (filtrables par état)
+func GetUserDuels(ctx context.Context, userID, state string) ([]model.Duel, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT `+duelColumns+` `+duelFrom+`
+		WHERE d.challenger_id = $1 OR d.opponent_id = $1
+		ORDER BY d.created_at DESC
+		LIMIT 100
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	duels := []model.Duel{}
+	for rows.Next() {
+		duel, err := scanDuel(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		duels = append(duels, *duel)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	filtered := []model.Duel{}
+	for i := range duels {
+		duel := &duels[i]
+		if duel.Status == model.DuelStatusActive {
+			if _, err := applyLiveDuelScores(ctx, duel, now); err != nil {
+				return nil, err
+			}
+		}
+		finishDuelView(duel, userID, now)
+		if state == "" || duel.State == state {
+			filtered = append(filtered, *duel)
+		}
+	}
+
+	return filtered, nil
+}
+
+// finishDuelView renseigne l'état et le temps restant pour l'utilisateur
+func finishDuelView(duel *model.Duel, userID string, now time.Time) {
+	duel.State = DuelStateFor(*duel, userID)
+	if duel.Status == model.DuelStatusPending && !now.Before(duel.ExpiresAt) {
+		// Invitation expirée pas encore traitée par le job
+		duel.State = model.DuelStateExpired
+	}
+	switch duel.Status {
+	case model.DuelStatusPending:
+		duel.TimeRemainingSeconds = remainingSeconds(duel.ExpiresAt, now)
+	case model.DuelStatusActive:
+		if duel.EndsAt != nil {
+			duel.TimeRemainingSeconds = remainingSeconds(*duel.EndsAt, now)
+		}
+	}
+}
+
+func remainingSeconds(until, now time.Time) int64 {
+	remaining := int64(until.Sub(now).Seconds())
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RespondToDuel accepte ou refuse une invitation (réservé à l'adversaire invité)
+func RespondToDuel(ctx context.Context, duelID, userID string, accept bool) (*model.Duel, error) {
+	var res pgconn.CommandTag
+	var err error
+	if accept {
+		res, err = database.DB.Exec(ctx, `
+			UPDATE duels
+			SET status = 'active', responded_at = NOW(), starts_at = NOW(),
+				ends_at = NOW() + make_interval(hours => duration_hours), updated_at = NOW()
+			WHERE id = $1 AND opponent_id = $2 AND status = 'pending' AND expires_at > NOW()
+		`, duelID, userID)
+	} else {
+		res, err = database.DB.Exec(ctx, `
+			UPDATE duels
+			SET status = 'declined', responded_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND opponent_id = $2 AND status = 'pending' AND expires_at > NOW()
+		`, duelID, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if res.RowsAffected() == 0 {
+		duel, err := GetDuel(ctx, duelID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if duel.Opponent.UserID != userID {
+			return nil, ErrDuelNotParticipant
+		}
+		return nil, ErrDuelNotPending
+	}
+
+	return GetDuel(ctx, duelID, userID)
+}
+
+// loadDuelSessions charge les séances de pompes d'un participant pendant la fenêtre du duel
+func loadDuelSessions(ctx context.Context, userID string, from, to time.Time) ([]model.DuelSessionScore, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT
+			COALESCE(ws.total_reps, 0),
+			COALESCE((SELECT MAX(sr.completed_reps) FROM set_results sr WHERE sr.session_id = ws.id), ws.total_reps, 0),
+			COALESCE(ws.end_time, ws.created_at)
+		FROM workout_sessions ws
+		WHERE ws.user_id = $1 AND ws.start_time >= $2 AND ws.start_time < $3 AND `+ExerciseSessionCondition(4)+`
+	`, userID, from, to, model.ExerciseFamilyPushUp)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []model.DuelSessionScore{}
+	for rows.Next() {
+		var s model.DuelSessionScore
+		if err := rows.Scan(&s.Reps, &s.BestSet, &s.At); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// applyLiveDuelScores calcule les scores en direct d'un duel actif
+func applyLiveDuelScores(ctx context.Context, duel *model.Duel, now time.Time) (model.DuelOutcome, error) {
+	if duel.StartsAt == nil || duel.EndsAt == nil {
+		return model.DuelOutcome{}, nil
+	}
+
+	challengerSessions, err := loadDuelSessions(ctx, duel.Challenger.UserID, *duel.StartsAt, *duel.EndsAt)
+	if err != nil {
+		return model.DuelOutcome{}, err
+	}
+	opponentSessions, err := loadDuelSessions(ctx, duel.Opponent.UserID, *duel.StartsAt, *duel.EndsAt)
+	if err != nil {
+		return model.DuelOutcome{}, err
+	}
+
+	target := 0
+	if duel.TargetReps != nil {
+		target = *duel.TargetReps
+	}
+	outcome := ResolveDuel(duel.Type, target, *duel.EndsAt, now, challengerSessions, opponentSessions)
+
+	duel.Challenger.Score = outcome.ChallengerScore
+	duel.Challenger.ReachedAt = outcome.ChallengerReachedAt
+	duel.Opponent.Score = outcome.OpponentScore
+	duel.Opponent.ReachedAt = outcome.OpponentReachedAt
+	return outcome, nil
+}
+
+// SettleDuel clôture un duel actif si son issue est connue et récompense le vainqueur.
+// Retourne true si le duel vient d'être clôturé.
+func SettleDuel(ctx context.Context, duelID string) (bool, error) {
+	duel, err := scanDuel(database.DB.QueryRow(ctx, `SELECT `+duelColumns+` `+duelFrom+` WHERE d.id = $1`, duelID))
+	if err == pgx.ErrNoRows {
+		return false, ErrDuelNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	if duel.Status != model.DuelStatusActive {
+		return false, nil
+	}
+
+	outcome, err := applyLiveDuelScores(ctx, duel, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if !outcome.Finished {
+		return false, nil
+	}
+
+	var winnerID *string
+	switch outcome.Winner {
+	case DuelSideChallenger:
+		winnerID = &duel.Challenger.UserID
+	case DuelSideOpponent:
+		winnerID = &duel.Opponent.UserID
+	}
+
+	// La condition sur le statut évite une double clôture concurrente
+	res, err := database.DB.Exec(ctx, `
+		UPDATE duels
+		SET status = 'completed', winner_id = $2, challenger_score = $3, opponent_score = $4,
+			completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'active'
+	`, duel.ID, winnerID, outcome.ChallengerScore, outcome.OpponentScore)
+	if err != nil {
+		return false, err
+	}
+	if res.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	if winnerID != nil && duel.WinnerPoints > 0 {
+		if err := IncrementUserScore(ctx, *winnerID, duel.WinnerPoints); err != nil {
+			logger.Error("Impossible d'attribuer les points du duel %s à %s: %v", duel.ID, *winnerID, err)
+		}
+	}
+
+	return true, nil
+}
+
+// SettleUserDuels clôture les duels actifs d'un utilisateur dont l'issue est connue (ex: objectif first_to atteint)
+func SettleUserDuels(ctx context.Context, userID string) (int, error) {
+	return settleDuels(ctx, `
+		SELECT id FROM duels
+		WHERE status = 'active' AND (challenger_id = $1 OR opponent_id = $1)
+	`, userID)
+}
+
+// SettleEndedDuels clôture les duels actifs dont la durée est écoulée
+func SettleEndedDuels(ctx context.Context) (int, error) {
+	return settleDuels(ctx, `SELECT id FROM duels WHERE status = 'active' AND ends_at <= NOW()`)
+}
+
+func settleDuels(ctx context.Context, query string, args ...any) (int, error) {
+	rows, err := database.DB.Query(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for _, id := range ids {
+		done, err := SettleDuel(ctx, id)
+		if err != nil {
+			logger.Error("Impossible de clôturer le duel %s: %v", id, err)
+			continue
+		}
+		if done {
+			settled++
+		}
+	}
+	return settled, nil
+}
+
+// ExpirePendingDuels expire les invitations restées sans réponse
+func ExpirePendingDuels(ctx context.Context) (int, error) {
+	res, err := database.DB.Exec(ctx, `
+		UPDATE duels SET status = 'expired', updated_at = NOW()
+		WHERE status = 'pending' AND expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.RowsAffected()), nil
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrExerciseNotFound    = errors.New("exercice introuvable")
+	ErrExerciseProgramType = errors.New("type de programme incompatible avec un exercice mesuré en secondes")
+)
+
+// exerciseColumns colonnes d'un exercice, dans l'ordre de scanExercise
+const exerciseColumns = `
+	e.id, e.slug, e.name, e.family, e.variant, e.unit,
+	e.muscle_groups, e.difficulty_coefficient::float8, e.description`
+
+// scanExercise scanne une ligne sélectionnée avec exerciseColumns
+func scanExercise(row pgx.Row) (*model.Exercise, error) {
+	var e model.Exercise
+	err := row.Scan(
+		&e.ID, &e.Slug, &e.Name, &e.Family, &e.Variant, &e.Unit,
+		&e.MuscleGroups, &e.DifficultyCoefficient, &e.Description,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if e.MuscleGroups == nil {
+		e.MuscleGroups = []string{}
+	}
+	return &e, nil
+}
+
+// GetExercises liste le catalogue, éventuellement restreint à une famille
+func GetExercises(ctx context.Context, family string) ([]model.Exercise, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT`+exerciseColumns+`
+		FROM exercises e
+		WHERE $1 = '' OR e.family = $1
+		ORDER BY e.family, e.difficulty_coefficient, e.name
+	`, family)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []model.Exercise{}
+	for rows.Next() {
+		e, err := scanExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, *e)
+	}
+	return exercises, rows.Err()
+}
+
+// GetExercise récupère un exercice par son ID ou son slug
+func GetExercise(ctx context.Context, idOrSlug string) (*model.Exercise, error) {
+	e, err := scanExercise(database.DB.QueryRow(ctx, `
+		SELECT`+exerciseColumns+`
+		FROM exercises e
+		WHERE e.id::text = $1 OR e.slug = $1
+	`, idOrSlug))
+	if err == pgx.ErrNoRows {
+		return nil, ErrExerciseNotFound
+	}
+	return e, err
+}
+
+// ResolveExerciseFilter interprète le paramètre "exercise" des statistiques et classements :
+// un slug ou une famille, la famille des pompes par défaut (compatibilité des anciens clients)
+func ResolveExerciseFilter(ctx context.Context, value string) (model.ExerciseFilter, error) {
+	if value == "" {
+		value = model.ExerciseFamilyPushUp
+	}
+
+	filter := model.ExerciseFilter{Value: value}
+	err := database.DB.QueryRow(ctx, `
+		SELECT unit FROM exercises WHERE slug = $1 OR family = $1 LIMIT 1
+	`, value).Scan(&filter.Unit)
+	if err == pgx.ErrNoRows {
+		return filter, ErrExerciseNotFound
+	}
+	return filter, err
+}
+
+// ExerciseSessionCondition condition SQL restreignant les séances (alias ws) au filtre d'exercice passé en $n
+func ExerciseSessionCondition(n int) string {
+	return fmt.Sprintf("ws.exercise_id IN (SELECT id FROM exercises WHERE slug = $%d OR family = $%d)", n, n)
+}
+
+// CheckExerciseProgramType vérifie qu'un type de programme convient à l'unité de l'exercice :
+// un exercice tenu (secondes) n'a pas de répétitions à cibler
+func CheckExerciseProgramType(exercise *model.Exercise, programType model.ProgramType) error {
+	if exercise.Unit != model.ExerciseUnitSeconds {
+		return nil
+	}
+	switch programType {
+	case model.ProgramTypeFreeMode, model.ProgramTypeMaxTime:
+		return nil
+	default:
+		return ErrExerciseProgramType
+	}
+}
+
+// ResolveProgramExercise renseigne l'exercice d'un programme : celui demandé s'il existe,
+// sinon la variante de pompes correspondant au programme (pompes classiques à défaut)
+func ResolveProgramExercise(ctx context.Context, program *model.WorkoutProgram) error {
+	var exercise *model.Exercise
+	var err error
+
+	if program.ExerciseID != nil && *program.ExerciseID != "" {
+		exercise, err = GetExercise(ctx, *program.ExerciseID)
+	} else {
+		exercise, err = scanExercise(database.DB.QueryRow(ctx, `
+			SELECT`+exerciseColumns+`
+			FROM exercises e
+			WHERE e.family = $1 AND (e.variant = $2 OR e.slug = 'push-up-standard')
+			ORDER BY (e.variant = $2) DESC
+			LIMIT 1
+		`, model.ExerciseFamilyPushUp, program.Variant))
+		if err == pgx.ErrNoRows {
+			err = ErrExerciseNotFound
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := CheckExerciseProgramType(exercise, program.Type); err != nil {
+		return err
+	}
+
+	program.ExerciseID = &exercise.ID
+	program.Exercise = exercise
+	return nil
+}
+
+// AttachProgramExercises renseigne l'exercice de chaque programme
+func AttachProgramExercises(ctx context.Context, programs []model.WorkoutProgram) error {
+	if len(programs) == 0 {
+		return nil
+	}
+
+	programIDs := make([]string, 0, len(programs))
+	for _, p := range programs {
+		programIDs = append(programIDs, p.ID)
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT wp.id,`+exerciseColumns+`
+		FROM workout_programs wp
+		JOIN exercises e ON e.id = wp.exercise_id
+		WHERE wp.id = ANY($1::uuid[])
+	`, programIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	exercisesByProgram := map[string]*model.Exercise{}
+	for rows.Next() {
+		var programID string
+		var e model.Exercise
+		if err := rows.Scan(
+			&programID,
+			&e.ID, &e.Slug, &e.Name, &e.Family, &e.Variant, &e.Unit,
+			&e.MuscleGroups, &e.DifficultyCoefficient, &e.Description,
+		); err != nil {
+			return err
+		}
+		exercisesByProgram[programID] = &e
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range programs {
+		if e, ok := exercisesByProgram[programs[i].ID]; ok {
+			programs[i].ExerciseID = &e.ID
+			programs[i].Exercise = e
+		}
+	}
+	return nil
+}
+
+// AttachProgramExercise renseigne l'exercice d'un programme
+func AttachProgramExercise(ctx context.Context, program *model.WorkoutProgram) error {
+	programs := []model.WorkoutProgram{*program}
+	if err := AttachProgramExercises(ctx, programs); err != nil {
+		return err
+	}
+	program.ExerciseID = programs[0].ExerciseID
+	program.Exercise = programs[0].Exercise
+	return nil
+}
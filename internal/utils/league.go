@@ -0,0 +1,418 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// LeagueCohortSize nombre maximum d'utilisateurs dans un groupe de ligue
+const LeagueCohortSize = 30
+
+// LeaguePromotionCount nombre d'utilisateurs promus en fin de semaine
+const LeaguePromotionCount = 7
+
+// LeagueRelegationCount nombre d'utilisateurs relégués en fin de semaine
+const LeagueRelegationCount = 5
+
+// Zones et résultats de ligue
+const (
+	LeagueZonePromotion  = "promotion"
+	LeagueZoneSafe       = "safe"
+	LeagueZoneRelegation = "relegation"
+
+	LeagueOutcomePromoted  = "promoted"
+	LeagueOutcomeRelegated = "relegated"
+	LeagueOutcomeStayed    = "stayed"
+)
+
+// LeagueWeek retourne les bornes (lundi 00:00 UTC inclus, lundi suivant exclu) de la semaine contenant t
+func LeagueWeek(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	start := time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 0, 7)
+}
+
+// LeagueTierName retourne le nom d'un palier de ligue
+func LeagueTierName(tier int) string {
+	if tier < 0 || tier >= len(model.LeagueTierNames) {
+		return ""
+	}
+	return model.LeagueTierNames[tier]
+}
+
+// LeagueZone détermine la zone d'un rang dans un groupe de taille donnée.
+// Un utilisateur sans répétition dans la semaine ne peut pas être promu.
+func LeagueZone(rank, cohortSize, tier, weeklyReps int) string {
+	topTier := len(model.LeagueTierNames) - 1
+
+	if tier < topTier && rank <= LeaguePromotionCount && weeklyReps > 0 {
+		return LeagueZonePromotion
+	}
+	if tier > 0 && rank > LeaguePromotionCount && rank > cohortSize-LeagueRelegationCount {
+		return LeagueZoneRelegation
+	}
+	return LeagueZoneSafe
+}
+
+// EnsureLeagueMembership place l'utilisateur dans un groupe de son palier pour la semaine en cours
+// (si ce n'est pas déjà fait), après avoir clôturé ses semaines précédentes, et retourne l'identifiant du groupe
+func EnsureLeagueMembership(ctx context.Context, userID string) (string, error) {
+	weekStart, _ := LeagueWeek(time.Now())
+
+	var cohortID string
+	err := database.DB.QueryRow(ctx,
+		`SELECT cohort_id FROM league_memberships WHERE user_id = $1 AND week_start = $2`,
+		userID, weekStart,
+	).Scan(&cohortID)
+	if err == nil {
+		return cohortID, nil
+	}
+	if err != pgx.ErrNoRows {
+		return "", err
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	// Clôturer d'abord les semaines précédentes de l'utilisateur que le job n'a pas encore traitées :
+	// le palier de la nouvelle semaine tient compte de la promotion ou de la relégation
+	rows, err := tx.Query(ctx, `
+		SELECT lm.cohort_id
+		FROM league_memberships lm
+		INNER JOIN league_cohorts lc ON lc.id = lm.cohort_id
+		WHERE lm.user_id = $1 AND lm.week_start < $2 AND lc.finalized_at IS NULL
+		ORDER BY lm.week_start ASC
+	`, userID, weekStart)
+	if err != nil {
+		return "", err
+	}
+	var previousCohorts []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return "", err
+		}
+		previousCohorts = append(previousCohorts, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	for _, previousCohortID := range previousCohorts {
+		if err := finalizeLeagueCohortTx(ctx, tx, previousCohortID); err != nil {
+			return "", fmt.Errorf("impossible de clôturer le groupe de ligue %s: %w", previousCohortID, err)
+		}
+	}
+
+	var tier int
+	err = tx.QueryRow(ctx,
+		`SELECT league_tier FROM users WHERE id = $1 AND deleted_at IS NULL`,
+		userID,
+	).Scan(&tier)
+	if err != nil {
+		return "", err
+	}
+
+	// Premier groupe non complet du palier, sinon création d'un nouveau groupe
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM league_cohorts
+		WHERE tier = $1 AND week_start = $2 AND member_count < $3 AND finalized_at IS NULL
+		ORDER BY created_at ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, tier, weekStart, LeagueCohortSize).Scan(&cohortID)
+	if err == pgx.ErrNoRows {
+		err = tx.QueryRow(ctx, `
+			INSERT INTO league_cohorts(tier, week_start, member_count, created_at, updated_at)
+			VALUES($1, $2, 0, NOW(), NOW())
+			RETURNING id
+		`, tier, weekStart).Scan(&cohortID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("impossible de trouver un groupe de ligue: %w", err)
+	}
+
+	res, err := tx.Exec(ctx, `
+		INSERT INTO league_memberships(cohort_id, user_id, tier, week_start, created_at, updated_at)
+		VALUES($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (user_id, week_start) DO NOTHING
+	`, cohortID, userID, tier, weekStart)
+	if err != nil {
+		return "", fmt.Errorf("impossible d'inscrire l'utilisateur dans la ligue: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		// Inscription concurrente déjà effectuée
+		tx.Rollback(ctx)
+		err = database.DB.QueryRow(ctx,
+			`SELECT cohort_id FROM league_memberships WHERE user_id = $1 AND week_start = $2`,
+			userID, weekStart,
+		).Scan(&cohortID)
+		return cohortID, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE league_cohorts SET member_count = member_count + 1, updated_at = NOW() WHERE id = $1`,
+		cohortID,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+
+	return cohortID, nil
+}
+
+// GetLeagueStandings calcule le classement en direct d'un groupe à partir des sessions de la semaine
+func GetLeagueStandings(ctx context.Context, cohortID string, currentUserID string) ([]model.LeagueStanding, error) {
+	return loadLeagueStandings(ctx, database.DB, cohortID, currentUserID)
+}
+
+func loadLeagueStandings(ctx context.Context, db dbExecutor, cohortID string, currentUserID string) ([]model.LeagueStanding, error) {
+	rows, err := db.Query(ctx, `
+		SELECT
+			lm.user_id,
+			u.name,
+			u.avatar,
+			lm.tier,
+			COALESCE(SUM(ws.total_reps), 0)::int as weekly_reps
+		FROM league_memberships lm
+		INNER JOIN users u ON u.id = lm.user_id
+		LEFT JOIN workout_sessions ws ON ws.user_id = lm.user_id
+			AND ws.completed = TRUE
+			AND ws.start_time >= lm.week_start
+			AND ws.start_time < lm.week_start + INTERVAL '7 days'
+			AND `+ExerciseSessionCondition(2)+`
+		WHERE lm.cohort_id = $1
+		GROUP BY lm.user_id, u.name, u.avatar, lm.tier, lm.created_at
+		ORDER BY weekly_reps DESC, lm.created_at ASC
+	`, cohortID, model.ExerciseFamilyPushUp)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	standings := []model.LeagueStanding{}
+	tiers := []int{}
+	for rows.Next() {
+		var standing model.LeagueStanding
+		var avatar sql.NullString
+		var tier int
+		if err := rows.Scan(&standing.UserID, &standing.UserName, &avatar, &tier, &standing.WeeklyReps); err != nil {
+			return nil, err
+		}
+		standing.Avatar = NullStringToPointer(avatar)
+		standing.IsCurrentUser = standing.UserID == currentUserID
+		standings = append(standings, standing)
+		tiers = append(tiers, tier)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range standings {
+		standings[i].Rank = i + 1
+		standings[i].Zone = LeagueZone(i+1, len(standings), tiers[i], standings[i].WeeklyReps)
+	}
+
+	return standings, nil
+}
+
+// GetUserLeague récupère le groupe de ligue de la semaine en cours d'un utilisateur
+func GetUserLeague(ctx context.Context, userID string) (*model.League, error) {
+	cohortID, err := EnsureLeagueMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	league := &model.League{
+		CohortID:        cohortID,
+		PromotionCount:  LeaguePromotionCount,
+		RelegationCount: LeagueRelegationCount,
+	}
+
+	var weekStart time.Time
+	err = database.DB.QueryRow(ctx,
+		`SELECT tier, week_start FROM league_cohorts WHERE id = $1`,
+		cohortID,
+	).Scan(&league.Tier, &weekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	league.TierName = LeagueTierName(league.Tier)
+	league.WeekStart, league.WeekEnd = LeagueWeek(weekStart)
+	league.TimeRemainingSeconds = int64(time.Until(league.WeekEnd).Seconds())
+	if league.TimeRemainingSeconds < 0 {
+		league.TimeRemainingSeconds = 0
+	}
+
+	league.Standings, err = GetLeagueStandings(ctx, cohortID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return league, nil
+}
+
+// GetLeagueHistory récupère l'historique des semaines de ligue d'un utilisateur
+func GetLeagueHistory(ctx context.Context, userID string, limit, offset int) ([]model.LeagueHistoryEntry, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT cohort_id, week_start, tier, weekly_reps, final_rank, outcome
+		FROM league_memberships
+		WHERE user_id = $1
+		ORDER BY week_start DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []model.LeagueHistoryEntry{}
+	for rows.Next() {
+		var entry model.LeagueHistoryEntry
+		var weekStart time.Time
+		var finalRank sql.NullInt64
+		var outcome sql.NullString
+		if err := rows.Scan(&entry.CohortID, &weekStart, &entry.Tier, &entry.WeeklyReps, &finalRank, &outcome); err != nil {
+			return nil, err
+		}
+		entry.WeekStart = weekStart.Format("2006-01-02")
+		entry.TierName = LeagueTierName(entry.Tier)
+		entry.FinalRank = NullInt64ToPointer(finalRank)
+		entry.Outcome = NullStringToPointer(outcome)
+		history = append(history, entry)
+	}
+
+	return history, rows.Err()
+}
+
+// FinalizeLeagueWeeks clôture les groupes des semaines terminées : fige le classement,
+// applique promotions et relégations. Retourne le nombre de groupes clôturés.
+func FinalizeLeagueWeeks(ctx context.Context) (int, error) {
+	currentWeekStart, _ := LeagueWeek(time.Now())
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT id FROM league_cohorts
+		WHERE week_start < $1 AND finalized_at IS NULL
+		ORDER BY week_start ASC
+	`, currentWeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	var cohortIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		cohortIDs = append(cohortIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	finalized := 0
+	for _, cohortID := range cohortIDs {
+		if err := finalizeLeagueCohort(ctx, cohortID); err != nil {
+			logger.Error("Impossible de clôturer le groupe de ligue %s: %v", cohortID, err)
+			continue
+		}
+		finalized++
+	}
+
+	return finalized, nil
+}
+
+// finalizeLeagueCohort clôture un groupe dans une transaction
+func finalizeLeagueCohort(ctx context.Context, cohortID string) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := finalizeLeagueCohortTx(ctx, tx, cohortID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// finalizeLeagueCohortTx clôture un groupe dans la transaction de l'appelant : le verrou du groupe
+// est pris avant le calcul du classement pour éviter une double clôture
+func finalizeLeagueCohortTx(ctx context.Context, tx pgx.Tx, cohortID string) error {
+	var finalizedAt sql.NullTime
+	err := tx.QueryRow(ctx,
+		`SELECT finalized_at FROM league_cohorts WHERE id = $1 FOR UPDATE`,
+		cohortID,
+	).Scan(&finalizedAt)
+	if err != nil {
+		return err
+	}
+	if finalizedAt.Valid {
+		return nil
+	}
+
+	standings, err := loadLeagueStandings(ctx, tx, cohortID, "")
+	if err != nil {
+		return err
+	}
+
+	for _, standing := range standings {
+		outcome := LeagueOutcomeStayed
+		tierDelta := 0
+		switch standing.Zone {
+		case LeagueZonePromotion:
+			outcome = LeagueOutcomePromoted
+			tierDelta = 1
+		case LeagueZoneRelegation:
+			outcome = LeagueOutcomeRelegated
+			tierDelta = -1
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE league_memberships
+			SET weekly_reps = $1, final_rank = $2, outcome = $3, updated_at = NOW()
+			WHERE cohort_id = $4 AND user_id = $5
+		`, standing.WeeklyReps, standing.Rank, outcome, cohortID, standing.UserID)
+		if err != nil {
+			return err
+		}
+
+		if tierDelta != 0 {
+			_, err = tx.Exec(ctx, `
+				UPDATE users
+				SET league_tier = LEAST(GREATEST(league_tier + $1, 0), $2)
+				WHERE id = $3
+			`, tierDelta, len(model.LeagueTierNames)-1, standing.UserID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE league_cohorts SET finalized_at = NOW(), updated_at = NOW() WHERE id = $1`,
+		cohortID,
+	)
+	return err
+}
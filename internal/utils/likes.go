@@ -6,6 +6,7 @@ import (
 
 	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
 	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
 )
 
 // AddLike ajoute un like pour une entité
@@ -113,19 +114,34 @@ func GetUserLikes(ctx context.Context, userID string, entityType model.EntityTyp
 	return entityIDs, nil
 }
 
-// GetTopLikedEntities récupère les entités les plus likées d'un type donné
-func GetTopLikedEntities(ctx context.Context, entityType model.EntityType, limit int) ([]model.LikesCount, error) {
-	rows, err := database.DB.Query(ctx, `
-		SELECT entity_type, entity_id, COUNT(*) as total_likes
-		FROM likes
-		WHERE entity_type = $1
-		GROUP BY entity_type, entity_id
-		ORDER BY total_likes DESC
-		LIMIT $2
-	`, entityType, limit)
+// topLikedSort tri de GetTopLikedEntities : les entités les plus likées d'abord
+var topLikedSort = pagination.Sort{
+	Name:    "likes",
+	Columns: []pagination.Column{{Expr: "t.total_likes", Cast: "int"}},
+	Desc:    true,
+}
 
+// GetTopLikedEntities récupère les entités les plus likées d'un type donné (paginé)
+func GetTopLikedEntities(ctx context.Context, entityType model.EntityType, page pagination.Params) (pagination.Page[model.LikesCount], error) {
+	sqlQuery := `
+		SELECT entity_type, entity_id, total_likes
+		FROM (
+			SELECT entity_type, entity_id, COUNT(*)::int AS total_likes
+			FROM likes
+			WHERE entity_type = $1
+			GROUP BY entity_type, entity_id
+		) t
+		WHERE TRUE
+	`
+
+	sqlQuery, args, err := page.Apply(sqlQuery, []interface{}{entityType}, 2, topLikedSort, "t.entity_id::uuid")
 	if err != nil {
-		return nil, err
+		return pagination.Page[model.LikesCount]{}, err
+	}
+
+	rows, err := database.DB.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return pagination.Page[model.LikesCount]{}, err
 	}
 	defer rows.Close()
 
@@ -133,10 +149,15 @@ func GetTopLikedEntities(ctx context.Context, entityType model.EntityType, limit
 	for rows.Next() {
 		var lc model.LikesCount
 		if err := rows.Scan(&lc.EntityType, &lc.EntityID, &lc.TotalLikes); err != nil {
-			return nil, err
+			return pagination.Page[model.LikesCount]{}, err
 		}
 		results = append(results, lc)
 	}
+	if err := rows.Err(); err != nil {
+		return pagination.Page[model.LikesCount]{}, err
+	}
 
-	return results, nil
+	return pagination.NewPage(results, page, topLikedSort, func(lc model.LikesCount) ([]string, string) {
+		return []string{pagination.IntKey(lc.TotalLikes)}, lc.EntityID
+	}), nil
 }
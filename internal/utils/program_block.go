@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
+)
+
+// EstimatedSecondsPerRep durée moyenne d'une répétition pour l'estimation d'un circuit
+const EstimatedSecondsPerRep = 2
+
+// Tolérances de complétion d'un bloc de circuit
+const (
+	circuitRepsTolerance = 0.9
+	circuitTimeTolerance = 0.95
+)
+
+// EstimateCircuitDuration estime la durée totale d'un circuit en secondes :
+// blocs de chaque tour, plus le repos entre les tours
+func EstimateCircuitDuration(blocks []model.ProgramBlock, rounds, restBetweenRounds *int) int {
+	perRound := 0
+	for _, b := range blocks {
+		switch b.Kind {
+		case model.BlockKindReps:
+			if b.TargetReps != nil {
+				perRound += *b.TargetReps * EstimatedSecondsPerRep
+			}
+		case model.BlockKindTime, model.BlockKindRest:
+			if b.Duration != nil {
+				perRound += *b.Duration
+			}
+		}
+	}
+
+	n := 1
+	if rounds != nil && *rounds > 1 {
+		n = *rounds
+	}
+	total := perRound * n
+	if restBetweenRounds != nil {
+		total += *restBetweenRounds * (n - 1)
+	}
+	return total
+}
+
+// CircuitCompleted indique si chaque bloc d'exercice de chaque tour a atteint sa cible
+func CircuitCompleted(program *model.WorkoutProgram, results []model.SessionBlockResult) bool {
+	if len(program.Blocks) == 0 || program.Sets == nil {
+		return false
+	}
+
+	byBlock := make(map[[2]int]model.SessionBlockResult, len(results))
+	for _, res := range results {
+		byBlock[[2]int{res.Position, res.Round}] = res
+	}
+
+	for round := 1; round <= *program.Sets; round++ {
+		for _, b := range program.Blocks {
+			if b.Kind == model.BlockKindRest {
+				continue
+			}
+			res, ok := byBlock[[2]int{b.Position, round}]
+			if !ok {
+				return false
+			}
+			switch b.Kind {
+			case model.BlockKindReps:
+				if b.TargetReps == nil || float64(res.Reps) < float64(*b.TargetReps)*circuitRepsTolerance {
+					return false
+				}
+			case model.BlockKindTime:
+				if b.Duration == nil || float64(res.Duration) < float64(*b.Duration)*circuitTimeTolerance {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// CircuitTotalReps total des répétitions des blocs REPS d'une séance de circuit
+func CircuitTotalReps(blocks []model.ProgramBlock, results []model.SessionBlockResult) int {
+	repsBlocks := map[int]bool{}
+	for _, b := range blocks {
+		if b.Kind == model.BlockKindReps {
+			repsBlocks[b.Position] = true
+		}
+	}
+
+	total := 0
+	for _, res := range results {
+		if repsBlocks[res.Position] {
+			total += res.Reps
+		}
+	}
+	return total
+}
+
+// ResolveProgramBlockExercises vérifie les exercices des blocs : un bloc REPS compte des
+// répétitions, un bloc TIME une durée (erreurs de validation sur blocks[i].exerciseId)
+func ResolveProgramBlockExercises(ctx context.Context, blocks []model.ProgramBlock) error {
+	v := validation.New()
+	for i, b := range blocks {
+		if b.ExerciseID == nil || b.Kind == model.BlockKindRest {
+			continue
+		}
+		field := fmt.Sprintf("blocks[%d].exerciseId", i)
+
+		exercise, err := GetExercise(ctx, *b.ExerciseID)
+		if errors.Is(err, ErrExerciseNotFound) {
+			v.Add(field, err.Error())
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		blocks[i].ExerciseID = &exercise.ID
+		switch b.Kind {
+		case model.BlockKindReps:
+			v.Check(exercise.Unit == model.ExerciseUnitReps, field, "un bloc REPS requiert un exercice en répétitions")
+		case model.BlockKindTime:
+			v.Check(exercise.Unit == model.ExerciseUnitSeconds, field, "un bloc TIME requiert un exercice mesuré en secondes")
+		}
+	}
+	return v.Err()
+}
+
+// LoadProgramBlocks charge les blocs d'un programme dans l'ordre
+func LoadProgramBlocks(ctx context.Context, programID string) ([]model.ProgramBlock, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT id, position, kind, variant, exercise_id, target_reps, duration
+		FROM program_blocks
+		WHERE program_id = $1
+		ORDER BY position
+	`, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := []model.ProgramBlock{}
+	for rows.Next() {
+		var b model.ProgramBlock
+		if err := rows.Scan(&b.ID, &b.Position, &b.Kind, &b.Variant, &b.ExerciseID, &b.TargetReps, &b.Duration); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, rows.Err()
+}
+
+// SaveProgramBlocks remplace les blocs d'un programme (à appeler dans la transaction d'écriture).
+// Les positions suivent l'ordre de la liste ; un bloc REPS sans exercice prend la variante de pompes.
+func SaveProgramBlocks(ctx context.Context, db dbExecutor, programID string, blocks []model.ProgramBlock) ([]model.ProgramBlock, error) {
+	if _, err := db.Exec(ctx, `DELETE FROM program_blocks WHERE program_id = $1`, programID); err != nil {
+		return nil, err
+	}
+
+	saved := make([]model.ProgramBlock, 0, len(blocks))
+	for i, b := range blocks {
+		b.Position = i + 1
+		err := db.QueryRow(ctx, `
+			INSERT INTO program_blocks (program_id, position, kind, variant, exercise_id, target_reps, duration)
+			VALUES (
+				$1, $2, $3, $4,
+				CASE WHEN $3 = 'REPS' THEN COALESCE($5::uuid, (
+					SELECT id FROM exercises WHERE family = 'push-up' AND variant = $4
+				)) ELSE $5::uuid END,
+				$6, $7
+			)
+			RETURNING id, exercise_id
+		`, programID, b.Position, b.Kind, b.Variant, b.ExerciseID, b.TargetReps, b.Duration).Scan(&b.ID, &b.ExerciseID)
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, b)
+	}
+	return saved, nil
+}
+
+// LoadCircuitStructure renseigne les blocs et la durée estimée d'un programme CIRCUIT
+func LoadCircuitStructure(ctx context.Context, program *model.WorkoutProgram) error {
+	if program.Type != model.ProgramTypeCircuit {
+		return nil
+	}
+
+	blocks, err := LoadProgramBlocks(ctx, program.ID)
+	if err != nil {
+		return err
+	}
+	program.Blocks = blocks
+
+	estimated := EstimateCircuitDuration(blocks, program.Sets, program.RestBetweenSets)
+	program.EstimatedDuration = &estimated
+	return nil
+}
+
+// SaveSessionBlockResults enregistre les résultats par bloc d'une séance (dans la transaction de la séance)
+func SaveSessionBlockResults(ctx context.Context, db dbExecutor, sessionID string, results []model.SessionBlockResult) error {
+	for _, res := range results {
+		_, err := db.Exec(ctx, `
+			INSERT INTO workout_session_blocks (session_id, position, round, reps, duration)
+			VALUES ($1, $2, $3, $4, $5)
+		`, sessionID, res.Position, res.Round, res.Reps, res.Duration)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadSessionBlockResults charge les résultats par bloc d'une séance
+func LoadSessionBlockResults(ctx context.Context, sessionID string) ([]model.SessionBlockResult, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT position, round, reps, duration
+		FROM workout_session_blocks
+		WHERE session_id = $1
+		ORDER BY round, position
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.SessionBlockResult{}
+	for rows.Next() {
+		var res model.SessionBlockResult
+		if err := rows.Scan(&res.Position, &res.Round, &res.Reps, &res.Duration); err != nil {
+			return nil, err
+		}
+		results = append(results, res)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	"github.com/jackc/pgx/v5"
+)
+
+// Actions possibles sur un programme
+type ProgramAction string
+
+const (
+	ProgramActionCreate    ProgramAction = "create"
+	ProgramActionUpdate    ProgramAction = "update"
+	ProgramActionDelete    ProgramAction = "delete"
+	ProgramActionDuplicate ProgramAction = "duplicate"
+	ProgramActionFeature   ProgramAction = "feature" // mise en avant ou statut officiel
+)
+
+var (
+	ErrProgramNotFound  = errors.New("programme introuvable")
+	ErrProgramForbidden = errors.New("action non autorisée sur ce programme")
+)
+
+// ProgramActor utilisateur qui agit sur un programme
+type ProgramActor struct {
+	UserID  string
+	IsAdmin bool
+}
+
+// ProgramOwnership propriété d'un programme existant
+type ProgramOwnership struct {
+	OwnerID  string
+	IsCustom bool // false = programme officiel
+}
+
+// ProgramFlags champs réservés aux admins
+type ProgramFlags struct {
+	IsCustom   bool
+	IsFeatured bool
+}
+
+// CanPerformProgramAction indique si l'acteur peut effectuer l'action (sans accès base de données).
+// Les admins peuvent tout faire ; un utilisateur peut créer et dupliquer,
+// et modifier ou supprimer ses propres programmes personnalisés.
+// program est ignoré pour la création.
+func CanPerformProgramAction(actor ProgramActor, program ProgramOwnership, action ProgramAction) bool {
+	if actor.UserID == "" {
+		return false
+	}
+	if actor.IsAdmin {
+		return true
+	}
+
+	switch action {
+	case ProgramActionCreate, ProgramActionDuplicate:
+		return true
+	case ProgramActionUpdate, ProgramActionDelete:
+		return program.IsCustom && program.OwnerID != "" && program.OwnerID == actor.UserID
+	default:
+		return false
+	}
+}
+
+// ResolveProgramFlags détermine les drapeaux à enregistrer : les admins choisissent,
+// les autres conservent current et ne peuvent pas demander de mise en avant.
+func ResolveProgramFlags(actor ProgramActor, requested, current ProgramFlags) (ProgramFlags, error) {
+	if CanPerformProgramAction(actor, ProgramOwnership{}, ProgramActionFeature) {
+		return requested, nil
+	}
+	if requested.IsFeatured && !current.IsFeatured {
+		return current, ErrProgramForbidden
+	}
+	return current, nil
+}
+
+// LoadProgramOwnership charge la propriété d'un programme non supprimé
+func LoadProgramOwnership(ctx context.Context, programID string) (ProgramOwnership, ProgramFlags, error) {
+	var ownership ProgramOwnership
+	var flags ProgramFlags
+	var ownerID sql.NullString
+
+	err := database.DB.QueryRow(ctx,
+		`SELECT created_by, is_custom, is_featured FROM workout_programs WHERE id = $1 AND deleted_at IS NULL`,
+		programID,
+	).Scan(&ownerID, &ownership.IsCustom, &flags.IsFeatured)
+	if err == pgx.ErrNoRows {
+		return ownership, flags, ErrProgramNotFound
+	}
+	if err != nil {
+		return ownership, flags, err
+	}
+
+	ownership.OwnerID = ownerID.String
+	flags.IsCustom = ownership.IsCustom
+	return ownership, flags, nil
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCanPerformProgramAction(t *testing.T) {
+	var (
+		anonymous = ProgramActor{}
+		owner     = ProgramActor{UserID: "owner"}
+		other     = ProgramActor{UserID: "other"}
+		admin     = ProgramActor{UserID: "admin", IsAdmin: true}
+
+		custom = ProgramOwnership{OwnerID: "owner", IsCustom: true}
+		// programme officiel créé par le même utilisateur : la propriété ne suffit pas
+		official = ProgramOwnership{OwnerID: "owner", IsCustom: false}
+	)
+
+	tests := []struct {
+		name    string
+		actor   ProgramActor
+		program ProgramOwnership
+		want    map[ProgramAction]bool
+	}{
+		{
+			name:    "anonyme sur un programme personnalisé",
+			actor:   anonymous,
+			program: custom,
+			want:    map[ProgramAction]bool{},
+		},
+		{
+			name:    "anonyme sur un programme officiel",
+			actor:   anonymous,
+			program: official,
+			want:    map[ProgramAction]bool{},
+		},
+		{
+			name:    "propriétaire sur son programme personnalisé",
+			actor:   owner,
+			program: custom,
+			want: map[ProgramAction]bool{
+				ProgramActionCreate: true, ProgramActionUpdate: true, ProgramActionDelete: true, ProgramActionDuplicate: true,
+			},
+		},
+		{
+			name:    "propriétaire sur un programme officiel",
+			actor:   owner,
+			program: official,
+			want:    map[ProgramAction]bool{ProgramActionCreate: true, ProgramActionDuplicate: true},
+		},
+		{
+			name:    "autre utilisateur sur un programme personnalisé",
+			actor:   other,
+			program: custom,
+			want:    map[ProgramAction]bool{ProgramActionCreate: true, ProgramActionDuplicate: true},
+		},
+		{
+			name:    "autre utilisateur sur un programme officiel",
+			actor:   other,
+			program: official,
+			want:    map[ProgramAction]bool{ProgramActionCreate: true, ProgramActionDuplicate: true},
+		},
+		{
+			name:    "admin sur un programme personnalisé",
+			actor:   admin,
+			program: custom,
+			want: map[ProgramAction]bool{
+				ProgramActionCreate: true, ProgramActionUpdate: true, ProgramActionDelete: true,
+				ProgramActionDuplicate: true, ProgramActionFeature: true,
+			},
+		},
+		{
+			name:    "admin sur un programme officiel",
+			actor:   admin,
+			program: official,
+			want: map[ProgramAction]bool{
+				ProgramActionCreate: true, ProgramActionUpdate: true, ProgramActionDelete: true,
+				ProgramActionDuplicate: true, ProgramActionFeature: true,
+			},
+		},
+		{
+			name:    "programme personnalisé sans propriétaire",
+			actor:   owner,
+			program: ProgramOwnership{IsCustom: true},
+			want:    map[ProgramAction]bool{ProgramActionCreate: true, ProgramActionDuplicate: true},
+		},
+	}
+
+	actions := []ProgramAction{
+		ProgramActionCreate, ProgramActionUpdate, ProgramActionDelete, ProgramActionDuplicate, ProgramActionFeature,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, action := range actions {
+				if got := CanPerformProgramAction(tt.actor, tt.program, action); got != tt.want[action] {
+					t.Errorf("%s = %v, want %v", action, got, tt.want[action])
+				}
+			}
+		})
+	}
+}
+
+func TestResolveProgramFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		actor     ProgramActor
+		requested ProgramFlags
+		current   ProgramFlags
+		want      ProgramFlags
+		wantErr   error
+	}{
+		{
+			name:      "admin choisit les drapeaux",
+			actor:     ProgramActor{UserID: "admin", IsAdmin: true},
+			requested: ProgramFlags{IsCustom: false, IsFeatured: true},
+			current:   ProgramFlags{IsCustom: true},
+			want:      ProgramFlags{IsCustom: false, IsFeatured: true},
+		},
+		{
+			name:      "admin retire la mise en avant",
+			actor:     ProgramActor{UserID: "admin", IsAdmin: true},
+			requested: ProgramFlags{IsCustom: false},
+			current:   ProgramFlags{IsCustom: false, IsFeatured: true},
+			want:      ProgramFlags{IsCustom: false},
+		},
+		{
+			name:      "utilisateur demandant une mise en avant",
+			actor:     ProgramActor{UserID: "owner"},
+			requested: ProgramFlags{IsCustom: true, IsFeatured: true},
+			current:   ProgramFlags{IsCustom: true},
+			want:      ProgramFlags{IsCustom: true},
+			wantErr:   ErrProgramForbidden,
+		},
+		{
+			name:      "anonyme demandant une mise en avant",
+			actor:     ProgramActor{},
+			requested: ProgramFlags{IsFeatured: true},
+			current:   ProgramFlags{IsCustom: true},
+			want:      ProgramFlags{IsCustom: true},
+			wantErr:   ErrProgramForbidden,
+		},
+		{
+			name:      "utilisateur renvoyant une mise en avant existante",
+			actor:     ProgramActor{UserID: "owner"},
+			requested: ProgramFlags{IsCustom: true, IsFeatured: true},
+			current:   ProgramFlags{IsCustom: true, IsFeatured: true},
+			want:      ProgramFlags{IsCustom: true, IsFeatured: true},
+		},
+		{
+			name:      "utilisateur demandant le statut officiel",
+			actor:     ProgramActor{UserID: "owner"},
+			requested: ProgramFlags{IsCustom: false},
+			current:   ProgramFlags{IsCustom: true},
+			want:      ProgramFlags{IsCustom: true},
+		},
+		{
+			name:      "utilisateur ne peut pas retirer la mise en avant",
+			actor:     ProgramActor{UserID: "owner"},
+			requested: ProgramFlags{IsCustom: true},
+			current:   ProgramFlags{IsCustom: true, IsFeatured: true},
+			want:      ProgramFlags{IsCustom: true, IsFeatured: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveProgramFlags(tt.actor, tt.requested, tt.current)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("flags = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
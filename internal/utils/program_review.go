@@ -0,0 +1,459 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
+	"github.com/jackc/pgx/v5"
+)
+
+// Paramètres de la moyenne bayésienne : chaque programme part de ProgramRatingPriorWeight
+// notes fictives égales à la moyenne globale (ProgramRatingDefaultMean sans aucune note)
+const (
+	ProgramRatingPriorWeight = 5.0
+	ProgramRatingDefaultMean = 3.0
+)
+
+// ProgramReviewAutoHideReports nombre de signalements au-delà duquel un avis est masqué en attente de modération
+const ProgramReviewAutoHideReports = 3
+
+var (
+	ErrProgramReviewNotFound        = errors.New("avis introuvable")
+	ErrProgramReviewNotAllowed      = errors.New("seuls les utilisateurs ayant fait une séance sur ce programme peuvent le noter")
+	ErrProgramReviewOwnReport       = errors.New("impossible de signaler son propre avis")
+	ErrProgramReviewAlreadyReported = errors.New("avis déjà signalé")
+)
+
+// programReviewColumns colonnes d'un avis, dans l'ordre de scanProgramReview
+const programReviewColumns = `
+	pr.id, pr.program_id, pr.user_id, pr.rating, pr.comment, pr.hidden, pr.reports_count,
+	pr.created_at, pr.updated_at,
+	u.id, u.name, u.avatar`
+
+// programReviewSort tri des avis : les plus récents d'abord
+var programReviewSort = pagination.Sort{
+	Name:    "recent",
+	Columns: []pagination.Column{{Expr: "pr.created_at", Cast: "timestamp"}},
+	Desc:    true,
+}
+
+// scanProgramReview scanne une ligne sélectionnée avec programReviewColumns
+func scanProgramReview(row pgx.Row) (*model.ProgramReview, error) {
+	var r model.ProgramReview
+	var userID, userName, userAvatar *string
+
+	err := row.Scan(
+		&r.ID, &r.ProgramID, &r.UserID, &r.Rating, &r.Comment, &r.Hidden, &r.ReportsCount,
+		&r.CreatedAt, &r.UpdatedAt,
+		&userID, &userName, &userAvatar,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != nil {
+		r.User = &model.UserCreator{ID: *userID}
+		if userName != nil {
+			r.User.Name = *userName
+		}
+		if userAvatar != nil {
+			r.User.Avatar = *userAvatar
+		}
+	}
+	return &r, nil
+}
+
+// programRatingScoreExpr moyenne bayésienne d'un programme (alias wp) pour la moyenne globale global.mean
+const programRatingScoreExpr = `(wp.rating_average::float8 * wp.rating_count + $2::float8 * global.mean) / (wp.rating_count + $2::float8)`
+
+// programRatingGlobalMean CTE de la moyenne globale des avis visibles ($1 sans aucune note)
+const programRatingGlobalMean = `
+	WITH global AS (
+		SELECT COALESCE(AVG(rating)::float8, $1::float8) AS mean
+		FROM program_reviews
+		WHERE hidden = FALSE
+	)`
+
+// refreshProgramRatings recalcule les agrégats et la moyenne bayésienne d'un programme (avis masqués exclus).
+// Les scores des autres programmes suivent la moyenne globale via RefreshProgramRatingScores.
+func refreshProgramRatings(ctx context.Context, db dbExecutor, programID string) error {
+	_, err := db.Exec(ctx, `
+		UPDATE workout_programs wp SET
+			rating_count = s.count,
+			rating_average = s.average
+		FROM (
+			SELECT COUNT(*) AS count, COALESCE(AVG(rating), 0) AS average
+			FROM program_reviews
+			WHERE program_id = $1 AND hidden = FALSE
+		) s
+		WHERE wp.id = $1
+	`, programID)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(ctx, programRatingGlobalMean+`
+		UPDATE workout_programs wp SET rating_score = `+programRatingScoreExpr+`
+		FROM global
+		WHERE wp.id = $3
+	`, ProgramRatingDefaultMean, ProgramRatingPriorWeight, programID)
+	return err
+}
+
+// RefreshProgramRatingScores recalcule la moyenne bayésienne des programmes dont la moyenne globale
+// a déplacé le score, et retourne le nombre de programmes mis à jour
+func RefreshProgramRatingScores(ctx context.Context) (int64, error) {
+	tag, err := database.DB.Exec(ctx, programRatingGlobalMean+`
+		UPDATE workout_programs wp SET rating_score = `+programRatingScoreExpr+`
+		FROM global
+		WHERE wp.deleted_at IS NULL
+		  AND wp.rating_score IS DISTINCT FROM `+programRatingScoreExpr+`
+	`, ProgramRatingDefaultMean, ProgramRatingPriorWeight)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// UpsertProgramReview crée ou remplace l'avis d'un utilisateur ayant au moins une séance sur le programme
+func UpsertProgramReview(ctx context.Context, programID, userID string, req model.ProgramReviewRequest) (*model.ProgramReview, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists, hasSession bool
+	err = tx.QueryRow(ctx, `
+		SELECT
+			EXISTS (SELECT 1 FROM workout_programs WHERE id = $1 AND deleted_at IS NULL),
+			EXISTS (SELECT 1 FROM workout_sessions WHERE program_id = $1 AND user_id = $2)
+	`, programID, userID).Scan(&exists, &hasSession)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProgramNotFound
+	}
+	if !hasSession {
+		return nil, ErrProgramReviewNotAllowed
+	}
+
+	// Modifier son avis ne lève pas un masquage de la modération
+	var reviewID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO program_reviews (program_id, user_id, rating, comment)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (program_id, user_id) DO UPDATE SET
+			rating = EXCLUDED.rating,
+			comment = EXCLUDED.comment,
+			updated_at = NOW()
+		RETURNING id
+	`, programID, userID, req.Rating, req.Comment).Scan(&reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := refreshProgramRatings(ctx, tx, programID); err != nil {
+		return nil, err
+	}
+
+	review, err := scanProgramReview(tx.QueryRow(ctx, `
+		SELECT`+programReviewColumns+`
+		FROM program_reviews pr
+		LEFT JOIN users u ON u.id = pr.user_id AND u.deleted_at IS NULL
+		WHERE pr.id = $1
+	`, reviewID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// DeleteProgramReview supprime l'avis d'un utilisateur sur un programme
+func DeleteProgramReview(ctx context.Context, programID, userID string) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	res, err := tx.Exec(ctx, `DELETE FROM program_reviews WHERE program_id = $1 AND user_id = $2`, programID, userID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return ErrProgramReviewNotFound
+	}
+
+	if err := refreshProgramRatings(ctx, tx, programID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetUserProgramReview récupère l'avis d'un utilisateur sur un programme (masqué compris)
+func GetUserProgramReview(ctx context.Context, programID, userID string) (*model.ProgramReview, error) {
+	review, err := scanProgramReview(database.DB.QueryRow(ctx, `
+		SELECT`+programReviewColumns+`
+		FROM program_reviews pr
+		LEFT JOIN users u ON u.id = pr.user_id AND u.deleted_at IS NULL
+		WHERE pr.program_id = $1 AND pr.user_id = $2
+	`, programID, userID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrProgramReviewNotFound
+	}
+	return review, err
+}
+
+// ListProgramReviews liste les avis d'un programme ; les avis masqués et le nombre de
+// signalements ne sont visibles que des admins (includeHidden)
+func ListProgramReviews(ctx context.Context, programID string, includeHidden bool, page pagination.Params) (pagination.Page[model.ProgramReview], error) {
+	sqlQuery := `
+		SELECT` + programReviewColumns + `
+		FROM program_reviews pr
+		LEFT JOIN users u ON u.id = pr.user_id AND u.deleted_at IS NULL
+		WHERE pr.program_id = $1
+	`
+	args := []interface{}{programID}
+	argCount := 2
+
+	if !includeHidden {
+		sqlQuery += " AND pr.hidden = FALSE"
+	}
+
+	sqlQuery, args, err := page.Apply(sqlQuery, args, argCount, programReviewSort, "pr.id")
+	if err != nil {
+		return pagination.Page[model.ProgramReview]{}, err
+	}
+
+	rows, err := database.DB.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return pagination.Page[model.ProgramReview]{}, err
+	}
+	defer rows.Close()
+
+	var reviews []model.ProgramReview
+	for rows.Next() {
+		review, err := scanProgramReview(rows)
+		if err != nil {
+			return pagination.Page[model.ProgramReview]{}, err
+		}
+		if !includeHidden {
+			review.ReportsCount = 0
+		}
+		reviews = append(reviews, *review)
+	}
+	if err := rows.Err(); err != nil {
+		return pagination.Page[model.ProgramReview]{}, err
+	}
+
+	return pagination.NewPage(reviews, page, programReviewSort, func(r model.ProgramReview) ([]string, string) {
+		return []string{pagination.TimeKey(r.CreatedAt)}, r.ID
+	}), nil
+}
+
+// ReportProgramReview signale un avis abusif : le signalement rejoint la file de modération
+// (bug report de catégorie abuse) et l'avis est masqué après ProgramReviewAutoHideReports signalements
+func ReportProgramReview(ctx context.Context, programID, reviewID, reporterID, reason string) (*model.ProgramReviewReport, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var authorID, programName string
+	var rating int
+	var comment *string
+	err = tx.QueryRow(ctx, `
+		SELECT pr.user_id, pr.rating, pr.comment, wp.name
+		FROM program_reviews pr
+		JOIN workout_programs wp ON wp.id = pr.program_id
+		WHERE pr.id = $1 AND pr.program_id = $2
+	`, reviewID, programID).Scan(&authorID, &rating, &comment, &programName)
+	if err == pgx.ErrNoRows {
+		return nil, ErrProgramReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authorID == reporterID {
+		return nil, ErrProgramReviewOwnReport
+	}
+
+	var report model.ProgramReviewReport
+	err = tx.QueryRow(ctx, `
+		INSERT INTO program_review_reports (review_id, reported_by, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (review_id, reported_by) DO NOTHING
+		RETURNING id, review_id, created_at
+	`, reviewID, reporterID, StringToNullString(reason)).Scan(&report.ID, &report.ReviewID, &report.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return nil, ErrProgramReviewAlreadyReported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Avis %s de l'utilisateur %s (note %d/5)", reviewID, authorID, rating)
+	if comment != nil {
+		description += "\n\n" + *comment
+	}
+	if reason != "" {
+		description += "\n\nMotif : " + reason
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO bug_reports (user_id, title, description, category, severity, status, page_url, created_at, updated_at)
+		VALUES ($1, $2, $3, 'abuse', 'medium', 'open', $4, NOW(), NOW())
+		RETURNING id
+	`, reporterID, "Avis signalé sur le programme "+strconv.Quote(programName), description,
+		"/programs/"+programID+"/reviews/"+reviewID,
+	).Scan(&report.BugReportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE program_review_reports SET bug_report_id = $1 WHERE id = $2`, report.BugReportID, report.ID); err != nil {
+		return nil, err
+	}
+
+	var hiddenNow bool
+	err = tx.QueryRow(ctx, `
+		UPDATE program_reviews SET
+			reports_count = reports_count + 1,
+			hidden = hidden OR reports_count + 1 >= $2
+		WHERE id = $1
+		RETURNING hidden AND reports_count = $2
+	`, reviewID, ProgramReviewAutoHideReports).Scan(&hiddenNow)
+	if err != nil {
+		return nil, err
+	}
+	if hiddenNow {
+		if err := refreshProgramRatings(ctx, tx, programID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ModerateProgramReview masque ou rétablit un avis (admin) et recalcule les notes du programme
+func ModerateProgramReview(ctx context.Context, reviewID string, hidden bool) (*model.ProgramReview, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var programID string
+	err = tx.QueryRow(ctx, `
+		UPDATE program_reviews SET hidden = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING program_id
+	`, reviewID, hidden).Scan(&programID)
+	if err == pgx.ErrNoRows {
+		return nil, ErrProgramReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := refreshProgramRatings(ctx, tx, programID); err != nil {
+		return nil, err
+	}
+
+	review, err := scanProgramReview(tx.QueryRow(ctx, `
+		SELECT`+programReviewColumns+`
+		FROM program_reviews pr
+		LEFT JOIN users u ON u.id = pr.user_id AND u.deleted_at IS NULL
+		WHERE pr.id = $1
+	`, reviewID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// AttachProgramRatings renseigne les agrégats de notes de chaque programme
+func AttachProgramRatings(ctx context.Context, programs []model.WorkoutProgram) error {
+	if len(programs) == 0 {
+		return nil
+	}
+
+	programIDs := make([]string, 0, len(programs))
+	for _, p := range programs {
+		programIDs = append(programIDs, p.ID)
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT id, rating_average::float8, rating_count, rating_score
+		FROM workout_programs
+		WHERE id = ANY($1::uuid[])
+	`, programIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type rating struct {
+		average float64
+		count   int
+		score   float64
+	}
+	ratings := map[string]rating{}
+	for rows.Next() {
+		var id string
+		var r rating
+		if err := rows.Scan(&id, &r.average, &r.count, &r.score); err != nil {
+			return err
+		}
+		ratings[id] = r
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range programs {
+		if r, ok := ratings[programs[i].ID]; ok {
+			programs[i].RatingAverage = r.average
+			programs[i].RatingCount = r.count
+			programs[i].RatingScore = r.score
+		}
+	}
+	return nil
+}
+
+// AttachProgramDetails renseigne l'exercice et les notes de chaque programme
+func AttachProgramDetails(ctx context.Context, programs []model.WorkoutProgram) error {
+	if err := AttachProgramExercises(ctx, programs); err != nil {
+		return err
+	}
+	return AttachProgramRatings(ctx, programs)
+}
+
+// AttachProgramDetail renseigne l'exercice et les notes d'un programme
+func AttachProgramDetail(ctx context.Context, program *model.WorkoutProgram) error {
+	programs := []model.WorkoutProgram{*program}
+	if err := AttachProgramDetails(ctx, programs); err != nil {
+		return err
+	}
+	*program = programs[0]
+	return nil
+}
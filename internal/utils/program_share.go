@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ProgramShareCodePrefix préfixe de la forme compacte (version du format incluse)
+const ProgramShareCodePrefix = "PP1."
+
+// MaxProgramShareCodeLength taille maximale acceptée pour un code de partage
+const MaxProgramShareCodeLength = 8192
+
+var (
+	ErrInvalidProgramShare   = errors.New("partage de programme invalide")
+	ErrProgramShareSignature = errors.New("signature du partage invalide")
+)
+
+var programShareSecret []byte
+
+// ErrProgramShareSecretMissing clé de signature des partages absente
+var ErrProgramShareSecretMissing = errors.New("PROGRAM_SHARE_SECRET non défini")
+
+// ConfigureProgramShare définit la clé de signature des partages. La clé est obligatoire :
+// une clé éphémère invaliderait tous les codes et QR codes au redémarrage.
+func ConfigureProgramShare(secret string) error {
+	if secret == "" {
+		return ErrProgramShareSecretMissing
+	}
+	programShareSecret = []byte(secret)
+	return nil
+}
+
+// programShareSignature calcule la signature HMAC-SHA256 d'un partage (hors champ sig)
+func programShareSignature(share model.ProgramShare) (string, error) {
+	if programShareSecret == nil {
+		return "", ErrProgramShareSecretMissing
+	}
+
+	share.Signature = ""
+	payload, err := json.Marshal(share)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, programShareSecret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// SignProgramShare signe un partage
+func SignProgramShare(share *model.ProgramShare) error {
+	signature, err := programShareSignature(*share)
+	if err != nil {
+		return err
+	}
+	share.Signature = signature
+	return nil
+}
+
+// VerifyProgramShare vérifie le format et la signature d'un partage
+func VerifyProgramShare(share model.ProgramShare) error {
+	if share.Format != model.ProgramShareFormat {
+		return fmt.Errorf("%w: format inconnu", ErrInvalidProgramShare)
+	}
+	if share.Version != model.ProgramShareFormatVersion {
+		return fmt.Errorf("%w: version %d non supportée", ErrInvalidProgramShare, share.Version)
+	}
+	if share.Signature == "" {
+		return ErrProgramShareSignature
+	}
+
+	expected, err := programShareSignature(share)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(expected), []byte(share.Signature)) {
+		return ErrProgramShareSignature
+	}
+	return nil
+}
+
+// EncodeProgramShareCode produit la forme compacte : préfixe + base64url(deflate(JSON))
+func EncodeProgramShareCode(share model.ProgramShare) (string, error) {
+	payload, err := json.Marshal(share)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write(payload); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return ProgramShareCodePrefix + base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeProgramShareCode décode la forme compacte (la signature n'est pas vérifiée)
+func DecodeProgramShareCode(code string) (model.ProgramShare, error) {
+	var share model.ProgramShare
+
+	code = strings.TrimSpace(code)
+	if len(code) > MaxProgramShareCodeLength {
+		return share, fmt.Errorf("%w: code trop long", ErrInvalidProgramShare)
+	}
+	encoded, ok := strings.CutPrefix(code, ProgramShareCodePrefix)
+	if !ok {
+		return share, fmt.Errorf("%w: préfixe inconnu", ErrInvalidProgramShare)
+	}
+
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return share, fmt.Errorf("%w: %v", ErrInvalidProgramShare, err)
+	}
+
+	reader := flate.NewReader(bytes.NewReader(compressed))
+	defer reader.Close()
+	payload, err := io.ReadAll(io.LimitReader(reader, 64*1024))
+	if err != nil {
+		return share, fmt.Errorf("%w: %v", ErrInvalidProgramShare, err)
+	}
+
+	if err := json.Unmarshal(payload, &share); err != nil {
+		return share, fmt.Errorf("%w: %v", ErrInvalidProgramShare, err)
+	}
+	return share, nil
+}
+
+// BuildProgramShare construit le partage signé de la dernière version d'un programme
+func BuildProgramShare(ctx context.Context, programID string) (*model.ProgramShareExport, error) {
+	var createdBy *string
+	var importCount int
+	err := database.DB.QueryRow(ctx,
+		`SELECT created_by, import_count FROM workout_programs WHERE id = $1 AND deleted_at IS NULL`,
+		programID,
+	).Scan(&createdBy, &importCount)
+	if err == pgx.ErrNoRows {
+		return nil, ErrProgramNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := scanProgramVersion(database.DB.QueryRow(ctx, `
+		SELECT`+programVersionColumns+`
+		FROM program_versions pv
+		WHERE pv.program_id = $1
+		ORDER BY pv.version DESC
+		LIMIT 1
+	`, programID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrProgramVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	share := model.ProgramShare{
+		Format:        model.ProgramShareFormat,
+		Version:       model.ProgramShareFormatVersion,
+		SourceID:      programID,
+		SourceVersion: version.Version,
+		Program:       version.Snapshot,
+		IssuedAt:      time.Now().Unix(),
+	}
+	if author, err := LoadCreator(ctx, createdBy); err == nil && author != nil {
+		// Seuls l'identifiant et le nom font partie de l'attribution signée
+		share.Author = &model.UserCreator{ID: author.ID, Name: author.Name}
+	}
+
+	if err := SignProgramShare(&share); err != nil {
+		return nil, err
+	}
+	code, err := EncodeProgramShareCode(share)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ProgramShareExport{Share: share, Code: code, ImportCount: importCount}, nil
+}
+
+// ImportProgramShare crée un programme personnalisé à partir d'un partage vérifié et validé.
+// Le programme source (s'il existe encore) voit son compteur d'imports incrémenté.
+func ImportProgramShare(ctx context.Context, share model.ProgramShare, userID string) (string, error) {
+	s := share.Program
+
+	var repsSequenceJSON []byte
+	if s.RepsSequence != nil {
+		encoded, err := json.Marshal(s.RepsSequence)
+		if err != nil {
+			return "", err
+		}
+		repsSequenceJSON = encoded
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var programID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO workout_programs(
+			name, description, type, variant, difficulty, rest_between_sets,
+			target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
+			reps_sequence, reps_per_minute, total_minutes,
+			is_custom, is_featured, usage_count, exercise_id,
+			created_by, created_at, updated_at
+		) VALUES(
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, true, false, 0, $16, $17, NOW(), NOW()
+		)
+		RETURNING id
+	`,
+		s.Name, s.Description, s.Type, s.Variant, s.Difficulty, s.RestBetweenSets,
+		s.TargetReps, s.TimeLimit, s.Duration, s.AllowRest, s.Sets, s.RepsPerSet,
+		repsSequenceJSON, s.RepsPerMinute, s.TotalMinutes, s.ExerciseID, userID,
+	).Scan(&programID)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := SaveProgramBlocks(ctx, tx, programID, s.Blocks); err != nil {
+		return "", err
+	}
+
+	if _, err := CreateProgramVersion(ctx, tx, programID, s, userID, nil); err != nil {
+		return "", err
+	}
+
+	var authorID, authorName *string
+	if share.Author != nil {
+		authorID, authorName = &share.Author.ID, &share.Author.Name
+	}
+
+	// La source et l'auteur peuvent avoir disparu : l'attribution conserve le nom signé
+	_, err = tx.Exec(ctx, `
+		INSERT INTO program_imports (
+			program_id, source_program_id, source_version, original_creator_id, original_creator_name, imported_by
+		) VALUES (
+			$1,
+			(SELECT id FROM workout_programs WHERE id::text = $2),
+			$3,
+			(SELECT id FROM users WHERE id::text = $4),
+			$5, $6
+		)
+	`, programID, share.SourceID, share.SourceVersion, authorID, authorName, userID)
+	if err != nil {
+		return "", err
+	}
+
+	// Un même utilisateur qui réimporte le partage ne compte qu'une fois
+	_, err = tx.Exec(ctx, `
+		UPDATE workout_programs wp SET import_count = (
+			SELECT COUNT(DISTINCT pi.imported_by) FROM program_imports pi WHERE pi.source_program_id = wp.id
+		)
+		WHERE wp.id::text = $1
+	`, share.SourceID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return programID, nil
+}
+
+// LoadProgramSharing renseigne l'attribution et le nombre d'imports d'un programme
+func LoadProgramSharing(ctx context.Context, program *model.WorkoutProgram) error {
+	if err := database.DB.QueryRow(ctx,
+		`SELECT import_count FROM workout_programs WHERE id = $1`,
+		program.ID,
+	).Scan(&program.ImportCount); err != nil {
+		return err
+	}
+
+	var attribution model.ProgramAttribution
+	var creatorID, creatorName, creatorAvatar *string
+	err := database.DB.QueryRow(ctx, `
+		SELECT pi.source_program_id, pi.source_version, pi.original_creator_id,
+			COALESCE(u.name, pi.original_creator_name), u.avatar, pi.created_at
+		FROM program_imports pi
+		LEFT JOIN users u ON u.id = pi.original_creator_id AND u.deleted_at IS NULL
+		WHERE pi.program_id = $1
+	`, program.ID).Scan(
+		&attribution.SourceProgramID, &attribution.SourceVersion, &creatorID,
+		&creatorName, &creatorAvatar, &attribution.ImportedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if creatorName != nil {
+		attribution.OriginalCreator = &model.UserCreator{Name: *creatorName}
+		if creatorID != nil {
+			attribution.OriginalCreator.ID = *creatorID
+		}
+		if creatorAvatar != nil {
+			attribution.OriginalCreator.Avatar = *creatorAvatar
+		}
+	}
+	program.Attribution = &attribution
+	return nil
+}
@@ -0,0 +1,325 @@
+package utils
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrProgramVersionNotFound = errors.New("version de programme introuvable")
+
+// programVersionColumns colonnes d'une version, dans l'ordre de scanProgramVersion
+const programVersionColumns = `
+	pv.id, pv.program_id, pv.version, pv.reverted_from,
+	pv.name, pv.description, pv.type, pv.variant, pv.difficulty, pv.exercise_id, pv.rest_between_sets,
+	pv.target_reps, pv.time_limit, pv.duration, pv.allow_rest, pv.sets, pv.reps_per_set,
+	pv.reps_sequence, pv.reps_per_minute, pv.total_minutes, pv.blocks,
+	pv.created_by, pv.created_at`
+
+// ProgramSnapshotOf extrait les paramètres versionnés d'un programme
+func ProgramSnapshotOf(p *model.WorkoutProgram) model.ProgramSnapshot {
+	return model.ProgramSnapshot{
+		Name:            p.Name,
+		Description:     p.Description,
+		Type:            p.Type,
+		Variant:         p.Variant,
+		Difficulty:      p.Difficulty,
+		ExerciseID:      p.ExerciseID,
+		RestBetweenSets: p.RestBetweenSets,
+		TargetReps:      p.TargetReps,
+		TimeLimit:       p.TimeLimit,
+		Duration:        p.Duration,
+		AllowRest:       p.AllowRest,
+		Sets:            p.Sets,
+		RepsPerSet:      p.RepsPerSet,
+		RepsSequence:    p.RepsSequence,
+		RepsPerMinute:   p.RepsPerMinute,
+		TotalMinutes:    p.TotalMinutes,
+		Blocks:          snapshotBlocks(p.Blocks),
+	}
+}
+
+// snapshotBlocks copie les blocs sans leur identifiant (recréés à chaque écriture)
+func snapshotBlocks(blocks []model.ProgramBlock) []model.ProgramBlock {
+	if len(blocks) == 0 {
+		return nil
+	}
+	copied := make([]model.ProgramBlock, len(blocks))
+	for i, b := range blocks {
+		b.ID = ""
+		b.Position = i + 1
+		copied[i] = b
+	}
+	return copied
+}
+
+// encodeSnapshotJSON encode une liste optionnelle en JSONB (NULL si vide)
+func encodeSnapshotJSON[T any](values []T) ([]byte, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(values)
+}
+
+// ApplyProgramSnapshot remplace les paramètres d'un programme par ceux d'une version
+func ApplyProgramSnapshot(p *model.WorkoutProgram, s model.ProgramSnapshot) {
+	p.Name = s.Name
+	p.Description = s.Description
+	p.Type = s.Type
+	p.Variant = s.Variant
+	p.Difficulty = s.Difficulty
+	p.ExerciseID = s.ExerciseID
+	p.RestBetweenSets = s.RestBetweenSets
+	p.TargetReps = s.TargetReps
+	p.TimeLimit = s.TimeLimit
+	p.Duration = s.Duration
+	p.AllowRest = s.AllowRest
+	p.Sets = s.Sets
+	p.RepsPerSet = s.RepsPerSet
+	p.RepsSequence = s.RepsSequence
+	p.RepsPerMinute = s.RepsPerMinute
+	p.TotalMinutes = s.TotalMinutes
+	p.Blocks = s.Blocks
+}
+
+// snapshotFieldValue valeur JSON comparable d'un champ (nil si absent)
+func snapshotFieldValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return v.Elem().Interface()
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return nil
+		}
+	}
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		value, _ := valuer.Value()
+		return value
+	}
+	return v.Interface()
+}
+
+// DiffProgramSnapshots liste les champs qui diffèrent entre deux versions (noms JSON)
+func DiffProgramSnapshots(from, to model.ProgramSnapshot) []model.ProgramVersionChange {
+	changes := []model.ProgramVersionChange{}
+
+	fromValue, toValue := reflect.ValueOf(from), reflect.ValueOf(to)
+	snapshotType := fromValue.Type()
+	for i := 0; i < snapshotType.NumField(); i++ {
+		before := snapshotFieldValue(fromValue.Field(i))
+		after := snapshotFieldValue(toValue.Field(i))
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+
+		field, _, _ := strings.Cut(snapshotType.Field(i).Tag.Get("json"), ",")
+		changes = append(changes, model.ProgramVersionChange{Field: field, From: before, To: after})
+	}
+	return changes
+}
+
+// scanProgramVersion scanne une ligne sélectionnée avec programVersionColumns
+func scanProgramVersion(row pgx.Row) (*model.ProgramVersion, error) {
+	var v model.ProgramVersion
+	var repsSequenceJSON, blocksJSON []byte
+	s := &v.Snapshot
+
+	err := row.Scan(
+		&v.ID, &v.ProgramID, &v.Version, &v.RevertedFrom,
+		&s.Name, &s.Description, &s.Type, &s.Variant, &s.Difficulty, &s.ExerciseID, &s.RestBetweenSets,
+		&s.TargetReps, &s.TimeLimit, &s.Duration, &s.AllowRest, &s.Sets, &s.RepsPerSet,
+		&repsSequenceJSON, &s.RepsPerMinute, &s.TotalMinutes, &blocksJSON,
+		&v.CreatedBy, &v.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if repsSequenceJSON != nil {
+		if err := json.Unmarshal(repsSequenceJSON, &s.RepsSequence); err != nil {
+			return nil, err
+		}
+	}
+	if blocksJSON != nil {
+		if err := json.Unmarshal(blocksJSON, &s.Blocks); err != nil {
+			return nil, err
+		}
+	}
+	return &v, nil
+}
+
+// CreateProgramVersion enregistre une nouvelle version d'un programme (à appeler dans la transaction d'écriture)
+func CreateProgramVersion(ctx context.Context, db dbExecutor, programID string, snapshot model.ProgramSnapshot, createdBy string, revertedFrom *int) (*model.ProgramVersion, error) {
+	var repsSequenceJSON []byte
+	if snapshot.RepsSequence != nil {
+		encoded, err := json.Marshal(snapshot.RepsSequence)
+		if err != nil {
+			return nil, err
+		}
+		repsSequenceJSON = encoded
+	}
+	blocksJSON, err := encodeSnapshotJSON(snapshotBlocks(snapshot.Blocks))
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.QueryRow(ctx, `
+		WITH next AS (
+			SELECT COALESCE(MAX(version), 0) + 1 AS version FROM program_versions WHERE program_id = $1
+		)
+		INSERT INTO program_versions AS pv (
+			program_id, version, reverted_from,
+			name, description, type, variant, difficulty, exercise_id, rest_between_sets,
+			target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
+			reps_sequence, reps_per_minute, total_minutes, blocks,
+			created_by, created_at
+		)
+		SELECT $1, next.version, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, NOW()
+		FROM next
+		RETURNING`+programVersionColumns,
+		programID, revertedFrom,
+		snapshot.Name, snapshot.Description, snapshot.Type, snapshot.Variant, snapshot.Difficulty, snapshot.ExerciseID, snapshot.RestBetweenSets,
+		snapshot.TargetReps, snapshot.TimeLimit, snapshot.Duration, snapshot.AllowRest, snapshot.Sets, snapshot.RepsPerSet,
+		repsSequenceJSON, snapshot.RepsPerMinute, snapshot.TotalMinutes, blocksJSON,
+		StringToNullString(createdBy),
+	)
+	return scanProgramVersion(row)
+}
+
+// GetProgramVersions liste les versions d'un programme, de la plus récente à la plus ancienne
+func GetProgramVersions(ctx context.Context, programID string) ([]model.ProgramVersion, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT`+programVersionColumns+`
+		FROM program_versions pv
+		WHERE pv.program_id = $1
+		ORDER BY pv.version DESC
+	`, programID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []model.ProgramVersion{}
+	for rows.Next() {
+		v, err := scanProgramVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *v)
+	}
+	return versions, rows.Err()
+}
+
+// GetProgramVersion récupère une version précise d'un programme
+func GetProgramVersion(ctx context.Context, programID string, version int) (*model.ProgramVersion, error) {
+	v, err := scanProgramVersion(database.DB.QueryRow(ctx, `
+		SELECT`+programVersionColumns+`
+		FROM program_versions pv
+		WHERE pv.program_id = $1 AND pv.version = $2
+	`, programID, version))
+	if err == pgx.ErrNoRows {
+		return nil, ErrProgramVersionNotFound
+	}
+	return v, err
+}
+
+// GetSessionProgramVersion récupère la version du programme utilisée par une séance (nil si inconnue)
+func GetSessionProgramVersion(ctx context.Context, sessionID string) (*model.ProgramVersion, error) {
+	v, err := scanProgramVersion(database.DB.QueryRow(ctx, `
+		SELECT`+programVersionColumns+`
+		FROM workout_sessions ws
+		JOIN program_versions pv ON pv.id = ws.program_version_id
+		WHERE ws.id = $1
+	`, sessionID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+// DiffProgramVersions compare deux versions d'un programme
+func DiffProgramVersions(ctx context.Context, programID string, from, to int) (*model.ProgramVersionDiff, error) {
+	fromVersion, err := GetProgramVersion(ctx, programID, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := GetProgramVersion(ctx, programID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ProgramVersionDiff{
+		ProgramID: programID,
+		From:      from,
+		To:        to,
+		Changes:   DiffProgramSnapshots(fromVersion.Snapshot, toVersion.Snapshot),
+	}, nil
+}
+
+// RevertProgramVersion restaure les paramètres d'une ancienne version : le programme est mis à jour
+// et une nouvelle version est créée (l'historique n'est jamais réécrit)
+func RevertProgramVersion(ctx context.Context, programID string, version int, actorID string) (*model.ProgramVersion, error) {
+	target, err := GetProgramVersion(ctx, programID, version)
+	if err != nil {
+		return nil, err
+	}
+	s := target.Snapshot
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var repsSequenceJSON []byte
+	if s.RepsSequence != nil {
+		if repsSequenceJSON, err = json.Marshal(s.RepsSequence); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := tx.Exec(ctx, `
+		UPDATE workout_programs SET
+			name=$1, description=$2, type=$3, variant=$4, difficulty=$5, rest_between_sets=$6,
+			target_reps=$7, time_limit=$8, duration=$9, allow_rest=$10, sets=$11, reps_per_set=$12,
+			reps_sequence=$13, reps_per_minute=$14, total_minutes=$15,
+			exercise_id=COALESCE($16, exercise_id),
+			updated_by=$17, updated_at=NOW()
+		WHERE id=$18 AND deleted_at IS NULL
+	`,
+		s.Name, s.Description, s.Type, s.Variant, s.Difficulty, s.RestBetweenSets,
+		s.TargetReps, s.TimeLimit, s.Duration, s.AllowRest, s.Sets, s.RepsPerSet,
+		repsSequenceJSON, s.RepsPerMinute, s.TotalMinutes, s.ExerciseID,
+		actorID, programID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected() == 0 {
+		return nil, ErrProgramNotFound
+	}
+
+	if _, err := SaveProgramBlocks(ctx, tx, programID, s.Blocks); err != nil {
+		return nil, err
+	}
+
+	created, err := CreateProgramVersion(ctx, tx, programID, s, actorID, &version)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// ProgressionParams paramètres d'un programme ajustables par la progression adaptative
+type ProgressionParams struct {
+	TargetReps    *int
+	RepsPerSet    *int
+	RepsSequence  []int
+	RepsPerMinute *int
+}
+
+// ProgressionInput état connu au moment de décider de la prochaine séance
+type ProgressionInput struct {
+	ProgramType          model.ProgramType
+	Base                 ProgressionParams // paramètres d'origine du programme
+	Current              ProgressionParams // paramètres de la séance qui vient d'être faite
+	Completed            bool
+	ConsecutiveSuccesses int // avant la séance courante
+	ConsecutiveFailures  int // avant la séance courante
+}
+
+// ProgressionDecision résultat d'une politique de progression
+type ProgressionDecision struct {
+	Action               string // model.ProgressionIncrease, ProgressionHold, ProgressionDeload
+	Params               ProgressionParams
+	ConsecutiveSuccesses int
+	ConsecutiveFailures  int
+}
+
+// ProgressionPolicy calcule les paramètres de la prochaine séance
+type ProgressionPolicy interface {
+	Next(input ProgressionInput) ProgressionDecision
+}
+
+// StepProgressionPolicy politique par paliers :
+// augmentation après SuccessesToIncrease réussites, maintien sur un échec isolé,
+// allègement après FailuresToDeload échecs consécutifs
+type StepProgressionPolicy struct {
+	StepRatio           float64 // augmentation relative (0.1 = +10%)
+	MinStep             int     // augmentation minimale en reps
+	SuccessesToIncrease int
+	FailuresToDeload    int
+	DeloadRatio         float64 // facteur appliqué en cas d'allègement (0.9 = -10%)
+	MinRatioOfBase      float64 // plancher par rapport aux paramètres d'origine
+}
+
+// DefaultProgressionPolicy politique utilisée par défaut
+var DefaultProgressionPolicy ProgressionPolicy = StepProgressionPolicy{
+	StepRatio:           0.1,
+	MinStep:             1,
+	SuccessesToIncrease: 1,
+	FailuresToDeload:    2,
+	DeloadRatio:         0.9,
+	MinRatioOfBase:      0.5,
+}
+
+var progressionPolicy = DefaultProgressionPolicy
+
+// SetProgressionPolicy remplace la politique de progression (nil = politique par défaut)
+func SetProgressionPolicy(policy ProgressionPolicy) {
+	if policy == nil {
+		policy = DefaultProgressionPolicy
+	}
+	progressionPolicy = policy
+}
+
+// IsProgressionType indique si le type de programme a des paramètres ajustables
+func IsProgressionType(programType model.ProgramType) bool {
+	switch programType {
+	case model.ProgramTypeTargetReps, model.ProgramTypeSetsReps, model.ProgramTypePyramid, model.ProgramTypeEMOM:
+		return true
+	default:
+		return false
+	}
+}
+
+// Next implémente ProgressionPolicy
+func (p StepProgressionPolicy) Next(input ProgressionInput) ProgressionDecision {
+	decision := ProgressionDecision{Action: model.ProgressionHold, Params: input.Current}
+	if !IsProgressionType(input.ProgramType) {
+		return decision
+	}
+
+	if input.Completed {
+		decision.ConsecutiveSuccesses = input.ConsecutiveSuccesses + 1
+		if decision.ConsecutiveSuccesses >= max(p.SuccessesToIncrease, 1) {
+			decision.Action = model.ProgressionIncrease
+			decision.Params = mapProgressionParams(input.Current, input.Base, func(current, _ int) int {
+				return current + max(int(math.Round(float64(current)*p.StepRatio)), p.MinStep)
+			})
+			decision.ConsecutiveSuccesses = 0
+		}
+		return decision
+	}
+
+	decision.ConsecutiveFailures = input.ConsecutiveFailures + 1
+	if decision.ConsecutiveFailures >= max(p.FailuresToDeload, 1) {
+		decision.Action = model.ProgressionDeload
+		decision.Params = mapProgressionParams(input.Current, input.Base, func(current, base int) int {
+			floor := max(int(math.Ceil(float64(base)*p.MinRatioOfBase)), 1)
+			return max(int(math.Floor(float64(current)*p.DeloadRatio)), floor)
+		})
+		decision.ConsecutiveFailures = 0
+	}
+	return decision
+}
+
+// mapProgressionParams applique fn(valeur courante, valeur d'origine) à chaque paramètre défini
+func mapProgressionParams(current, base ProgressionParams, fn func(current, base int) int) ProgressionParams {
+	apply := func(value, baseValue *int) *int {
+		if value == nil {
+			return nil
+		}
+		b := *value
+		if baseValue != nil {
+			b = *baseValue
+		}
+		result := fn(*value, b)
+		return &result
+	}
+
+	result := ProgressionParams{
+		TargetReps:    apply(current.TargetReps, base.TargetReps),
+		RepsPerSet:    apply(current.RepsPerSet, base.RepsPerSet),
+		RepsPerMinute: apply(current.RepsPerMinute, base.RepsPerMinute),
+	}
+	if current.RepsSequence != nil {
+		result.RepsSequence = make([]int, len(current.RepsSequence))
+		for i, reps := range current.RepsSequence {
+			b := reps
+			if i < len(base.RepsSequence) {
+				b = base.RepsSequence[i]
+			}
+			result.RepsSequence[i] = fn(reps, b)
+		}
+	}
+	return result
+}
+
+// ProgramProgressionParams extrait les paramètres ajustables d'un programme
+func ProgramProgressionParams(program *model.WorkoutProgram) ProgressionParams {
+	return ProgressionParams{
+		TargetReps:    program.TargetReps,
+		RepsPerSet:    program.RepsPerSet,
+		RepsSequence:  program.RepsSequence,
+		RepsPerMinute: program.RepsPerMinute,
+	}
+}
+
+// ApplyProgramOverride fusionne les paramètres ajustés d'un utilisateur dans le programme
+func ApplyProgramOverride(program *model.WorkoutProgram, override *model.ProgramOverride) {
+	if override == nil || !override.Enabled {
+		return
+	}
+	if override.TargetReps != nil && program.TargetReps != nil {
+		program.TargetReps = override.TargetReps
+	}
+	if override.RepsPerSet != nil && program.RepsPerSet != nil {
+		program.RepsPerSet = override.RepsPerSet
+	}
+	if len(override.RepsSequence) == len(program.RepsSequence) && len(program.RepsSequence) > 0 {
+		program.RepsSequence = override.RepsSequence
+	}
+	if override.RepsPerMinute != nil && program.RepsPerMinute != nil {
+		program.RepsPerMinute = override.RepsPerMinute
+	}
+	program.Override = override
+}
+
+// GetProgramOverride récupère la progression d'un utilisateur sur un programme (nil si aucune)
+func GetProgramOverride(ctx context.Context, userID, programID string) (*model.ProgramOverride, error) {
+	var override model.ProgramOverride
+	var repsSequenceJSON []byte
+	err := database.DB.QueryRow(ctx, `
+		SELECT program_id, enabled, target_reps, reps_per_set, reps_sequence, reps_per_minute,
+			last_action, consecutive_successes, consecutive_failures, sessions_count, updated_at
+		FROM user_program_overrides
+		WHERE user_id = $1 AND program_id = $2
+	`, userID, programID).Scan(
+		&override.ProgramID, &override.Enabled, &override.TargetReps, &override.RepsPerSet,
+		&repsSequenceJSON, &override.RepsPerMinute,
+		&override.LastAction, &override.ConsecutiveSuccesses, &override.ConsecutiveFailures,
+		&override.SessionsCount, &override.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if repsSequenceJSON != nil {
+		if err := json.Unmarshal(repsSequenceJSON, &override.RepsSequence); err != nil {
+			return nil, err
+		}
+	}
+	return &override, nil
+}
+
+// SetProgramProgression active ou désactive la progression adaptative d'un utilisateur sur un programme
+func SetProgramProgression(ctx context.Context, userID, programID string, enabled bool) (*model.ProgramOverride, error) {
+	_, err := database.DB.Exec(ctx, `
+		INSERT INTO user_program_overrides (user_id, program_id, enabled)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, program_id) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()
+	`, userID, programID, enabled)
+	if err != nil {
+		return nil, err
+	}
+	return GetProgramOverride(ctx, userID, programID)
+}
+
+// ResetProgramProgression supprime la progression d'un utilisateur (retour aux paramètres d'origine)
+func ResetProgramProgression(ctx context.Context, userID, programID string) error {
+	_, err := database.DB.Exec(ctx,
+		`DELETE FROM user_program_overrides WHERE user_id = $1 AND program_id = $2`,
+		userID, programID,
+	)
+	return err
+}
+
+// AdvanceProgramProgression calcule et enregistre les paramètres de la prochaine séance.
+// base est le programme d'origine, current la valeur effective de la séance réalisée.
+// Renvoie nil si la progression adaptative n'est pas activée pour ce programme.
+func AdvanceProgramProgression(ctx context.Context, userID string, base *model.WorkoutProgram, current *model.WorkoutProgram, completed bool) (*model.ProgramOverride, error) {
+	override := current.Override
+	if override == nil || !override.Enabled {
+		return nil, nil
+	}
+
+	decision := progressionPolicy.Next(ProgressionInput{
+		ProgramType:          base.Type,
+		Base:                 ProgramProgressionParams(base),
+		Current:              ProgramProgressionParams(current),
+		Completed:            completed,
+		ConsecutiveSuccesses: override.ConsecutiveSuccesses,
+		ConsecutiveFailures:  override.ConsecutiveFailures,
+	})
+
+	var repsSequenceJSON []byte
+	if decision.Params.RepsSequence != nil {
+		encoded, err := json.Marshal(decision.Params.RepsSequence)
+		if err != nil {
+			return nil, err
+		}
+		repsSequenceJSON = encoded
+	}
+
+	_, err := database.DB.Exec(ctx, `
+		UPDATE user_program_overrides SET
+			target_reps = $3, reps_per_set = $4, reps_sequence = $5, reps_per_minute = $6,
+			last_action = $7, consecutive_successes = $8, consecutive_failures = $9,
+			sessions_count = sessions_count + 1, updated_at = NOW()
+		WHERE user_id = $1 AND program_id = $2
+	`,
+		userID, base.ID,
+		decision.Params.TargetReps, decision.Params.RepsPerSet, repsSequenceJSON, decision.Params.RepsPerMinute,
+		decision.Action, decision.ConsecutiveSuccesses, decision.ConsecutiveFailures,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetProgramOverride(ctx, userID, base.ID)
+}
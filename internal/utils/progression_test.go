@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestStepProgressionPolicyNext(t *testing.T) {
+	defaultPolicy := DefaultProgressionPolicy.(StepProgressionPolicy)
+	slowPolicy := defaultPolicy
+	slowPolicy.SuccessesToIncrease = 2
+
+	tests := []struct {
+		name          string
+		policy        StepProgressionPolicy
+		input         ProgressionInput
+		wantAction    string
+		wantParams    ProgressionParams
+		wantSuccesses int
+		wantFailures  int
+	}{
+		{
+			name:   "augmentation après une réussite",
+			policy: defaultPolicy,
+			input: ProgressionInput{
+				ProgramType: model.ProgramTypeTargetReps,
+				Base:        ProgressionParams{TargetReps: intPtr(20)},
+				Current:     ProgressionParams{TargetReps: intPtr(20)},
+				Completed:   true,
+			},
+			wantAction: model.ProgressionIncrease,
+			wantParams: ProgressionParams{TargetReps: intPtr(22)},
+		},
+		{
+			name:   "augmentation minimale",
+			policy: defaultPolicy,
+			input: ProgressionInput{
+				ProgramType: model.ProgramTypeSetsReps,
+				Base:        ProgressionParams{RepsPerSet: intPtr(3)},
+				Current:     ProgressionParams{RepsPerSet: intPtr(3)},
+				Completed:   true,
+			},
+			wantAction: model.ProgressionIncrease,
+			wantParams: ProgressionParams{RepsPerSet: intPtr(4)},
+		},
+		{
+			name:   "réussites insuffisantes pour augmenter",
+			policy: slowPolicy,
+			input: ProgressionInput{
+				ProgramType: model.ProgramTypeEMOM,
+				Base:        ProgressionParams{RepsPerMinute: intPtr(10)},
+				Current:     ProgressionParams{RepsPerMinute: intPtr(10)},
+				Completed:   true,
+			},
+			wantAction:    model.ProgressionHold,
+			wantParams:    ProgressionParams{RepsPerMinute: intPtr(10)},
+			wantSuccesses: 1,
+		},
+		{
+			name:   "maintien sur un échec isolé",
+			policy: defaultPolicy,
+			input: ProgressionInput{
+				ProgramType:          model.ProgramTypeTargetReps,
+				Base:                 ProgressionParams{TargetReps: intPtr(20)},
+				Current:              ProgressionParams{TargetReps: intPtr(24)},
+				ConsecutiveSuccesses: 3,
+			},
+			wantAction:   model.ProgressionHold,
+			wantParams:   ProgressionParams{TargetReps: intPtr(24)},
+			wantFailures: 1,
+		},
+		{
+			name:   "allègement après FailuresToDeload échecs",
+			policy: defaultPolicy,
+			input: ProgressionInput{
+				ProgramType:         model.ProgramTypeTargetReps,
+				Base:                ProgressionParams{TargetReps: intPtr(20)},
+				Current:             ProgressionParams{TargetReps: intPtr(20)},
+				ConsecutiveFailures: defaultPolicy.FailuresToDeload - 1,
+			},
+			wantAction: model.ProgressionDeload,
+			wantParams: ProgressionParams{TargetReps: intPtr(18)},
+		},
+		{
+			name:   "plancher MinRatioOfBase",
+			policy: defaultPolicy,
+			input: ProgressionInput{
+				ProgramType:         model.ProgramTypeTargetReps,
+				Base:                ProgressionParams{TargetReps: intPtr(20)},
+				Current:             ProgressionParams{TargetReps: intPtr(10)},
+				ConsecutiveFailures: defaultPolicy.FailuresToDeload - 1,
+			},
+			wantAction: model.ProgressionDeload,
+			wantParams: ProgressionParams{TargetReps: intPtr(10)},
+		},
+		{
+			name:   "séquence pyramidale augmentée palier par palier",
+			policy: defaultPolicy,
+			input: ProgressionInput{
+				ProgramType: model.ProgramTypePyramid,
+				Base:        ProgressionParams{RepsSequence: []int{5, 10, 15}},
+				Current:     ProgressionParams{RepsSequence: []int{5, 10, 15}},
+				Completed:   true,
+			},
+			wantAction: model.ProgressionIncrease,
+			wantParams: ProgressionParams{RepsSequence: []int{6, 11, 17}},
+		},
+		{
+			name:   "séquence pyramidale allégée avec plancher par palier",
+			policy: defaultPolicy,
+			input: ProgressionInput{
+				ProgramType:         model.ProgramTypePyramid,
+				Base:                ProgressionParams{RepsSequence: []int{10, 20}},
+				Current:             ProgressionParams{RepsSequence: []int{10, 11}},
+				ConsecutiveFailures: defaultPolicy.FailuresToDeload - 1,
+			},
+			wantAction: model.ProgressionDeload,
+			wantParams: ProgressionParams{RepsSequence: []int{9, 10}},
+		},
+	}
+
+	for _, programType := range []model.ProgramType{
+		model.ProgramTypeFreeMode, model.ProgramTypeMaxTime, model.ProgramTypeAMRAP, model.ProgramTypeCircuit,
+	} {
+		tests = append(tests, struct {
+			name          string
+			policy        StepProgressionPolicy
+			input         ProgressionInput
+			wantAction    string
+			wantParams    ProgressionParams
+			wantSuccesses int
+			wantFailures  int
+		}{
+			name:   "type sans progression " + string(programType),
+			policy: defaultPolicy,
+			input: ProgressionInput{
+				ProgramType:         programType,
+				Current:             ProgressionParams{TargetReps: intPtr(20)},
+				Completed:           true,
+				ConsecutiveFailures: 5,
+			},
+			wantAction: model.ProgressionHold,
+			wantParams: ProgressionParams{TargetReps: intPtr(20)},
+		})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.Next(tt.input)
+			if got.Action != tt.wantAction {
+				t.Errorf("action = %q, want %q", got.Action, tt.wantAction)
+			}
+			if !reflect.DeepEqual(got.Params, tt.wantParams) {
+				t.Errorf("params = %+v, want %+v", got.Params, tt.wantParams)
+			}
+			if got.ConsecutiveSuccesses != tt.wantSuccesses || got.ConsecutiveFailures != tt.wantFailures {
+				t.Errorf("counters = (%d, %d), want (%d, %d)",
+					got.ConsecutiveSuccesses, got.ConsecutiveFailures, tt.wantSuccesses, tt.wantFailures)
+			}
+		})
+	}
+}
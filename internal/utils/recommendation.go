@@ -0,0 +1,388 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+const (
+	// RecommendationHistoryDays fenêtre d'historique prise en compte
+	RecommendationHistoryDays = 90
+	// RecommendationMaxSessions nombre maximum de sessions récentes analysées
+	RecommendationMaxSessions = 50
+	// RecommendationStreakLevelUp nombre de séances réussies d'affilée avant de proposer le niveau supérieur
+	RecommendationStreakLevelUp = 3
+	// RecommendationMinCompletionRate taux de réussite sous lequel on redescend d'un niveau
+	RecommendationMinCompletionRate = 0.5
+
+	// RecommendationCandidateLimit nombre de programmes candidats classés
+	RecommendationCandidateLimit = 200
+
+	recommendationCoUsageLimit = 50
+)
+
+var difficultyLevels = []string{"BEGINNER", "INTERMEDIATE", "ADVANCED"}
+
+// RecommendationSession session récente utilisée pour le profil de recommandation
+type RecommendationSession struct {
+	ProgramID   string
+	Type        string
+	Variant     string
+	Difficulty  string
+	TotalReps   int
+	BestSetReps int
+	Completed   bool
+	StartTime   time.Time
+}
+
+// RecommendationProfile historique d'un utilisateur servant au classement (sessions de la plus récente à la plus ancienne)
+type RecommendationProfile struct {
+	Sessions        []RecommendationSession
+	LikedProgramIDs []string
+	// CoUsage nombre d'utilisateurs au profil proche ayant utilisé chaque programme
+	CoUsage map[string]int
+}
+
+// RecommendationStreak série de séances réussies d'affilée sur le même type et la même difficulté
+type RecommendationStreak struct {
+	Type       string
+	Difficulty string
+	Count      int
+}
+
+// difficultyIndex position d'une difficulté (-1 si inconnue)
+func difficultyIndex(difficulty string) int {
+	for i, d := range difficultyLevels {
+		if d == difficulty {
+			return i
+		}
+	}
+	return -1
+}
+
+// shiftDifficulty difficulté décalée de delta niveaux, bornée
+func shiftDifficulty(difficulty string, delta int) string {
+	i := difficultyIndex(difficulty)
+	if i < 0 {
+		return difficulty
+	}
+	i += delta
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(difficultyLevels) {
+		i = len(difficultyLevels) - 1
+	}
+	return difficultyLevels[i]
+}
+
+// EstimateMaxReps estime le maximum de pompes en une série à partir des sessions récentes
+func EstimateMaxReps(sessions []RecommendationSession) int {
+	best := 0
+	for _, s := range sessions {
+		reps := s.BestSetReps
+		if reps == 0 {
+			reps = s.TotalReps
+		}
+		if reps > best {
+			best = reps
+		}
+	}
+	return best
+}
+
+// CompletionRates taux de sessions terminées par difficulté
+func CompletionRates(sessions []RecommendationSession) map[string]float64 {
+	total := map[string]int{}
+	completed := map[string]int{}
+	for _, s := range sessions {
+		total[s.Difficulty]++
+		if s.Completed {
+			completed[s.Difficulty]++
+		}
+	}
+	rates := make(map[string]float64, len(total))
+	for d, n := range total {
+		rates[d] = float64(completed[d]) / float64(n)
+	}
+	return rates
+}
+
+// CurrentStreak série de séances terminées d'affilée (depuis la plus récente) sur le même type et la même difficulté
+func CurrentStreak(sessions []RecommendationSession) RecommendationStreak {
+	var streak RecommendationStreak
+	for _, s := range sessions {
+		if !s.Completed {
+			break
+		}
+		if streak.Count == 0 {
+			streak = RecommendationStreak{Type: s.Type, Difficulty: s.Difficulty, Count: 1}
+			continue
+		}
+		if s.Type != streak.Type || s.Difficulty != streak.Difficulty {
+			break
+		}
+		streak.Count++
+	}
+	return streak
+}
+
+// difficultyForMaxReps niveau indicatif selon le maximum estimé
+func difficultyForMaxReps(maxReps int) string {
+	switch {
+	case maxReps >= 35:
+		return "ADVANCED"
+	case maxReps >= 15:
+		return "INTERMEDIATE"
+	}
+	return "BEGINNER"
+}
+
+// TargetDifficulty niveau visé : dernier niveau pratiqué, relevé après une série réussie,
+// abaissé si le taux de réussite est trop faible ; à défaut, déduit du maximum estimé
+func TargetDifficulty(sessions []RecommendationSession) (string, string) {
+	if len(sessions) == 0 {
+		return "BEGINNER", "Idéal pour commencer"
+	}
+
+	current := sessions[0].Difficulty
+	if difficultyIndex(current) < 0 {
+		current = difficultyForMaxReps(EstimateMaxReps(sessions))
+	}
+
+	if streak := CurrentStreak(sessions); streak.Count >= RecommendationStreakLevelUp && streak.Difficulty == current {
+		if next := shiftDifficulty(current, 1); next != current {
+			return next, fmt.Sprintf("Vous avez terminé %d %s %s d'affilée", streak.Count, streak.Difficulty, streak.Type)
+		}
+	}
+
+	rates := CompletionRates(sessions)
+	attempts := 0
+	for _, s := range sessions {
+		if s.Difficulty == current {
+			attempts++
+		}
+	}
+	if attempts >= RecommendationStreakLevelUp && rates[current] < RecommendationMinCompletionRate {
+		if prev := shiftDifficulty(current, -1); prev != current {
+			return prev, fmt.Sprintf("Vous terminez %d%% de vos séances %s", int(math.Round(rates[current]*100)), current)
+		}
+	}
+
+	return current, fmt.Sprintf("Correspond à votre niveau %s", current)
+}
+
+// ProgramRequiredReps pompes demandées en une série par un programme (0 si non applicable)
+func ProgramRequiredReps(p model.WorkoutProgram) int {
+	switch p.Type {
+	case "TARGET_REPS":
+		if p.TargetReps != nil {
+			return *p.TargetReps
+		}
+	case "SETS_REPS":
+		if p.RepsPerSet != nil {
+			return *p.RepsPerSet
+		}
+	case "PYRAMID":
+		best := 0
+		for _, reps := range p.RepsSequence {
+			if reps > best {
+				best = reps
+			}
+		}
+		return best
+	case "EMOM":
+		if p.RepsPerMinute != nil {
+			return *p.RepsPerMinute
+		}
+	}
+	return 0
+}
+
+// recommendationFactor contribution d'un critère au score, avec sa raison éventuelle
+type recommendationFactor struct {
+	weight float64
+	reason string
+}
+
+// RankProgramRecommendations classe les programmes candidats pour un profil (sans accès à la base).
+// Chaque recommandation porte la raison principale (critère le plus contributif) et la liste des raisons.
+func RankProgramRecommendations(profile RecommendationProfile, candidates []model.WorkoutProgram) []model.ProgramRecommendation {
+	target, targetReason := TargetDifficulty(profile.Sessions)
+	streak := CurrentStreak(profile.Sessions)
+	maxReps := EstimateMaxReps(profile.Sessions)
+	hasHistory := len(profile.Sessions) > 0
+
+	triedVariants := map[string]bool{}
+	recentPrograms := map[string]bool{}
+	for i, s := range profile.Sessions {
+		triedVariants[s.Variant] = true
+		if i < RecommendationStreakLevelUp {
+			recentPrograms[s.ProgramID] = true
+		}
+	}
+	liked := map[string]bool{}
+	for _, id := range profile.LikedProgramIDs {
+		liked[id] = true
+	}
+
+	recommendations := make([]model.ProgramRecommendation, 0, len(candidates))
+	for _, p := range candidates {
+		var factors []recommendationFactor
+
+		switch gap := difficultyIndex(string(p.Difficulty)) - difficultyIndex(target); {
+		case gap == 0:
+			factors = append(factors, recommendationFactor{3, targetReason})
+			// Même type que la série qui a justifié le passage au niveau supérieur
+			if streak.Count >= RecommendationStreakLevelUp && string(p.Type) == streak.Type && string(p.Difficulty) != streak.Difficulty {
+				factors = append(factors, recommendationFactor{1.5, ""})
+			}
+		case gap == 1 || gap == -1:
+			factors = append(factors, recommendationFactor{1, ""})
+		default:
+			factors = append(factors, recommendationFactor{-2, ""})
+		}
+
+		if liked[p.ID] {
+			factors = append(factors, recommendationFactor{2.5, "Vous avez aimé ce programme"})
+		}
+
+		if n := profile.CoUsage[p.ID]; n > 0 {
+			factors = append(factors, recommendationFactor{
+				0.6 * math.Min(float64(n), 5),
+				fmt.Sprintf("%d sportifs avec un historique proche du vôtre l'utilisent", n),
+			})
+		}
+
+		if hasHistory && !triedVariants[string(p.Variant)] {
+			factors = append(factors, recommendationFactor{1.5, fmt.Sprintf("Nouvelle variante à essayer : %s", p.Variant)})
+		} else if !hasHistory && p.Variant == "STANDARD" {
+			factors = append(factors, recommendationFactor{1, ""})
+		}
+
+		if required := ProgramRequiredReps(p); required > 0 && maxReps > 0 {
+			if float64(required) <= float64(maxReps)*1.2 {
+				factors = append(factors, recommendationFactor{1, fmt.Sprintf("Adapté à votre maximum estimé de %d pompes", maxReps)})
+			} else {
+				factors = append(factors, recommendationFactor{-2, ""})
+			}
+		}
+
+		if recentPrograms[p.ID] {
+			factors = append(factors, recommendationFactor{-1.5, ""})
+		}
+
+		// Départage par popularité
+		factors = append(factors, recommendationFactor{0.2 * math.Log1p(float64(p.UsageCount)), ""})
+		if p.IsFeatured {
+			factors = append(factors, recommendationFactor{0.5, "Programme mis en avant"})
+		}
+
+		rec := model.ProgramRecommendation{WorkoutProgram: p, Reasons: []string{}}
+		bestWeight := 0.0
+		for _, f := range factors {
+			rec.Score += f.weight
+			if f.reason == "" || f.weight <= 0 {
+				continue
+			}
+			rec.Reasons = append(rec.Reasons, f.reason)
+			if f.weight > bestWeight {
+				bestWeight = f.weight
+				rec.Reason = f.reason
+			}
+		}
+		if rec.Reason == "" {
+			rec.Reason = "Programme populaire"
+		}
+		rec.Score = math.Round(rec.Score*1000) / 1000
+
+		recommendations = append(recommendations, rec)
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Score != recommendations[j].Score {
+			return recommendations[i].Score > recommendations[j].Score
+		}
+		return recommendations[i].ID < recommendations[j].ID
+	})
+
+	return recommendations
+}
+
+// LoadRecommendationProfile charge l'historique récent, les likes et le co-usage d'un utilisateur
+func LoadRecommendationProfile(ctx context.Context, userID string) (RecommendationProfile, error) {
+	profile := RecommendationProfile{CoUsage: map[string]int{}}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT ws.program_id, wp.type, wp.variant, wp.difficulty,
+			ws.total_reps,
+			COALESCE((SELECT MAX(sr.completed_reps) FROM set_results sr WHERE sr.session_id = ws.id), 0),
+			ws.completed, ws.start_time
+		FROM workout_sessions ws
+		INNER JOIN workout_programs wp ON wp.id = ws.program_id
+		WHERE ws.user_id = $1
+		  AND ws.start_time >= NOW() - make_interval(days => $2)
+		ORDER BY ws.start_time DESC
+		LIMIT $3
+	`, userID, RecommendationHistoryDays, RecommendationMaxSessions)
+	if err != nil {
+		return profile, err
+	}
+	for rows.Next() {
+		var s RecommendationSession
+		if err := rows.Scan(&s.ProgramID, &s.Type, &s.Variant, &s.Difficulty,
+			&s.TotalReps, &s.BestSetReps, &s.Completed, &s.StartTime); err != nil {
+			rows.Close()
+			return profile, err
+		}
+		profile.Sessions = append(profile.Sessions, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return profile, err
+	}
+
+	if profile.LikedProgramIDs, err = GetUserLikes(ctx, userID, model.EntityTypeProgram); err != nil {
+		return profile, err
+	}
+
+	// Filtrage collaboratif : programmes utilisés par ceux qui partagent des programmes avec l'utilisateur
+	rows, err = database.DB.Query(ctx, `
+		WITH mine AS (
+			SELECT DISTINCT program_id FROM workout_sessions WHERE user_id = $1
+		),
+		peers AS (
+			SELECT DISTINCT ws.user_id
+			FROM workout_sessions ws
+			INNER JOIN mine ON mine.program_id = ws.program_id
+			WHERE ws.user_id <> $1
+		)
+		SELECT ws.program_id, COUNT(DISTINCT ws.user_id)::int AS peers
+		FROM workout_sessions ws
+		INNER JOIN peers ON peers.user_id = ws.user_id
+		WHERE ws.program_id NOT IN (SELECT program_id FROM mine)
+		GROUP BY ws.program_id
+		ORDER BY peers DESC
+		LIMIT $2
+	`, userID, recommendationCoUsageLimit)
+	if err != nil {
+		return profile, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var programID string
+		var count int
+		if err := rows.Scan(&programID, &count); err != nil {
+			return profile, err
+		}
+		profile.CoUsage[programID] = count
+	}
+
+	return profile, rows.Err()
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"slices"
+	"testing"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+// recSessions sessions de la plus récente à la plus ancienne, toutes sur le même type et la même variante
+func recSessions(difficulty string, completed ...bool) []RecommendationSession {
+	sessions := make([]RecommendationSession, 0, len(completed))
+	for _, c := range completed {
+		sessions = append(sessions, RecommendationSession{
+			ProgramID:  "history",
+			Type:       "SETS_REPS",
+			Variant:    "STANDARD",
+			Difficulty: difficulty,
+			TotalReps:  20,
+			Completed:  c,
+		})
+	}
+	return sessions
+}
+
+func recProgram(id, programType, variant, difficulty string) model.WorkoutProgram {
+	return model.WorkoutProgram{
+		ID:         id,
+		Type:       model.ProgramType(programType),
+		Variant:    model.Variant(variant),
+		Difficulty: model.Difficulty(difficulty),
+	}
+}
+
+func TestTargetDifficulty(t *testing.T) {
+	tests := []struct {
+		name       string
+		sessions   []RecommendationSession
+		wantLevel  string
+		wantReason string
+	}{
+		{
+			name:       "sans historique",
+			wantLevel:  "BEGINNER",
+			wantReason: "Idéal pour commencer",
+		},
+		{
+			name:       "niveau supérieur après la série de réussites",
+			sessions:   recSessions("BEGINNER", true, true, true),
+			wantLevel:  "INTERMEDIATE",
+			wantReason: "Vous avez terminé 3 BEGINNER SETS_REPS d'affilée",
+		},
+		{
+			name:       "série trop courte",
+			sessions:   recSessions("BEGINNER", true, true, false),
+			wantLevel:  "BEGINNER",
+			wantReason: "Correspond à votre niveau BEGINNER",
+		},
+		{
+			name:       "déjà au niveau maximum",
+			sessions:   recSessions("ADVANCED", true, true, true),
+			wantLevel:  "ADVANCED",
+			wantReason: "Correspond à votre niveau ADVANCED",
+		},
+		{
+			name:       "niveau inférieur sous le taux de réussite minimum",
+			sessions:   recSessions("INTERMEDIATE", false, false, true, false),
+			wantLevel:  "BEGINNER",
+			wantReason: "Vous terminez 25% de vos séances INTERMEDIATE",
+		},
+		{
+			name:       "taux de réussite au seuil",
+			sessions:   recSessions("INTERMEDIATE", false, true, true, false),
+			wantLevel:  "INTERMEDIATE",
+			wantReason: "Correspond à votre niveau INTERMEDIATE",
+		},
+		{
+			name:       "pas assez de tentatives pour redescendre",
+			sessions:   recSessions("INTERMEDIATE", false, false),
+			wantLevel:  "INTERMEDIATE",
+			wantReason: "Correspond à votre niveau INTERMEDIATE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, reason := TargetDifficulty(tt.sessions)
+			if level != tt.wantLevel || reason != tt.wantReason {
+				t.Errorf("TargetDifficulty() = (%q, %q), want (%q, %q)", level, reason, tt.wantLevel, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRankProgramRecommendations(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     RecommendationProfile
+		candidates  []model.WorkoutProgram
+		wantFirst   string
+		wantReason  string // raison principale du premier
+		wantReasons string // raison attendue dans la liste du premier
+	}{
+		{
+			name:    "niveau supérieur après la série de réussites",
+			profile: RecommendationProfile{Sessions: recSessions("BEGINNER", true, true, true)},
+			candidates: []model.WorkoutProgram{
+				recProgram("beginner", "SETS_REPS", "STANDARD", "BEGINNER"),
+				recProgram("intermediate", "SETS_REPS", "STANDARD", "INTERMEDIATE"),
+			},
+			wantFirst:  "intermediate",
+			wantReason: "Vous avez terminé 3 BEGINNER SETS_REPS d'affilée",
+		},
+		{
+			name:    "niveau inférieur sous le taux de réussite minimum",
+			profile: RecommendationProfile{Sessions: recSessions("INTERMEDIATE", false, false, false)},
+			candidates: []model.WorkoutProgram{
+				recProgram("intermediate", "SETS_REPS", "STANDARD", "INTERMEDIATE"),
+				recProgram("beginner", "SETS_REPS", "STANDARD", "BEGINNER"),
+			},
+			wantFirst:  "beginner",
+			wantReason: "Vous terminez 0% de vos séances INTERMEDIATE",
+		},
+		{
+			name:    "variante jamais essayée",
+			profile: RecommendationProfile{Sessions: recSessions("BEGINNER", true)},
+			candidates: []model.WorkoutProgram{
+				recProgram("standard", "FREE_MODE", "STANDARD", "BEGINNER"),
+				recProgram("diamond", "FREE_MODE", "DIAMOND", "BEGINNER"),
+			},
+			wantFirst:   "diamond",
+			wantReason:  "Correspond à votre niveau BEGINNER",
+			wantReasons: "Nouvelle variante à essayer : DIAMOND",
+		},
+		{
+			name:    "programme aimé",
+			profile: RecommendationProfile{LikedProgramIDs: []string{"liked"}},
+			candidates: []model.WorkoutProgram{
+				recProgram("a-other", "FREE_MODE", "STANDARD", "BEGINNER"),
+				recProgram("liked", "FREE_MODE", "STANDARD", "BEGINNER"),
+			},
+			wantFirst:   "liked",
+			wantReason:  "Idéal pour commencer",
+			wantReasons: "Vous avez aimé ce programme",
+		},
+		{
+			name:    "co-usage",
+			profile: RecommendationProfile{CoUsage: map[string]int{"peers": 8}},
+			candidates: []model.WorkoutProgram{
+				recProgram("a-other", "FREE_MODE", "STANDARD", "BEGINNER"),
+				recProgram("peers", "FREE_MODE", "STANDARD", "BEGINNER"),
+			},
+			wantFirst:   "peers",
+			wantReason:  "Idéal pour commencer",
+			wantReasons: "8 sportifs avec un historique proche du vôtre l'utilisent",
+		},
+		{
+			name:    "un like pèse plus que peu de co-usage",
+			profile: RecommendationProfile{LikedProgramIDs: []string{"liked"}, CoUsage: map[string]int{"peers": 1}},
+			candidates: []model.WorkoutProgram{
+				recProgram("peers", "FREE_MODE", "STANDARD", "BEGINNER"),
+				recProgram("liked", "FREE_MODE", "STANDARD", "BEGINNER"),
+			},
+			wantFirst:  "liked",
+			wantReason: "Idéal pour commencer",
+		},
+		{
+			name:    "aucune raison positive",
+			profile: RecommendationProfile{},
+			candidates: []model.WorkoutProgram{
+				recProgram("advanced", "FREE_MODE", "STANDARD", "ADVANCED"),
+			},
+			wantFirst:  "advanced",
+			wantReason: "Programme populaire",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs := RankProgramRecommendations(tt.profile, tt.candidates)
+			if len(recs) != len(tt.candidates) {
+				t.Fatalf("got %d recommendations, want %d", len(recs), len(tt.candidates))
+			}
+			first := recs[0]
+			if first.ID != tt.wantFirst {
+				t.Errorf("first = %q (score %v), want %q", first.ID, first.Score, tt.wantFirst)
+			}
+			if first.Reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", first.Reason, tt.wantReason)
+			}
+			if tt.wantReasons != "" && !slices.Contains(first.Reasons, tt.wantReasons) {
+				t.Errorf("reasons = %q, want to contain %q", first.Reasons, tt.wantReasons)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fréquences supportées (sous-ensemble RRULE)
+const (
+	RecurrenceDaily   = "DAILY"
+	RecurrenceWeekly  = "WEEKLY"
+	RecurrenceMonthly = "MONTHLY"
+)
+
+// RecurrenceRule règle de récurrence : FREQ, INTERVAL, BYDAY (hebdomadaire), BYMONTHDAY (mensuelle), COUNT
+type RecurrenceRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int // -1 = dernier jour du mois
+	Count      int   // 0 = illimité
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRecurrenceRule analyse une règle au format RRULE (ex: FREQ=WEEKLY;BYDAY=MO,WE,FR)
+func ParseRecurrenceRule(value string) (RecurrenceRule, error) {
+	rule := RecurrenceRule{Interval: 1}
+
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+	if value == "" {
+		return rule, fmt.Errorf("règle de récurrence vide")
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return rule, fmt.Errorf("élément de récurrence invalide: %q", part)
+		}
+
+		switch key {
+		case "FREQ":
+			switch val {
+			case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly:
+				rule.Freq = val
+			default:
+				return rule, fmt.Errorf("FREQ non supportée: %s (DAILY, WEEKLY ou MONTHLY)", val)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 || n > 52 {
+				return rule, fmt.Errorf("INTERVAL doit être compris entre 1 et 52")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("COUNT doit être positif")
+			}
+			rule.Count = n
+		case "BYDAY":
+			for _, code := range strings.Split(val, ",") {
+				day, ok := rruleWeekdays[code]
+				if !ok {
+					return rule, fmt.Errorf("jour invalide dans BYDAY: %s", code)
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, raw := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(raw)
+				if err != nil || n == 0 || n < -1 || n > 31 {
+					return rule, fmt.Errorf("jour du mois invalide dans BYMONTHDAY: %s (1 à 31 ou -1)", raw)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		default:
+			return rule, fmt.Errorf("élément de récurrence non supporté: %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, fmt.Errorf("FREQ est requis")
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != RecurrenceWeekly {
+		return rule, fmt.Errorf("BYDAY n'est supporté qu'avec FREQ=WEEKLY")
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq != RecurrenceMonthly {
+		return rule, fmt.Errorf("BYMONTHDAY n'est supporté qu'avec FREQ=MONTHLY")
+	}
+
+	return rule, nil
+}
+
+// truncateToDay ramène une date à minuit UTC
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// weekStart retourne le lundi de la semaine d'une date
+func weekStart(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// Matches indique si le jour donné est une occurrence de la règle commençant à dtstart
+func (rule RecurrenceRule) Matches(dtstart, day time.Time) bool {
+	dtstart = truncateToDay(dtstart)
+	day = truncateToDay(day)
+	if day.Before(dtstart) {
+		return false
+	}
+
+	interval := rule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	switch rule.Freq {
+	case RecurrenceDaily:
+		days := int(day.Sub(dtstart).Hours() / 24)
+		return days%interval == 0
+
+	case RecurrenceWeekly:
+		weeks := int(weekStart(day).Sub(weekStart(dtstart)).Hours() / (24 * 7))
+		if weeks%interval != 0 {
+			return false
+		}
+		if len(rule.ByDay) == 0 {
+			return day.Weekday() == dtstart.Weekday()
+		}
+		for _, weekday := range rule.ByDay {
+			if day.Weekday() == weekday {
+				return true
+			}
+		}
+		return false
+
+	case RecurrenceMonthly:
+		months := (day.Year()-dtstart.Year())*12 + int(day.Month()) - int(dtstart.Month())
+		if months%interval != 0 {
+			return false
+		}
+		if len(rule.ByMonthDay) == 0 {
+			return day.Day() == dtstart.Day()
+		}
+		lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		for _, monthDay := range rule.ByMonthDay {
+			if monthDay == day.Day() || (monthDay == -1 && day.Day() == lastDay) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return false
+}
+
+// NextOccurrence retourne la première occurrence à partir de from (incluse)
+func (rule RecurrenceRule) NextOccurrence(dtstart, from time.Time) (time.Time, bool) {
+	day := truncateToDay(from)
+	if start := truncateToDay(dtstart); day.Before(start) {
+		day = start
+	}
+
+	// Une occurrence existe forcément dans l'horizon d'un intervalle complet (BYMONTHDAY=31 inclus)
+	horizon := 366*rule.Interval + 62
+	for i := 0; i < horizon; i++ {
+		if rule.Matches(dtstart, day) {
+			return day, true
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, false
+}
+
+// PeriodDays durée approximative d'une période de la règle, en jours
+func (rule RecurrenceRule) PeriodDays() int {
+	interval := rule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	switch rule.Freq {
+	case RecurrenceWeekly:
+		return 7 * interval
+	case RecurrenceMonthly:
+		return 31 * interval
+	default:
+		return interval
+	}
+}
+
+// OccurrenceEnd calcule la fin d'une occurrence : durationDays si défini, sinon le début de l'occurrence suivante
+func (rule RecurrenceRule) OccurrenceEnd(dtstart, start time.Time, durationDays *int) time.Time {
+	start = truncateToDay(start)
+	if durationDays != nil && *durationDays > 0 {
+		return start.AddDate(0, 0, *durationDays)
+	}
+	if next, ok := rule.NextOccurrence(dtstart, start.AddDate(0, 0, 1)); ok {
+		return next
+	}
+	return start.AddDate(0, 0, 1)
+}
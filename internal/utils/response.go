@@ -2,10 +2,12 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/MassBabyGeek/PumpPro-backend/internal/logger"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/validation"
 )
 
 type APIResponse struct {
@@ -13,6 +15,8 @@ type APIResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Message string      `json:"message,omitempty"`
+	// Errors erreurs de validation par champ
+	Errors validation.Errors `json:"errors,omitempty"`
 }
 
 func JSON(w http.ResponseWriter, status int, payload interface{}) {
@@ -45,3 +49,14 @@ func ErrorSimple(w http.ResponseWriter, status int, message string) {
 func Message(w http.ResponseWriter, msg string) {
 	JSON(w, http.StatusOK, APIResponse{Success: true, Message: msg})
 }
+
+// ValidationError retourne une erreur 400 avec le détail des champs invalides
+func ValidationError(w http.ResponseWriter, err error) {
+	var fieldErrors validation.Errors
+	if !errors.As(err, &fieldErrors) {
+		Error(w, http.StatusBadRequest, "données invalides", err)
+		return
+	}
+	logger.Error("[%d] données invalides: %v", http.StatusBadRequest, err)
+	JSON(w, http.StatusBadRequest, APIResponse{Success: false, Error: "données invalides", Errors: fieldErrors})
+}
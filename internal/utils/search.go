@@ -0,0 +1,260 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
+)
+
+const (
+	SearchMinQueryLength = 2
+	SearchMaxQueryLength = 100
+	SearchDefaultLimit   = 20
+	SearchMaxLimit       = 50
+)
+
+var (
+	ErrInvalidSearchQuery  = errors.New("requête de recherche invalide")
+	ErrInvalidSearchCursor = errors.New("curseur de recherche invalide")
+	ErrInvalidSearchType   = errors.New("type d'entité invalide")
+)
+
+// SearchParams paramètres d'une recherche globale
+type SearchParams struct {
+	Query      string
+	EntityType string
+	Difficulty string
+	Variant    string
+	Limit      int
+	Cursor     string
+
+	// Utilisateur courant (vide si anonyme), pour la visibilité des challenges et programmes perso
+	ViewerID string
+	IsAdmin  bool
+}
+
+// searchSortName identifiant du tri par pertinence dans les curseurs de recherche
+const searchSortName = "relevance"
+
+// NormalizeSearchQuery nettoie la requête et vérifie sa longueur
+func NormalizeSearchQuery(q string) (string, error) {
+	q = strings.Join(strings.Fields(q), " ")
+	n := utf8.RuneCountInString(q)
+	if n < SearchMinQueryLength || n > SearchMaxQueryLength {
+		return "", ErrInvalidSearchQuery
+	}
+	return q, nil
+}
+
+// NormalizeSearchEntityType valide le filtre de type d'entité (vide = tous)
+func NormalizeSearchEntityType(entityType string) (string, error) {
+	entityType = strings.ToLower(strings.TrimSpace(entityType))
+	switch entityType {
+	case "", model.SearchEntityChallenge, model.SearchEntityProgram, model.SearchEntityUser:
+		return entityType, nil
+	}
+	return "", ErrInvalidSearchType
+}
+
+// ClampSearchLimit borne la taille de page
+func ClampSearchLimit(limit int) int {
+	if limit <= 0 {
+		return SearchDefaultLimit
+	}
+	if limit > SearchMaxLimit {
+		return SearchMaxLimit
+	}
+	return limit
+}
+
+// DecodeSearchCursor relit un curseur de recherche : clés (score, type d'entité) puis id
+func DecodeSearchCursor(s string) (pagination.Cursor, error) {
+	c, err := pagination.DecodeCursor(s)
+	if err != nil || c.Sort != searchSortName || len(c.Keys) != 2 {
+		return pagination.Cursor{}, ErrInvalidSearchCursor
+	}
+	if _, err := strconv.ParseFloat(c.Keys[0], 64); err != nil {
+		return pagination.Cursor{}, ErrInvalidSearchCursor
+	}
+	if _, err := NormalizeSearchEntityType(c.Keys[1]); err != nil {
+		return pagination.Cursor{}, ErrInvalidSearchCursor
+	}
+	return c, nil
+}
+
+// searchMatchesCTE ensemble des résultats correspondant à la requête ($1 = q, $2 = utilisateur, $3 = admin).
+// Plein texte pondéré (français + anglais) avec repli trigramme pour les fautes de frappe.
+const searchMatchesCTE = `
+	WITH q AS (
+		SELECT
+			websearch_to_tsquery('french', $1) || websearch_to_tsquery('english', $1) AS tsq,
+			websearch_to_tsquery('simple', $1) AS simple_tsq
+	),
+	matches AS (
+		SELECT 'challenge' AS entity_type, c.id::text AS id, c.title AS title,
+			NULLIF(c.description, '') AS description, c.difficulty, NULLIF(c.variant, '') AS variant,
+			c.image_url AS image_url,
+			ROUND((ts_rank_cd(c.search_vector, q.tsq) + 0.5 * similarity(c.title, $1))::numeric, 6) AS score
+		FROM challenges c, q
+		WHERE c.deleted_at IS NULL
+		  AND (c.search_vector @@ q.tsq OR c.title % $1)
+		  AND (
+			c.visibility = 'public'
+			OR $3
+			OR ($2 <> '' AND (
+				c.created_by::text = $2
+				OR EXISTS(SELECT 1 FROM challenge_allowed_users cau WHERE cau.challenge_id = c.id AND cau.user_id::text = $2)
+				OR EXISTS(SELECT 1 FROM user_challenge_progress ucp WHERE ucp.challenge_id = c.id AND ucp.user_id::text = $2)
+			))
+		  )
+
+		UNION ALL
+
+		SELECT 'program', p.id::text, p.name,
+			NULLIF(p.description, ''), p.difficulty, p.variant,
+			NULL,
+			ROUND((ts_rank_cd(p.search_vector, q.tsq) + 0.5 * similarity(p.name, $1))::numeric, 6)
+		FROM workout_programs p, q
+		WHERE p.deleted_at IS NULL
+		  AND (p.search_vector @@ q.tsq OR p.name % $1)
+		  AND (p.is_custom = FALSE OR $3 OR ($2 <> '' AND p.created_by::text = $2))
+
+		UNION ALL
+
+		SELECT 'user', u.id::text, u.name,
+			NULL, NULL, NULL,
+			NULLIF(u.avatar, ''),
+			ROUND((ts_rank_cd(u.search_vector, q.simple_tsq) + 0.5 * similarity(u.name, $1))::numeric, 6)
+		FROM users u, q
+		WHERE u.deleted_at IS NULL
+		  AND (u.search_vector @@ q.simple_tsq OR u.name % $1)
+	)
+`
+
+// Search recherche globale classée sur les challenges, programmes et utilisateurs.
+// Les facettes portent sur l'ensemble des correspondances, avant filtres et pagination.
+func Search(ctx context.Context, params SearchParams) (model.SearchResponse, error) {
+	resp := model.SearchResponse{
+		Query:   params.Query,
+		Results: []model.SearchResult{},
+		Facets: model.SearchFacets{
+			EntityTypes:  map[string]int{},
+			Difficulties: map[string]int{},
+			Variants:     map[string]int{},
+		},
+	}
+
+	var cursor *pagination.Cursor
+	if params.Cursor != "" {
+		c, err := DecodeSearchCursor(params.Cursor)
+		if err != nil {
+			return resp, err
+		}
+		cursor = &c
+	}
+
+	baseArgs := []interface{}{params.Query, params.ViewerID, params.IsAdmin}
+
+	// Facettes
+	rows, err := database.DB.Query(ctx, searchMatchesCTE+`
+		SELECT 'entityType', entity_type, COUNT(*)::int FROM matches GROUP BY entity_type
+		UNION ALL
+		SELECT 'difficulty', difficulty, COUNT(*)::int FROM matches WHERE difficulty IS NOT NULL GROUP BY difficulty
+		UNION ALL
+		SELECT 'variant', variant, COUNT(*)::int FROM matches WHERE variant IS NOT NULL GROUP BY variant
+	`, baseArgs...)
+	if err != nil {
+		return resp, err
+	}
+	for rows.Next() {
+		var facet, value string
+		var count int
+		if err := rows.Scan(&facet, &value, &count); err != nil {
+			rows.Close()
+			return resp, err
+		}
+		switch facet {
+		case "entityType":
+			resp.Facets.EntityTypes[value] = count
+		case "difficulty":
+			resp.Facets.Difficulties[value] = count
+		case "variant":
+			resp.Facets.Variants[value] = count
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	// Résultats
+	query := searchMatchesCTE + `
+		SELECT entity_type, id, title, description, difficulty, variant, image_url, score::float8
+		FROM matches
+		WHERE TRUE
+	`
+	args := append([]interface{}{}, baseArgs...)
+	argCount := len(args) + 1
+
+	filters := []struct{ col, val string }{
+		{"entity_type", params.EntityType},
+		{"difficulty", params.Difficulty},
+		{"variant", params.Variant},
+	}
+	for _, f := range filters {
+		if f.val != "" {
+			query += " AND " + f.col + " = $" + strconv.Itoa(argCount)
+			args = append(args, f.val)
+			argCount++
+		}
+	}
+
+	if cursor != nil {
+		query += " AND (score < $" + strconv.Itoa(argCount) + "::numeric OR (score = $" + strconv.Itoa(argCount) +
+			"::numeric AND (entity_type, id) > ($" + strconv.Itoa(argCount+1) + ", $" + strconv.Itoa(argCount+2) + ")))"
+		args = append(args, cursor.Keys[0], cursor.Keys[1], cursor.ID)
+		argCount += 3
+	}
+
+	limit := ClampSearchLimit(params.Limit)
+	query += " ORDER BY score DESC, entity_type, id LIMIT $" + strconv.Itoa(argCount)
+	args = append(args, limit+1)
+
+	rows, err = database.DB.Query(ctx, query, args...)
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var res model.SearchResult
+		if err := rows.Scan(&res.EntityType, &res.ID, &res.Title, &res.Description,
+			&res.Difficulty, &res.Variant, &res.ImageURL, &res.Score); err != nil {
+			return resp, err
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	if len(resp.Results) > limit {
+		resp.Results = resp.Results[:limit]
+		resp.HasMore = true
+		last := resp.Results[limit-1]
+		next := pagination.Cursor{
+			Sort: searchSortName,
+			Keys: []string{strconv.FormatFloat(last.Score, 'f', -1, 64), last.EntityType},
+			ID:   last.ID,
+		}.Encode()
+		resp.NextCursor = &next
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,384 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// StreakFreezeMilestone nombre de jours consécutifs nécessaires pour gagner un gel de série
+const StreakFreezeMilestone = 7
+
+// MaxAvailableStreakFreezes nombre maximum de gels de série détenus en même temps
+const MaxAvailableStreakFreezes = 2
+
+// StreakHistoryLimit nombre de séries retournées dans l'historique
+const StreakHistoryLimit = 10
+
+// ErrUserNotFound utilisateur inexistant ou supprimé
+var ErrUserNotFound = errors.New("utilisateur introuvable")
+
+// GetUserTimezone récupère le fuseau horaire d'un utilisateur (UTC par défaut)
+func GetUserTimezone(ctx context.Context, userID string) (string, error) {
+	var timezone sql.NullString
+	err := database.DB.QueryRow(ctx,
+		`SELECT timezone FROM users WHERE id = $1 AND deleted_at IS NULL`,
+		userID,
+	).Scan(&timezone)
+	if err == pgx.ErrNoRows {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !timezone.Valid || timezone.String == "" {
+		return "UTC", nil
+	}
+	return timezone.String, nil
+}
+
+// UserLocalToday retourne la date du jour dans le fuseau horaire de l'utilisateur
+func UserLocalToday(ctx context.Context, userID string) (time.Time, error) {
+	timezone, err := GetUserTimezone(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	now := time.Now().In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// scanStreakPeriods lit les lignes de la vue user_streak_islands
+func scanStreakPeriods(rows pgx.Rows) ([]model.StreakPeriod, error) {
+	defer rows.Close()
+
+	periods := []model.StreakPeriod{}
+	for rows.Next() {
+		var period model.StreakPeriod
+		var startDate, endDate time.Time
+		if err := rows.Scan(&startDate, &endDate, &period.Length, &period.IsCurrent); err != nil {
+			return nil, err
+		}
+		period.StartDate = startDate.Format("2006-01-02")
+		period.EndDate = endDate.Format("2006-01-02")
+		periods = append(periods, period)
+	}
+
+	return periods, rows.Err()
+}
+
+// GetCurrentStreak retourne la série en cours d'un utilisateur (nil si aucune)
+func GetCurrentStreak(ctx context.Context, userID string) (*model.StreakPeriod, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT start_date, end_date, length, is_current
+		FROM user_streak_islands
+		WHERE user_id = $1 AND is_current
+		ORDER BY end_date DESC
+		LIMIT 1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	periods, err := scanStreakPeriods(rows)
+	if err != nil || len(periods) == 0 {
+		return nil, err
+	}
+	return &periods[0], nil
+}
+
+// GetStreakHistory retourne les plus longues séries d'un utilisateur
+func GetStreakHistory(ctx context.Context, userID string, limit int) ([]model.StreakPeriod, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT start_date, end_date, length, is_current
+		FROM user_streak_islands
+		WHERE user_id = $1
+		ORDER BY length DESC, end_date DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStreakPeriods(rows)
+}
+
+// GetStreakSummary calcule la série en cours, la meilleure série et l'historique d'un utilisateur
+func GetStreakSummary(ctx context.Context, userID string) (*model.StreakSummary, error) {
+	timezone, err := GetUserTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.StreakSummary{UserID: userID, Timezone: timezone}
+
+	current, err := GetCurrentStreak(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		summary.Current = current
+		summary.CurrentStreak = current.Length
+	}
+
+	history, err := GetStreakHistory(ctx, userID, StreakHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	summary.History = history
+	if len(history) > 0 {
+		summary.Longest = &history[0]
+		summary.MaxStreak = history[0].Length
+	}
+
+	var lastWorkout sql.NullTime
+	err = database.DB.QueryRow(ctx, `
+		SELECT MAX((start_time AT TIME ZONE 'UTC' AT TIME ZONE $2)::date)
+		FROM workout_sessions
+		WHERE user_id = $1 AND completed = TRUE
+	`, userID, timezone).Scan(&lastWorkout)
+	if err != nil {
+		return nil, err
+	}
+	if lastWorkout.Valid {
+		date := lastWorkout.Time.Format("2006-01-02")
+		summary.LastWorkoutDate = &date
+	}
+
+	summary.FreezesAvailable, err = CountAvailableStreakFreezes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// CountAvailableStreakFreezes compte les gels de série non utilisés d'un utilisateur
+func CountAvailableStreakFreezes(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := database.DB.QueryRow(ctx,
+		`SELECT COUNT(*) FROM streak_freezes WHERE user_id = $1 AND used_on IS NULL`,
+		userID,
+	).Scan(&count)
+	return count, err
+}
+
+// GetStreakFreezes récupère tous les gels de série d'un utilisateur (disponibles en premier)
+func GetStreakFreezes(ctx context.Context, userID string) ([]model.StreakFreeze, error) {
+	rows, err := database.DB.Query(ctx, `
+		SELECT id, user_id, source, milestone, used_on, earned_at, used_at
+		FROM streak_freezes
+		WHERE user_id = $1
+		ORDER BY used_on IS NOT NULL, used_on DESC, earned_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	freezes := []model.StreakFreeze{}
+	for rows.Next() {
+		freeze, err := scanStreakFreeze(rows)
+		if err != nil {
+			return nil, err
+		}
+		freezes = append(freezes, *freeze)
+	}
+
+	return freezes, rows.Err()
+}
+
+func scanStreakFreeze(row pgx.Row) (*model.StreakFreeze, error) {
+	var freeze model.StreakFreeze
+	var milestone sql.NullInt64
+	var usedOn, usedAt sql.NullTime
+
+	if err := row.Scan(&freeze.ID, &freeze.UserID, &freeze.Source, &milestone, &usedOn, &freeze.EarnedAt, &usedAt); err != nil {
+		return nil, err
+	}
+
+	freeze.Milestone = NullInt64ToPointer(milestone)
+	freeze.UsedAt = NullTimeToPointer(usedAt)
+	if usedOn.Valid {
+		date := usedOn.Time.Format("2006-01-02")
+		freeze.UsedOn = &date
+	}
+
+	return &freeze, nil
+}
+
+// AwardStreakFreezes attribue un gel de série lorsque la série en cours atteint un nouveau palier.
+// Retourne true si un gel a été gagné.
+func AwardStreakFreezes(ctx context.Context, userID string) (bool, error) {
+	current, err := GetCurrentStreak(ctx, userID)
+	if err != nil || current == nil {
+		return false, err
+	}
+
+	milestone := (current.Length / StreakFreezeMilestone) * StreakFreezeMilestone
+	if milestone == 0 {
+		return false, nil
+	}
+
+	available, err := CountAvailableStreakFreezes(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if available >= MaxAvailableStreakFreezes {
+		return false, nil
+	}
+
+	res, err := database.DB.Exec(ctx, `
+		INSERT INTO streak_freezes(user_id, source, streak_start, milestone, earned_at, created_at, updated_at)
+		VALUES($1, 'earned', $2, $3, NOW(), NOW(), NOW())
+		ON CONFLICT (user_id, streak_start, milestone) DO NOTHING
+	`, userID, current.StartDate, milestone)
+	if err != nil {
+		return false, fmt.Errorf("impossible d'attribuer le gel de série: %w", err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// UseStreakFreeze dépense un gel de série disponible pour protéger un jour de repos passé
+func UseStreakFreeze(ctx context.Context, userID string, day time.Time) (*model.StreakFreeze, error) {
+	today, err := UserLocalToday(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if !day.Before(today) {
+		return nil, fmt.Errorf("seul un jour passé peut être protégé")
+	}
+
+	timezone, err := GetUserTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return useStreakFreeze(ctx, database.DB, userID, timezone, day)
+}
+
+// useStreakFreeze dépense le plus ancien gel disponible sur un jour sans entraînement ni gel
+func useStreakFreeze(ctx context.Context, db dbExecutor, userID, timezone string, day time.Time) (*model.StreakFreeze, error) {
+	var alreadyActive bool
+	err := db.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM workout_sessions
+			WHERE user_id = $1 AND completed = TRUE
+			AND (start_time AT TIME ZONE 'UTC' AT TIME ZONE $2)::date = $3
+		) OR EXISTS(
+			SELECT 1 FROM streak_freezes WHERE user_id = $1 AND used_on = $3
+		)
+	`, userID, timezone, day).Scan(&alreadyActive)
+	if err != nil {
+		return nil, err
+	}
+	if alreadyActive {
+		return nil, fmt.Errorf("ce jour est déjà couvert par un entraînement ou un gel")
+	}
+
+	row := db.QueryRow(ctx, `
+		UPDATE streak_freezes
+		SET used_on = $2, used_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM streak_freezes
+			WHERE user_id = $1 AND used_on IS NULL
+			ORDER BY earned_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, source, milestone, used_on, earned_at, used_at
+	`, userID, day)
+
+	freeze, err := scanStreakFreeze(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("aucun gel de série disponible")
+		}
+		return nil, err
+	}
+
+	return freeze, nil
+}
+
+// ProtectStreak dépense automatiquement les gels disponibles pour combler les jours de repos
+// entre le dernier jour actif (entraînement ou gel) et aujourd'hui. Les gels sont dépensés
+// tous ensemble ou pas du tout. Retourne le nombre de gels utilisés.
+func ProtectStreak(ctx context.Context, userID string) (int, error) {
+	today, err := UserLocalToday(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	timezone, err := GetUserTimezone(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Verrouiller les gels disponibles pour éviter une dépense concurrente
+	var available int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM (
+			SELECT id FROM streak_freezes
+			WHERE user_id = $1 AND used_on IS NULL
+			FOR UPDATE
+		) f
+	`, userID).Scan(&available)
+	if err != nil || available == 0 {
+		return 0, err
+	}
+
+	// Dernier jour actif avant aujourd'hui
+	var lastActive sql.NullTime
+	err = tx.QueryRow(ctx, `
+		SELECT MAX(day) FROM (
+			SELECT (start_time AT TIME ZONE 'UTC' AT TIME ZONE $2)::date AS day
+			FROM workout_sessions
+			WHERE user_id = $1 AND completed = TRUE
+			UNION ALL
+			SELECT used_on FROM streak_freezes
+			WHERE user_id = $1 AND used_on IS NOT NULL
+		) days
+		WHERE day < $3
+	`, userID, timezone, today).Scan(&lastActive)
+	if err != nil || !lastActive.Valid {
+		return 0, err
+	}
+
+	gap := int(today.Sub(lastActive.Time).Hours()/24) - 1
+	if gap <= 0 || gap > available {
+		// Rien à combler, ou pas assez de gels pour sauver la série : on les garde
+		return 0, nil
+	}
+
+	for i := 1; i <= gap; i++ {
+		if _, err := useStreakFreeze(ctx, tx, userID, timezone, lastActive.Time.AddDate(0, 0, i)); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return gap, nil
+}
@@ -0,0 +1,800 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/MassBabyGeek/PumpPro-backend/internal/pagination"
+	"github.com/jackc/pgx/v5"
+)
+
+// TrainingPlanMaxWeeks durée maximale d'un plan
+const TrainingPlanMaxWeeks = 52
+
+var (
+	ErrTrainingPlanNotFound       = errors.New("plan d'entraînement introuvable")
+	ErrTrainingEnrollmentNotFound = errors.New("inscription au plan introuvable")
+	ErrAlreadyEnrolled            = errors.New("déjà inscrit à ce plan")
+	ErrInvalidMissedDayPolicy     = errors.New("politique de jour manqué invalide (shift, skip ou repeat)")
+)
+
+// NormalizeMissedDayPolicy valide une politique de jour manqué (vide = fallback)
+func NormalizeMissedDayPolicy(policy, fallback string) (string, error) {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if policy == "" {
+		policy = fallback
+	}
+	switch policy {
+	case model.MissedDayPolicyShift, model.MissedDayPolicySkip, model.MissedDayPolicyRepeat:
+		return policy, nil
+	}
+	return "", ErrInvalidMissedDayPolicy
+}
+
+// ValidateTrainingPlan vérifie un plan avant enregistrement et applique les valeurs par défaut
+func ValidateTrainingPlan(plan *model.TrainingPlan) error {
+	plan.Name = strings.TrimSpace(plan.Name)
+	if plan.Name == "" {
+		return errors.New("le nom du plan est requis")
+	}
+	if difficultyIndex(plan.Difficulty) < 0 {
+		return errors.New("difficulté invalide (BEGINNER, INTERMEDIATE ou ADVANCED)")
+	}
+	if plan.Weeks < 1 || plan.Weeks > TrainingPlanMaxWeeks {
+		return fmt.Errorf("le plan doit durer entre 1 et %d semaines", TrainingPlanMaxWeeks)
+	}
+
+	policy, err := NormalizeMissedDayPolicy(plan.MissedDayPolicy, model.MissedDayPolicyShift)
+	if err != nil {
+		return err
+	}
+	plan.MissedDayPolicy = policy
+
+	seen := map[int]bool{}
+	workouts := 0
+	for _, d := range plan.Days {
+		if d.Week < 1 || d.Week > plan.Weeks {
+			return fmt.Errorf("semaine %d hors du plan (1 à %d)", d.Week, plan.Weeks)
+		}
+		if d.Day < 1 || d.Day > 7 {
+			return fmt.Errorf("jour %d invalide (1 à 7)", d.Day)
+		}
+		idx := PlanDayIndex(d.Week, d.Day)
+		if seen[idx] {
+			return fmt.Errorf("jour en double : semaine %d jour %d", d.Week, d.Day)
+		}
+		seen[idx] = true
+		if d.ProgramID != nil && *d.ProgramID != "" {
+			workouts++
+		}
+	}
+	if workouts == 0 {
+		return errors.New("le plan doit contenir au moins une séance")
+	}
+	plan.WorkoutCount = workouts
+
+	return nil
+}
+
+// PlanDayIndex position d'un jour dans le plan (0 = semaine 1 jour 1)
+func PlanDayIndex(week, day int) int {
+	return (week-1)*7 + (day - 1)
+}
+
+// PlanWeekDay semaine et jour (à partir de 1) d'une position
+func PlanWeekDay(position int) (int, int) {
+	return position/7 + 1, position%7 + 1
+}
+
+// BuildPlanSchedule programme prévu pour chaque jour du plan (nil = repos)
+func BuildPlanSchedule(weeks int, days []model.TrainingPlanDay) []*string {
+	schedule := make([]*string, weeks*7)
+	for _, d := range days {
+		idx := PlanDayIndex(d.Week, d.Day)
+		if idx >= 0 && idx < len(schedule) && d.ProgramID != nil && *d.ProgramID != "" {
+			schedule[idx] = d.ProgramID
+		}
+	}
+	return schedule
+}
+
+// hasRemainingWorkout indique s'il reste une séance à partir d'une position
+func hasRemainingWorkout(schedule []*string, position int) bool {
+	for i := position; i < len(schedule); i++ {
+		if schedule[i] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvePlanPosition rattrape le calendrier jusqu'à aujourd'hui : les jours de repos passés sont consommés,
+// les séances manquées sont traitées selon la politique. Retourne la position, sa date prévue et le nombre de séances manquées.
+func ResolvePlanPosition(schedule []*string, position int, scheduled, today time.Time, policy string) (int, time.Time, int) {
+	missed := 0
+	for scheduled.Before(today) && position < len(schedule) {
+		if schedule[position] == nil {
+			position++
+			scheduled = scheduled.AddDate(0, 0, 1)
+			continue
+		}
+
+		missed++
+		switch policy {
+		case model.MissedDayPolicySkip:
+			position++
+			scheduled = scheduled.AddDate(0, 0, 1)
+		case model.MissedDayPolicyRepeat:
+			week, _ := PlanWeekDay(position)
+			position = PlanDayIndex(week, 1)
+			scheduled = today
+		default:
+			scheduled = today
+		}
+	}
+	return position, scheduled, missed
+}
+
+// NextPlanWorkoutDate date de la prochaine séance à partir d'une position (nil s'il n'y en a plus)
+func NextPlanWorkoutDate(schedule []*string, position int, scheduled time.Time) *time.Time {
+	for i := position; i < len(schedule); i++ {
+		if schedule[i] != nil {
+			date := scheduled.AddDate(0, 0, i-position)
+			return &date
+		}
+	}
+	return nil
+}
+
+const trainingPlanColumns = `tp.id, tp.name, tp.description, tp.difficulty, tp.weeks, tp.missed_day_policy,
+	(SELECT COUNT(*) FROM training_plan_days tpd WHERE tpd.plan_id = tp.id AND tpd.program_id IS NOT NULL)::int,
+	tp.created_by, tp.updated_by, tp.created_at, tp.updated_at`
+
+func scanTrainingPlan(row pgx.Row) (*model.TrainingPlan, error) {
+	var plan model.TrainingPlan
+	var description, createdBy, updatedBy sql.NullString
+
+	err := row.Scan(
+		&plan.ID, &plan.Name, &description, &plan.Difficulty, &plan.Weeks, &plan.MissedDayPolicy,
+		&plan.WorkoutCount, &createdBy, &updatedBy, &plan.CreatedAt, &plan.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Description = NullStringToPointer(description)
+	plan.CreatedBy = NullStringToPointer(createdBy)
+	plan.UpdatedBy = NullStringToPointer(updatedBy)
+
+	return &plan, nil
+}
+
+// getTrainingPlanDays charge les jours d'un plan avec le nom des programmes
+func getTrainingPlanDays(ctx context.Context, db dbExecutor, planID string) ([]model.TrainingPlanDay, error) {
+	rows, err := db.Query(ctx, `
+		SELECT tpd.week_number, tpd.day_number, tpd.program_id, wp.name, tpd.notes
+		FROM training_plan_days tpd
+		LEFT JOIN workout_programs wp ON wp.id = tpd.program_id AND wp.deleted_at IS NULL
+		WHERE tpd.plan_id = $1
+		ORDER BY tpd.week_number, tpd.day_number
+	`, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []model.TrainingPlanDay{}
+	for rows.Next() {
+		var d model.TrainingPlanDay
+		var programID, programName, notes sql.NullString
+		if err := rows.Scan(&d.Week, &d.Day, &programID, &programName, &notes); err != nil {
+			return nil, err
+		}
+		d.ProgramID = NullStringToPointer(programID)
+		d.ProgramName = NullStringToPointer(programName)
+		d.Notes = NullStringToPointer(notes)
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// insertTrainingPlanDays enregistre les jours d'un plan
+func insertTrainingPlanDays(ctx context.Context, tx pgx.Tx, planID string, days []model.TrainingPlanDay) error {
+	for _, d := range days {
+		programID := d.ProgramID
+		if programID != nil && *programID == "" {
+			programID = nil
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO training_plan_days(plan_id, week_number, day_number, program_id, notes)
+			VALUES($1, $2, $3, $4, $5)
+		`, planID, d.Week, d.Day, programID, d.Notes)
+		if err != nil {
+			return fmt.Errorf("impossible d'enregistrer le jour %d de la semaine %d: %w", d.Day, d.Week, err)
+		}
+	}
+	return nil
+}
+
+// CreateTrainingPlan crée un plan et ses jours
+func CreateTrainingPlan(ctx context.Context, plan *model.TrainingPlan, creatorID string) (*model.TrainingPlan, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var planID string
+	err = tx.QueryRow(ctx, `
+		INSERT INTO training_plans(name, description, difficulty, weeks, missed_day_policy, created_by, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING id
+	`, plan.Name, plan.Description, plan.Difficulty, plan.Weeks, plan.MissedDayPolicy, creatorID).Scan(&planID)
+	if err != nil {
+		return nil, fmt.Errorf("impossible de créer le plan: %w", err)
+	}
+
+	if err := insertTrainingPlanDays(ctx, tx, planID, plan.Days); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return GetTrainingPlan(ctx, planID)
+}
+
+// UpdateTrainingPlan met à jour un plan et remplace ses jours
+func UpdateTrainingPlan(ctx context.Context, planID string, plan *model.TrainingPlan, updatedBy string) (*model.TrainingPlan, error) {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE training_plans
+		SET name = $2, description = $3, difficulty = $4, weeks = $5, missed_day_policy = $6,
+			updated_by = $7, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`, planID, plan.Name, plan.Description, plan.Difficulty, plan.Weeks, plan.MissedDayPolicy, updatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrTrainingPlanNotFound
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM training_plan_days WHERE plan_id = $1`, planID); err != nil {
+		return nil, err
+	}
+	if err := insertTrainingPlanDays(ctx, tx, planID, plan.Days); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return GetTrainingPlan(ctx, planID)
+}
+
+// DeleteTrainingPlan supprime un plan (soft delete) et clôt les inscriptions en cours
+func DeleteTrainingPlan(ctx context.Context, planID, deletedBy string) error {
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE training_plans SET deleted_at = NOW(), deleted_by = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, planID, deletedBy)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTrainingPlanNotFound
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE training_plan_enrollments SET status = $2, updated_at = NOW()
+		WHERE plan_id = $1 AND status = $3
+	`, planID, model.TrainingEnrollmentAbandoned, model.TrainingEnrollmentActive)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetTrainingPlan récupère un plan avec ses jours
+func GetTrainingPlan(ctx context.Context, planID string) (*model.TrainingPlan, error) {
+	plan, err := scanTrainingPlan(database.DB.QueryRow(ctx, `
+		SELECT `+trainingPlanColumns+`
+		FROM training_plans tp
+		WHERE tp.id = $1 AND tp.deleted_at IS NULL
+	`, planID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrTrainingPlanNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if plan.Days, err = getTrainingPlanDays(ctx, database.DB, planID); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// GetTrainingPlanOwner retourne le créateur d'un plan ("" si inconnu)
+func GetTrainingPlanOwner(ctx context.Context, planID string) (string, error) {
+	var createdBy sql.NullString
+	err := database.DB.QueryRow(ctx,
+		`SELECT created_by FROM training_plans WHERE id = $1 AND deleted_at IS NULL`,
+		planID,
+	).Scan(&createdBy)
+	if err == pgx.ErrNoRows {
+		return "", ErrTrainingPlanNotFound
+	}
+	return NullStringToString(createdBy), err
+}
+
+// trainingPlanSort tri de la liste des plans : plus récents d'abord
+var trainingPlanSort = pagination.Sort{
+	Name:    "created",
+	Columns: []pagination.Column{{Expr: "tp.created_at", Cast: "timestamp"}},
+	Desc:    true,
+}
+
+// ListTrainingPlans liste les plans (filtre de difficulté optionnel), sans le détail des jours
+func ListTrainingPlans(ctx context.Context, difficulty string, page pagination.Params) (pagination.Page[model.TrainingPlan], error) {
+	sqlQuery := `
+		SELECT ` + trainingPlanColumns + `
+		FROM training_plans tp
+		WHERE tp.deleted_at IS NULL
+	`
+	args := []interface{}{}
+	argCount := 1
+
+	if difficulty != "" {
+		sqlQuery += " AND tp.difficulty = $" + strconv.Itoa(argCount)
+		args = append(args, difficulty)
+		argCount++
+	}
+
+	sqlQuery, args, err := page.Apply(sqlQuery, args, argCount, trainingPlanSort, "tp.id")
+	if err != nil {
+		return pagination.Page[model.TrainingPlan]{}, err
+	}
+
+	rows, err := database.DB.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return pagination.Page[model.TrainingPlan]{}, err
+	}
+	defer rows.Close()
+
+	var plans []model.TrainingPlan
+	for rows.Next() {
+		plan, err := scanTrainingPlan(rows)
+		if err != nil {
+			return pagination.Page[model.TrainingPlan]{}, err
+		}
+		plans = append(plans, *plan)
+	}
+	if err := rows.Err(); err != nil {
+		return pagination.Page[model.TrainingPlan]{}, err
+	}
+
+	return pagination.NewPage(plans, page, trainingPlanSort, func(p model.TrainingPlan) ([]string, string) {
+		return []string{pagination.TimeKey(p.CreatedAt)}, p.ID
+	}), nil
+}
+
+// loadPlanSchedule charge le calendrier d'un plan
+func loadPlanSchedule(ctx context.Context, db dbExecutor, planID string) ([]*string, []model.TrainingPlanDay, error) {
+	var weeks int
+	if err := db.QueryRow(ctx, `SELECT weeks FROM training_plans WHERE id = $1`, planID).Scan(&weeks); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil, ErrTrainingPlanNotFound
+		}
+		return nil, nil, err
+	}
+	days, err := getTrainingPlanDays(ctx, db, planID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return BuildPlanSchedule(weeks, days), days, nil
+}
+
+const trainingEnrollmentColumns = `e.id, e.plan_id, tp.name, e.user_id, e.status, e.missed_day_policy,
+	e.start_date, e.position, e.scheduled_date, e.completed_workouts, e.missed_workouts,
+	(SELECT COUNT(*) FROM training_plan_days tpd WHERE tpd.plan_id = e.plan_id AND tpd.program_id IS NOT NULL)::int,
+	e.completed_at, e.created_at, e.updated_at`
+
+// trainingEnrollmentState état brut d'une inscription (dates calendaires)
+type trainingEnrollmentState struct {
+	enrollment    model.TrainingPlanEnrollment
+	position      int
+	startDate     time.Time
+	scheduledDate time.Time
+}
+
+func scanTrainingEnrollment(row pgx.Row) (*trainingEnrollmentState, error) {
+	var st trainingEnrollmentState
+	e := &st.enrollment
+	err := row.Scan(
+		&e.ID, &e.PlanID, &e.PlanName, &e.UserID, &e.Status, &e.MissedDayPolicy,
+		&st.startDate, &st.position, &st.scheduledDate, &e.CompletedWorkouts, &e.MissedWorkouts,
+		&e.TotalWorkouts, &e.CompletedAt, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	st.sync()
+	return &st, nil
+}
+
+// sync recopie la position et les dates dans l'inscription exposée
+func (st *trainingEnrollmentState) sync() {
+	st.enrollment.StartDate = st.startDate.Format("2006-01-02")
+	st.enrollment.ScheduledDate = st.scheduledDate.Format("2006-01-02")
+	st.enrollment.Week, st.enrollment.Day = PlanWeekDay(st.position)
+}
+
+// resolve rattrape le calendrier jusqu'à aujourd'hui ; retourne true si l'état a changé
+func (st *trainingEnrollmentState) resolve(schedule []*string, today time.Time) bool {
+	if st.enrollment.Status != model.TrainingEnrollmentActive {
+		return false
+	}
+	position, scheduled, missed := ResolvePlanPosition(schedule, st.position, st.scheduledDate, today, st.enrollment.MissedDayPolicy)
+	changed := position != st.position || !scheduled.Equal(st.scheduledDate)
+	st.position, st.scheduledDate = position, scheduled
+	st.enrollment.MissedWorkouts += missed
+	st.finishIfDone(schedule)
+	st.sync()
+	return changed || missed > 0
+}
+
+// finishIfDone termine l'inscription quand il ne reste plus de séance
+func (st *trainingEnrollmentState) finishIfDone(schedule []*string) {
+	if st.enrollment.Status == model.TrainingEnrollmentActive && !hasRemainingWorkout(schedule, st.position) {
+		st.enrollment.Status = model.TrainingEnrollmentCompleted
+		now := time.Now()
+		st.enrollment.CompletedAt = &now
+	}
+}
+
+// save enregistre la position, les compteurs et le statut
+func (st *trainingEnrollmentState) save(ctx context.Context, db dbExecutor) error {
+	_, err := db.Exec(ctx, `
+		UPDATE training_plan_enrollments
+		SET position = $2, scheduled_date = $3, completed_workouts = $4, missed_workouts = $5,
+			status = $6, completed_at = $7, updated_at = NOW()
+		WHERE id = $1
+	`, st.enrollment.ID, st.position, st.scheduledDate, st.enrollment.CompletedWorkouts,
+		st.enrollment.MissedWorkouts, st.enrollment.Status, st.enrollment.CompletedAt)
+	return err
+}
+
+// getActiveEnrollmentStates charge les inscriptions actives d'un utilisateur
+func getActiveEnrollmentStates(ctx context.Context, db dbExecutor, userID string) ([]*trainingEnrollmentState, error) {
+	rows, err := db.Query(ctx, `
+		SELECT `+trainingEnrollmentColumns+`
+		FROM training_plan_enrollments e
+		INNER JOIN training_plans tp ON tp.id = e.plan_id AND tp.deleted_at IS NULL
+		WHERE e.user_id = $1 AND e.status = $2
+		ORDER BY e.created_at
+	`, userID, model.TrainingEnrollmentActive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []*trainingEnrollmentState
+	for rows.Next() {
+		st, err := scanTrainingEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, st)
+	}
+	return states, rows.Err()
+}
+
+// EnrollInTrainingPlan inscrit un utilisateur à un plan à partir d'une date (aujourd'hui si nil)
+func EnrollInTrainingPlan(ctx context.Context, planID, userID string, startDate *time.Time, policy string) (*model.TrainingPlanEnrollment, error) {
+	plan, err := GetTrainingPlan(ctx, planID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err = NormalizeMissedDayPolicy(policy, plan.MissedDayPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	start := startDate
+	if start == nil {
+		today, err := UserLocalToday(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		start = &today
+	}
+
+	var enrollmentID string
+	err = database.DB.QueryRow(ctx, `
+		INSERT INTO training_plan_enrollments(plan_id, user_id, status, missed_day_policy, start_date, position, scheduled_date, created_at, updated_at)
+		VALUES($1, $2, $3, $4, $5, 0, $5, NOW(), NOW())
+		ON CONFLICT (plan_id, user_id) WHERE status = 'active' DO NOTHING
+		RETURNING id
+	`, planID, userID, model.TrainingEnrollmentActive, policy, *start).Scan(&enrollmentID)
+	if err == pgx.ErrNoRows {
+		return nil, ErrAlreadyEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return GetTrainingEnrollment(ctx, enrollmentID, userID)
+}
+
+// GetTrainingEnrollment récupère une inscription de l'utilisateur, calendrier rattrapé
+func GetTrainingEnrollment(ctx context.Context, enrollmentID, userID string) (*model.TrainingPlanEnrollment, error) {
+	st, err := scanTrainingEnrollment(database.DB.QueryRow(ctx, `
+		SELECT `+trainingEnrollmentColumns+`
+		FROM training_plan_enrollments e
+		INNER JOIN training_plans tp ON tp.id = e.plan_id
+		WHERE e.id = $1 AND e.user_id = $2
+	`, enrollmentID, userID))
+	if err == pgx.ErrNoRows {
+		return nil, ErrTrainingEnrollmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if st.enrollment.Status == model.TrainingEnrollmentActive {
+		schedule, _, err := loadPlanSchedule(ctx, database.DB, st.enrollment.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		today, err := UserLocalToday(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if st.resolve(schedule, today) {
+			if err := st.save(ctx, database.DB); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &st.enrollment, nil
+}
+
+// GetUserTrainingEnrollments récupère toutes les inscriptions d'un utilisateur (actives d'abord)
+func GetUserTrainingEnrollments(ctx context.Context, userID string) ([]model.TrainingPlanEnrollment, error) {
+	today, err := UserLocalToday(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := syncActiveEnrollments(ctx, userID, today); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT `+trainingEnrollmentColumns+`
+		FROM training_plan_enrollments e
+		INNER JOIN training_plans tp ON tp.id = e.plan_id
+		WHERE e.user_id = $1
+		ORDER BY (e.status = 'active') DESC, e.updated_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments := []model.TrainingPlanEnrollment{}
+	for rows.Next() {
+		st, err := scanTrainingEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, st.enrollment)
+	}
+	return enrollments, rows.Err()
+}
+
+// UpdateTrainingEnrollmentPolicy change la politique de jour manqué d'une inscription active
+func UpdateTrainingEnrollmentPolicy(ctx context.Context, enrollmentID, userID, policy string) (*model.TrainingPlanEnrollment, error) {
+	policy, err := NormalizeMissedDayPolicy(policy, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Rattraper le calendrier avec l'ancienne politique avant d'appliquer la nouvelle
+	if _, err := GetTrainingEnrollment(ctx, enrollmentID, userID); err != nil {
+		return nil, err
+	}
+
+	tag, err := database.DB.Exec(ctx, `
+		UPDATE training_plan_enrollments SET missed_day_policy = $3, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = $4
+	`, enrollmentID, userID, policy, model.TrainingEnrollmentActive)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, ErrTrainingEnrollmentNotFound
+	}
+
+	return GetTrainingEnrollment(ctx, enrollmentID, userID)
+}
+
+// AbandonTrainingEnrollment met fin à une inscription active
+func AbandonTrainingEnrollment(ctx context.Context, enrollmentID, userID string) error {
+	tag, err := database.DB.Exec(ctx, `
+		UPDATE training_plan_enrollments SET status = $3, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = $4
+	`, enrollmentID, userID, model.TrainingEnrollmentAbandoned, model.TrainingEnrollmentActive)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTrainingEnrollmentNotFound
+	}
+	return nil
+}
+
+// syncedEnrollment inscription rattrapée avec le calendrier de son plan
+type syncedEnrollment struct {
+	state    *trainingEnrollmentState
+	schedule []*string
+	days     []model.TrainingPlanDay
+}
+
+// syncActiveEnrollments rattrape le calendrier des inscriptions actives jusqu'à aujourd'hui et enregistre les changements
+func syncActiveEnrollments(ctx context.Context, userID string, today time.Time) ([]syncedEnrollment, error) {
+	states, err := getActiveEnrollmentStates(ctx, database.DB, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	synced := make([]syncedEnrollment, 0, len(states))
+	for _, st := range states {
+		schedule, days, err := loadPlanSchedule(ctx, database.DB, st.enrollment.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		if st.resolve(schedule, today) {
+			if err := st.save(ctx, database.DB); err != nil {
+				return nil, err
+			}
+		}
+		synced = append(synced, syncedEnrollment{state: st, schedule: schedule, days: days})
+	}
+	return synced, nil
+}
+
+// GetTrainingPlansToday séance du jour de chaque plan suivi par l'utilisateur (le programme est à charger par l'appelant)
+func GetTrainingPlansToday(ctx context.Context, userID string) ([]model.TrainingPlanToday, error) {
+	today, err := UserLocalToday(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	synced, err := syncActiveEnrollments(ctx, userID, today)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []model.TrainingPlanToday{}
+	for _, se := range synced {
+		st, schedule, days := se.state, se.schedule, se.days
+		if st.enrollment.Status != model.TrainingEnrollmentActive {
+			continue
+		}
+
+		entry := model.TrainingPlanToday{Enrollment: st.enrollment, Date: today.Format("2006-01-02")}
+
+		switch {
+		case st.scheduledDate.After(today):
+			// Séance du jour déjà faite, ou plan pas encore commencé
+			entry.Done = !st.startDate.After(today)
+			entry.IsRestDay = !entry.Done
+		case schedule[st.position] == nil:
+			entry.IsRestDay = true
+		default:
+			entry.Program = &model.WorkoutProgram{ID: *schedule[st.position]}
+			week, day := PlanWeekDay(st.position)
+			for _, d := range days {
+				if d.Week == week && d.Day == day {
+					entry.Notes = d.Notes
+					break
+				}
+			}
+		}
+
+		if next := NextPlanWorkoutDate(schedule, st.position, st.scheduledDate); next != nil && entry.Program == nil {
+			date := next.Format("2006-01-02")
+			entry.NextWorkoutDate = &date
+		}
+
+		result = append(result, entry)
+	}
+
+	return result, nil
+}
+
+// AdvanceTrainingPlans fait avancer les plans dont la séance du jour correspond au programme réalisé.
+// Appelée après l'enregistrement d'une session terminée ; retourne les inscriptions modifiées.
+func AdvanceTrainingPlans(ctx context.Context, userID, programID string) ([]model.TrainingPlanEnrollment, error) {
+	today, err := UserLocalToday(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Verrouiller les inscriptions pour éviter un double avancement
+	if _, err := tx.Exec(ctx, `
+		SELECT 1 FROM training_plan_enrollments WHERE user_id = $1 AND status = $2 FOR UPDATE
+	`, userID, model.TrainingEnrollmentActive); err != nil {
+		return nil, err
+	}
+
+	states, err := getActiveEnrollmentStates(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var advanced []model.TrainingPlanEnrollment
+	for _, st := range states {
+		schedule, _, err := loadPlanSchedule(ctx, tx, st.enrollment.PlanID)
+		if err != nil {
+			return nil, err
+		}
+
+		changed := st.resolve(schedule, today)
+		if st.enrollment.Status == model.TrainingEnrollmentActive &&
+			st.scheduledDate.Equal(today) &&
+			schedule[st.position] != nil && *schedule[st.position] == programID {
+			st.position++
+			st.scheduledDate = today.AddDate(0, 0, 1)
+			st.enrollment.CompletedWorkouts++
+			st.finishIfDone(schedule)
+			st.sync()
+			advanced = append(advanced, st.enrollment)
+			changed = true
+		}
+
+		if changed {
+			if err := st.save(ctx, tx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return advanced, nil
+}
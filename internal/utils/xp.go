@@ -0,0 +1,533 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/MassBabyGeek/PumpPro-backend/internal/database"
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+	"github.com/lib/pq"
+)
+
+// Sources de gain d'XP
+const (
+	XPSourceWorkout       = "workout"
+	XPSourceChallenge     = "challenge"
+	XPSourceChallengeTask = "challenge_task"
+	XPSourceRecalculation = "recalculation"
+)
+
+// Règles de gain d'XP
+const (
+	XPPerRep                 = 1
+	XPFirstWorkoutOfDayBonus = 25
+	XPPersonalRecordBonus    = 50
+	XPStreakMultiplierStep   = 0.05 // +5% par jour de série au-delà du premier
+	XPStreakMultiplierMax    = 1.5
+	XPMaxLevel               = 200
+)
+
+// xpDifficultyBonus XP accordée pour une séance complétée selon la difficulté
+var xpDifficultyBonus = map[string]int{
+	"BEGINNER":     10,
+	"INTERMEDIATE": 20,
+	"ADVANCED":     30,
+}
+
+// XPCurve courbe de progression : XP pour passer du niveau N à N+1 = Base * N^Exponent
+type XPCurve struct {
+	Base     float64
+	Exponent float64
+}
+
+var xpCurve = XPCurve{Base: 100, Exponent: 1.5}
+
+// ConfigureXPCurve définit la courbe de progression utilisée pour le calcul des niveaux
+func ConfigureXPCurve(base, exponent float64) {
+	if base <= 0 || exponent <= 0 {
+		return
+	}
+	xpCurve = XPCurve{Base: base, Exponent: exponent}
+}
+
+// XPRequiredForLevel XP nécessaire pour passer du niveau level au niveau suivant
+func XPRequiredForLevel(level int) int64 {
+	if level < 1 {
+		level = 1
+	}
+	return int64(math.Round(xpCurve.Base * math.Pow(float64(level), xpCurve.Exponent)))
+}
+
+// LevelFromXP calcule le niveau atteint avec xp, l'XP acquise dans ce niveau et l'XP restante
+// pour atteindre le suivant
+func LevelFromXP(xp int64) (level int, xpIntoLevel int64, xpToNextLevel int64) {
+	level = 1
+	remaining := xp
+	for level < XPMaxLevel {
+		required := XPRequiredForLevel(level)
+		if remaining < required {
+			return level, remaining, required - remaining
+		}
+		remaining -= required
+		level++
+	}
+	return level, remaining, 0
+}
+
+// WorkoutXPInput données d'une séance nécessaires au calcul de l'XP
+type WorkoutXPInput struct {
+	TotalReps      int
+	Difficulty     string
+	Completed      bool
+	CurrentStreak  int // Longueur de la série en incluant le jour de la séance
+	FirstOfDay     bool
+	PersonalRecord bool
+}
+
+// StreakXPMultiplier multiplicateur d'XP pour une série de streak jours
+func StreakXPMultiplier(streak int) float64 {
+	if streak <= 1 {
+		return 1
+	}
+	multiplier := 1 + float64(streak-1)*XPStreakMultiplierStep
+	if multiplier > XPStreakMultiplierMax {
+		multiplier = XPStreakMultiplierMax
+	}
+	return math.Round(multiplier*100) / 100
+}
+
+// ComputeWorkoutXP calcule l'XP d'une séance (sans accès base de données)
+func ComputeWorkoutXP(in WorkoutXPInput) model.XPAward {
+	award := model.XPAward{
+		BaseXP:     in.TotalReps * XPPerRep,
+		Multiplier: 1,
+		Reasons:    []string{},
+	}
+
+	if in.Completed {
+		award.BonusXP += xpDifficultyBonus[in.Difficulty]
+		award.Reasons = append(award.Reasons, "completed_"+in.Difficulty)
+	}
+	if in.FirstOfDay && in.TotalReps > 0 {
+		award.BonusXP += XPFirstWorkoutOfDayBonus
+		award.Reasons = append(award.Reasons, "first_workout_of_day")
+	}
+	if in.PersonalRecord {
+		award.BonusXP += XPPersonalRecordBonus
+		award.Reasons = append(award.Reasons, "personal_record")
+	}
+
+	award.Multiplier = StreakXPMultiplier(in.CurrentStreak)
+	if award.Multiplier > 1 {
+		award.Reasons = append(award.Reasons, fmt.Sprintf("streak_x%.2f", award.Multiplier))
+	}
+
+	award.TotalXP = int(math.Round(float64(award.BaseXP+award.BonusXP) * award.Multiplier))
+	return award
+}
+
+// WorkoutXPHistoryItem séance passée utilisée pour recalculer l'XP
+type WorkoutXPHistoryItem struct {
+	Day        time.Time // Jour local de la séance
+	TotalReps  int
+	Difficulty string
+	Completed  bool
+}
+
+// xpDayKey clé d'un jour calendaire (indépendante de la durée du jour lors des changements d'heure)
+func xpDayKey(day time.Time) string {
+	return day.Format("2006-01-02")
+}
+
+// replayStreak longueur de la série en cours au jour day, selon les règles de user_streak_islands :
+// les jours de séance complétée et les jours gelés se suivent, seuls les premiers comptent,
+// et la série est en cours si elle se termine la veille ou le jour même (1 sans série)
+func replayStreak(activeDays, freezeDays map[string]bool, day time.Time) int {
+	linked := func(d time.Time) bool {
+		return activeDays[xpDayKey(d)] || freezeDays[xpDayKey(d)]
+	}
+
+	if !linked(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	length := 0
+	for ; linked(day); day = day.AddDate(0, 0, -1) {
+		if activeDays[xpDayKey(day)] {
+			length++
+		}
+	}
+	if length == 0 {
+		return 1
+	}
+	return length
+}
+
+// ReplayWorkoutXP recalcule l'XP totale d'un historique de séances triées chronologiquement,
+// avec les jours couverts par un gel de série triés (sans accès base de données).
+// Chaque séance reçoit la série qu'aurait lue GetCurrentStreak au moment de son enregistrement.
+func ReplayWorkoutXP(history []WorkoutXPHistoryItem, freezeDays []time.Time) int64 {
+	var total int64
+	var lastDay time.Time
+	bestReps := 0
+	hasPrevious := false
+
+	activeDays := map[string]bool{}
+	usedFreezes := map[string]bool{}
+	nextFreeze := 0
+
+	for _, item := range history {
+		firstOfDay := lastDay.IsZero() || xpDayKey(item.Day) != xpDayKey(lastDay)
+		lastDay = item.Day
+
+		if item.Completed {
+			activeDays[xpDayKey(item.Day)] = true
+		}
+		// Les gels sont dépensés avant l'attribution de l'XP de la séance (ProtectStreak)
+		for ; nextFreeze < len(freezeDays) && xpDayKey(freezeDays[nextFreeze]) <= xpDayKey(item.Day); nextFreeze++ {
+			usedFreezes[xpDayKey(freezeDays[nextFreeze])] = true
+		}
+
+		personalRecord := hasPrevious && item.Completed && item.TotalReps > bestReps
+		award := ComputeWorkoutXP(WorkoutXPInput{
+			TotalReps:      item.TotalReps,
+			Difficulty:     item.Difficulty,
+			Completed:      item.Completed,
+			CurrentStreak:  replayStreak(activeDays, usedFreezes, item.Day),
+			FirstOfDay:     firstOfDay,
+			PersonalRecord: personalRecord,
+		})
+		total += int64(award.TotalXP)
+
+		if item.Completed {
+			if item.TotalReps > bestReps {
+				bestReps = item.TotalReps
+			}
+			hasPrevious = true
+		}
+	}
+
+	return total
+}
+
+// BuildWorkoutXPInput collecte en base les informations de bonus d'une séance qui vient d'être enregistrée
+func BuildWorkoutXPInput(ctx context.Context, userID, sessionID string, totalReps int, difficulty string, completed bool) (WorkoutXPInput, error) {
+	in := WorkoutXPInput{TotalReps: totalReps, Difficulty: difficulty, Completed: completed, CurrentStreak: 1}
+
+	timezone, err := GetUserTimezone(ctx, userID)
+	if err != nil {
+		return in, err
+	}
+
+	var sameDaySessions, previousCompleted, previousBest int
+	err = database.DB.QueryRow(ctx, `
+		WITH current AS (
+			SELECT start_time FROM workout_sessions WHERE id = $2
+		)
+		SELECT
+			(SELECT COUNT(*) FROM workout_sessions ws, current c
+				WHERE ws.user_id = $1 AND ws.id <> $2
+				AND (ws.start_time AT TIME ZONE 'UTC' AT TIME ZONE $3)::date = (c.start_time AT TIME ZONE 'UTC' AT TIME ZONE $3)::date
+				AND ws.start_time <= c.start_time)::int,
+			(SELECT COUNT(*) FROM workout_sessions WHERE user_id = $1 AND id <> $2 AND completed = TRUE)::int,
+			(SELECT COALESCE(MAX(total_reps), 0) FROM workout_sessions WHERE user_id = $1 AND id <> $2 AND completed = TRUE)::int
+	`, userID, sessionID, timezone).Scan(&sameDaySessions, &previousCompleted, &previousBest)
+	if err != nil {
+		return in, err
+	}
+
+	in.FirstOfDay = sameDaySessions == 0
+	in.PersonalRecord = completed && previousCompleted > 0 && totalReps > previousBest
+
+	current, err := GetCurrentStreak(ctx, userID)
+	if err != nil {
+		return in, err
+	}
+	if current != nil {
+		in.CurrentStreak = current.Length
+	}
+
+	return in, nil
+}
+
+// GrantXP ajoute de l'XP à un utilisateur, met à jour son niveau et enregistre un éventuel passage de niveau
+func GrantXP(ctx context.Context, userID, source string, sourceID *string, award model.XPAward) (*model.LevelUp, error) {
+	if award.TotalXP <= 0 {
+		return nil, nil
+	}
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO xp_events(user_id, source, source_id, base_xp, bonus_xp, multiplier, total_xp, reasons, created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+	`, userID, source, sourceID, award.BaseXP, award.BonusXP, award.Multiplier, award.TotalXP, pq.Array(award.Reasons))
+	if err != nil {
+		return nil, fmt.Errorf("impossible d'enregistrer le gain d'XP: %w", err)
+	}
+
+	var xp int64
+	var previousLevel int
+	err = tx.QueryRow(ctx, `
+		UPDATE users SET xp = xp + $1 WHERE id = $2 AND deleted_at IS NULL
+		RETURNING xp, level
+	`, award.TotalXP, userID).Scan(&xp, &previousLevel)
+	if err != nil {
+		return nil, fmt.Errorf("impossible de mettre à jour l'XP: %w", err)
+	}
+
+	var levelUp *model.LevelUp
+	newLevel, _, _ := LevelFromXP(xp)
+	if newLevel != previousLevel {
+		if _, err := tx.Exec(ctx, `UPDATE users SET level = $1 WHERE id = $2`, newLevel, userID); err != nil {
+			return nil, err
+		}
+	}
+	if newLevel > previousLevel {
+		levelUp = &model.LevelUp{FromLevel: previousLevel, ToLevel: newLevel, XP: xp}
+		err = tx.QueryRow(ctx, `
+			INSERT INTO user_level_ups(user_id, from_level, to_level, xp, created_at)
+			VALUES($1, $2, $3, $4, NOW())
+			RETURNING created_at
+		`, userID, previousLevel, newLevel, xp).Scan(&levelUp.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("impossible d'enregistrer le passage de niveau: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return levelUp, nil
+}
+
+// GrantFlatXP ajoute un gain d'XP fixe (challenge, tâche...)
+func GrantFlatXP(ctx context.Context, userID, source string, sourceID *string, xp int) (*model.LevelUp, error) {
+	return GrantXP(ctx, userID, source, sourceID, model.XPAward{
+		BaseXP:     xp,
+		Multiplier: 1,
+		TotalXP:    xp,
+		Reasons:    []string{source},
+	})
+}
+
+// GetUserXP récupère la progression d'XP, les derniers gains et passages de niveau d'un utilisateur
+func GetUserXP(ctx context.Context, userID string, limit int) (*model.UserXP, error) {
+	userXP := &model.UserXP{UserID: userID}
+
+	err := database.DB.QueryRow(ctx,
+		`SELECT xp FROM users WHERE id = $1 AND deleted_at IS NULL`,
+		userID,
+	).Scan(&userXP.XP)
+	if err != nil {
+		return nil, err
+	}
+	userXP.Level, userXP.XPIntoLevel, userXP.XPToNextLevel = LevelFromXP(userXP.XP)
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT id, source, source_id, base_xp, bonus_xp, multiplier::float8, total_xp, reasons, created_at
+		FROM xp_events
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userXP.RecentEvents = []model.XPEvent{}
+	for rows.Next() {
+		var event model.XPEvent
+		var sourceID sql.NullString
+		var reasons []string
+		if err := rows.Scan(
+			&event.ID, &event.Source, &sourceID, &event.BaseXP, &event.BonusXP,
+			&event.Multiplier, &event.TotalXP, pq.Array(&reasons), &event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		event.SourceID = NullStringToPointer(sourceID)
+		event.Reasons = reasons
+		userXP.RecentEvents = append(userXP.RecentEvents, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	levelRows, err := database.DB.Query(ctx, `
+		SELECT from_level, to_level, xp, created_at
+		FROM user_level_ups
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer levelRows.Close()
+
+	userXP.LevelUps = []model.LevelUp{}
+	for levelRows.Next() {
+		var levelUp model.LevelUp
+		if err := levelRows.Scan(&levelUp.FromLevel, &levelUp.ToLevel, &levelUp.XP, &levelUp.CreatedAt); err != nil {
+			return nil, err
+		}
+		userXP.LevelUps = append(userXP.LevelUps, levelUp)
+	}
+
+	return userXP, levelRows.Err()
+}
+
+// RecalculateUserXP recalcule l'XP d'un utilisateur à partir de son historique
+// (séances, challenges et tâches complétés) et remplace son journal d'XP
+func RecalculateUserXP(ctx context.Context, userID string) (*model.UserXP, error) {
+	timezone, err := GetUserTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.DB.Query(ctx, `
+		SELECT
+			(ws.start_time AT TIME ZONE 'UTC' AT TIME ZONE $2)::date as day,
+			COALESCE(ws.total_reps, 0),
+			COALESCE(wp.difficulty, ''),
+			COALESCE(ws.completed, FALSE)
+		FROM workout_sessions ws
+		LEFT JOIN workout_programs wp ON wp.id = ws.program_id
+		WHERE ws.user_id = $1
+		ORDER BY ws.start_time ASC
+	`, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []WorkoutXPHistoryItem{}
+	for rows.Next() {
+		var item WorkoutXPHistoryItem
+		if err := rows.Scan(&item.Day, &item.TotalReps, &item.Difficulty, &item.Completed); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		history = append(history, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	freezeRows, err := database.DB.Query(ctx, `
+		SELECT used_on FROM streak_freezes
+		WHERE user_id = $1 AND used_on IS NOT NULL
+		ORDER BY used_on
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	freezeDays := []time.Time{}
+	for freezeRows.Next() {
+		var day time.Time
+		if err := freezeRows.Scan(&day); err != nil {
+			freezeRows.Close()
+			return nil, err
+		}
+		freezeDays = append(freezeDays, day)
+	}
+	freezeRows.Close()
+	if err := freezeRows.Err(); err != nil {
+		return nil, err
+	}
+
+	workoutXP := ReplayWorkoutXP(history, freezeDays)
+
+	var challengeXP, taskXP int64
+	err = database.DB.QueryRow(ctx, `
+		SELECT
+			(SELECT COALESCE(SUM(c.points), 0) FROM user_challenge_progress ucp
+				INNER JOIN challenges c ON c.id = ucp.challenge_id
+				WHERE ucp.user_id = $1 AND ucp.completed_at IS NOT NULL),
+			(SELECT COALESCE(SUM(ct.score), 0) FROM user_challenge_task_progress uctp
+				INNER JOIN challenge_tasks ct ON ct.id = uctp.task_id
+				WHERE uctp.user_id = $1 AND uctp.completed = TRUE)
+	`, userID).Scan(&challengeXP, &taskXP)
+	if err != nil {
+		return nil, err
+	}
+
+	total := workoutXP + challengeXP + taskXP
+	level, _, _ := LevelFromXP(total)
+
+	tx, err := database.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM xp_events WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO xp_events(user_id, source, base_xp, bonus_xp, multiplier, total_xp, reasons, created_at)
+		VALUES($1, $2, $3, 0, 1, $3, $4, NOW())
+	`, userID, XPSourceRecalculation, total, pq.Array([]string{
+		fmt.Sprintf("workouts:%d", workoutXP),
+		fmt.Sprintf("challenges:%d", challengeXP),
+		fmt.Sprintf("tasks:%d", taskXP),
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE users SET xp = $1, level = $2 WHERE id = $3`,
+		total, level, userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return GetUserXP(ctx, userID, 20)
+}
+
+// RecalculateAllUsersXP recalcule l'XP de tous les utilisateurs actifs. Retourne le nombre d'utilisateurs traités.
+func RecalculateAllUsersXP(ctx context.Context) (int, error) {
+	rows, err := database.DB.Query(ctx, `SELECT id FROM users WHERE deleted_at IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+
+	var userIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, userID := range userIDs {
+		if _, err := RecalculateUserXP(ctx, userID); err != nil {
+			return i, fmt.Errorf("recalcul de l'XP de %s: %w", userID, err)
+		}
+	}
+
+	return len(userIDs), nil
+}
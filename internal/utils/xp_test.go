@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// xpDay jour local à minuit ; Europe/Paris passe à l'heure d'été le 30 mars 2025 (journée de 23h)
+func xpDay(t *testing.T, value string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("fuseau horaire indisponible: %v", err)
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return day
+}
+
+func xpDaySet(t *testing.T, values ...string) map[string]bool {
+	set := map[string]bool{}
+	for _, v := range values {
+		set[xpDayKey(xpDay(t, v))] = true
+	}
+	return set
+}
+
+func TestReplayStreak(t *testing.T) {
+	tests := []struct {
+		name    string
+		active  []string
+		freezes []string
+		day     string
+		want    int
+	}{
+		{
+			name: "sans séance",
+			day:  "2025-03-28",
+			want: 1,
+		},
+		{
+			name:   "jours consécutifs à travers le passage à l'heure d'été",
+			active: []string{"2025-03-29", "2025-03-30", "2025-03-31"},
+			day:    "2025-03-31",
+			want:   3,
+		},
+		{
+			name:   "série de la veille encore en cours",
+			active: []string{"2025-03-29", "2025-03-30"},
+			day:    "2025-03-31",
+			want:   2,
+		},
+		{
+			name:   "série interrompue",
+			active: []string{"2025-03-27", "2025-03-28", "2025-03-31"},
+			day:    "2025-03-31",
+			want:   1,
+		},
+		{
+			name:    "gel reliant deux séries sans compter",
+			active:  []string{"2025-03-29", "2025-03-30", "2025-04-01"},
+			freezes: []string{"2025-03-31"},
+			day:     "2025-04-01",
+			want:    3,
+		},
+		{
+			name:    "gel seul",
+			freezes: []string{"2025-03-31"},
+			day:     "2025-04-01",
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := replayStreak(xpDaySet(t, tt.active...), xpDaySet(t, tt.freezes...), xpDay(t, tt.day))
+			if got != tt.want {
+				t.Errorf("replayStreak() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReplayWorkoutXPMatchesLiveAwards rejoue un historique et le compare aux gains attribués en direct,
+// séance par séance, avec la série que GetCurrentStreak lit dans user_streak_islands
+func TestReplayWorkoutXPMatchesLiveAwards(t *testing.T) {
+	steps := []struct {
+		name string
+		item WorkoutXPHistoryItem
+		live WorkoutXPInput // entrée calculée par BuildWorkoutXPInput au moment de la séance
+	}{
+		{
+			name: "première séance",
+			item: WorkoutXPHistoryItem{Day: xpDay(t, "2025-03-28"), TotalReps: 10, Difficulty: "BEGINNER", Completed: true},
+			live: WorkoutXPInput{CurrentStreak: 1, FirstOfDay: true},
+		},
+		{
+			name: "lendemain avec record",
+			item: WorkoutXPHistoryItem{Day: xpDay(t, "2025-03-29"), TotalReps: 20, Difficulty: "BEGINNER", Completed: true},
+			live: WorkoutXPInput{CurrentStreak: 2, FirstOfDay: true, PersonalRecord: true},
+		},
+		{
+			name: "jour du changement d'heure",
+			item: WorkoutXPHistoryItem{Day: xpDay(t, "2025-03-30"), TotalReps: 20, Difficulty: "BEGINNER", Completed: true},
+			live: WorkoutXPInput{CurrentStreak: 3, FirstOfDay: true},
+		},
+		{
+			name: "deuxième séance non complétée du jour",
+			item: WorkoutXPHistoryItem{Day: xpDay(t, "2025-03-30"), TotalReps: 5, Difficulty: "BEGINNER"},
+			live: WorkoutXPInput{CurrentStreak: 3},
+		},
+		{
+			name: "après un jour gelé",
+			item: WorkoutXPHistoryItem{Day: xpDay(t, "2025-04-01"), TotalReps: 10, Difficulty: "BEGINNER", Completed: true},
+			live: WorkoutXPInput{CurrentStreak: 4, FirstOfDay: true},
+		},
+		{
+			name: "après un jour manqué",
+			item: WorkoutXPHistoryItem{Day: xpDay(t, "2025-04-03"), TotalReps: 30, Difficulty: "BEGINNER", Completed: true},
+			live: WorkoutXPInput{CurrentStreak: 1, FirstOfDay: true, PersonalRecord: true},
+		},
+	}
+
+	history := []WorkoutXPHistoryItem{}
+	liveAwards := []int64{}
+	var liveTotal int64
+	for _, step := range steps {
+		in := step.live
+		in.TotalReps = step.item.TotalReps
+		in.Difficulty = step.item.Difficulty
+		in.Completed = step.item.Completed
+		liveAwards = append(liveAwards, int64(ComputeWorkoutXP(in).TotalXP))
+		history = append(history, step.item)
+	}
+
+	// Chaque préfixe de l'historique correspond aux gains cumulés en direct
+	freezes := []time.Time{xpDay(t, "2025-03-31")}
+	for i, step := range steps {
+		liveTotal += liveAwards[i]
+		if got := ReplayWorkoutXP(history[:i+1], freezes); got != liveTotal {
+			t.Errorf("%s: ReplayWorkoutXP() = %d, want %d (gains en direct)", step.name, got, liveTotal)
+		}
+	}
+}
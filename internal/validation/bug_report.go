@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"net/mail"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+// Bornes des signalements
+const (
+	MaxBugReportTitleLength       = 255
+	MaxBugReportDescriptionLength = 10000
+)
+
+// ValidateBugReport valide un nouveau signalement
+func ValidateBugReport(req *model.CreateBugReportRequest) error {
+	v := New()
+
+	v.Length("title", req.Title, 1, MaxBugReportTitleLength)
+	v.Length("description", req.Description, 1, MaxBugReportDescriptionLength)
+	OneOf(v, "category", req.Category, model.BugReportCategories)
+	if req.Severity != "" {
+		OneOf(v, "severity", req.Severity, model.BugReportSeverities)
+	}
+	if req.UserEmail != "" {
+		_, err := mail.ParseAddress(req.UserEmail)
+		v.Check(err == nil, "userEmail", "adresse email invalide")
+	}
+
+	return v.Err()
+}
+
+// ValidateBugReportUpdate valide la mise à jour d'un signalement par un admin
+func ValidateBugReportUpdate(req *model.UpdateBugReportRequest) error {
+	v := New()
+
+	if req.Status != "" {
+		OneOf(v, "status", req.Status, model.BugReportStatuses)
+	}
+
+	return v.Err()
+}
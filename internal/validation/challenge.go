@@ -0,0 +1,47 @@
+package validation
+
+import (
+	"fmt"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+// Bornes des challenges
+const (
+	MaxChallengeTitleLength       = 255
+	MaxChallengeDescriptionLength = 5000
+	MaxChallengePoints            = 100000
+	MaxChallengeTags              = 20
+	MaxChallengeBadgeLength       = 10 // user_badges.badge_emoji VARCHAR(10)
+)
+
+// ValidateChallenge valide les champs communs d'un challenge (les modes et tâches ont leurs propres contrôles)
+func ValidateChallenge(c *model.Challenge) error {
+	v := New()
+
+	v.Length("title", c.Title, 1, MaxChallengeTitleLength)
+	v.Length("description", c.Description, 0, MaxChallengeDescriptionLength)
+	if c.Type != "" {
+		OneOf(v, "type", model.ProgramType(c.Type), model.ProgramTypes)
+	}
+	if c.Variant != "" {
+		OneOf(v, "variant", model.Variant(c.Variant), model.Variants)
+	}
+	OneOf(v, "difficulty", model.Difficulty(c.Difficulty), model.Difficulties)
+
+	v.IntRange("targetReps", c.TargetReps, 1, MaxReps)
+	v.IntRange("duration", c.Duration, 1, MaxDurationSeconds)
+	v.IntRange("sets", c.Sets, 1, MaxSets)
+	v.IntRange("repsPerSet", c.RepsPerSet, 1, MaxRepsPerStep)
+	v.Check(c.Points >= 0 && c.Points <= MaxChallengePoints, "points", fmt.Sprintf("doit être compris entre 0 et %d", MaxChallengePoints))
+	v.Check(len(c.Tags) <= MaxChallengeTags, "tags", fmt.Sprintf("%d tags maximum", MaxChallengeTags))
+	if c.Badge != nil {
+		v.Length("badge", *c.Badge, 0, MaxChallengeBadgeLength)
+	}
+
+	if c.StartDate != nil && c.EndDate != nil {
+		v.Check(c.EndDate.After(*c.StartDate), "endDate", "doit être postérieure à startDate")
+	}
+
+	return v.Err()
+}
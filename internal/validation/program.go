@@ -0,0 +1,110 @@
+package validation
+
+import (
+	"fmt"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+// Bornes des paramètres de programme
+const (
+	MaxProgramNameLength        = 255
+	MaxProgramDescriptionLength = 2000
+	MaxReps                     = 10000 // reps totales d'un objectif
+	MaxRepsPerStep              = 1000  // reps d'une série, d'un palier ou d'une minute
+	MaxSets                     = 100
+	MaxPyramidSteps             = 50
+	MaxDurationSeconds          = 4 * 3600
+	MaxRestSeconds              = 3600
+	MaxEMOMMinutes              = 180
+)
+
+// programFields champs spécifiques à un type de programme
+var programFields = []string{
+	"targetReps", "timeLimit", "duration", "allowRest", "sets", "repsPerSet",
+	"repsSequence", "repsPerMinute", "totalMinutes", "blocks",
+}
+
+// programRules champs requis et optionnels par type (les autres champs spécifiques sont interdits)
+var programRules = map[model.ProgramType]struct {
+	required []string
+	optional []string
+}{
+	model.ProgramTypeFreeMode:   {},
+	model.ProgramTypeTargetReps: {required: []string{"targetReps"}, optional: []string{"timeLimit"}},
+	model.ProgramTypeMaxTime:    {required: []string{"duration"}, optional: []string{"allowRest"}},
+	model.ProgramTypeSetsReps:   {required: []string{"sets", "repsPerSet"}},
+	model.ProgramTypePyramid:    {required: []string{"repsSequence"}},
+	model.ProgramTypeEMOM:       {required: []string{"repsPerMinute", "totalMinutes"}},
+	model.ProgramTypeAMRAP:      {required: []string{"duration"}},
+	model.ProgramTypeCircuit:    {required: []string{"sets", "blocks"}},
+}
+
+// programFieldPresence indique quels champs spécifiques sont renseignés
+func programFieldPresence(p *model.WorkoutProgram) map[string]bool {
+	return map[string]bool{
+		"targetReps":    p.TargetReps != nil,
+		"timeLimit":     p.TimeLimit != nil,
+		"duration":      p.Duration != nil,
+		"allowRest":     p.AllowRest.Valid,
+		"sets":          p.Sets != nil,
+		"repsPerSet":    p.RepsPerSet != nil,
+		"repsSequence":  len(p.RepsSequence) > 0,
+		"repsPerMinute": p.RepsPerMinute != nil,
+		"totalMinutes":  p.TotalMinutes != nil,
+		"blocks":        len(p.Blocks) > 0,
+	}
+}
+
+// ValidateProgram valide un programme avant création ou mise à jour
+func ValidateProgram(p *model.WorkoutProgram) error {
+	v := New()
+
+	v.Length("name", p.Name, 1, MaxProgramNameLength)
+	if p.Description != nil {
+		v.Length("description", *p.Description, 0, MaxProgramDescriptionLength)
+	}
+	OneOf(v, "type", p.Type, model.ProgramTypes)
+	OneOf(v, "variant", p.Variant, model.Variants)
+	OneOf(v, "difficulty", p.Difficulty, model.Difficulties)
+
+	if rules, ok := programRules[p.Type]; ok {
+		present := programFieldPresence(p)
+		allowed := map[string]bool{}
+		for _, field := range rules.required {
+			v.Required(field, present[field])
+			allowed[field] = true
+		}
+		for _, field := range rules.optional {
+			allowed[field] = true
+		}
+		for _, field := range programFields {
+			if !allowed[field] {
+				v.Forbidden(field, present[field], fmt.Sprintf("non utilisé par le type %s", p.Type))
+			}
+		}
+	}
+
+	v.IntRange("restBetweenSets", p.RestBetweenSets, 0, MaxRestSeconds)
+	v.IntRange("targetReps", p.TargetReps, 1, MaxReps)
+	v.IntRange("timeLimit", p.TimeLimit, 1, MaxDurationSeconds)
+	v.IntRange("duration", p.Duration, 1, MaxDurationSeconds)
+	v.IntRange("sets", p.Sets, 1, MaxSets)
+	v.IntRange("repsPerSet", p.RepsPerSet, 1, MaxRepsPerStep)
+	v.IntRange("repsPerMinute", p.RepsPerMinute, 1, MaxRepsPerStep)
+	v.IntRange("totalMinutes", p.TotalMinutes, 1, MaxEMOMMinutes)
+
+	if len(p.RepsSequence) > MaxPyramidSteps {
+		v.Add("repsSequence", fmt.Sprintf("%d paliers maximum", MaxPyramidSteps))
+	}
+	for _, reps := range p.RepsSequence {
+		if reps < 1 || reps > MaxRepsPerStep {
+			v.Add("repsSequence", fmt.Sprintf("chaque palier doit être compris entre 1 et %d", MaxRepsPerStep))
+			break
+		}
+	}
+
+	validateProgramBlocks(v, p.Blocks)
+
+	return v.Err()
+}
@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"fmt"
+
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+// MaxCircuitBlocks nombre maximal de blocs d'un circuit
+const MaxCircuitBlocks = 30
+
+// validateProgramBlocks valide chaque bloc d'un circuit (champs "blocks[i].xxx")
+func validateProgramBlocks(v *Validator, blocks []model.ProgramBlock) {
+	if len(blocks) == 0 {
+		return
+	}
+	if len(blocks) > MaxCircuitBlocks {
+		v.Add("blocks", fmt.Sprintf("%d blocs maximum", MaxCircuitBlocks))
+	}
+
+	hasExercise := false
+	for i, b := range blocks {
+		field := func(name string) string { return fmt.Sprintf("blocks[%d].%s", i, name) }
+
+		OneOf(v, field("kind"), b.Kind, model.BlockKinds)
+		if b.Variant != nil {
+			OneOf(v, field("variant"), *b.Variant, model.Variants)
+		}
+
+		switch b.Kind {
+		case model.BlockKindReps:
+			hasExercise = true
+			v.Check(b.Variant != nil || b.ExerciseID != nil, field("variant"), "variant ou exerciseId requis")
+			v.Required(field("targetReps"), b.TargetReps != nil)
+			v.IntRange(field("targetReps"), b.TargetReps, 1, MaxRepsPerStep)
+			v.Forbidden(field("duration"), b.Duration != nil, "non utilisé par un bloc REPS")
+		case model.BlockKindTime:
+			hasExercise = true
+			v.Required(field("exerciseId"), b.ExerciseID != nil)
+			v.Required(field("duration"), b.Duration != nil)
+			v.IntRange(field("duration"), b.Duration, 1, MaxDurationSeconds)
+			v.Forbidden(field("targetReps"), b.TargetReps != nil, "non utilisé par un bloc TIME")
+		case model.BlockKindRest:
+			v.Required(field("duration"), b.Duration != nil)
+			v.IntRange(field("duration"), b.Duration, 1, MaxRestSeconds)
+			v.Forbidden(field("targetReps"), b.TargetReps != nil, "non utilisé par un bloc REST")
+			v.Forbidden(field("variant"), b.Variant != nil, "non utilisé par un bloc REST")
+			v.Forbidden(field("exerciseId"), b.ExerciseID != nil, "non utilisé par un bloc REST")
+		}
+	}
+	v.Check(hasExercise, "blocks", "au moins un bloc d'exercice requis")
+}
+
+// ValidateSessionBlockResults valide les résultats par bloc d'une séance de circuit
+// (rounds = nombre de tours du programme)
+func ValidateSessionBlockResults(blocks []model.ProgramBlock, rounds int, results []model.SessionBlockResult) error {
+	v := New()
+
+	kinds := make(map[int]model.BlockKind, len(blocks))
+	for _, b := range blocks {
+		kinds[b.Position] = b.Kind
+	}
+
+	seen := map[[2]int]bool{}
+	for i, res := range results {
+		field := func(name string) string { return fmt.Sprintf("blockResults[%d].%s", i, name) }
+
+		kind, ok := kinds[res.Position]
+		v.Check(ok, field("position"), "bloc inconnu")
+		v.Check(kind != model.BlockKindRest, field("position"), "un bloc de repos n'a pas de résultat")
+		v.Check(res.Round >= 1 && res.Round <= rounds, field("round"), fmt.Sprintf("doit être compris entre 1 et %d", rounds))
+		v.Check(res.Reps >= 0 && res.Reps <= MaxRepsPerStep, field("reps"), fmt.Sprintf("doit être compris entre 0 et %d", MaxRepsPerStep))
+		v.Check(res.Duration >= 0 && res.Duration <= MaxDurationSeconds, field("duration"), fmt.Sprintf("doit être compris entre 0 et %d", MaxDurationSeconds))
+
+		key := [2]int{res.Position, res.Round}
+		v.Check(!seen[key], field("position"), "résultat en double pour ce bloc et ce tour")
+		seen[key] = true
+	}
+
+	return v.Err()
+}
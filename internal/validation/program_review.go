@@ -0,0 +1,32 @@
+package validation
+
+import (
+	model "github.com/MassBabyGeek/PumpPro-backend/internal/models"
+)
+
+// Bornes des avis
+const (
+	MaxProgramReviewCommentLength = 2000
+	MaxProgramReviewReasonLength  = 500
+)
+
+// ValidateProgramReview valide la note et le commentaire d'un avis
+func ValidateProgramReview(req *model.ProgramReviewRequest) error {
+	v := New()
+
+	v.IntRange("rating", &req.Rating, model.MinProgramRating, model.MaxProgramRating)
+	if req.Comment != nil {
+		v.Length("comment", *req.Comment, 0, MaxProgramReviewCommentLength)
+	}
+
+	return v.Err()
+}
+
+// ValidateProgramReviewReport valide le motif d'un signalement d'avis
+func ValidateProgramReviewReport(req *model.ProgramReviewReportRequest) error {
+	v := New()
+
+	v.Length("reason", req.Reason, 0, MaxProgramReviewReasonLength)
+
+	return v.Err()
+}
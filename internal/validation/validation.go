@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError erreur de validation sur un champ ({"field":"repsSequence","error":"..."})
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"error"`
+}
+
+// Errors liste d'erreurs de validation, utilisable comme error
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validator accumule les erreurs de validation d'une entité
+type Validator struct {
+	errs Errors
+}
+
+// New crée un validateur vide
+func New() *Validator {
+	return &Validator{}
+}
+
+// Add ajoute une erreur sur un champ
+func (v *Validator) Add(field, message string) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: message})
+}
+
+// Check ajoute une erreur si la condition n'est pas remplie
+func (v *Validator) Check(ok bool, field, message string) {
+	if !ok {
+		v.Add(field, message)
+	}
+}
+
+// Required vérifie qu'un champ est renseigné
+func (v *Validator) Required(field string, present bool) {
+	v.Check(present, field, "champ requis")
+}
+
+// Forbidden vérifie qu'un champ n'est pas renseigné
+func (v *Validator) Forbidden(field string, present bool, reason string) {
+	v.Check(!present, field, reason)
+}
+
+// IntRange vérifie qu'un entier optionnel est compris entre min et max (inclus)
+func (v *Validator) IntRange(field string, value *int, min, max int) {
+	if value != nil {
+		v.Check(*value >= min && *value <= max, field, fmt.Sprintf("doit être compris entre %d et %d", min, max))
+	}
+}
+
+// Length vérifie la longueur d'une chaîne en caractères
+func (v *Validator) Length(field, value string, min, max int) {
+	n := utf8.RuneCountInString(strings.TrimSpace(value))
+	switch {
+	case min > 0 && n == 0:
+		v.Add(field, "champ requis")
+	case n < min || n > max:
+		v.Add(field, fmt.Sprintf("doit contenir entre %d et %d caractères", min, max))
+	}
+}
+
+// OneOf vérifie qu'une valeur fait partie des valeurs autorisées
+func OneOf[T ~string](v *Validator, field string, value T, allowed []T) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	values := make([]string, len(allowed))
+	for i, a := range allowed {
+		values[i] = string(a)
+	}
+	v.Add(field, "valeur invalide (attendu: "+strings.Join(values, ", ")+")")
+}
+
+// Err renvoie les erreurs accumulées (nil si aucune)
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
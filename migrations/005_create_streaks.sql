@@ -0,0 +1,67 @@
+-- Migration: Moteur de séries (streaks) et gels de série
+-- Date: 2025-11-03
+
+-- Fuseau horaire de l'utilisateur (utilisé pour découper les journées d'entraînement)
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- Table des gels de série (protègent une série pendant un jour de repos)
+CREATE TABLE IF NOT EXISTS streak_freezes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL DEFAULT 'earned', -- earned, admin
+    streak_start DATE, -- Début de la série qui a permis de gagner le gel
+    milestone INTEGER, -- Palier atteint (7, 14, 21...)
+    used_on DATE, -- Jour de repos protégé (NULL = gel disponible)
+    earned_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, streak_start, milestone),
+    UNIQUE(user_id, used_on)
+);
+
+CREATE INDEX idx_streak_freezes_user_id ON streak_freezes(user_id);
+CREATE INDEX idx_streak_freezes_used_on ON streak_freezes(user_id, used_on);
+CREATE INDEX idx_sessions_user_start_time ON workout_sessions(user_id, start_time);
+
+-- Vue "gaps and islands" : une ligne par série de jours consécutifs d'un utilisateur.
+-- Les jours sont calculés dans le fuseau horaire de l'utilisateur, les jours gelés
+-- relient deux séries sans compter dans leur longueur. Seules les séances complétées
+-- comptent, comme l'ancienne série du classement.
+CREATE OR REPLACE VIEW user_streak_islands AS
+WITH active_days AS (
+    SELECT user_id, day, BOOL_AND(is_freeze) AS is_freeze
+    FROM (
+        SELECT
+            ws.user_id,
+            (ws.start_time AT TIME ZONE 'UTC' AT TIME ZONE COALESCE(u.timezone, 'UTC'))::date AS day,
+            FALSE AS is_freeze
+        FROM workout_sessions ws
+        INNER JOIN users u ON u.id = ws.user_id
+        WHERE ws.completed = TRUE
+        UNION ALL
+        SELECT sf.user_id, sf.used_on AS day, TRUE AS is_freeze
+        FROM streak_freezes sf
+        WHERE sf.used_on IS NOT NULL
+    ) days
+    GROUP BY user_id, day
+),
+grouped AS (
+    SELECT
+        user_id,
+        day,
+        is_freeze,
+        day - (ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY day))::int AS grp
+    FROM active_days
+)
+SELECT
+    g.user_id,
+    MIN(g.day) AS start_date,
+    MAX(g.day) AS end_date,
+    (COUNT(*) FILTER (WHERE NOT g.is_freeze))::int AS length,
+    MAX(g.day) >= (NOW() AT TIME ZONE COALESCE(u.timezone, 'UTC'))::date - 1 AS is_current
+FROM grouped g
+INNER JOIN users u ON u.id = g.user_id
+GROUP BY g.user_id, g.grp, u.timezone
+HAVING COUNT(*) FILTER (WHERE NOT g.is_freeze) > 0;
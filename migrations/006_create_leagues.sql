@@ -0,0 +1,37 @@
+-- Migration: Ligues hebdomadaires avec promotion et relégation
+-- Date: 2025-11-10
+
+-- Palier de ligue actuel de l'utilisateur (0 = Bronze)
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS league_tier INTEGER NOT NULL DEFAULT 0;
+
+-- Table des groupes de ligue (~30 utilisateurs d'un même palier pour une semaine)
+CREATE TABLE IF NOT EXISTS league_cohorts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tier INTEGER NOT NULL,
+    week_start DATE NOT NULL, -- Lundi (UTC) de la semaine
+    member_count INTEGER NOT NULL DEFAULT 0,
+    finalized_at TIMESTAMP, -- Renseigné quand les promotions/relégations ont été appliquées
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Table des participations (une par utilisateur et par semaine, sert aussi d'historique)
+CREATE TABLE IF NOT EXISTS league_memberships (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    cohort_id UUID NOT NULL REFERENCES league_cohorts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tier INTEGER NOT NULL,
+    week_start DATE NOT NULL,
+    weekly_reps INTEGER NOT NULL DEFAULT 0, -- Figé à la clôture de la semaine
+    final_rank INTEGER,
+    outcome VARCHAR(20), -- promoted, relegated, stayed
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, week_start)
+);
+
+CREATE INDEX idx_league_cohorts_week_tier ON league_cohorts(week_start, tier);
+CREATE INDEX idx_league_cohorts_finalized_at ON league_cohorts(finalized_at);
+CREATE INDEX idx_league_memberships_cohort_id ON league_memberships(cohort_id);
+CREATE INDEX idx_league_memberships_user_id ON league_memberships(user_id, week_start DESC);
@@ -0,0 +1,51 @@
+-- Migration: Clubs, classements de clubs et challenges de club
+-- Date: 2025-11-17
+
+-- Table des clubs
+CREATE TABLE IF NOT EXISTS clubs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    avatar TEXT,
+    invite_code VARCHAR(16) NOT NULL UNIQUE,
+    max_members INTEGER NOT NULL DEFAULT 50,
+    member_count INTEGER NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+-- Table des membres de club
+CREATE TABLE IF NOT EXISTS club_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member', -- owner, member
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(club_id, user_id)
+);
+
+-- Table des challenges de club (objectif de répétitions cumulé par tous les membres)
+CREATE TABLE IF NOT EXISTS club_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    club_id UUID NOT NULL REFERENCES clubs(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    goal_reps INTEGER NOT NULL,
+    start_date TIMESTAMP NOT NULL,
+    end_date TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP,
+    CHECK (goal_reps > 0),
+    CHECK (end_date > start_date)
+);
+
+CREATE INDEX idx_clubs_deleted_at ON clubs(deleted_at);
+CREATE INDEX idx_club_members_club_id ON club_members(club_id);
+CREATE INDEX idx_club_members_user_id ON club_members(user_id);
+CREATE INDEX idx_club_challenges_club_id ON club_challenges(club_id);
@@ -0,0 +1,33 @@
+-- Migration: Système d'XP et de niveaux (distinct du score)
+-- Date: 2025-11-24
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS xp BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS level INTEGER NOT NULL DEFAULT 1;
+
+-- Journal des gains d'XP
+CREATE TABLE IF NOT EXISTS xp_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source VARCHAR(30) NOT NULL, -- workout, challenge, challenge_task, recalculation
+    source_id UUID, -- Session, challenge ou tâche à l'origine du gain
+    base_xp INTEGER NOT NULL DEFAULT 0,
+    bonus_xp INTEGER NOT NULL DEFAULT 0,
+    multiplier NUMERIC(4,2) NOT NULL DEFAULT 1.00,
+    total_xp INTEGER NOT NULL,
+    reasons TEXT[] DEFAULT '{}', -- first_workout_of_day, personal_record, streak_x1.25...
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Historique des passages de niveau
+CREATE TABLE IF NOT EXISTS user_level_ups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    from_level INTEGER NOT NULL,
+    to_level INTEGER NOT NULL,
+    xp BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_xp_events_user_id ON xp_events(user_id, created_at DESC);
+CREATE INDEX idx_user_level_ups_user_id ON user_level_ups(user_id, created_at DESC);
@@ -0,0 +1,44 @@
+-- Migration: Tâches de challenge (planning multi-jours)
+-- Date: 2025-12-01
+
+-- Table des tâches de challenge (une tâche par jour du challenge)
+CREATE TABLE IF NOT EXISTS challenge_tasks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    day INTEGER NOT NULL, -- Jour du challenge (1..N, contigus)
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    type VARCHAR(50), -- Type d'exercice, REST pour un jour de repos
+    variant VARCHAR(100),
+    target_reps INTEGER,
+    duration INTEGER,
+    sets INTEGER,
+    reps_per_set INTEGER,
+    score INTEGER DEFAULT 0,
+    scheduled_date TIMESTAMP,
+    is_locked BOOLEAN DEFAULT false,
+    created_by UUID,
+    updated_by UUID,
+    deleted_by UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+-- Table de progression des utilisateurs sur les tâches
+CREATE TABLE IF NOT EXISTS user_challenge_task_progress (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id UUID NOT NULL REFERENCES challenge_tasks(id) ON DELETE CASCADE,
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    completed BOOLEAN DEFAULT false,
+    completed_at TIMESTAMP,
+    score INTEGER,
+    attempts INTEGER DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(user_id, task_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenge_tasks_challenge_day ON challenge_tasks(challenge_id, day) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_user_challenge_task_progress_user ON user_challenge_task_progress(user_id, challenge_id);
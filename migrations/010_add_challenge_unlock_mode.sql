@@ -0,0 +1,11 @@
+-- Migration: Déverrouillage des tâches par participant
+-- Date: 2025-12-01
+
+-- Mode de déverrouillage des tâches :
+--   daily      : la tâche du jour N s'ouvre N-1 jours après le démarrage du participant (minuit, fuseau de l'utilisateur)
+--   sequential : comme daily, et la tâche précédente doit être complétée
+ALTER TABLE challenges
+    ADD COLUMN IF NOT EXISTS unlock_mode VARCHAR(20) NOT NULL DEFAULT 'daily';
+
+-- challenge_tasks.is_locked n'est plus lu : l'état de verrouillage est calculé par utilisateur
+COMMENT ON COLUMN challenge_tasks.is_locked IS 'Obsolète : verrouillage calculé par participant (voir challenges.unlock_mode)';
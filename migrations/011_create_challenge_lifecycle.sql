@@ -0,0 +1,40 @@
+-- Migration: Cycle de vie automatique des challenges
+-- Date: 2025-12-02
+
+-- Date de clôture des résultats (classement figé, badges attribués)
+ALTER TABLE challenges
+    ADD COLUMN IF NOT EXISTS finalized_at TIMESTAMP;
+
+-- Statut de la participation : in_progress, completed, failed
+ALTER TABLE user_challenge_progress
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'in_progress';
+
+UPDATE user_challenge_progress SET status = 'completed'
+WHERE completed_at IS NOT NULL AND status = 'in_progress';
+
+-- Classement figé à la fin d'un challenge
+CREATE TABLE IF NOT EXISTS challenge_leaderboard_snapshots (
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rank INTEGER NOT NULL,
+    score INTEGER NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (challenge_id, user_id)
+);
+
+-- Événements du cycle de vie (file de sortie pour les notifications)
+-- Types : challenge_started, challenge_ended, challenge_completed, challenge_failed
+CREATE TABLE IF NOT EXISTS challenge_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenge_events_unprocessed ON challenge_events(created_at) WHERE processed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_challenge_events_user ON challenge_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_challenges_lifecycle ON challenges(status, end_date) WHERE deleted_at IS NULL AND finalized_at IS NULL;
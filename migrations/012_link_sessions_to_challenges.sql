@@ -0,0 +1,15 @@
+-- Migration: Progression des challenges calculée depuis les séances
+-- Date: 2025-12-03
+
+-- Séances liées à un challenge (et éventuellement à une tâche)
+ALTER TABLE workout_sessions
+    ADD COLUMN IF NOT EXISTS challenge_id UUID REFERENCES challenges(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS challenge_task_id UUID REFERENCES challenge_tasks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_user_challenge ON workout_sessions(user_id, challenge_id) WHERE challenge_id IS NOT NULL;
+
+-- Mode de calcul de la progression :
+--   linked     : seules les séances liées au challenge (challenge_id) comptent
+--   cumulative : toutes les séances de la période du challenge comptent (ex: 1 000 reps ce mois-ci)
+ALTER TABLE challenges
+    ADD COLUMN IF NOT EXISTS progress_mode VARCHAR(20) NOT NULL DEFAULT 'linked';
@@ -0,0 +1,32 @@
+-- Migration: Duels entre deux utilisateurs
+-- Date: 2025-12-04
+
+-- Types de duel : most_reps (le plus de reps sur la durée), first_to (premier à atteindre l'objectif),
+--                 best_set (meilleure série unique sur la durée)
+-- Statuts : pending, active, completed, declined, expired
+CREATE TABLE IF NOT EXISTS duels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    challenger_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    opponent_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    target_reps INTEGER,
+    duration_hours INTEGER NOT NULL DEFAULT 24,
+    winner_points INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    winner_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    challenger_score INTEGER NOT NULL DEFAULT 0,
+    opponent_score INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    responded_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (challenger_id != opponent_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_duels_challenger ON duels(challenger_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_duels_opponent ON duels(opponent_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_duels_pending_expiry ON duels(expires_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_duels_active_end ON duels(ends_at) WHERE status = 'active';
@@ -0,0 +1,19 @@
+-- Migration: Challenges privés et sur invitation
+-- Date: 2025-12-05
+
+-- Visibilité : public (listé), unlisted (accessible par lien, non listé), private (liste d'invités uniquement)
+ALTER TABLE challenges
+    ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'public',
+    ADD COLUMN IF NOT EXISTS invite_code VARCHAR(16) UNIQUE;
+
+-- Liste des utilisateurs autorisés pour les challenges privés
+CREATE TABLE IF NOT EXISTS challenge_allowed_users (
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    added_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (challenge_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenges_visibility ON challenges(visibility) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_challenge_allowed_users_user ON challenge_allowed_users(user_id);
@@ -0,0 +1,53 @@
+-- Migration: Modèles de challenges récurrents
+-- Date: 2025-12-06
+
+-- Règle de récurrence (sous-ensemble RRULE) :
+--   FREQ=DAILY[;INTERVAL=n]
+--   FREQ=WEEKLY[;INTERVAL=n][;BYDAY=MO,WE,FR]
+--   FREQ=MONTHLY[;INTERVAL=n][;BYMONTHDAY=1,15]
+--   COUNT=n limite le nombre d'occurrences
+-- variants : variantes utilisées à tour de rôle (ex: DIAMOND, WIDE, ARCHER)
+-- difficulties : difficultés utilisées à tour de rôle, l'objectif est mis à l'échelle selon la difficulté
+CREATE TABLE IF NOT EXISTS challenge_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    category VARCHAR(50) NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    variants TEXT[] NOT NULL DEFAULT '{}',
+    difficulty VARCHAR(50) NOT NULL DEFAULT 'BEGINNER',
+    difficulties TEXT[] NOT NULL DEFAULT '{}',
+    target_reps INTEGER NOT NULL,
+    reps_increment INTEGER NOT NULL DEFAULT 0,
+    max_target_reps INTEGER,
+    sets INTEGER,
+    points INTEGER NOT NULL DEFAULT 0,
+    icon_name VARCHAR(100) NOT NULL DEFAULT '',
+    icon_color VARCHAR(50) NOT NULL DEFAULT '',
+    image_url TEXT,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    is_official BOOLEAN NOT NULL DEFAULT FALSE,
+    progress_mode VARCHAR(20) NOT NULL DEFAULT 'cumulative',
+    task_template JSONB,
+    recurrence VARCHAR(255) NOT NULL,
+    duration_days INTEGER,
+    starts_on DATE NOT NULL,
+    until DATE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    occurrence_count INTEGER NOT NULL DEFAULT 0,
+    last_occurrence_at TIMESTAMP,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_challenge_templates_active ON challenge_templates(active) WHERE deleted_at IS NULL;
+
+-- Lien entre un challenge généré et son modèle (une instance par date de début)
+ALTER TABLE challenges
+    ADD COLUMN IF NOT EXISTS template_id UUID REFERENCES challenge_templates(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS occurrence INTEGER;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_challenges_template_start ON challenges(template_id, start_date) WHERE template_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Migration: Certificats de réussite des challenges
+-- Date: 2025-12-07
+
+-- Cache des certificats générés (PNG et PDF) par utilisateur et challenge.
+-- fingerprint : empreinte des données affichées, le certificat est regénéré si elles changent (nom, rang...)
+CREATE TABLE IF NOT EXISTS challenge_certificates (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    fingerprint VARCHAR(64) NOT NULL,
+    png BYTEA NOT NULL,
+    pdf BYTEA NOT NULL,
+    image_url TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, challenge_id)
+);
@@ -0,0 +1,45 @@
+-- Migration: Recherche plein texte (challenges, programmes, utilisateurs)
+-- Date: 2025-12-08
+
+-- Similarité trigramme pour tolérer les fautes de frappe
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- array_to_string n'est pas IMMUTABLE : enveloppe utilisable dans une colonne générée
+CREATE OR REPLACE FUNCTION pumppro_search_text(arr TEXT[]) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+    AS $$ SELECT COALESCE(array_to_string(arr, ' '), '') $$;
+
+-- Poids : A = titre, B = tags, C = description (dictionnaires français et anglais)
+ALTER TABLE challenges
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('french', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('french', pumppro_search_text(tags)), 'B') ||
+        setweight(to_tsvector('english', pumppro_search_text(tags)), 'B') ||
+        setweight(to_tsvector('french', COALESCE(description, '')), 'C') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'C')
+    ) STORED;
+
+-- Les programmes n'ont pas de tags : type et variante en tiennent lieu
+ALTER TABLE workout_programs
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('french', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(type, '') || ' ' || COALESCE(variant, '')), 'B') ||
+        setweight(to_tsvector('french', COALESCE(description, '')), 'C') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'C')
+    ) STORED;
+
+-- Noms d'utilisateurs : pas de racinisation
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(name, '')), 'A')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_challenges_search ON challenges USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_workout_programs_search ON workout_programs USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_users_search ON users USING GIN(search_vector);
+
+CREATE INDEX IF NOT EXISTS idx_challenges_title_trgm ON challenges USING GIN(title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_workout_programs_name_trgm ON workout_programs USING GIN(name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING GIN(name gin_trgm_ops);
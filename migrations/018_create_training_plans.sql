@@ -0,0 +1,52 @@
+-- Migration: Plans d'entraînement sur plusieurs semaines
+-- Date: 2025-12-08
+
+CREATE TABLE IF NOT EXISTS training_plans (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    difficulty VARCHAR(50) NOT NULL, -- BEGINNER, INTERMEDIATE, ADVANCED
+    weeks INTEGER NOT NULL CHECK (weeks BETWEEN 1 AND 52),
+    missed_day_policy VARCHAR(20) NOT NULL DEFAULT 'shift', -- shift, skip, repeat
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    deleted_at TIMESTAMP
+);
+
+-- Un jour du plan : un programme, ou repos si program_id est NULL (les jours absents sont aussi du repos)
+CREATE TABLE IF NOT EXISTS training_plan_days (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plan_id UUID NOT NULL REFERENCES training_plans(id) ON DELETE CASCADE,
+    week_number INTEGER NOT NULL CHECK (week_number >= 1),
+    day_number INTEGER NOT NULL CHECK (day_number BETWEEN 1 AND 7),
+    program_id UUID REFERENCES workout_programs(id) ON DELETE SET NULL,
+    notes TEXT,
+    UNIQUE (plan_id, week_number, day_number)
+);
+
+-- Inscription d'un utilisateur : position = index du jour prévu (0 = semaine 1 jour 1),
+-- scheduled_date = date calendaire à laquelle ce jour est prévu
+CREATE TABLE IF NOT EXISTS training_plan_enrollments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    plan_id UUID NOT NULL REFERENCES training_plans(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, completed, abandoned
+    missed_day_policy VARCHAR(20) NOT NULL DEFAULT 'shift',
+    start_date DATE NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    scheduled_date DATE NOT NULL,
+    completed_workouts INTEGER NOT NULL DEFAULT 0,
+    missed_workouts INTEGER NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_training_plans_difficulty ON training_plans(difficulty) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_training_plan_days_plan ON training_plan_days(plan_id, week_number, day_number);
+CREATE INDEX IF NOT EXISTS idx_training_plan_enrollments_user ON training_plan_enrollments(user_id, status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_training_plan_enrollments_active
+    ON training_plan_enrollments(plan_id, user_id) WHERE status = 'active';
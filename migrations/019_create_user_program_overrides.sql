@@ -0,0 +1,24 @@
+-- Migration: Progression adaptative (paramètres de programme propres à chaque utilisateur)
+-- Date: 2025-12-08
+
+CREATE TABLE IF NOT EXISTS user_program_overrides (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    program_id UUID NOT NULL REFERENCES workout_programs(id) ON DELETE CASCADE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Paramètres ajustés (NULL = valeur du programme)
+    target_reps INTEGER,
+    reps_per_set INTEGER,
+    reps_sequence JSONB,
+    reps_per_minute INTEGER,
+
+    last_action VARCHAR(20) NOT NULL DEFAULT 'hold', -- increase, hold, deload
+    consecutive_successes INTEGER NOT NULL DEFAULT 0,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    sessions_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, program_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_program_overrides_program ON user_program_overrides(program_id);
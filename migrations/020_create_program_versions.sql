@@ -0,0 +1,51 @@
+-- Migration: Versions immuables des programmes
+-- Date: 2025-12-08
+
+-- Chaque création ou modification d'un programme enregistre une nouvelle version figée
+CREATE TABLE IF NOT EXISTS program_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL REFERENCES workout_programs(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL CHECK (version >= 1),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    type VARCHAR(50) NOT NULL,
+    variant VARCHAR(50) NOT NULL,
+    difficulty VARCHAR(50) NOT NULL,
+    rest_between_sets INTEGER,
+    target_reps INTEGER,
+    time_limit INTEGER,
+    duration INTEGER,
+    allow_rest BOOLEAN,
+    sets INTEGER,
+    reps_per_set INTEGER,
+    reps_sequence JSONB,
+    reps_per_minute INTEGER,
+    total_minutes INTEGER,
+    reverted_from INTEGER, -- version restaurée, NULL pour une modification classique
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (program_id, version)
+);
+
+ALTER TABLE workout_sessions
+    ADD COLUMN IF NOT EXISTS program_version_id UUID REFERENCES program_versions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_program_version ON workout_sessions(program_version_id);
+
+-- Version 1 des programmes existants (paramètres actuels, faute d'historique)
+INSERT INTO program_versions (
+    program_id, version, name, description, type, variant, difficulty, rest_between_sets,
+    target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
+    reps_sequence, reps_per_minute, total_minutes, created_by, created_at
+)
+SELECT
+    id, 1, name, description, type, variant, difficulty, rest_between_sets,
+    target_reps, time_limit, duration, allow_rest, sets, reps_per_set,
+    reps_sequence, reps_per_minute, total_minutes, created_by, COALESCE(updated_at, created_at)
+FROM workout_programs
+ON CONFLICT (program_id, version) DO NOTHING;
+
+UPDATE workout_sessions ws
+SET program_version_id = pv.id
+FROM program_versions pv
+WHERE pv.program_id = ws.program_id AND pv.version = 1 AND ws.program_version_id IS NULL;
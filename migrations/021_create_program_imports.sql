@@ -0,0 +1,19 @@
+-- Migration: Partage et import de programmes
+-- Date: 2025-12-08
+
+-- Nombre d'utilisateurs distincts ayant importé un programme partagé
+ALTER TABLE workout_programs ADD COLUMN IF NOT EXISTS import_count INTEGER NOT NULL DEFAULT 0;
+
+-- Origine d'un programme importé (attribution au créateur d'origine)
+CREATE TABLE IF NOT EXISTS program_imports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL UNIQUE REFERENCES workout_programs(id) ON DELETE CASCADE,
+    source_program_id UUID REFERENCES workout_programs(id) ON DELETE SET NULL,
+    source_version INTEGER NOT NULL,
+    original_creator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    original_creator_name VARCHAR(255), -- conservé si le compte d'origine disparaît
+    imported_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_imports_source ON program_imports(source_program_id);
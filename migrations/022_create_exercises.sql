@@ -0,0 +1,101 @@
+-- Migration: Catalogue d'exercices (variantes de pompes, tractions, dips, squats, gainage)
+-- Date: 2025-12-08
+
+CREATE TABLE IF NOT EXISTS exercises (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    family VARCHAR(50) NOT NULL, -- push-up, pull-up, dip, squat, plank
+    variant VARCHAR(50), -- variante de pompe (STANDARD, WIDE...) pour la famille push-up
+    unit VARCHAR(10) NOT NULL DEFAULT 'reps' CHECK (unit IN ('reps', 'seconds')),
+    muscle_groups TEXT[] NOT NULL DEFAULT '{}',
+    difficulty_coefficient NUMERIC(4, 2) NOT NULL DEFAULT 1.0,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (family, variant)
+);
+
+INSERT INTO exercises (slug, name, family, variant, unit, muscle_groups, difficulty_coefficient) VALUES
+('push-up-standard', 'Pompes classiques', 'push-up', 'STANDARD', 'reps', '{chest,triceps,shoulders,core}', 1.00),
+('push-up-incline', 'Pompes inclinées', 'push-up', 'INCLINE', 'reps', '{chest,triceps,shoulders}', 0.70),
+('push-up-decline', 'Pompes déclinées', 'push-up', 'DECLINE', 'reps', '{chest,shoulders,triceps}', 1.20),
+('push-up-diamond', 'Pompes diamant', 'push-up', 'DIAMOND', 'reps', '{triceps,chest}', 1.30),
+('push-up-wide', 'Pompes larges', 'push-up', 'WIDE', 'reps', '{chest,shoulders}', 1.05),
+('push-up-pike', 'Pompes piquées', 'push-up', 'PIKE', 'reps', '{shoulders,triceps}', 1.30),
+('push-up-archer', 'Pompes archer', 'push-up', 'ARCHER', 'reps', '{chest,triceps,shoulders,core}', 1.60),
+('pull-up', 'Tractions', 'pull-up', NULL, 'reps', '{back,biceps,forearms}', 2.00),
+('dip', 'Dips', 'dip', NULL, 'reps', '{triceps,chest,shoulders}', 1.50),
+('squat', 'Squats', 'squat', NULL, 'reps', '{quadriceps,glutes,hamstrings}', 0.60),
+('plank', 'Gainage', 'plank', NULL, 'seconds', '{core,shoulders}', 0.20)
+ON CONFLICT (slug) DO NOTHING;
+
+-- Programmes : exercice déduit de la variante (pompes classiques par défaut)
+ALTER TABLE workout_programs ADD COLUMN IF NOT EXISTS exercise_id UUID REFERENCES exercises(id);
+
+UPDATE workout_programs wp
+SET exercise_id = COALESCE(
+    (SELECT e.id FROM exercises e WHERE e.family = 'push-up' AND e.variant = wp.variant),
+    (SELECT e.id FROM exercises e WHERE e.slug = 'push-up-standard')
+)
+WHERE wp.exercise_id IS NULL;
+
+ALTER TABLE workout_programs ALTER COLUMN exercise_id SET NOT NULL;
+
+-- Versions : l'exercice fait partie des paramètres figés
+ALTER TABLE program_versions ADD COLUMN IF NOT EXISTS exercise_id UUID REFERENCES exercises(id);
+
+UPDATE program_versions pv
+SET exercise_id = wp.exercise_id
+FROM workout_programs wp
+WHERE wp.id = pv.program_id AND pv.exercise_id IS NULL;
+
+-- Séances : exercice du programme au moment de la séance
+-- (pour un exercice mesuré en secondes, total_reps contient la durée tenue)
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS exercise_id UUID REFERENCES exercises(id);
+
+UPDATE workout_sessions ws
+SET exercise_id = wp.exercise_id
+FROM workout_programs wp
+WHERE wp.id = ws.program_id AND ws.exercise_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_programs_exercise ON workout_programs(exercise_id);
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_exercise ON workout_sessions(exercise_id, user_id, start_time);
+
+-- Le classement en cache reste celui des pompes
+CREATE OR REPLACE FUNCTION refresh_leaderboard_cache(p_period VARCHAR)
+RETURNS void AS $$
+DECLARE
+    v_start_date TIMESTAMP;
+BEGIN
+    CASE p_period
+        WHEN 'daily' THEN
+            v_start_date := CURRENT_DATE;
+        WHEN 'weekly' THEN
+            v_start_date := CURRENT_DATE - INTERVAL '7 days';
+        WHEN 'monthly' THEN
+            v_start_date := CURRENT_DATE - INTERVAL '30 days';
+        ELSE
+            v_start_date := '1970-01-01'::TIMESTAMP;
+    END CASE;
+
+    DELETE FROM leaderboard_cache WHERE period = p_period;
+
+    INSERT INTO leaderboard_cache (period, user_id, score, rank, updated_at)
+    SELECT
+        p_period,
+        user_id,
+        score,
+        rank,
+        NOW()
+    FROM (
+        SELECT
+            ws.user_id,
+            SUM(ws.total_reps) as score,
+            ROW_NUMBER() OVER (ORDER BY SUM(ws.total_reps) DESC) as rank
+        FROM workout_sessions ws
+        JOIN exercises e ON e.id = ws.exercise_id
+        WHERE ws.start_time >= v_start_date AND e.family = 'push-up'
+        GROUP BY ws.user_id
+    ) ranked_users;
+END;
+$$ LANGUAGE plpgsql;
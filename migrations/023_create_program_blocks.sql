@@ -0,0 +1,33 @@
+-- Migration: Programmes en circuit (blocs ordonnés) et résultats par bloc des séances
+-- Date: 2025-12-08
+
+-- Blocs d'un programme CIRCUIT (sets = nombre de tours, rest_between_sets = repos entre les tours)
+CREATE TABLE IF NOT EXISTS program_blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL REFERENCES workout_programs(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 1),
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('REPS', 'TIME', 'REST')),
+    variant VARCHAR(50),
+    exercise_id UUID REFERENCES exercises(id),
+    target_reps INTEGER,
+    duration INTEGER, -- secondes (bloc tenu ou repos)
+    UNIQUE (program_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_blocks_program ON program_blocks(program_id, position);
+
+-- Les blocs font partie des paramètres versionnés
+ALTER TABLE program_versions ADD COLUMN IF NOT EXISTS blocks JSONB;
+
+-- Résultats par bloc et par tour d'une séance de circuit
+CREATE TABLE IF NOT EXISTS workout_session_blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES workout_sessions(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    round INTEGER NOT NULL CHECK (round >= 1),
+    reps INTEGER NOT NULL DEFAULT 0,
+    duration INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (session_id, position, round)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workout_session_blocks_session ON workout_session_blocks(session_id);
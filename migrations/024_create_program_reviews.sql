@@ -0,0 +1,35 @@
+-- Migration: Notes (1 à 5 étoiles) et avis sur les programmes, signalement des avis abusifs
+-- Date: 2025-12-08
+
+CREATE TABLE IF NOT EXISTS program_reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL REFERENCES workout_programs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE, -- masqué par la modération (exclu des agrégats)
+    reports_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (program_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_reviews_program ON program_reviews(program_id, created_at DESC);
+
+-- Un signalement par utilisateur et par avis, relié au signalement de modération
+CREATE TABLE IF NOT EXISTS program_review_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    review_id UUID NOT NULL REFERENCES program_reviews(id) ON DELETE CASCADE,
+    reported_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT,
+    bug_report_id UUID REFERENCES bug_reports(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (review_id, reported_by)
+);
+
+-- Agrégats des notes : moyenne brute et moyenne bayésienne utilisée pour le tri
+ALTER TABLE workout_programs ADD COLUMN IF NOT EXISTS rating_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE workout_programs ADD COLUMN IF NOT EXISTS rating_average NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE workout_programs ADD COLUMN IF NOT EXISTS rating_score DOUBLE PRECISION NOT NULL DEFAULT 3;
+
+CREATE INDEX IF NOT EXISTS idx_programs_rating_score ON workout_programs(rating_score DESC, usage_count DESC);